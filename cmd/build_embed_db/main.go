@@ -1,4 +1,5 @@
-// Program build_embed_db creates cmd/gdql/embeddb/default.db for embedding in the gdql binary.
+// Program build_embed_db creates cmd/gdql/embeddb/default.db for embedding in the gdql binary,
+// migrated to the current schema version so gdql doesn't have to on first run.
 // Run from repo root:
 //
 //	go run ./cmd/build_embed_db              # create from schema+seed (small default)
@@ -29,10 +30,16 @@ func main() {
 	}
 
 	if from != "" {
+		if abs, err := filepath.Abs(from); err == nil && abs == outPath {
+			// copyFile would truncate the source before reading it.
+			fmt.Fprintf(os.Stderr, "--from %s is the output file; copy it elsewhere first\n", from)
+			os.Exit(1)
+		}
 		if err := copyFile(from, outPath); err != nil {
 			fmt.Fprintf(os.Stderr, "copy %s -> %s: %v\n", from, outPath, err)
 			os.Exit(1)
 		}
+		upgrade(outPath)
 		fmt.Println(outPath, "(copied from", from+")")
 		return
 	}
@@ -45,9 +52,25 @@ func main() {
 		fmt.Fprintf(os.Stderr, "init: %v\n", err)
 		os.Exit(1)
 	}
+	upgrade(outPath)
 	fmt.Println(outPath)
 }
 
+// upgrade adds whatever tables and indexes schema.sql has that the database
+// lacks (its statements are all IF NOT EXISTS), then runs the migrations for
+// the columns and data fixes schema.sql can't apply to existing tables; they
+// also record schema_version.
+func upgrade(path string) {
+	if err := sqlite.InitSchema(path); err != nil {
+		fmt.Fprintf(os.Stderr, "schema: %v\n", err)
+		os.Exit(1)
+	}
+	if err := sqlite.Upgrade(path); err != nil {
+		fmt.Fprintf(os.Stderr, "upgrade: %v\n", err)
+		os.Exit(1)
+	}
+}
+
 func copyFile(src, dst string) error {
 	in, err := os.Open(src)
 	if err != nil {
@@ -3,11 +3,17 @@
 //
 // Usage:
 //
-//	gdql-import [-db path] setlistfm          Import from setlist.fm API
-//	gdql-import [-db path] json <file>        Import from canonical JSON
+//	gdql-import [-db path] setlistfm [--resume]  Import from setlist.fm API
+//	gdql-import [-db path] json [--strict] <file>  Import from canonical JSON
 //	gdql-import [-db path] lyrics <file>      Import lyrics JSON
 //	gdql-import [-db path] aliases <file>     Import song alias mappings
+//	gdql-import [-db path] covers <file>      Flag cover songs from a curated list
+//	gdql-import [-db path] ratings <file>     Import per-source show ratings
 //	gdql-import [-db path] fix-sets           Re-infer set numbers from song order
+//
+// Global flags: --quiet suppresses progress and summaries (errors still print);
+// --json writes the summary as one JSON object on stdout instead; --dry-run
+// (json, deadlists, setlistfm) reports what would be added without writing.
 package main
 
 import (
@@ -16,15 +22,26 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
+	"strings"
 	"github.com/gdql/gdql/internal/data/sqlite"
 	"github.com/gdql/gdql/internal/import/canonical"
 	"github.com/gdql/gdql/internal/import/deadlists"
 	"github.com/gdql/gdql/internal/import/setlistfm"
+	"github.com/gdql/gdql/internal/import/shared"
 
 	_ "github.com/ncruces/go-sqlite3/driver"
 )
 
+// Output modes set by global flags.
+var (
+	quiet   bool // --quiet: no progress or summaries
+	jsonOut bool // --json: summary as JSON on stdout
+	dryRun  bool // --dry-run: preview shows/songs/venues, write nothing
+)
+
 func main() {
 	args := os.Args[1:]
 	dbPath := "shows.db"
@@ -37,6 +54,21 @@ func main() {
 			break
 		}
 	}
+	// Parse --quiet / --json / --dry-run anywhere on the line
+	rest := args[:0]
+	for _, a := range args {
+		switch a {
+		case "--quiet", "-quiet", "-q":
+			quiet = true
+		case "--json", "-json":
+			jsonOut = true
+		case "--dry-run", "-dry-run":
+			dryRun = true
+		default:
+			rest = append(rest, a)
+		}
+	}
+	args = rest
 	if p := os.Getenv("GDQL_DB"); p != "" && dbPath == "shows.db" {
 		dbPath = p
 	}
@@ -45,6 +77,9 @@ func main() {
 		printUsage()
 		os.Exit(1)
 	}
+	if dryRun && args[0] != "json" && args[0] != "deadlists" && args[0] != "setlistfm" {
+		fatal(fmt.Errorf("--dry-run is supported for json, deadlists, and setlistfm, not %s", args[0]))
+	}
 
 	switch args[0] {
 	case "setlistfm":
@@ -54,22 +89,74 @@ func main() {
 			fmt.Fprintln(os.Stderr, "Get an API key at https://www.setlist.fm/settings/api")
 			os.Exit(1)
 		}
-		if err := sqlite.InitSchema(dbPath); err != nil {
+		importPath, cleanup := dbPath, func() {}
+		if dryRun {
+			scratch, removeScratch, err := scratchCopy(dbPath)
+			if err != nil {
+				fatal(err)
+			}
+			importPath, cleanup = scratch, removeScratch
+			defer cleanup()
+		} else if err := sqlite.InitSchema(dbPath); err != nil {
 			fatal(err)
 		}
 		client := setlistfm.NewClient(apiKey)
-		showsAdded, songsAdded, err := setlistfm.Import(context.Background(), dbPath, client)
+		resume := false
+		for _, a := range args[1:] {
+			if a == "--resume" || a == "-resume" {
+				resume = true
+			}
+		}
+		progress, done := progressLine("setlist.fm")
+		showsAdded, songsAdded, err := setlistfm.ImportWithOptions(context.Background(), importPath, client, setlistfm.Options{Progress: progress, Resume: resume, DryRun: dryRun})
+		done()
 		if err != nil {
+			cleanup()
 			fatal(err)
 		}
-		fmt.Fprintf(os.Stderr, "Import complete: %d shows, %d songs\n", showsAdded, songsAdded)
+		if dryRun {
+			report("setlistfm", fmt.Sprintf("Dry run, nothing written: would add %d shows, %d songs", showsAdded, songsAdded), map[string]int{"dry_run": 1, "shows": showsAdded, "songs": songsAdded})
+			return
+		}
+		report("setlistfm", fmt.Sprintf("Import complete: %d shows, %d songs", showsAdded, songsAdded), map[string]int{"shows": showsAdded, "songs": songsAdded})
 
 	case "json":
-		path := argOrFlag(args[1:])
+		strict := false
+		jsonArgs := []string{}
+		for _, a := range args[1:] {
+			if a == "--strict" || a == "-strict" {
+				strict = true
+				continue
+			}
+			jsonArgs = append(jsonArgs, a)
+		}
+		path := argOrFlag(jsonArgs)
 		if path == "" {
-			fmt.Fprintln(os.Stderr, "Usage: gdql-import [-db path] json <file.json>")
+			fmt.Fprintln(os.Stderr, "Usage: gdql-import [-db path] json [--strict] <file.json>")
 			os.Exit(1)
 		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fatal(err)
+		}
+		shows, err := canonical.DecodeShows(data, strict)
+		if err != nil {
+			fatal(fmt.Errorf("parsing %s: %w", path, err))
+		}
+		shows, problems := canonical.Validate(shows)
+		for i, p := range problems {
+			if i == maxProblemsShown {
+				info("  ... and %d more", len(problems)-i)
+				break
+			}
+			info("Skipping %s", p)
+		}
+		if dryRun {
+			if err := previewImport("json", dbPath, shows, map[string]int{"skipped": len(problems)}); err != nil {
+				fatal(err)
+			}
+			return
+		}
 		if err := sqlite.InitSchema(dbPath); err != nil {
 			fatal(err)
 		}
@@ -78,19 +165,18 @@ func main() {
 			fatal(err)
 		}
 		defer db.Close()
-		data, err := os.ReadFile(path)
+		progress, done := progressLine("json")
+		sum, err := canonical.WriteShowsDetailed(context.Background(), db.DB(), shows, canonical.Options{Progress: progress})
+		done()
 		if err != nil {
 			fatal(err)
 		}
-		var shows []canonical.Show
-		if err := json.Unmarshal(data, &shows); err != nil {
-			fatal(fmt.Errorf("parsing JSON: %w", err))
-		}
-		showsAdded, songsAdded, err := canonical.WriteShows(context.Background(), db.DB(), shows)
-		if err != nil {
-			fatal(err)
+		text, counts := importSummary(sum)
+		if len(problems) > 0 {
+			text += "; skipped " + summarizeProblems(problems)
+			counts["skipped"] += len(problems)
 		}
-		fmt.Fprintf(os.Stderr, "Import complete: %d shows, %d songs\n", showsAdded, songsAdded)
+		report("json", text, counts)
 
 	case "lyrics":
 		if len(args) < 2 {
@@ -106,7 +192,7 @@ func main() {
 		if err != nil {
 			fatal(err)
 		}
-		fmt.Fprintf(os.Stderr, "Lyrics: %d loaded, %d skipped\n", loaded, skipped)
+		report("lyrics", fmt.Sprintf("Lyrics: %d loaded, %d skipped", loaded, skipped), map[string]int{"loaded": loaded, "skipped": skipped})
 
 	case "aliases":
 		if len(args) < 2 {
@@ -122,7 +208,23 @@ func main() {
 		if err != nil {
 			fatal(err)
 		}
-		fmt.Fprintf(os.Stderr, "Aliases: %d loaded, %d skipped\n", loaded, skipped)
+		report("aliases", fmt.Sprintf("Aliases: %d loaded, %d skipped", loaded, skipped), map[string]int{"loaded": loaded, "skipped": skipped})
+
+	case "covers":
+		if len(args) < 2 {
+			fmt.Fprintln(os.Stderr, "Usage: gdql-import [-db path] covers <file.json>")
+			os.Exit(1)
+		}
+		db, err := sqlite.Open(dbPath)
+		if err != nil {
+			fatal(err)
+		}
+		defer db.Close()
+		loaded, skipped, err := sqlite.LoadCoversFromFile(context.Background(), db.DB(), args[1])
+		if err != nil {
+			fatal(err)
+		}
+		report("covers", fmt.Sprintf("Covers: %d loaded, %d skipped", loaded, skipped), map[string]int{"loaded": loaded, "skipped": skipped})
 
 	case "relations":
 		if len(args) < 2 {
@@ -138,7 +240,7 @@ func main() {
 		if err != nil {
 			fatal(err)
 		}
-		fmt.Fprintf(os.Stderr, "Relations: %d loaded, %d skipped\n", loaded, skipped)
+		report("relations", fmt.Sprintf("Relations: %d loaded, %d skipped", loaded, skipped), map[string]int{"loaded": loaded, "skipped": skipped})
 
 	case "geo":
 		if len(args) < 2 {
@@ -154,7 +256,7 @@ func main() {
 		if err != nil {
 			fatal(err)
 		}
-		fmt.Fprintf(os.Stderr, "Geo: %d loaded, %d skipped\n", loaded, skipped)
+		report("geo", fmt.Sprintf("Geo: %d loaded, %d skipped", loaded, skipped), map[string]int{"loaded": loaded, "skipped": skipped})
 
 	case "weather":
 		if len(args) < 2 {
@@ -170,7 +272,7 @@ func main() {
 		if err != nil {
 			fatal(err)
 		}
-		fmt.Fprintf(os.Stderr, "Weather: %d loaded, %d skipped\n", loaded, skipped)
+		report("weather", fmt.Sprintf("Weather: %d loaded, %d skipped", loaded, skipped), map[string]int{"loaded": loaded, "skipped": skipped})
 
 	case "recordings":
 		if len(args) < 2 {
@@ -186,7 +288,23 @@ func main() {
 		if err != nil {
 			fatal(err)
 		}
-		fmt.Fprintf(os.Stderr, "Recordings: %d loaded, %d skipped\n", loaded, skipped)
+		report("recordings", fmt.Sprintf("Recordings: %d loaded, %d skipped", loaded, skipped), map[string]int{"loaded": loaded, "skipped": skipped})
+
+	case "ratings":
+		if len(args) < 2 {
+			fmt.Fprintln(os.Stderr, "Usage: gdql-import [-db path] ratings <ratings.json>")
+			os.Exit(1)
+		}
+		db, err := sqlite.Open(dbPath)
+		if err != nil {
+			fatal(err)
+		}
+		defer db.Close()
+		loaded, skipped, err := sqlite.LoadRatingsFromFile(context.Background(), db.DB(), args[1])
+		if err != nil {
+			fatal(err)
+		}
+		report("ratings", fmt.Sprintf("Ratings: %d loaded, %d skipped", loaded, skipped), map[string]int{"loaded": loaded, "skipped": skipped})
 
 	case "merge-songs":
 		if len(args) < 2 {
@@ -209,9 +327,9 @@ func main() {
 		if err != nil {
 			fatal(err)
 		}
-		fmt.Fprintf(os.Stderr, "Merges: %d applied, %d skipped\n", len(records), skipped)
+		report("merge-songs", fmt.Sprintf("Merges: %d applied, %d skipped", len(records), skipped), map[string]int{"applied": len(records), "skipped": skipped})
 		for _, r := range records {
-			fmt.Fprintf(os.Stderr, "  %q -> %q (absorbed %d plays)\n", r.FromName, r.ToName, r.FromTimesPlayed)
+			info("  %q -> %q (absorbed %d plays)", r.FromName, r.ToName, r.FromTimesPlayed)
 		}
 		if recordPath != "" && len(records) > 0 {
 			// Merge with any prior records file so re-runs accumulate history
@@ -236,7 +354,7 @@ func main() {
 			if err := os.WriteFile(recordPath, out, 0644); err != nil {
 				fatal(err)
 			}
-			fmt.Fprintf(os.Stderr, "Wrote merge records to %s\n", recordPath)
+			info("Wrote merge records to %s", recordPath)
 		}
 
 	case "deadlists":
@@ -265,15 +383,6 @@ func main() {
 
 // importDeadlists crawls setlists.net for shows with proper set structure.
 func importDeadlists(dbPath string, firstYear, lastYear int) error {
-	if err := sqlite.InitSchema(dbPath); err != nil {
-		return err
-	}
-	db, err := sqlite.Open(dbPath)
-	if err != nil {
-		return err
-	}
-	defer db.Close()
-
 	client := deadlists.NewClient()
 	var allShows []canonical.Show
 	var allIDs []int
@@ -284,30 +393,140 @@ func importDeadlists(dbPath string, firstYear, lastYear int) error {
 			fmt.Fprintf(os.Stderr, "Warning: %d: %v\n", year, err)
 			continue
 		}
-		fmt.Fprintf(os.Stderr, "%d: %d shows\n", year, len(ids))
+		info("%d: %d shows", year, len(ids))
 		allIDs = append(allIDs, ids...)
 	}
 
-	fmt.Fprintf(os.Stderr, "Fetching %d shows (10 concurrent)...\n", len(allIDs))
+	info("Fetching %d shows (10 concurrent)...", len(allIDs))
 	shows := client.FetchShowsConcurrent(allIDs, 10)
 	for _, s := range shows {
 		allShows = append(allShows, *s)
 	}
-	fmt.Fprintf(os.Stderr, "Fetched %d shows successfully\n", len(allShows))
+	info("Fetched %d shows successfully", len(allShows))
 
 	if len(allShows) == 0 {
-		fmt.Fprintln(os.Stderr, "No shows fetched.")
+		report("deadlists", "No shows fetched.", map[string]int{"shows": 0, "songs": 0, "fetched": 0})
 		return nil
 	}
+	if dryRun {
+		return previewImport("deadlists", dbPath, allShows, map[string]int{"fetched": len(allShows)})
+	}
 
-	showsAdded, songsAdded, err := canonical.WriteShows(context.Background(), db.DB(), allShows)
+	if err := sqlite.InitSchema(dbPath); err != nil {
+		return err
+	}
+	db, err := sqlite.Open(dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	progress, done := progressLine("deadlists")
+	sum, err := canonical.WriteShowsDetailed(context.Background(), db.DB(), allShows, canonical.Options{Progress: progress})
+	done()
+	if err != nil {
+		return err
+	}
+	text, counts := importSummary(sum)
+	counts["fetched"] = len(allShows)
+	report("deadlists", fmt.Sprintf("%s; %d fetched", text, len(allShows)), counts)
+	return nil
+}
+
+// importSummary renders what an import wrote, with the same count keys as
+// previewImport's dry run so the two can be compared.
+func importSummary(sum *canonical.Summary) (string, map[string]int) {
+	text := fmt.Sprintf("Import complete: %d shows, %d songs, %d venues (%d duplicate shows skipped, %d names resolved via new aliases)",
+		sum.Shows, sum.Songs, sum.Venues, sum.Duplicates, sum.Aliases)
+	if sum.Skipped > 0 {
+		text += fmt.Sprintf("; %d undated shows skipped", sum.Skipped)
+	}
+	for _, g := range sum.Gaps {
+		info("  gap:      %s", g)
+	}
+	if len(sum.Gaps) > 0 {
+		text += fmt.Sprintf("; %d blank songs left out of their sets", len(sum.Gaps))
+	}
+	return text, map[string]int{"shows": sum.Shows, "songs": sum.Songs, "venues": sum.Venues, "duplicates": sum.Duplicates, "aliases": sum.Aliases, "skipped": sum.Skipped, "gaps": len(sum.Gaps)}
+}
+
+// previewImport reports what importing shows into dbPath would add, without
+// writing: counts, the songs that would be created (so aliases can be added
+// first), and raw names that would resolve via a new alias. A database that
+// doesn't exist yet previews as empty.
+func previewImport(command, dbPath string, shows []canonical.Show, extra map[string]int) error {
+	scratch, cleanup, err := scratchCopy(dbPath)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	db, err := sqlite.Open(scratch)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	p, err := canonical.PreviewShows(context.Background(), db.DB(), shows)
 	if err != nil {
 		return err
 	}
-	fmt.Fprintf(os.Stderr, "Import complete: %d shows, %d songs (from %d fetched)\n", showsAdded, songsAdded, len(allShows))
+	for _, name := range p.NewSongs {
+		info("  new song: %s", name)
+	}
+	raws := make([]string, 0, len(p.Aliases))
+	for raw := range p.Aliases {
+		raws = append(raws, raw)
+	}
+	sort.Strings(raws)
+	for _, raw := range raws {
+		info("  alias:    %q -> %s", raw, p.Aliases[raw])
+	}
+	for _, g := range p.Gaps {
+		info("  gap:      %s", g)
+	}
+	counts := map[string]int{"dry_run": 1, "shows": p.Shows, "songs": len(p.NewSongs), "venues": p.Venues, "duplicates": p.Duplicates, "aliases": len(p.Aliases), "gaps": len(p.Gaps)}
+	for k, v := range extra {
+		counts[k] = v
+	}
+	report(command, fmt.Sprintf("Dry run, nothing written: would add %d shows, %d songs, %d venues (%d duplicate shows skipped, %d names resolved via new aliases)",
+		p.Shows, len(p.NewSongs), p.Venues, p.Duplicates, len(p.Aliases)), counts)
 	return nil
 }
 
+// scratchCopy copies dbPath into a temporary file, with the current schema,
+// for a dry run to import into: the real database is only ever read, even
+// when it is read-only or needs migrating. A database that doesn't exist yet
+// becomes an empty one. cleanup removes the copy.
+func scratchCopy(dbPath string) (path string, cleanup func(), err error) {
+	dir, err := os.MkdirTemp("", "gdql-dry-run")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup = func() { os.RemoveAll(dir) }
+	path = filepath.Join(dir, "shows.db")
+	if _, statErr := os.Stat(dbPath); statErr == nil {
+		src, err := sqlite.OpenReadOnly(dbPath)
+		if err != nil {
+			cleanup()
+			return "", nil, err
+		}
+		_, err = src.DB().Exec("VACUUM INTO ?", path)
+		src.Close()
+		if err != nil {
+			cleanup()
+			return "", nil, fmt.Errorf("copying %s for the dry run: %w", dbPath, err)
+		}
+	}
+	if err := sqlite.InitSchema(path); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	if err := sqlite.Upgrade(path); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return path, cleanup, nil
+}
+
 // fixSets re-infers set numbers for all shows that have everything in set 1.
 func fixSets(dbPath string) error {
 	db, err := sql.Open("sqlite3", dbPath)
@@ -343,10 +562,10 @@ func fixSets(dbPath string) error {
 	rows.Close()
 
 	if len(shows) == 0 {
-		fmt.Fprintln(os.Stderr, "No shows need set number fixes.")
+		report("fix-sets", "No shows need set number fixes.", map[string]int{"fixed": 0, "candidates": 0})
 		return nil
 	}
-	fmt.Fprintf(os.Stderr, "Fixing set numbers for %d shows...\n", len(shows))
+	info("Fixing set numbers for %d shows...", len(shows))
 
 	fixed := 0
 	for _, show := range shows {
@@ -433,10 +652,87 @@ func fixSets(dbPath string) error {
 		fixed++
 	}
 
-	fmt.Fprintf(os.Stderr, "Fixed %d of %d shows.\n", fixed, len(shows))
+	report("fix-sets", fmt.Sprintf("Fixed %d of %d shows.", fixed, len(shows)), map[string]int{"fixed": fixed, "candidates": len(shows)})
 	return nil
 }
 
+// progressLine returns a ProgressFunc that keeps a single status line updated on
+// stderr, and a done func that ends the line once the import returns.
+// Returns a no-op under --quiet or --json.
+func progressLine(label string) (shared.ProgressFunc, func()) {
+	if quiet || jsonOut {
+		return nil, func() {}
+	}
+	printed := false
+	progress := func(p shared.Progress) {
+		printed = true
+		fmt.Fprintf(os.Stderr, "\r%s: ", label)
+		if p.Page > 0 {
+			fmt.Fprintf(os.Stderr, "page %d, ", p.Page)
+		}
+		if p.Total > 0 {
+			fmt.Fprintf(os.Stderr, "%d/%d shows examined", p.Processed, p.Total)
+		} else {
+			fmt.Fprintf(os.Stderr, "%d shows examined", p.Processed)
+		}
+		fmt.Fprintf(os.Stderr, ", %d added, %d new songs ", p.ShowsAdded, p.SongsAdded)
+	}
+	done := func() {
+		if printed {
+			fmt.Fprintln(os.Stderr)
+		}
+	}
+	return progress, done
+}
+
+// info prints an informational line to stderr unless --quiet or --json is set.
+func info(format string, a ...interface{}) {
+	if quiet || jsonOut {
+		return
+	}
+	fmt.Fprintf(os.Stderr, format+"\n", a...)
+}
+
+// report prints a command's final summary: text on stderr normally, or under
+// --json a single object on stdout such as {"command":"lyrics","loaded":2,"skipped":1}.
+// --quiet suppresses the text form only.
+func report(command, text string, counts map[string]int) {
+	if jsonOut {
+		out := map[string]interface{}{"command": command}
+		for k, v := range counts {
+			out[k] = v
+		}
+		b, err := json.Marshal(out)
+		if err != nil {
+			fatal(err)
+		}
+		fmt.Println(string(b))
+		return
+	}
+	info("%s", text)
+}
+
+// maxProblemsShown caps the per-show validation lines printed before a summary.
+const maxProblemsShown = 20
+
+// summarizeProblems renders skip counts by reason, e.g.
+// "3 invalid shows (2 missing date, 1 empty venue name)".
+func summarizeProblems(problems []canonical.Problem) string {
+	counts := map[string]int{}
+	var reasons []string
+	for _, p := range problems {
+		if counts[p.Reason] == 0 {
+			reasons = append(reasons, p.Reason)
+		}
+		counts[p.Reason]++
+	}
+	parts := make([]string, len(reasons))
+	for i, r := range reasons {
+		parts[i] = fmt.Sprintf("%d %s", counts[r], r)
+	}
+	return fmt.Sprintf("%d invalid shows (%s)", len(problems), strings.Join(parts, ", "))
+}
+
 func argOrFlag(args []string) string {
 	if len(args) == 0 {
 		return ""
@@ -458,18 +754,25 @@ func printUsage() {
 	fmt.Fprintln(w)
 	fmt.Fprintln(w, "Commands:")
 	fmt.Fprintln(w, "  deadlists [first] [last]   Crawl setlists.net for proper set data (default: 1965-1995)")
-	fmt.Fprintln(w, "  setlistfm                  Import shows from setlist.fm (requires SETLISTFM_API_KEY)")
-	fmt.Fprintln(w, "  json <file>                Import from canonical JSON")
+	fmt.Fprintln(w, "  setlistfm [--resume]       Import shows from setlist.fm (requires SETLISTFM_API_KEY);")
+	fmt.Fprintln(w, "                             --resume continues after the last completed page")
+	fmt.Fprintln(w, "  json [--strict] <file>     Import from canonical JSON; shows without a date or venue")
+	fmt.Fprintln(w, "                             name are skipped and listed. --strict rejects unknown fields")
 	fmt.Fprintln(w, "  lyrics <file>              Import lyrics from JSON")
 	fmt.Fprintln(w, "  aliases <file>             Import song alias mappings")
+	fmt.Fprintln(w, "  covers <file>              Flag cover songs (is_cover, original_artist) from JSON")
 	fmt.Fprintln(w, "  relations <file>           Import song-to-song relations (variant_of, merge_into, pairs_with)")
 	fmt.Fprintln(w, "  merge-songs <file>         Apply kind=merge_into rows destructively (see --record to log)")
 	fmt.Fprintln(w, "  geo <file>                 Load venue lat/lon from venues_geo.json")
 	fmt.Fprintln(w, "  weather <file>             Load daily weather from weather.json")
 	fmt.Fprintln(w, "  recordings <file>          Load archive.org recordings from recordings.json")
+	fmt.Fprintln(w, "  ratings <file>             Load per-source show ratings; shows.rating becomes their average")
 	fmt.Fprintln(w, "  fix-sets                   Re-infer set numbers for shows with flat set data")
 	fmt.Fprintln(w)
 	fmt.Fprintln(w, "Options:")
 	fmt.Fprintln(w, "  -db <path>         Database path (default: shows.db, or GDQL_DB env)")
+	fmt.Fprintln(w, "  --quiet, -q        Suppress progress and summaries (errors still print)")
+	fmt.Fprintln(w, "  --json             Print the summary as JSON on stdout")
+	fmt.Fprintln(w, "  --dry-run          json/deadlists/setlistfm: report shows, songs, and venues that would be added; write nothing")
 }
 
@@ -50,7 +50,7 @@ func runQuery(query string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	ex := executor.New(db)
+	ex := executor.NewWithOptions(db, executor.Options{ShowCompleteness: true})
 	fmtr := formatter.New()
 
 	stmts := run.SplitStatements(query)
@@ -4,117 +4,402 @@ package main
 import (
 	"bufio"
 	"context"
+	"crypto/sha256"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"text/tabwriter"
+	"time"
 	"unicode"
 	"unicode/utf16"
 	"unicode/utf8"
 
+	"github.com/gdql/gdql/internal/cli"
 	"github.com/gdql/gdql/internal/executor"
 	"github.com/gdql/gdql/internal/formatter"
 	"github.com/gdql/gdql/internal/data/sqlite"
+	"github.com/gdql/gdql/internal/import/shared"
+	"github.com/gdql/gdql/internal/ir"
+	"github.com/gdql/gdql/internal/planner/expander"
+	"github.com/gdql/gdql/internal/planner/resolver"
 	"github.com/gdql/gdql/run"
 )
 
 func main() {
-	args := os.Args[1:]
-
-	// No args → interactive REPL (gdql>>)
-	if len(args) == 0 {
-		runREPL(defaultDBPathSentinel)
-		return
+	err := newDispatcher().Run(os.Args[1:], os.Getenv("GDQL_DB"))
+	var usageErr *cli.UsageError
+	switch {
+	case err == nil:
+	case errors.Is(err, flag.ErrHelp):
+		printUsage()
+	case errors.As(err, &usageErr):
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		printUsage()
+		os.Exit(1)
+	default:
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
-	if args[0] == "init" {
-		path := "shows.db"
-		if len(args) >= 2 {
-			path = args[1]
-		}
-		if err := sqlite.Init(path); err != nil {
-			fmt.Fprintf(os.Stderr, "Error initializing database: %v\n", err)
-			os.Exit(1)
+}
+
+// newDispatcher wires gdql's subcommands. Add new ones here.
+func newDispatcher() *cli.Dispatcher {
+	out := &output{}
+	global := flag.NewFlagSet("gdql", flag.ContinueOnError)
+	global.BoolVar(&out.rawJSON, "raw-json", false, "print SQL and unmapped rows as JSON")
+	global.Var(&out.override, "format", "output format; beats the query's AS clause")
+	global.BoolVar(&out.explainPlan, "explain-plan", false, "print SQLite's query plan instead of running the query")
+	global.BoolVar(&out.sqlOnly, "sql-only", false, "print the generated SQL with its arguments filled in instead of running the query")
+	global.BoolVar(&out.pager, "pager", false, "always page output on a terminal")
+	global.BoolVar(&out.noPager, "no-pager", false, "never page output")
+	global.BoolVar(&out.strict, "strict", false, "resolve song names by exact name or alias only")
+	global.IntVar(&out.songWidth, "song-width", formatter.DefaultSongWidth, "song column width in setlist tables")
+	global.BoolVar(&out.wrap, "wrap", false, "wrap long song names in setlist tables instead of truncating")
+	global.BoolVar(&out.shortNames, "short-names", false, "show songs by short name (\"Scarlet > Fire\") in setlists and performances")
+	global.IntVar(&out.yearPivot, "year-pivot", 0, "two-digit years below this are 20xx (default: all 19xx)")
+	global.DurationVar(&out.timeout, "timeout", 0, "stop a query that runs longer than this, e.g. 10s (default: no limit)")
+	d := &cli.Dispatcher{
+		Query: &cli.Command{Name: "query", Run: func(inv *cli.Invocation) error { return runQuery(inv, out) }},
+		REPL:  &cli.Command{Name: "repl", Run: func(inv *cli.Invocation) error { runREPL(inv.DBPath, out); return nil }},
+		Flags: global,
+	}
+
+	var quiet, empty bool
+	var seedFile string
+	initFlags := flag.NewFlagSet("init", flag.ContinueOnError)
+	initFlags.BoolVar(&quiet, "quiet", false, "no confirmation message")
+	initFlags.BoolVar(&quiet, "q", false, "no confirmation message")
+	initFlags.BoolVar(&empty, "empty", false, "schema only, no sample shows")
+	initFlags.StringVar(&seedFile, "seed", "", "apply this SQL file instead of the sample shows")
+	var mergeDryRun bool
+	venuesMergeFlags := flag.NewFlagSet("venues merge", flag.ContinueOnError)
+	venuesMergeFlags.BoolVar(&mergeDryRun, "dry-run", false, "report the duplicate venues; change nothing")
+	var asJSON bool
+	setlistFlags := flag.NewFlagSet("setlist", flag.ContinueOnError)
+	setlistFlags.BoolVar(&asJSON, "json", false, "print the setlist as grouped JSON for embedding")
+	d.Register(
+		&cli.Command{Name: "init", Flags: initFlags, Run: func(inv *cli.Invocation) error {
+			return runInit(inv, quiet, empty, seedFile)
+		}},
+		&cli.Command{Name: "import", Run: func(*cli.Invocation) error {
+			return fmt.Errorf("import commands have moved to gdql-import\nUsage: gdql-import [-db <path>] setlistfm|json|lyrics|aliases|fix-sets")
+		}},
+		&cli.Command{Name: "alias", Run: func(inv *cli.Invocation) error {
+			runAlias(inv.DBPath, inv.Args)
+			return nil
+		}},
+		&cli.Command{Name: "songs merge", Run: func(inv *cli.Invocation) error {
+			runSongsMerge(inv.DBPath, inv.Args)
+			return nil
+		}},
+		&cli.Command{Name: "dedup performances", Run: func(inv *cli.Invocation) error {
+			runDedupPerformances(inv.DBPath, inv.Args)
+			return nil
+		}},
+		&cli.Command{Name: "venues merge", Flags: venuesMergeFlags, Run: func(inv *cli.Invocation) error {
+			return runVenuesMerge(inv.DBPath, inv.Args, mergeDryRun)
+		}},
+		&cli.Command{Name: "upgrade", Run: func(inv *cli.Invocation) error {
+			return runUpgrade(inv.DBPath, inv.Args)
+		}},
+		&cli.Command{Name: "recount", Run: func(inv *cli.Invocation) error {
+			runRecount(inv.DBPath, inv.Args)
+			return nil
+		}},
+		&cli.Command{Name: "setlist", Flags: setlistFlags, Run: func(inv *cli.Invocation) error {
+			return runSetlist(inv, asJSON, out)
+		}},
+		&cli.Command{Name: "eras", Run: func(*cli.Invocation) error {
+			runEras(os.Stdout)
+			return nil
+		}},
+	)
+	return d
+}
+
+// runInit handles: gdql init [path] [--quiet] [--empty | --seed <file>]. Without
+// a path it uses -db, else shows.db. --empty leaves out the sample shows, for
+// databases that will only hold imported data; --seed replaces them.
+func runInit(inv *cli.Invocation, quiet, empty bool, seedFile string) error {
+	path := "shows.db"
+	switch {
+	case len(inv.Args) > 1:
+		return cli.Usagef("init takes one database path, got %q and %q", inv.Args[0], inv.Args[1])
+	case len(inv.Args) == 1:
+		path = inv.Args[0]
+	case inv.DBFlag:
+		path = inv.DBPath
+	}
+	var err error
+	switch {
+	case empty && seedFile != "":
+		return cli.Usagef("init takes --empty or --seed, not both")
+	case empty:
+		err = sqlite.InitSchema(path)
+	case seedFile != "":
+		seed, readErr := os.ReadFile(seedFile)
+		if readErr != nil {
+			return fmt.Errorf("reading seed: %w", readErr)
 		}
+		err = sqlite.InitWithSeed(path, string(seed))
+	default:
+		err = sqlite.Init(path)
+	}
+	if err != nil {
+		return fmt.Errorf("initializing database: %w", err)
+	}
+	if !quiet {
 		fmt.Fprintf(os.Stderr, "Database created: %s\n", path)
-		return
 	}
+	return nil
+}
 
-	dbPath := getDBPath(args)
-	args = stripDBArg(args)
+// runEras handles: gdql eras. It lists the era aliases a date range accepts
+// (SHOWS FROM EUROPE72) with the days they cover.
+func runEras(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ERA\tALSO\tFROM\tTO\tNOTE")
+	for _, e := range expander.Eras() {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", e.Names[0], strings.Join(e.Names[1:], ", "),
+			e.Start.Format("2006-01-02"), e.End.Format("2006-01-02"), e.Note)
+	}
+	tw.Flush()
+}
 
-	// Only -db (no query) → REPL
-	if len(args) == 0 {
-		runREPL(dbPath)
-		return
+// output holds the global flags that decide how results are printed.
+type output struct {
+	rawJSON     bool          // --raw-json: SQL and rows as returned, skipping row mapping
+	override    formatFlag    // --format
+	explainPlan bool          // --explain-plan: show the SQL and its plan, don't run it
+	sqlOnly     bool          // --sql-only: show the SQL with its arguments inlined, don't run it
+	pager       bool          // --pager: page even output that fits on screen
+	noPager     bool          // --no-pager: print straight to stdout
+	strict      bool          // --strict: song names must match a name or alias exactly
+	songWidth   int           // --song-width: setlist table song column width
+	wrap        bool          // --wrap: wrap long song names rather than truncate them
+	shortNames  bool          // --short-names: songs by short name where they have one
+	timeout     time.Duration // --timeout: limit on each run of queries; 0 for none
+	yearPivot   int           // --year-pivot: two-digit years below it are 20xx
+}
+
+// context returns the context queries run under, bounded by --timeout.
+func (o *output) context() (context.Context, context.CancelFunc) {
+	if o.timeout > 0 {
+		return context.WithTimeout(context.Background(), o.timeout)
 	}
+	return context.WithCancel(context.Background())
+}
 
-	if len(args) >= 1 && args[0] == "import" {
-		fmt.Fprintln(os.Stderr, "Import commands have moved to gdql-import.")
-		fmt.Fprintln(os.Stderr, "Usage: gdql-import [-db <path>] setlistfm|json|lyrics|aliases|fix-sets")
-		os.Exit(1)
+// timedOut replaces a timeout error with one naming the --timeout limit, so
+// it reads differently from a query that failed; other errors pass through.
+func (o *output) timedOut(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("query timed out after %s; narrow it (e.g. a date range) or raise --timeout", o.timeout)
+	}
+	return err
+}
+
+// newExecutor builds the executor for db, applying --strict and --year-pivot.
+// --format json also asks for show completeness, which AS JSON gets anyway.
+func (o *output) newExecutor(db *sqlite.DB) executor.Executor {
+	return executor.NewWithOptions(db, executor.Options{
+		Strict:           o.strict,
+		YearPivot:        o.yearPivot,
+		ShowCompleteness: o.override.set && o.override.format == formatter.FormatJSON,
+	})
+}
+
+// newFormatter builds the formatter, applying --song-width, --wrap, and --short-names.
+func (o *output) newFormatter() formatter.Formatter {
+	return formatter.NewWithOptions(formatter.Options{SongWidth: o.songWidth, Wrap: o.wrap, ShortNames: o.shortNames})
+}
+
+// format picks the formatter output for a result: --raw-json, then --format,
+// then the query's AS clause (whose default is a table).
+func (o *output) format(result *executor.Result) formatter.OutputFormat {
+	switch {
+	case o.rawJSON:
+		return formatter.FormatRawJSON
+	case o.override.set:
+		return o.override.format
 	}
-	query, err := readQuery(args)
+	return formatter.FromIR(result.OutputFmt)
+}
+
+// formatFlag is --format <name>; unknown names fail while parsing the command line.
+type formatFlag struct {
+	name   string
+	format formatter.OutputFormat
+	set    bool
+}
+
+func (f *formatFlag) String() string { return f.name }
+
+func (f *formatFlag) Set(name string) error {
+	format, err := formatter.ParseFormat(name)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		return err
 	}
-	// If the shell merged args into one (e.g. Windows: "-db shows.db SHOWS FROM 1977"),
-	// strip the leading -db and path from the query.
-	if dbPath, query = stripLeadingDBFromQuery(dbPath, query); query == "" {
-		fmt.Fprintln(os.Stderr, "Error: no query after -db")
-		printUsage()
-		os.Exit(1)
+	f.name, f.format, f.set = name, format, true
+	return nil
+}
+
+// runQuery runs the query from the command line, -f <file>, or stdin.
+func runQuery(inv *cli.Invocation, o *output) error {
+	query, err := readQuery(inv)
+	if err != nil {
+		return err
+	}
+	if query == "" {
+		return fmt.Errorf("empty query")
 	}
 
-	dbPath, err = ensureDefaultDB(dbPath)
+	dbPath, err := ensureDefaultDB(inv.DBPath)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		return err
 	}
-	db, err := sqlite.Open(dbPath)
+	db, err := openQueryDB(dbPath)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("opening database: %w", err)
 	}
 	defer db.Close()
 
-	ex := executor.New(db)
-	fmtr := formatter.New()
+	ex := o.newExecutor(db)
+	if o.explainPlan {
+		return explainPlan(ex, db, query, o)
+	}
+	if o.sqlOnly {
+		return printSQL(ex, query)
+	}
+	fmtr := o.newFormatter()
+
+	// Several statements (e.g. a .gdql script via -f) print one after another,
+	// separated by a blank line. Results before a failing statement still print.
+	// Output that may be paged is collected first so it goes through one
+	// pager; otherwise it streams to stdout (e.g. a large CSV export).
+	ctx, cancel := o.context()
+	defer cancel()
+	results, execErr := executor.ExecuteAll(ctx, ex, query)
+	var buf strings.Builder
+	var w io.Writer = &buf
+	streaming := !o.mayPage()
+	if streaming {
+		w = os.Stdout
+	}
+	flush := func() {
+		if !streaming {
+			o.page(buf.String())
+		}
+	}
+	for i, result := range results {
+		if i > 0 {
+			io.WriteString(w, "\n")
+		}
+		if err := fmtr.FormatTo(w, result, o.format(result)); err != nil {
+			flush()
+			return fmt.Errorf("formatting: %w", err)
+		}
+		io.WriteString(w, "\n")
+		warnSlow(result)
+	}
+	flush()
+	return o.timedOut(execErr)
+}
 
-	stmts := run.SplitStatements(query)
-	for i, stmt := range stmts {
-		result, err := ex.Execute(context.Background(), stmt)
+// explainPlan prints each statement's SQL followed by the plan SQLite picks
+// for it, e.g. to check that a segue or date query searches an index rather
+// than scanning a table. Nothing is executed.
+func explainPlan(ex executor.Executor, db *sqlite.DB, query string, o *output) error {
+	ctx := context.Background()
+	stmts, err := ex.Compile(ctx, query)
+	if err != nil {
+		return err
+	}
+	var buf strings.Builder
+	for i, sq := range stmts {
+		plan, err := db.ExplainPlan(ctx, sq.SQL, sq.Args...)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
+			return fmt.Errorf("explaining query plan: %w", err)
 		}
-		out, err := fmtr.Format(result, formatter.FromIR(result.OutputFmt))
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error formatting: %v\n", err)
-			os.Exit(1)
+		if i > 0 {
+			buf.WriteString("\n")
 		}
-		fmt.Println(out)
-		if i < len(stmts)-1 {
+		buf.WriteString(songMatchLines(sq.SongMatches))
+		buf.WriteString(sq.SQL + "\n\n" + plan.String())
+	}
+	o.page(buf.String())
+	return nil
+}
+
+// songMatchLines says how each song name in a statement resolved, one SQL
+// comment per name, e.g. -- "Scarlet" -> Scarlet Begonias (#1, fuzzy), so a
+// surprising result can be traced to the lookup step that picked the song.
+func songMatchLines(matches []ir.SongMatch) string {
+	var b strings.Builder
+	for _, m := range matches {
+		if m.Song != "" {
+			fmt.Fprintf(&b, "-- %q -> %s (#%d, %s)\n", m.Name, m.Song, m.IDs[0], m.Stage)
+			continue
+		}
+		ids := make([]string, len(m.IDs))
+		for i, id := range m.IDs {
+			ids[i] = "#" + strconv.Itoa(id)
+		}
+		fmt.Fprintf(&b, "-- %q -> %s (%s)\n", m.Name, strings.Join(ids, ", "), m.Stage)
+	}
+	if b.Len() > 0 {
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// printSQL prints each statement the query compiles to, with its arguments
+// written in as literals and a terminating semicolon, ready to paste into
+// the sqlite3 shell. Nothing is executed.
+func printSQL(ex executor.Executor, query string) error {
+	stmts, err := ex.Compile(context.Background(), query)
+	if err != nil {
+		return err
+	}
+	for i, sq := range stmts {
+		if i > 0 {
 			fmt.Println()
 		}
+		fmt.Println(strings.TrimRight(sq.Inline(), "; \n") + ";")
 	}
+	return nil
 }
 
-func runREPL(dbPath string) {
+func runREPL(dbPath string, o *output) {
 	dbPath, err := ensureDefaultDB(dbPath)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
-	db, err := sqlite.Open(dbPath)
+	db, err := openQueryDB(dbPath)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
 		os.Exit(1)
 	}
 	defer db.Close()
 
-	ex := executor.New(db)
-	fmtr := formatter.New()
+	// The REPL itself never writes, so song lookups are cached across queries
+	// until another process (gdql-import, gdql alias) changes the file.
+	dsr := resolver.NewDataSourceResolver(db)
+	dsr.Strict = o.strict
+	cache := resolver.NewCachingResolver(dsr)
+	stamp := dbStamp(dbPath)
+	ex := executor.NewWithOptions(db, executor.Options{
+		Resolver:         cache,
+		YearPivot:        o.yearPivot,
+		ShowCompleteness: o.override.set && o.override.format == formatter.FormatJSON,
+	})
+	fmtr := o.newFormatter()
 	scanner := bufio.NewScanner(os.Stdin)
 
 	fmt.Fprintln(os.Stderr, "GDQL — type a query and press Enter. End with ; to run. .quit to exit.")
@@ -150,40 +435,335 @@ func runREPL(dbPath string) {
 			continue
 		}
 
-		result, err := ex.Execute(context.Background(), query)
+		if s := dbStamp(dbPath); s != stamp {
+			cache.Invalidate()
+			stamp = s
+		}
+		ctx, cancel := o.context()
+		result, err := ex.Execute(ctx, query)
+		cancel()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Error: %v\n", o.timedOut(err))
 			continue
 		}
 
-		out, err := fmtr.Format(result, formatter.FromIR(result.OutputFmt))
+		out, err := fmtr.Format(result, o.format(result))
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error formatting: %v\n", err)
 			continue
 		}
 		fmt.Println(out)
+		warnSlow(result)
 	}
 }
 
+// dbStamp is the size and modification time of the database file and its
+// WAL, if any: it changes when another process commits a write.
+func dbStamp(path string) string {
+	var b strings.Builder
+	for _, p := range []string{path, path + "-wal"} {
+		if fi, err := os.Stat(p); err == nil {
+			fmt.Fprintf(&b, "%d/%d;", fi.Size(), fi.ModTime().UnixNano())
+		}
+	}
+	return b.String()
+}
 
-// defaultDBPathSentinel means "use embedded default"; only -db overrides.
-const defaultDBPathSentinel = ""
+// warnSlow prints a stderr note for results flagged slow by the executor,
+// and the executor's hint for an empty result, if it has one.
+func warnSlow(result *executor.Result) {
+	if result.Slow {
+		fmt.Fprintf(os.Stderr, "Warning: slow query (%s)\n", result.Duration.Round(time.Millisecond))
+	}
+	if result.Hint != "" {
+		fmt.Fprintf(os.Stderr, "Hint: %s\n", result.Hint)
+	}
+}
+
+
+// runAlias handles: gdql -db <path> alias list | add "<alias>" "<canonical>" | rm "<alias>".
+// Requires an explicit database because the default one is re-extracted on every run.
+func runAlias(dbPath string, args []string) {
+	usage := func() {
+		fmt.Fprintln(os.Stderr, `Usage: gdql -db <path> alias list`)
+		fmt.Fprintln(os.Stderr, `       gdql -db <path> alias add "<alias>" "<canonical>"`)
+		fmt.Fprintln(os.Stderr, `       gdql -db <path> alias rm "<alias>"`)
+		os.Exit(1)
+	}
+	if dbPath == defaultDBPathSentinel {
+		fmt.Fprintln(os.Stderr, "Error: alias commands need -db <path>; the default database is replaced whenever gdql updates it")
+		os.Exit(1)
+	}
+	if len(args) == 0 {
+		usage()
+	}
+	db, err := sqlite.Open(dbPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	switch args[0] {
+	case "list", "ls":
+		aliases, err := sqlite.ListAliases(ctx, db.DB())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		for _, a := range aliases {
+			canonical := a.Canonical
+			if canonical == "" {
+				canonical = "(missing song)"
+			}
+			fmt.Printf("%s -> %s\n", a.Alias, canonical)
+		}
+	case "add":
+		if len(args) != 3 {
+			usage()
+		}
+		if err := sqlite.AddAlias(ctx, db.DB(), args[1], args[2]); err != nil {
+			if err == sqlite.ErrCanonicalNotFound {
+				fmt.Fprintf(os.Stderr, "Error: no song named %q\n", args[2])
+			} else {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			}
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Alias added: %s -> %s\n", args[1], args[2])
+	case "rm", "remove":
+		if len(args) != 2 {
+			usage()
+		}
+		removed, err := sqlite.RemoveAlias(ctx, db.DB(), args[1])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if !removed {
+			fmt.Fprintf(os.Stderr, "No alias %q\n", args[1])
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Alias removed: %s\n", args[1])
+	default:
+		usage()
+	}
+}
+
+// runSetlist handles: gdql setlist <date> [--json]. The date may be 5/8/77,
+// 1977-05-08, or any form SETLIST FOR takes. --json prints the embeddable
+// grouped shape (formatter.SetlistJSON); otherwise it prints as AS SETLIST
+// unless --format says different. Anything other than a lone date,
+// e.g. a lowercase "setlist for 5/8/77 as csv", runs as a query.
+func runSetlist(inv *cli.Invocation, asJSON bool, o *output) error {
+	if len(inv.Args) != 1 {
+		inv.Query = strings.TrimSpace("SETLIST " + strings.Join(inv.Args, " "))
+		return runQuery(inv, o)
+	}
+	dbPath, err := ensureDefaultDB(inv.DBPath)
+	if err != nil {
+		return err
+	}
+	db, err := openQueryDB(dbPath)
+	if err != nil {
+		return fmt.Errorf("opening database: %w", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := o.context()
+	defer cancel()
+	result, err := o.newExecutor(db).Execute(ctx, "SETLIST FOR "+inv.Args[0]+" AS SETLIST")
+	if err != nil {
+		return o.timedOut(err)
+	}
+	if result.Setlist == nil || len(result.Setlist.Performances) == 0 {
+		return fmt.Errorf("no setlist for %s", inv.Args[0])
+	}
+	var text string
+	if asJSON {
+		text, err = formatter.SetlistJSON(result.Setlist)
+	} else {
+		text, err = o.newFormatter().Format(result, o.format(result))
+	}
+	if err != nil {
+		return fmt.Errorf("formatting: %w", err)
+	}
+	o.page(text + "\n")
+	return nil
+}
+
+// runSongsMerge handles: gdql -db <path> songs merge <keep_id> <drop_id>.
+func runSongsMerge(dbPath string, args []string) {
+	if len(args) != 2 {
+		fmt.Fprintln(os.Stderr, "Usage: gdql -db <path> songs merge <keep_id> <drop_id>")
+		os.Exit(1)
+	}
+	if dbPath == defaultDBPathSentinel {
+		fmt.Fprintln(os.Stderr, "Error: songs merge needs -db <path>; the default database is replaced whenever gdql updates it")
+		os.Exit(1)
+	}
+	keepID, err1 := strconv.Atoi(args[0])
+	dropID, err2 := strconv.Atoi(args[1])
+	if err1 != nil || err2 != nil {
+		fmt.Fprintln(os.Stderr, "Error: song ids must be integers")
+		os.Exit(1)
+	}
+	db, err := sqlite.Open(dbPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+	if err := db.MergeSongs(context.Background(), keepID, dropID); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "Merged song %d into %d\n", dropID, keepID)
+}
+
+// runDedupPerformances handles: gdql -db <path> dedup performances.
+func runDedupPerformances(dbPath string, args []string) {
+	if len(args) != 0 {
+		fmt.Fprintln(os.Stderr, "Usage: gdql -db <path> dedup performances")
+		os.Exit(1)
+	}
+	if dbPath == defaultDBPathSentinel {
+		fmt.Fprintln(os.Stderr, "Error: dedup performances needs -db <path>; the default database is replaced whenever gdql updates it")
+		os.Exit(1)
+	}
+	// Open runs the migrations, which already dedup and add the unique index;
+	// the explicit pass reports what (if anything) is left.
+	db, err := sqlite.Open(dbPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+	n, err := db.DedupPerformances(context.Background())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "Removed %d duplicate performances\n", n)
+}
 
-func getDBPath(args []string) string {
-	for i, a := range args {
-		if a == "-db" && i+1 < len(args) {
-			return args[i+1]
+// runVenuesMerge handles: gdql -db <path> venues merge [--dry-run]. It folds
+// venues stored twice (e.g. once with the state spelled out, from before
+// importers normalized states) into one, listing each, then recounts songs
+// since merged shows can drop a repeated setlist.
+func runVenuesMerge(dbPath string, args []string, dryRun bool) error {
+	if len(args) != 0 {
+		return cli.Usagef("venues merge takes no arguments, got %q", args[0])
+	}
+	if dbPath == defaultDBPathSentinel {
+		return fmt.Errorf("venues merge needs -db <path>; the default database is replaced whenever gdql updates it")
+	}
+	db, err := sqlite.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("opening database: %w", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	merges, err := db.MergeVenues(ctx, dryRun)
+	if err != nil {
+		return err
+	}
+	if len(merges) == 0 {
+		fmt.Fprintln(os.Stderr, "No duplicate venues")
+		return nil
+	}
+	verb := "Merged"
+	if dryRun {
+		verb = "Would merge"
+	}
+	merged := 0
+	for _, m := range merges {
+		if m.Conflict != "" {
+			fmt.Fprintf(os.Stderr, "Skipped venue %d %q (duplicate of %d): %s\n", m.DropID, m.Name, m.KeepID, m.Conflict)
+			continue
 		}
+		merged++
+		fmt.Fprintf(os.Stderr, "%s venue %d %q into %d: %d shows moved, %d merged\n", verb, m.DropID, m.Name, m.KeepID, m.Moved, m.Merged)
+	}
+	if dryRun || merged == 0 {
+		return nil
+	}
+	if _, err := shared.RecountSongs(ctx, db.DB()); err != nil {
+		return fmt.Errorf("recounting songs: %w", err)
+	}
+	return nil
+}
+
+// runRecount handles: gdql -db <path> recount. It refreshes songs'
+// times_played and first/last_played from performances, for databases
+// edited by hand or imported before imports kept them up to date.
+func runRecount(dbPath string, args []string) {
+	if len(args) != 0 {
+		fmt.Fprintln(os.Stderr, "Usage: gdql -db <path> recount")
+		os.Exit(1)
+	}
+	if dbPath == defaultDBPathSentinel {
+		fmt.Fprintln(os.Stderr, "Error: recount needs -db <path>; the default database is replaced whenever gdql updates it")
+		os.Exit(1)
+	}
+	db, err := sqlite.Open(dbPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+	n, err := shared.RecountSongs(context.Background(), db.DB())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
-	if p := os.Getenv("GDQL_DB"); p != "" {
-		return p
+	fmt.Fprintf(os.Stderr, "Recounted plays for %d songs\n", n)
+}
+
+// runUpgrade handles: gdql -db <path> upgrade. It applies pending schema
+// migrations, which queries never do.
+func runUpgrade(dbPath string, args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("usage: gdql -db <path> upgrade")
+	}
+	if dbPath == defaultDBPathSentinel {
+		return fmt.Errorf("upgrade needs -db <path>; the default database is upgraded whenever gdql updates it")
+	}
+	// Upgrade would create a missing file.
+	if _, err := os.Stat(dbPath); err != nil {
+		return err
 	}
-	return defaultDBPathSentinel
+	if err := sqlite.Upgrade(dbPath); err != nil {
+		return fmt.Errorf("upgrading %s: %w", dbPath, err)
+	}
+	fmt.Fprintf(os.Stderr, "Upgraded %s to schema version %d\n", dbPath, sqlite.SchemaVersion())
+	return nil
 }
 
+// openQueryDB opens path read-only for a query. Queries never migrate a
+// database, since some migrations rewrite rows; one behind this build's
+// schema is reported on stderr with the command that upgrades it, and queries
+// needing what it lacks fail with a QueryError saying so.
+func openQueryDB(path string) (*sqlite.DB, error) {
+	db, err := sqlite.OpenReadOnly(path)
+	if err != nil {
+		return nil, err
+	}
+	if v, err := db.Version(); err == nil && v < sqlite.SchemaVersion() {
+		fmt.Fprintf(os.Stderr, "Warning: %s is at schema version %d; this gdql expects %d. Upgrade it with: gdql -db %s upgrade\n",
+			path, v, sqlite.SchemaVersion(), path)
+	}
+	return db, nil
+}
+
+// defaultDBPathSentinel means "use embedded default"; only -db overrides.
+const defaultDBPathSentinel = ""
+
 // ensureDefaultDB returns the path to use. When no -db was given (path is empty), it always uses
-// the embedded DB, unpacked to the config dir (e.g. ~/.config/gdql/shows.db). Use -db <path> to
-// override and use a different database.
+// the embedded DB, unpacked to the config dir (e.g. ~/.config/gdql/shows.db) and rewritten only
+// when this build embeds a different one (see embeddedStamp). Use -db <path> to override and use
+// a different database; it is used as-is, never migrated (see openQueryDB).
 func ensureDefaultDB(path string) (string, error) {
 	if path != defaultDBPathSentinel {
 		return path, nil
@@ -197,10 +777,24 @@ func ensureDefaultDB(path string) (string, error) {
 	if err := os.MkdirAll(gdqlDir, 0755); err != nil {
 		return "", fmt.Errorf("creating config dir %s: %w", gdqlDir, err)
 	}
-	if len(run.EmbeddedDB()) > 0 {
-		if err := os.WriteFile(dbPath, run.EmbeddedDB(), 0644); err != nil {
+	if embedded := run.EmbeddedDB(); len(embedded) > 0 {
+		stamp := embeddedStamp(embedded)
+		stampPath := dbPath + ".stamp"
+		if old, err := os.ReadFile(stampPath); err == nil && string(old) == stamp {
+			if _, err := os.Stat(dbPath); err == nil {
+				return dbPath, nil
+			}
+		}
+		if err := os.WriteFile(dbPath, embedded, 0644); err != nil {
 			return "", fmt.Errorf("writing database to %s: %w", dbPath, err)
 		}
+		// Queries open read-only, so bring the fresh copy up to date here.
+		if err := sqlite.Upgrade(dbPath); err != nil {
+			return "", fmt.Errorf("upgrading database at %s: %w", dbPath, err)
+		}
+		if err := os.WriteFile(stampPath, []byte(stamp), 0644); err != nil {
+			return "", fmt.Errorf("writing %s: %w", stampPath, err)
+		}
 	} else {
 		if err := sqlite.Init(dbPath); err != nil {
 			return "", fmt.Errorf("initializing database at %s: %w", dbPath, err)
@@ -209,49 +803,42 @@ func ensureDefaultDB(path string) (string, error) {
 	return dbPath, nil
 }
 
-func stripDBArg(args []string) []string {
-	out := make([]string, 0, len(args))
-	for i := 0; i < len(args); i++ {
-		if args[i] == "-db" {
-			i++
-			continue
-		}
-		out = append(out, args[i])
-	}
-	return out
-}
-
-// stripLeadingDBFromQuery handles the case where the shell passed one arg like "-db shows.db SHOWS FROM 1977".
-// Returns (dbPath, query); if the query started with "-db path ", path is used and the rest is the query.
-func stripLeadingDBFromQuery(defaultPath, query string) (dbPath, rest string) {
-	rest = query
-	q := strings.TrimSpace(query)
-	if !strings.HasPrefix(q, "-db ") {
-		return defaultPath, rest
-	}
-	// "-db path [rest of query]"
-	q = strings.TrimSpace(q[4:]) // after "-db "
-	if q == "" {
-		return defaultPath, ""
-	}
-	idx := strings.Index(q, " ")
-	if idx < 0 {
-		return q, "" // only path, no query
-	}
-	dbPath = q[:idx]
-	rest = strings.TrimSpace(q[idx+1:])
-	return dbPath, rest
+// embeddedStamp identifies the unpacked default database: the embedded bytes
+// and the schema version this build migrates them to. The copy is rewritten
+// when either changes, so a new release's data or migrations take effect.
+func embeddedStamp(embedded []byte) string {
+	return fmt.Sprintf("%x %d", sha256.Sum256(embedded), sqlite.SchemaVersion())
 }
 
 func printUsage() {
 	fmt.Fprintln(os.Stderr, "Usage: gdql [options] [query]")
 	fmt.Fprintln(os.Stderr, "       gdql                              interactive mode (gdql>>)")
-	fmt.Fprintln(os.Stderr, "       gdql init [path]                  create database with schema and sample data")
+	fmt.Fprintln(os.Stderr, "       gdql init [path] [--quiet]        create database with schema and sample data")
+	fmt.Fprintln(os.Stderr, "       gdql init [path] --empty          schema only, for importing your own data")
+	fmt.Fprintln(os.Stderr, "       gdql init [path] --seed <file>    schema plus the SQL in file instead of the samples")
 	fmt.Fprintln(os.Stderr, "       gdql -f <file>                    run queries from a file")
 	fmt.Fprintln(os.Stderr, "       gdql -                            read query from stdin")
+	fmt.Fprintln(os.Stderr, "       gdql -db <path> alias list|add|rm  manage song name aliases")
+	fmt.Fprintln(os.Stderr, "       gdql -db <path> songs merge <keep> <drop>  fold one song id into another")
+	fmt.Fprintln(os.Stderr, "       gdql -db <path> dedup performances  remove duplicate performance rows")
+	fmt.Fprintln(os.Stderr, "       gdql -db <path> venues merge [--dry-run]  fold venues stored twice into one")
+	fmt.Fprintln(os.Stderr, "       gdql -db <path> upgrade           apply schema migrations (queries never do)")
+	fmt.Fprintln(os.Stderr, "       gdql -db <path> recount           recompute songs' play counts and first/last played")
+	fmt.Fprintln(os.Stderr, "       gdql setlist <date> [--json]      one show's setlist; --json for embedding")
+	fmt.Fprintln(os.Stderr, "       gdql eras                         list era names usable as dates (FROM EUROPE72)")
 	fmt.Fprintln(os.Stderr)
 	fmt.Fprintln(os.Stderr, "Options:")
-	fmt.Fprintln(os.Stderr, "  -db <path>   Database path (default: embedded DB in config dir)")
+	fmt.Fprintln(os.Stderr, "  -db <path>   Database path (default: $GDQL_DB, else embedded DB in config dir)")
+	fmt.Fprintln(os.Stderr, "  --format <f> Output as table, json, csv, tsv, setlist, markdown, classic, html, or html-full (a whole page; overrides AS)")
+	fmt.Fprintln(os.Stderr, "  --raw-json   Print the SQL and its rows as returned, before mapping (debugging)")
+	fmt.Fprintln(os.Stderr, "  --explain-plan  Print the SQL and SQLite's query plan for it instead of running it")
+	fmt.Fprintln(os.Stderr, "  --sql-only   Print the SQL with its arguments filled in, ready for sqlite3, instead of running it")
+	fmt.Fprintln(os.Stderr, "  --pager      Always page output on a terminal (default: only when taller than the screen)")
+	fmt.Fprintln(os.Stderr, "  --no-pager   Never page output")
+	fmt.Fprintln(os.Stderr, "  --strict     Match song names exactly or by alias; no trimming or punctuation guesses")
+	fmt.Fprintln(os.Stderr, "  --timeout <d>  Stop queries running longer than d (e.g. 10s, 2m)")
+	fmt.Fprintln(os.Stderr, "  --year-pivot <n>  Read two-digit years below n as 20xx (default: every two-digit year is 19xx)")
+	fmt.Fprintln(os.Stderr, "  --           Treat the rest of the line as query text")
 	fmt.Fprintln(os.Stderr)
 	fmt.Fprintln(os.Stderr, "Examples:")
 	fmt.Fprintln(os.Stderr, "  gdql SHOWS FROM 1977 LIMIT 5")
@@ -349,18 +936,11 @@ func sanitizeQuery(s string) string {
 	return b.String()
 }
 
-// readQuery returns the query string from args: either a single arg, -f <file>, or - for stdin.
-func readQuery(args []string) (string, error) {
-	if len(args) == 0 {
-		return "", fmt.Errorf("no query or flag")
-	}
-
+// readQuery returns the query text: from -f <file>, from stdin for -, or as given on the command line.
+func readQuery(inv *cli.Invocation) (string, error) {
 	// -f <file>
-	if args[0] == "-f" {
-		if len(args) < 2 {
-			return "", fmt.Errorf("-f requires a filename")
-		}
-		b, err := os.ReadFile(args[1])
+	if inv.File != "" {
+		b, err := os.ReadFile(inv.File)
 		if err != nil {
 			return "", fmt.Errorf("reading file: %w", err)
 		}
@@ -369,7 +949,7 @@ func readQuery(args []string) (string, error) {
 	}
 
 	// - (stdin)
-	if args[0] == "-" {
+	if inv.Stdin {
 		scanner := bufio.NewScanner(os.Stdin)
 		var lines []string
 		for scanner.Scan() {
@@ -381,5 +961,5 @@ func readQuery(args []string) (string, error) {
 		return strings.TrimSpace(sanitizeQuery(strings.Join(lines, "\n"))), nil
 	}
 
-	return strings.TrimSpace(strings.Join(args, " ")), nil
+	return inv.Query, nil
 }
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"database/sql"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gdql/gdql/internal/data/sqlite"
+	"github.com/gdql/gdql/test/fixtures"
+	"github.com/stretchr/testify/require"
+)
+
+// captureStdout runs fn with os.Stdout redirected and returns what it wrote.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	orig := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = orig }()
+	fn()
+	require.NoError(t, w.Close())
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(out)
+}
+
+func TestRun_FormatHTMLFull(t *testing.T) {
+	path, cleanup := fixtures.CreateTestDB(t)
+	defer cleanup()
+
+	var runErr error
+	out := captureStdout(t, func() {
+		runErr = newDispatcher().Run([]string{"-db", path, "--no-pager", "--format", "html-full", "SHOWS FROM 1977"}, "")
+	})
+	require.NoError(t, runErr)
+	require.Contains(t, out, "<!DOCTYPE html")
+	require.Contains(t, out, "1977-05-08")
+}
+
+func TestRun_ExplainPlanShowsSongMatches(t *testing.T) {
+	path, cleanup := fixtures.CreateTestDB(t)
+	defer cleanup()
+
+	var runErr error
+	out := captureStdout(t, func() {
+		runErr = newDispatcher().Run([]string{"-db", path, "--no-pager", "--explain-plan", `FIRST "Scarlet Begonias-"`}, "")
+	})
+	require.NoError(t, runErr)
+	require.Contains(t, out, `-- "Scarlet Begonias-" -> Scarlet Begonias (#1, alias)`)
+}
+
+func TestRun_FormatHTML(t *testing.T) {
+	path, cleanup := fixtures.CreateTestDB(t)
+	defer cleanup()
+
+	var runErr error
+	out := captureStdout(t, func() {
+		runErr = newDispatcher().Run([]string{"-db", path, "--no-pager", "--format", "html", "SHOWS FROM 1977"}, "")
+	})
+	require.NoError(t, runErr)
+	require.Contains(t, out, "<table")
+	require.NotContains(t, out, "<!DOCTYPE html")
+}
+
+func TestDBStamp(t *testing.T) {
+	path, cleanup := fixtures.CreateTestDB(t)
+	defer cleanup()
+
+	before := dbStamp(path)
+	require.NotEmpty(t, before)
+	require.Equal(t, before, dbStamp(path))
+	require.NoError(t, os.WriteFile(path, []byte("changed"), 0o644))
+	require.NotEqual(t, before, dbStamp(path))
+}
+
+func TestQueryLeavesDBPathSchema_UpgradeMigrates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.db")
+	raw, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	_, err = raw.Exec(`
+		CREATE TABLE venues (id INTEGER PRIMARY KEY, name TEXT, city TEXT, state TEXT, country TEXT);
+		CREATE TABLE shows (id INTEGER PRIMARY KEY, date TEXT, venue_id INTEGER, tour TEXT, notes TEXT, rating REAL);
+		CREATE TABLE songs (id INTEGER PRIMARY KEY, name TEXT, short_name TEXT, writers TEXT, first_played TEXT, last_played TEXT, times_played INTEGER DEFAULT 0);
+		CREATE TABLE performances (id INTEGER PRIMARY KEY, show_id INTEGER, song_id INTEGER, set_number INTEGER, position INTEGER, segue_type TEXT, length_seconds INTEGER DEFAULT 0);`)
+	require.NoError(t, err)
+	require.NoError(t, raw.Close())
+
+	got, err := ensureDefaultDB(path)
+	require.NoError(t, err)
+	require.Equal(t, path, got)
+	query := func() error {
+		var runErr error
+		captureStdout(t, func() {
+			runErr = newDispatcher().Run([]string{"-db", path, "--no-pager", "SHOWS WHERE TAPE"}, "")
+		})
+		return runErr
+	}
+	require.Error(t, query(), "a query doesn't add the tape column")
+	db, err := sqlite.OpenReadOnly(path)
+	require.NoError(t, err)
+	v, err := db.Version()
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+	require.Equal(t, 0, v, "a query doesn't migrate")
+
+	require.NoError(t, newDispatcher().Run([]string{"-db", path, "upgrade"}, ""))
+	require.NoError(t, query(), "upgrade added the tape column")
+
+	missing := filepath.Join(t.TempDir(), "missing.db")
+	_, err = ensureDefaultDB(missing)
+	require.NoError(t, err)
+	require.Error(t, newDispatcher().Run([]string{"-db", missing, "upgrade"}, ""))
+	_, err = os.Stat(missing)
+	require.True(t, os.IsNotExist(err), "a missing -db file is not created")
+}
+
+func TestEnsureDefaultDB_UnpacksOnlyWhenEmbeddedChanges(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	path, err := ensureDefaultDB("")
+	require.NoError(t, err)
+
+	// A second run keeps the unpacked copy: the marker table survives.
+	conn, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	_, err = conn.Exec("CREATE TABLE marker (x INTEGER)")
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+	hasMarker := func() bool {
+		conn, err := sql.Open("sqlite3", path)
+		require.NoError(t, err)
+		defer conn.Close()
+		var n int
+		require.NoError(t, conn.QueryRow("SELECT count(*) FROM sqlite_master WHERE name = 'marker'").Scan(&n))
+		return n == 1
+	}
+	_, err = ensureDefaultDB("")
+	require.NoError(t, err)
+	require.True(t, hasMarker())
+
+	// A stamp from another build (different data or schema) rewrites it.
+	require.NoError(t, os.WriteFile(path+".stamp", []byte("other"), 0o644))
+	_, err = ensureDefaultDB("")
+	require.NoError(t, err)
+	require.False(t, hasMarker())
+}
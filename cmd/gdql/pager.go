@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// defaultScreenLines is the terminal height assumed when $LINES is unset.
+// Guessing low is harmless: less quits by itself when the output fits (-F).
+const defaultScreenLines = 24
+
+// page prints text to stdout, through a pager when o says to use one and
+// stdout is a terminal. Without --pager, only text taller than the screen
+// is paged. If no pager can be started, text goes straight to stdout.
+func (o *output) page(text string) {
+	if !o.mayPage() || !o.pager && lineCount(text) < screenLines() {
+		fmt.Print(text)
+		return
+	}
+	cmd := pagerCommand()
+	if cmd == nil {
+		fmt.Print(text)
+		return
+	}
+	cmd.Stdin = strings.NewReader(text)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if err := cmd.Start(); err != nil {
+		fmt.Print(text)
+		return
+	}
+	// The exit status is the pager's business (e.g. quitting early); the
+	// output has already been shown.
+	_ = cmd.Wait()
+}
+
+// mayPage reports whether page might use a pager, i.e. whether output has to
+// be collected before it is printed.
+func (o *output) mayPage() bool {
+	return !o.noPager && isTerminal(os.Stdout)
+}
+
+// pagerCommand returns the pager to run: $PAGER, else less. It returns nil
+// when the pager is not installed, or when $PAGER is set but empty or "cat",
+// the usual ways of asking for no pager.
+func pagerCommand() *exec.Cmd {
+	pager, ok := os.LookupEnv("PAGER")
+	if !ok {
+		pager = "less"
+	}
+	args := strings.Fields(pager)
+	if len(args) == 0 || args[0] == "cat" {
+		return nil
+	}
+	path, err := exec.LookPath(args[0])
+	if err != nil {
+		return nil
+	}
+	cmd := exec.Command(path, args[1:]...)
+	// Like git: unless the user configured less, quit when the output fits on
+	// one screen (F), keep table colours (R), and leave it on screen (X).
+	if _, ok := os.LookupEnv("LESS"); !ok {
+		cmd.Env = append(os.Environ(), "LESS=FRX")
+	}
+	return cmd
+}
+
+// isTerminal reports whether f is a terminal rather than a pipe or file.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// screenLines is the terminal height from $LINES, else defaultScreenLines.
+func screenLines() int {
+	if n, err := strconv.Atoi(os.Getenv("LINES")); err == nil && n > 0 {
+		return n
+	}
+	return defaultScreenLines
+}
+
+// lineCount is the number of lines text takes up, counting a final line
+// without a newline.
+func lineCount(text string) int {
+	n := strings.Count(text, "\n")
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		n++
+	}
+	return n
+}
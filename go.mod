@@ -5,6 +5,7 @@ go 1.25.0
 require (
 	github.com/ncruces/go-sqlite3 v0.33.3
 	github.com/stretchr/testify v1.8.4
+	golang.org/x/text v0.36.0
 )
 
 require (
@@ -12,9 +12,16 @@ func (*SetlistQuery) queryNode()    {}
 func (*CountQuery) queryNode()      {}
 func (*FirstLastQuery) queryNode()  {}
 func (*RandomShowQuery) queryNode() {}
-
-// ShowQuery represents: SHOWS [AT "venue"] [TOUR "name"] [FROM date_range] [WHERE conditions] [modifiers]
+func (*VenueQuery) queryNode()      {}
+func (*RunQuery) queryNode()        {}
+func (*CompareQuery) queryNode()    {}
+func (*CoverageQuery) queryNode()   {}
+func (*GapsQuery) queryNode()       {}
+
+// ShowQuery represents: [BEST] SHOWS [AT "venue"] [TOUR "name"] [FROM date_range] [WHERE conditions] [modifiers]
+// BEST SHOWS keeps only rated shows and, without an ORDER BY, sorts them by rating, best first.
 type ShowQuery struct {
+	Best      bool
 	At        string // venue name filter
 	Tour      string // tour name filter
 	From      *DateRange
@@ -24,26 +31,36 @@ type ShowQuery struct {
 	OutputFmt OutputFormat
 }
 
-// SongQuery represents: SONGS [WITH clause] [WRITTEN clause] [modifiers]
+// SongQuery represents: SONGS [FROM range] [WHERE COVER|ORIGINAL] [WITH clause] [WRITTEN clause] [DEBUTED clause] [modifiers]
 type SongQuery struct {
+	Where     *WhereClause // SONGS WHERE COVER / SONGS WHERE ORIGINAL
 	With      *WithClause
 	Written   *DateRange
-	From      *DateRange // SONGS FROM 1977 / SONGS PLAYED IN 1977
+	Debuted   *DateRange  // SONGS DEBUTED FROM 1977 (first_played in range)
+	From      *DateRange  // SONGS FROM 1977 / SONGS PLAYED IN 1977
+	EveryYear bool        // SONGS PLAYED EVERY YEAR FROM 1977-1980: played in each year of From
+	Once      bool        // SONGS PLAYED ONCE: exactly one performance ever
+	InSet     SetPosition // SONGS IN SET2: only performances in that set, ranked by count
 	OrderBy   *OrderClause
 	Limit     *int
 	OutputFmt OutputFormat
 }
 
-// PerformanceQuery represents: PERFORMANCES OF song [FROM range] [WITH clause]
+// PerformanceQuery represents: PERFORMANCES OF song [FROM range] [WITH clause] [BY YEAR]
+// BY YEAR returns a count per year instead of the performances themselves.
 type PerformanceQuery struct {
-	Song    *SongRef
-	From    *DateRange
-	With    *WithClause
-	OrderBy *OrderClause
-	Limit   *int
+	Song      *SongRef
+	More      []*SongRef // PERFORMANCES OF "A", "B": songs after the first
+	From      *DateRange
+	With      *WithClause
+	ByYear    bool
+	OrderBy   *OrderClause
+	Limit     *int
+	OutputFmt OutputFormat
 }
 
 // SetlistQuery represents: SETLIST FOR date [AS format]
+// A Date without a day (5/77, 1977-05, 1977) covers every show in that month or year.
 type SetlistQuery struct {
 	Date      *Date
 	OutputFmt OutputFormat
@@ -55,15 +72,59 @@ type FirstLastQuery struct {
 	IsLast bool // false = FIRST, true = LAST
 }
 
+// CompareQuery represents: COMPARE "Song A" "Song B" [AS format]
+// Returns play count, first/last played, and average length for each song.
+type CompareQuery struct {
+	Songs     []*SongRef // exactly two
+	OutputFmt OutputFormat
+}
+
+// CoverageQuery represents: COVERAGE [FROM date_range] [AS format]
+// Returns, per year, the shows in the database against the known total.
+type CoverageQuery struct {
+	From      *DateRange
+	OutputFmt OutputFormat
+}
+
+// GapsQuery represents: GAPS [IN] [TOUR "name"] [FROM date_range] [AS format]
+// Returns the runs of days with no show between the first and last day of the
+// span: the date range, or the tour's first and last show within it.
+type GapsQuery struct {
+	Tour      string
+	From      *DateRange
+	OutputFmt OutputFormat
+}
+
 // RandomShowQuery represents: RANDOM SHOW [FROM date_range]
 type RandomShowQuery struct {
 	From *DateRange
 }
 
-// CountQuery represents: COUNT "Song Name" [FROM date_range] or COUNT SHOWS [FROM date_range] [WHERE ...]
+// VenueQuery represents: [STATS] VENUES [FROM date_range] [modifiers]
+// Returns venues actually played in the range, with per-venue show counts.
+// STATS VENUES also returns each venue's first and last show in the range.
+type VenueQuery struct {
+	Stats     bool
+	From      *DateRange
+	OrderBy   *OrderClause
+	Limit     *int
+	OutputFmt OutputFormat
+}
+
+// RunQuery represents: RUNS [AT "venue"] [FROM date_range] [modifiers]
+// Returns stretches of two or more consecutive nights at the same venue.
+type RunQuery struct {
+	At        string // venue name filter, as for SHOWS AT
+	From      *DateRange
+	Limit     *int
+	OutputFmt OutputFormat
+}
+
+// CountQuery represents: COUNT "Song Name" [FROM date_range] or COUNT SHOWS|VENUES [FROM date_range] [WHERE ...]
 type CountQuery struct {
-	Song       *SongRef     // nil for COUNT SHOWS
-	CountShows bool         // true for COUNT SHOWS
+	Song        *SongRef     // nil for COUNT SHOWS / COUNT VENUES
+	CountShows  bool         // true for COUNT SHOWS
+	CountVenues bool         // true for COUNT VENUES (distinct venues played)
 	From       *DateRange
 	Where      *WhereClause // optional WHERE conditions (COUNT SHOWS WHERE ...)
 }
@@ -75,6 +136,12 @@ type DateRange struct {
 	Era   *EraAlias
 }
 
+// Sentinel years for open-ended ranges (AFTER/BEFORE, FROM 1977-, FROM -1972).
+const (
+	OpenStartYear = 1900
+	OpenEndYear   = 2100
+)
+
 // Date represents a date (year, optional month/day, optional season).
 type Date struct {
 	Year   int
@@ -95,10 +162,35 @@ const (
 	EraVince
 )
 
-// WhereClause represents WHERE conditions.
+// WhereClause represents WHERE conditions. Conditions and Operators are the
+// flat, source-order view (len(Operators) == len(Conditions)-1). Groups is the
+// same conditions grouped by precedence: AND binds tighter than OR, so
+// `a OR b AND c` is [[a] [b c]] — an OR of AND-groups.
 type WhereClause struct {
 	Conditions []Condition
 	Operators  []LogicOp
+	Groups     [][]Condition
+}
+
+// OrGroups returns the clause as an OR of AND-groups. Clauses built without
+// Groups (e.g. by hand in tests) are grouped from Conditions and Operators.
+func (w *WhereClause) OrGroups() [][]Condition {
+	if w.Groups != nil {
+		return w.Groups
+	}
+	var groups [][]Condition
+	var cur []Condition
+	for i, c := range w.Conditions {
+		if i > 0 && i-1 < len(w.Operators) && w.Operators[i-1] == OpOr {
+			groups = append(groups, cur)
+			cur = nil
+		}
+		cur = append(cur, c)
+	}
+	if cur != nil {
+		groups = append(groups, cur)
+	}
+	return groups
 }
 
 // LogicOp is AND or OR between conditions.
@@ -117,11 +209,23 @@ type Condition interface {
 func (*SegueCondition) conditionNode()     {}
 func (*PositionCondition) conditionNode()  {}
 func (*PlayedCondition) conditionNode()   {}
+func (*PlayedSetCondition) conditionNode() {}
 func (*LengthCondition) conditionNode()   {}
 func (*GuestCondition) conditionNode()     {}
 func (*SegueIntoCondition) conditionNode()    {}
 func (*NegatedSegueCondition) conditionNode()  {}
 func (*SegueWithNegation) conditionNode()      {}
+func (*NotesCondition) conditionNode()         {}
+func (*CoverCondition) conditionNode()         {}
+func (*TapeCondition) conditionNode()          {}
+func (*SourceCondition) conditionNode()        {}
+func (*CompleteCondition) conditionNode()      {}
+func (*WeekdayCondition) conditionNode()       {}
+func (*SetNameCondition) conditionNode()       {}
+func (*TimesPlayedCondition) conditionNode()   {}
+func (*MissingFieldCondition) conditionNode()  {}
+func (*RatingCondition) conditionNode()        {}
+func (*SongCountCondition) conditionNode()     {}
 
 // SegueCondition represents: "Song A" > "Song B" > "Song C"
 type SegueCondition struct {
@@ -175,6 +279,15 @@ type PlayedCondition struct {
 	Negated bool
 }
 
+// PlayedSetCondition represents: PLAYED ALL ("A", "B", ...) or PLAYED ANY (...),
+// optionally NOT. ALL needs every song somewhere in the show, ANY at least
+// one; order and adjacency don't matter.
+type PlayedSetCondition struct {
+	Songs   []*SongRef
+	All     bool
+	Negated bool
+}
+
 // LengthCondition represents: LENGTH("Song") > 20min or LENGTH > 20min
 type LengthCondition struct {
 	Song     *SongRef // optional, for PERFORMANCES OF "X" WITH LENGTH > 20
@@ -187,6 +300,71 @@ type GuestCondition struct {
 	Name string
 }
 
+// NotesCondition represents: NOTES CONTAINS "text"
+// Matches shows whose show-level notes contain the text (case-insensitive).
+type NotesCondition struct {
+	Text string
+}
+
+// CoverCondition represents: COVER or ORIGINAL (SONGS WHERE only).
+// Cover is true for COVER, false for ORIGINAL.
+type CoverCondition struct {
+	Cover bool
+}
+
+// RatingCondition represents: RATING >= 4.5 (the show's combined rating).
+type RatingCondition struct {
+	Operator CompOp
+	Value    float64
+}
+
+// SongCountCondition represents: SONGS > 20 (SHOWS WHERE only), the number of
+// songs in the show's setlist.
+type SongCountCondition struct {
+	Operator CompOp
+	Count    int
+}
+
+// MissingFieldCondition represents: NO SHORT_NAME or NO WRITERS (SONGS WHERE
+// only). Field is the upper-case field name.
+type MissingFieldCondition struct {
+	Field string
+}
+
+// TimesPlayedCondition represents: TIMES_PLAYED > 100 (SONGS WHERE / WITH).
+type TimesPlayedCondition struct {
+	Operator CompOp
+	Count    int
+}
+
+// TapeCondition represents: TAPE
+// Matches shows where at least one song was played from tape (setlist.fm's tape flag).
+type TapeCondition struct{}
+
+// SourceCondition represents: SOURCE = "SBD"
+// Matches shows with an archive.org recording of that source (sbd, matrix, fm, aud).
+type SourceCondition struct {
+	Source string
+}
+
+// CompleteCondition represents: COMPLETE or NOT COMPLETE
+// Matches shows whose setlist looks fully documented (see sqlgen.ShowComplete).
+type CompleteCondition struct {
+	Negated bool
+}
+
+// WeekdayCondition represents: WEEKDAY = "Saturday"
+// Day follows SQLite's strftime('%w'): 0 is Sunday, 6 is Saturday.
+type WeekdayCondition struct {
+	Day int
+}
+
+// SetNameCondition represents: SET NAMED "Acoustic"
+// Matches shows with a set whose imported name contains the text (case-insensitive).
+type SetNameCondition struct {
+	Name string
+}
+
 // NegatedSegueCondition represents: "Song A" NOT > "Song B"
 // Matches shows where Song A was played and the next song was NOT Song B.
 type NegatedSegueCondition struct {
@@ -231,9 +409,10 @@ type SongRef struct {
 // WithClause represents WITH conditions.
 type WithClause struct {
 	Conditions []WithCondition
+	Context    bool // WITH CONTEXT (PERFORMANCES only): include the songs either side
 }
 
-// WithCondition is implemented by LYRICS, LENGTH, GUEST conditions.
+// WithCondition is implemented by LYRICS, LENGTH, GUEST, TIMES_PLAYED conditions.
 type WithCondition interface {
 	withConditionNode()
 }
@@ -241,6 +420,7 @@ type WithCondition interface {
 func (*LyricsCondition) withConditionNode() {}
 func (*LengthWithCondition) withConditionNode() {}
 func (*GuestWithCondition) withConditionNode() {}
+func (*TimesPlayedCondition) withConditionNode() {}
 
 // LyricsCondition represents: LYRICS("word1", "word2")
 type LyricsCondition struct {
@@ -277,4 +457,6 @@ const (
 	OutputCalendar
 	OutputTable
 	OutputCount
+	OutputHTML
+	OutputClassic // compact one-line-per-set setlist
 )
@@ -0,0 +1,283 @@
+// Package cli dispatches a gdql command line to a subcommand.
+//
+// Global options are split off first: -db <path> (also -db=<path>) and any
+// flag in Dispatcher.Flags may appear anywhere, $GDQL_DB stands in when -db is
+// absent, and "--" makes the rest of the line query text. The first remaining word picks a registered Command;
+// anything else is a query (words on the line, -f <file>, or - for stdin), and
+// an empty line is the REPL.
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Handler runs a parsed command line.
+type Handler func(inv *Invocation) error
+
+// Command is a subcommand such as "init" or "songs merge".
+type Command struct {
+	Name string // command words, lowercase; "songs merge" takes two
+	// Flags, if set, is parsed from the words after Name; flags may be mixed
+	// with positional arguments. Commands without Flags get every word as-is.
+	Flags *flag.FlagSet
+	Run   Handler
+}
+
+// Invocation is a parsed command line.
+type Invocation struct {
+	DBPath string   // -db value, else $GDQL_DB, else "" (the embedded database)
+	DBFlag bool     // DBPath came from -db rather than the environment
+	Args   []string // positional arguments after the command words
+	Query  string   // query text given on the command line
+	File   string   // -f <file>
+	Stdin  bool     // "-": read the query from stdin
+}
+
+// UsageError is a malformed command line; callers print usage along with it.
+type UsageError struct {
+	Msg string
+}
+
+func (e *UsageError) Error() string { return e.Msg }
+
+// Usagef returns a *UsageError.
+func Usagef(format string, args ...any) error {
+	return &UsageError{Msg: fmt.Sprintf(format, args...)}
+}
+
+// Dispatcher maps command lines to handlers.
+type Dispatcher struct {
+	Query *Command // a query: words on the line, -f <file>, or -
+	REPL  *Command // no command and no query
+	// Flags are global options such as --raw-json, accepted anywhere before
+	// "--" as -name, --name, or --name=value. Words that don't name one of
+	// them are left for the command or query.
+	Flags    *flag.FlagSet
+	commands []*Command
+}
+
+// Register adds subcommands.
+func (d *Dispatcher) Register(cmds ...*Command) {
+	d.commands = append(d.commands, cmds...)
+}
+
+// Run parses args and runs the chosen command. -h/--help returns flag.ErrHelp.
+func (d *Dispatcher) Run(args []string, envDB string) error {
+	cmd, inv, err := d.Parse(args, envDB)
+	if err != nil {
+		return err
+	}
+	return cmd.Run(inv)
+}
+
+// queryKeywords are the words a query starts with; a -db value beginning with
+// one of them is almost certainly a query that landed in the path slot.
+var queryKeywords = []string{"SHOWS", "SONGS", "PERFORMANCES", "SETLIST", "COUNT", "FIRST", "LAST", "RANDOM", "VENUES", "RUNS", "STATS", "COMPARE", "BEST", "COVERAGE", "GAPS"}
+
+// Parse splits args into the command to run and its invocation. envDB is
+// $GDQL_DB, used when -db is absent.
+//
+// Some shells hand over the whole line as one argument ("-db shows.db SHOWS
+// FROM 1977"), so an argument starting with "-db " is split the same way.
+func (d *Dispatcher) Parse(args []string, envDB string) (*Command, *Invocation, error) {
+	inv := &Invocation{}
+	setDB := func(path string) error {
+		switch {
+		case path == "":
+			return Usagef("-db requires a database path")
+		case looksLikeQuery(path):
+			return Usagef("-db %q looks like a query, not a database path (usage: gdql -db <path> <query>)", path)
+		case inv.DBFlag && path != inv.DBPath:
+			return Usagef("-db given twice (%s and %s)", inv.DBPath, path)
+		}
+		inv.DBPath, inv.DBFlag = path, true
+		return nil
+	}
+
+	var rest []string
+	queryOnly := false // "--" came before any command word
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case a == "--":
+			queryOnly = len(rest) == 0
+			rest = append(rest, args[i+1:]...)
+			i = len(args)
+		case a == "-db" || a == "--db":
+			if i+1 == len(args) {
+				return nil, nil, Usagef("-db requires a database path")
+			}
+			i++
+			if err := setDB(args[i]); err != nil {
+				return nil, nil, err
+			}
+		case strings.HasPrefix(a, "-db=") || strings.HasPrefix(a, "--db="):
+			if err := setDB(a[strings.Index(a, "=")+1:]); err != nil {
+				return nil, nil, err
+			}
+		case strings.HasPrefix(a, "-db "):
+			path, query, _ := strings.Cut(strings.TrimSpace(a[4:]), " ")
+			if err := setDB(path); err != nil {
+				return nil, nil, err
+			}
+			if query = strings.TrimSpace(query); query != "" {
+				rest = append(rest, query)
+			}
+		default:
+			n, err := d.setGlobal(args[i:])
+			if err != nil {
+				return nil, nil, err
+			}
+			if n == 0 {
+				rest = append(rest, a)
+				continue
+			}
+			i += n - 1
+		}
+	}
+	if !inv.DBFlag && envDB != "" {
+		inv.DBPath = envDB
+	}
+
+	if len(rest) == 0 {
+		return d.REPL, inv, nil
+	}
+	if queryOnly {
+		inv.Query = strings.TrimSpace(strings.Join(rest, " "))
+		return d.Query, inv, nil
+	}
+	if cmd, n := d.lookup(rest); cmd != nil {
+		words := rest[n:]
+		if cmd.Flags == nil {
+			inv.Args = words
+			return cmd, inv, nil
+		}
+		pos, err := parseInterspersed(cmd.Flags, words)
+		if err != nil {
+			return nil, nil, err
+		}
+		inv.Args = pos
+		return cmd, inv, nil
+	}
+
+	switch rest[0] {
+	case "-h", "-help", "--help":
+		return nil, nil, flag.ErrHelp
+	case "-f":
+		if len(rest) < 2 {
+			return nil, nil, Usagef("-f requires a filename")
+		}
+		if len(rest) > 2 {
+			return nil, nil, Usagef("unexpected arguments after -f %s: %s", rest[1], strings.Join(rest[2:], " "))
+		}
+		inv.File = rest[1]
+	case "-":
+		if len(rest) > 1 {
+			return nil, nil, Usagef("unexpected arguments after - (stdin): %s", strings.Join(rest[1:], " "))
+		}
+		inv.Stdin = true
+	default:
+		if strings.HasPrefix(rest[0], "-") && len(rest[0]) > 1 {
+			return nil, nil, Usagef("unknown flag %s", rest[0])
+		}
+		inv.Query = strings.TrimSpace(strings.Join(rest, " "))
+	}
+	return d.Query, inv, nil
+}
+
+// setGlobal sets the global flag named by args[0], if there is one, and
+// returns how many args it used: 0 when args[0] isn't a global flag.
+func (d *Dispatcher) setGlobal(args []string) (int, error) {
+	a := args[0]
+	if d.Flags == nil || !strings.HasPrefix(a, "-") {
+		return 0, nil
+	}
+	name, value, hasValue := strings.Cut(strings.TrimPrefix(a[1:], "-"), "=")
+	f := d.Flags.Lookup(name)
+	if f == nil {
+		return 0, nil
+	}
+	n := 1
+	if bf, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && bf.IsBoolFlag() {
+		if !hasValue {
+			value = "true"
+		}
+	} else if !hasValue {
+		if len(args) < 2 {
+			return 0, Usagef("--%s requires a value", name)
+		}
+		value, n = args[1], 2
+	}
+	if err := d.Flags.Set(name, value); err != nil {
+		return 0, Usagef("--%s: %v", name, err)
+	}
+	return n, nil
+}
+
+// lookup finds the command whose words start rest, preferring the longest
+// name, and returns it with the number of words it used. Names are matched
+// case-sensitively, so a query like "SONGS ..." never lands on "songs merge".
+func (d *Dispatcher) lookup(rest []string) (*Command, int) {
+	var best *Command
+	n := 0
+	for _, c := range d.commands {
+		words := strings.Fields(c.Name)
+		if len(words) <= n || len(words) > len(rest) {
+			continue
+		}
+		match := true
+		for i, w := range words {
+			if rest[i] != w {
+				match = false
+				break
+			}
+		}
+		if match {
+			best, n = c, len(words)
+		}
+	}
+	return best, n
+}
+
+// parseInterspersed parses fs from args, allowing flags after positional
+// arguments ("init my.db --quiet"), and returns the positional arguments.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	fs.SetOutput(io.Discard)
+	var pos []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, Usagef("%s: %v", fs.Name(), err)
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return pos, nil
+		}
+		pos = append(pos, args[0])
+		args = args[1:]
+	}
+}
+
+// looksLikeQuery reports whether a -db value is really query text.
+func looksLikeQuery(s string) bool {
+	s = strings.TrimSpace(s)
+	if strings.HasSuffix(s, ";") {
+		return true
+	}
+	first, more, _ := strings.Cut(s, " ")
+	if more == "" {
+		return false // a lone word is a path, even one named "shows"
+	}
+	for _, kw := range queryKeywords {
+		if strings.EqualFold(first, kw) {
+			return true
+		}
+	}
+	return false
+}
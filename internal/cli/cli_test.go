@@ -0,0 +1,171 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testDispatcher mirrors gdql's commands; quiet is bound to init's flags.
+func testDispatcher(quiet *bool) *Dispatcher {
+	d := &Dispatcher{Query: &Command{Name: "query"}, REPL: &Command{Name: "repl"}}
+	initFlags := flag.NewFlagSet("init", flag.ContinueOnError)
+	initFlags.BoolVar(quiet, "quiet", false, "")
+	initFlags.BoolVar(quiet, "q", false, "")
+	d.Register(
+		&Command{Name: "init", Flags: initFlags},
+		&Command{Name: "import"},
+		&Command{Name: "alias"},
+		&Command{Name: "songs merge"},
+	)
+	return d
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		env   string
+		cmd   string
+		want  Invocation
+		quiet bool
+	}{
+		{"no args", nil, "", "repl", Invocation{}, false},
+		{"only -db", []string{"-db", "shows.db"}, "", "repl", Invocation{DBPath: "shows.db", DBFlag: true}, false},
+		{"env db", []string{"SHOWS", "FROM", "1977"}, "env.db", "query", Invocation{DBPath: "env.db", Query: "SHOWS FROM 1977"}, false},
+		{"-db beats env", []string{"-db", "shows.db", "SHOWS;"}, "env.db", "query", Invocation{DBPath: "shows.db", DBFlag: true, Query: "SHOWS;"}, false},
+		{"query words", []string{"SHOWS", "FROM", "1977", "LIMIT", "5"}, "", "query", Invocation{Query: "SHOWS FROM 1977 LIMIT 5"}, false},
+		{"query one arg", []string{"SHOWS FROM 1977;"}, "", "query", Invocation{Query: "SHOWS FROM 1977;"}, false},
+		{"-db before query", []string{"-db", "shows.db", "SHOWS FROM 1977;"}, "", "query", Invocation{DBPath: "shows.db", DBFlag: true, Query: "SHOWS FROM 1977;"}, false},
+		{"-db after query", []string{"SHOWS FROM 1977;", "-db", "shows.db"}, "", "query", Invocation{DBPath: "shows.db", DBFlag: true, Query: "SHOWS FROM 1977;"}, false},
+		{"-db=path", []string{"-db=shows.db", "SHOWS;"}, "", "query", Invocation{DBPath: "shows.db", DBFlag: true, Query: "SHOWS;"}, false},
+		{"--db", []string{"--db", "shows.db", "SHOWS;"}, "", "query", Invocation{DBPath: "shows.db", DBFlag: true, Query: "SHOWS;"}, false},
+		{"merged by shell", []string{"-db shows.db SHOWS FROM 1977"}, "", "query", Invocation{DBPath: "shows.db", DBFlag: true, Query: "SHOWS FROM 1977"}, false},
+		{"merged path only", []string{"-db shows.db"}, "", "repl", Invocation{DBPath: "shows.db", DBFlag: true}, false},
+		{"db named like a keyword", []string{"-db", "shows", "SHOWS;"}, "", "query", Invocation{DBPath: "shows", DBFlag: true, Query: "SHOWS;"}, false},
+		{"double dash", []string{"-db", "x.db", "--", "-db", "SHOWS;"}, "", "query", Invocation{DBPath: "x.db", DBFlag: true, Query: "-db SHOWS;"}, false},
+		{"double dash command word", []string{"--", "init"}, "", "query", Invocation{Query: "init"}, false},
+		{"file", []string{"-f", "q.gdql"}, "", "query", Invocation{File: "q.gdql"}, false},
+		{"file after -db", []string{"-db", "shows.db", "-f", "q.gdql"}, "", "query", Invocation{DBPath: "shows.db", DBFlag: true, File: "q.gdql"}, false},
+		{"stdin", []string{"-"}, "", "query", Invocation{Stdin: true}, false},
+		{"init", []string{"init"}, "", "init", Invocation{}, false},
+		{"init path quiet", []string{"init", "-q", "my.db"}, "", "init", Invocation{Args: []string{"my.db"}}, true},
+		{"init quiet after path", []string{"init", "my.db", "--quiet"}, "", "init", Invocation{Args: []string{"my.db"}}, true},
+		{"init with -db", []string{"-db", "my.db", "init"}, "", "init", Invocation{DBPath: "my.db", DBFlag: true}, false},
+		{"alias", []string{"-db", "shows.db", "alias", "add", "Scarlet", "Scarlet Begonias"}, "", "alias", Invocation{DBPath: "shows.db", DBFlag: true, Args: []string{"add", "Scarlet", "Scarlet Begonias"}}, false},
+		{"alias -db last", []string{"alias", "list", "-db", "shows.db"}, "", "alias", Invocation{DBPath: "shows.db", DBFlag: true, Args: []string{"list"}}, false},
+		{"songs merge", []string{"-db", "shows.db", "songs", "merge", "1", "2"}, "", "songs merge", Invocation{DBPath: "shows.db", DBFlag: true, Args: []string{"1", "2"}}, false},
+		{"SONGS is a query", []string{"SONGS", "merge"}, "", "query", Invocation{Query: "SONGS merge"}, false},
+		{"songs alone is a query", []string{"songs"}, "", "query", Invocation{Query: "songs"}, false},
+		{"import", []string{"import", "json", "x.json"}, "", "import", Invocation{Args: []string{"json", "x.json"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var quiet bool
+			cmd, inv, err := testDispatcher(&quiet).Parse(tt.args, tt.env)
+			require.NoError(t, err)
+			require.Equal(t, tt.cmd, cmd.Name)
+			require.Equal(t, tt.want, *inv)
+			require.Equal(t, tt.quiet, quiet)
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"-db without path", []string{"-db"}, "-db requires a database path"},
+		{"-db= without path", []string{"-db=", "SHOWS;"}, "-db requires a database path"},
+		{"query in path slot", []string{"-db", "SHOWS FROM 1977"}, "looks like a query"},
+		{"statement in path slot", []string{"-db", "SHOWS;"}, "looks like a query"},
+		{"-db twice", []string{"-db", "a.db", "-db", "b.db", "SHOWS;"}, "-db given twice"},
+		{"-f without file", []string{"-f"}, "-f requires a filename"},
+		{"-f with extra", []string{"-f", "q.gdql", "SHOWS;"}, "unexpected arguments after -f"},
+		{"stdin with extra", []string{"-", "SHOWS;"}, "unexpected arguments after -"},
+		{"unknown flag", []string{"-x", "SHOWS;"}, "unknown flag -x"},
+		{"unknown command flag", []string{"init", "--force"}, "init: flag provided but not defined: -force"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var quiet bool
+			_, _, err := testDispatcher(&quiet).Parse(tt.args, "")
+			require.Error(t, err)
+			var usageErr *UsageError
+			require.True(t, errors.As(err, &usageErr), "want a UsageError, got %T", err)
+			require.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestParse_Help(t *testing.T) {
+	var quiet bool
+	for _, args := range [][]string{{"-h"}, {"--help"}, {"-db", "shows.db", "-help"}, {"init", "-h"}} {
+		_, _, err := testDispatcher(&quiet).Parse(args, "")
+		require.ErrorIs(t, err, flag.ErrHelp, "%v", args)
+	}
+}
+
+func TestParse_GlobalFlags(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		want   Invocation
+		raw    bool
+		format string
+	}{
+		{"bool before query", []string{"--raw-json", "SHOWS;"}, Invocation{Query: "SHOWS;"}, true, ""},
+		{"bool after query", []string{"SHOWS", "FROM", "1977", "-raw-json"}, Invocation{Query: "SHOWS FROM 1977"}, true, ""},
+		{"bool=false", []string{"--raw-json=false", "SHOWS;"}, Invocation{Query: "SHOWS;"}, false, ""},
+		{"value", []string{"--format", "csv", "SHOWS;"}, Invocation{Query: "SHOWS;"}, false, "csv"},
+		{"value=", []string{"SHOWS;", "--format=json"}, Invocation{Query: "SHOWS;"}, false, "json"},
+		{"with -db", []string{"-db", "shows.db", "--raw-json", "-f", "q.gdql"}, Invocation{DBPath: "shows.db", DBFlag: true, File: "q.gdql"}, true, ""},
+		{"after double dash", []string{"--", "--raw-json"}, Invocation{Query: "--raw-json"}, false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var quiet, raw bool
+			var format string
+			d := testDispatcher(&quiet)
+			d.Flags = flag.NewFlagSet("gdql", flag.ContinueOnError)
+			d.Flags.BoolVar(&raw, "raw-json", false, "")
+			d.Flags.StringVar(&format, "format", "", "")
+			_, inv, err := d.Parse(tt.args, "")
+			require.NoError(t, err)
+			require.Equal(t, tt.want, *inv)
+			require.Equal(t, tt.raw, raw)
+			require.Equal(t, tt.format, format)
+		})
+	}
+
+	var quiet, raw bool
+	d := testDispatcher(&quiet)
+	d.Flags = flag.NewFlagSet("gdql", flag.ContinueOnError)
+	d.Flags.BoolVar(&raw, "raw-json", false, "")
+	d.Flags.String("format", "", "")
+	_, _, err := d.Parse([]string{"SHOWS;", "--format"}, "")
+	require.ErrorContains(t, err, "--format requires a value")
+	_, _, err = d.Parse([]string{"--raw-json=maybe", "SHOWS;"}, "")
+	require.ErrorContains(t, err, "--raw-json")
+	var usageErr *UsageError
+	require.True(t, errors.As(err, &usageErr))
+}
+
+func TestRun(t *testing.T) {
+	var got *Invocation
+	d := &Dispatcher{Query: &Command{Name: "query"}, REPL: &Command{Name: "repl"}}
+	d.Register(&Command{Name: "export", Run: func(inv *Invocation) error {
+		got = inv
+		return nil
+	}})
+	require.NoError(t, d.Run([]string{"export", "out.json", "-db", "shows.db"}, ""))
+	require.Equal(t, &Invocation{DBPath: "shows.db", DBFlag: true, Args: []string{"out.json"}}, got)
+
+	boom := errors.New("boom")
+	d.Register(&Command{Name: "fail", Run: func(*Invocation) error { return boom }})
+	require.ErrorIs(t, d.Run([]string{"fail"}, ""), boom)
+}
@@ -0,0 +1,111 @@
+package data
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// ComposeTitle returns s in Unicode NFC, so a title typed or imported with
+// decomposed accents ("e" plus a combining acute) is stored and looked up as
+// the same string as its precomposed form ("é"). The two look identical but
+// compare unequal, which would otherwise mean a duplicate song on import and
+// a failed exact match at query time.
+func ComposeTitle(s string) string {
+	return norm.NFC.String(s)
+}
+
+// foldLetters spells out the letters Unicode doesn't decompose into a base
+// letter and accents, so stripAccents can't reach them.
+var foldLetters = map[rune]string{
+	'æ': "ae", 'œ': "oe", 'ø': "o", 'ß': "ss", 'đ': "d", 'ł': "l", 'ı': "i",
+}
+
+// stripAccents lowercases s and removes its diacritics: each character is
+// decomposed (NFD, "é" → "e" plus a combining acute) and the combining marks
+// are dropped. foldLetters covers the rest.
+func stripAccents(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(strings.ToLower(s)) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		if f, ok := foldLetters[r]; ok {
+			b.WriteString(f)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// FoldText normalizes text for lyric search: lowercases, strips diacritics
+// ("Café" → "cafe"), and turns every non-letter/digit (including all apostrophe
+// variants) into a single space. Stored lyrics_fts and search words both go
+// through this so they compare on the same terms.
+func FoldText(s string) string {
+	var b strings.Builder
+	space := true
+	for _, r := range stripAccents(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			space = false
+		} else if !space {
+			b.WriteByte(' ')
+			space = true
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// NormalizeSongName reduces a song title to a matching key so mechanical
+// variants of one title compare equal: case, punctuation ("Franklin's" /
+// "Franklins", "U.S." / "US"), spacing, diacritics, "&" / "+" for "and",
+// spelled-out abbreviations ("Saint" / "St.") and dropped g's ("Goin'" /
+// "Going"). Only an -ing suffix loses its g: "Thing", "Swing" and "Spring"
+// have no vowel before the "ing" and stay as they are. Hyphens and dashes
+// separate words like a space, so "Brown-Eyed Women" matches "Brown Eyed
+// Women". Song lookups at query time and song matching at import both use
+// it, so a variant that resolves in one resolves in the other.
+func NormalizeSongName(s string) string {
+	var b strings.Builder
+	lastSpace := false
+	space := func() {
+		if !lastSpace && b.Len() > 0 {
+			b.WriteByte(' ')
+			lastSpace = true
+		}
+	}
+	for _, r := range stripAccents(s) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+			lastSpace = false
+		case r == '&' || r == '+':
+			space()
+			b.WriteString("and ")
+			lastSpace = true
+		case unicode.IsSpace(r) || unicode.Is(unicode.Pd, r):
+			space()
+		}
+		// Apostrophes, periods, commas etc. are dropped
+	}
+	words := strings.Fields(b.String())
+	for i, w := range words {
+		if canon, ok := songWordVariants[w]; ok {
+			w = canon
+		} else if stem, ok := strings.CutSuffix(w, "ing"); ok && strings.ContainsAny(stem, "aeiouy") {
+			w = stem + "in" // "going" → "goin", as in "Goin' Down the Road"
+		}
+		words[i] = w
+	}
+	return strings.Join(words, " ")
+}
+
+// songWordVariants maps spelled-out words to the form the band's setlists
+// use. Keep it minimal: add entries only for real mismatches.
+var songWordVariants = map[string]string{
+	"saint":  "st",
+	"mister": "mr",
+}
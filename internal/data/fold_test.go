@@ -0,0 +1,31 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFoldText(t *testing.T) {
+	for in, want := range map[string]string{
+		"Café":          "cafe",
+		"Cafe\u0301":    "cafe", // decomposed accent
+		"Ångström Ærø":  "angstrom aero",
+		"Straße":        "strasse",
+		"Walkin’ Blues": "walkin blues",
+		"Señor Łódź":    "senor lodz",
+	} {
+		require.Equal(t, want, FoldText(in), in)
+	}
+}
+
+func TestNormalizeSongName_Accents(t *testing.T) {
+	require.Equal(t, NormalizeSongName("Café Blues"), NormalizeSongName("Cafe\u0301 Blues"))
+	require.Equal(t, "senor", NormalizeSongName("Señor"))
+}
+
+func TestNormalizeSongName_Hyphens(t *testing.T) {
+	require.Equal(t, NormalizeSongName("Brown Eyed Women"), NormalizeSongName("Brown-Eyed Women"))
+	require.Equal(t, "scarlet begonias", NormalizeSongName("Scarlet Begonias-"))
+	require.Equal(t, "dark star", NormalizeSongName("Dark Star –"))
+}
@@ -0,0 +1,117 @@
+package data
+
+import (
+	"strconv"
+	"time"
+)
+
+// Row values are normalized by the data source (see NormalizeValue) to one of
+// nil, int64, float64, or string. The accessors below convert them to what a
+// mapper wants, so mappers don't each handle every driver type. An index past
+// the end of the row reads as NULL.
+
+// NormalizeValue maps a driver value to nil, int64, float64, or string:
+// TEXT that arrives as []byte becomes a string, other integer and float
+// widths widen, and bools become 0 or 1. Anything else is returned as-is.
+func NormalizeValue(v interface{}) interface{} {
+	switch x := v.(type) {
+	case []byte:
+		return string(x)
+	case int:
+		return int64(x)
+	case int32:
+		return int64(x)
+	case float32:
+		return float64(x)
+	case bool:
+		if x {
+			return int64(1)
+		}
+		return int64(0)
+	}
+	return v
+}
+
+func (r Row) value(i int) interface{} {
+	if i < 0 || i >= len(r) {
+		return nil
+	}
+	return r[i]
+}
+
+// IsNull reports whether column i is NULL (or missing).
+func (r Row) IsNull(i int) bool {
+	return r.value(i) == nil
+}
+
+// Int returns column i as an int. Floats are truncated and numeric text is
+// parsed; NULL and anything unconvertible are 0.
+func (r Row) Int(i int) int {
+	switch x := r.value(i).(type) {
+	case int:
+		return x
+	case int64:
+		return int(x)
+	case float64:
+		return int(x)
+	case string:
+		if n, err := strconv.Atoi(x); err == nil {
+			return n
+		}
+	}
+	return 0
+}
+
+// Float returns column i as a float64. A REAL column such as a rating can
+// come back as an integer when the stored value is whole (5 rather than 5.0),
+// so integers are accepted too. NULL is 0.
+func (r Row) Float(i int) float64 {
+	switch x := r.value(i).(type) {
+	case float64:
+		return x
+	case int:
+		return float64(x)
+	case int64:
+		return float64(x)
+	case string:
+		if f, err := strconv.ParseFloat(x, 64); err == nil {
+			return f
+		}
+	}
+	return 0
+}
+
+// Text returns column i as a string; NULL and non-text values are "".
+func (r Row) Text(i int) string {
+	s, _ := r.value(i).(string)
+	return s
+}
+
+// Time parses column i as a YYYY-MM-DD date (a longer timestamp is cut to its
+// date). NULL and unparseable values are the zero time.
+func (r Row) Time(i int) time.Time {
+	s := r.Text(i)
+	if len(s) > 10 {
+		s = s[:10]
+	}
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
+
+// NullInt is Int, but nil for NULL.
+func (r Row) NullInt(i int) *int {
+	if r.IsNull(i) {
+		return nil
+	}
+	n := r.Int(i)
+	return &n
+}
+
+// NullFloat is Float, but nil for NULL.
+func (r Row) NullFloat(i int) *float64 {
+	if r.IsNull(i) {
+		return nil
+	}
+	f := r.Float(i)
+	return &f
+}
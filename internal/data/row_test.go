@@ -0,0 +1,75 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeValue(t *testing.T) {
+	require.Equal(t, "Dew", NormalizeValue([]byte("Dew")))
+	require.Equal(t, int64(5), NormalizeValue(5))
+	require.Equal(t, int64(5), NormalizeValue(int32(5)))
+	require.Equal(t, float64(4.5), NormalizeValue(float32(4.5)))
+	require.Equal(t, int64(1), NormalizeValue(true))
+	require.Equal(t, int64(0), NormalizeValue(false))
+	require.Nil(t, NormalizeValue(nil))
+	require.Equal(t, "x", NormalizeValue("x"))
+}
+
+func TestRow_Int(t *testing.T) {
+	row := Row{5, int64(5), 5.9, "42", "not a number", nil, struct{}{}}
+	require.Equal(t, 5, row.Int(0))
+	require.Equal(t, 5, row.Int(1))
+	require.Equal(t, 5, row.Int(2))
+	require.Equal(t, 42, row.Int(3))
+	require.Equal(t, 0, row.Int(4))
+	require.Equal(t, 0, row.Int(5))
+	require.Equal(t, 0, row.Int(6))
+	require.Equal(t, 0, row.Int(7), "past the end reads as NULL")
+}
+
+func TestRow_Float(t *testing.T) {
+	// A rating column mixes whole numbers (stored as integers) with fractions.
+	row := Row{4.9, 5, int64(5), "3.5", nil}
+	require.Equal(t, 4.9, row.Float(0))
+	require.Equal(t, 5.0, row.Float(1))
+	require.Equal(t, 5.0, row.Float(2))
+	require.Equal(t, 3.5, row.Float(3))
+	require.Equal(t, 0.0, row.Float(4))
+	require.Equal(t, 0.0, row.Float(-1))
+}
+
+func TestRow_Text(t *testing.T) {
+	row := Row{"hello", nil, 42}
+	require.Equal(t, "hello", row.Text(0))
+	require.Equal(t, "", row.Text(1))
+	require.Equal(t, "", row.Text(2))
+	require.Equal(t, "", row.Text(3))
+}
+
+func TestRow_Time(t *testing.T) {
+	row := Row{"1977-05-08", "1977-05-08T00:00:00Z", "not a date", nil}
+	tm := row.Time(0)
+	require.Equal(t, 1977, tm.Year())
+	require.Equal(t, 5, int(tm.Month()))
+	require.Equal(t, 8, tm.Day())
+	require.Equal(t, tm, row.Time(1))
+	require.True(t, row.Time(2).IsZero())
+	require.True(t, row.Time(3).IsZero())
+}
+
+func TestRow_Nullable(t *testing.T) {
+	row := Row{nil, int64(0), 4, nil, 0.0, int64(5)}
+	require.True(t, row.IsNull(0))
+	require.False(t, row.IsNull(1))
+	require.True(t, row.IsNull(9))
+
+	require.Nil(t, row.NullInt(0))
+	require.Equal(t, 0, *row.NullInt(1), "zero is a value, not NULL")
+	require.Equal(t, 4, *row.NullInt(2))
+
+	require.Nil(t, row.NullFloat(3))
+	require.Equal(t, 0.0, *row.NullFloat(4))
+	require.Equal(t, 5.0, *row.NullFloat(5))
+}
@@ -21,6 +21,18 @@ type DataSource interface {
 	Close() error
 }
 
+// StrictSongSource is implemented by data sources that can resolve a song name
+// without heuristics: only exact (case-insensitive) names and aliases match.
+type StrictSongSource interface {
+	GetSongStrict(ctx context.Context, name string) (*Song, error)
+}
+
+// AliasSource is implemented by data sources that know whether they have a
+// song_aliases table; a read-only open of an older database may not.
+type AliasSource interface {
+	HasAliases() bool
+}
+
 // ResultSet is the result of a query.
 type ResultSet struct {
 	Columns []string
@@ -56,35 +68,51 @@ type Recording struct {
 
 // Show is a single show.
 type Show struct {
-	ID         int         `json:"id"`
-	Date       time.Time   `json:"date"`
-	VenueID    int         `json:"venue_id,omitempty"`
-	Venue      string      `json:"venue"`
-	City       string      `json:"city,omitempty"`
-	State      string      `json:"state,omitempty"`
-	Tour       string      `json:"tour,omitempty"`
-	Coords     *Coords     `json:"coords,omitempty"`
-	Weather    *Weather    `json:"weather,omitempty"`
-	Recordings []Recording `json:"recordings,omitempty"`
+	ID            int         `json:"id"`
+	Date          time.Time   `json:"date"`
+	VenueID       int         `json:"venue_id,omitempty"`
+	Venue         string      `json:"venue"`
+	City          string      `json:"city,omitempty"`
+	State         string      `json:"state,omitempty"`
+	Tour          string      `json:"tour,omitempty"`
+	LengthSeconds int         `json:"length_seconds,omitempty"` // total of performance lengths; 0 if unknown
+	MatchSet      int         `json:"match_set,omitempty"`      // segue queries: set where the chain started
+	MatchPosition int         `json:"match_position,omitempty"` // segue queries: position of the chain's first song; 0 otherwise
+	Complete      *bool       `json:"complete,omitempty"`       // setlist completeness heuristic; nil if not computed
+	Rating        *float64    `json:"rating,omitempty"`         // combined show_ratings average; nil without rating sources
+	RatingCount   int         `json:"rating_count,omitempty"`   // votes behind Rating
+	SourceURL     string      `json:"source_url,omitempty"`     // the show's page at its import source (e.g. setlist.fm)
+	Coords        *Coords     `json:"coords,omitempty"`
+	Weather       *Weather    `json:"weather,omitempty"`
+	Recordings    []Recording `json:"recordings,omitempty"`
 }
 
 // MarshalJSON renders Date as YYYY-MM-DD instead of full RFC3339.
 func (s Show) MarshalJSON() ([]byte, error) {
 	type showOut struct {
-		ID         int         `json:"id"`
-		Date       string      `json:"date"`
-		VenueID    int         `json:"venue_id,omitempty"`
-		Venue      string      `json:"venue"`
-		City       string      `json:"city,omitempty"`
-		State      string      `json:"state,omitempty"`
-		Tour       string      `json:"tour,omitempty"`
-		Coords     *Coords     `json:"coords,omitempty"`
-		Weather    *Weather    `json:"weather,omitempty"`
-		Recordings []Recording `json:"recordings,omitempty"`
+		ID            int         `json:"id"`
+		Date          string      `json:"date"`
+		VenueID       int         `json:"venue_id,omitempty"`
+		Venue         string      `json:"venue"`
+		City          string      `json:"city,omitempty"`
+		State         string      `json:"state,omitempty"`
+		Tour          string      `json:"tour,omitempty"`
+		LengthSeconds int         `json:"length_seconds,omitempty"`
+		MatchSet      int         `json:"match_set,omitempty"`
+		MatchPosition int         `json:"match_position,omitempty"`
+		Complete      *bool       `json:"complete,omitempty"`
+		Rating        *float64    `json:"rating,omitempty"`
+		RatingCount   int         `json:"rating_count,omitempty"`
+		SourceURL     string      `json:"source_url,omitempty"`
+		Coords        *Coords     `json:"coords,omitempty"`
+		Weather       *Weather    `json:"weather,omitempty"`
+		Recordings    []Recording `json:"recordings,omitempty"`
 	}
 	out := showOut{
 		ID: s.ID, VenueID: s.VenueID, Venue: s.Venue,
-		City: s.City, State: s.State, Tour: s.Tour,
+		City: s.City, State: s.State, Tour: s.Tour, LengthSeconds: s.LengthSeconds,
+		MatchSet: s.MatchSet, MatchPosition: s.MatchPosition, Complete: s.Complete,
+		Rating: s.Rating, RatingCount: s.RatingCount, SourceURL: s.SourceURL,
 		Coords: s.Coords, Weather: s.Weather, Recordings: s.Recordings,
 	}
 	if !s.Date.IsZero() {
@@ -112,9 +140,23 @@ type Song struct {
 	FirstPlayed time.Time      `json:"first_played,omitempty"`
 	LastPlayed  time.Time      `json:"last_played,omitempty"`
 	TimesPlayed int            `json:"times_played,omitempty"`
+	AvgLength   int            `json:"avg_length_seconds,omitempty"` // set by ORDER BY AVG_LENGTH
 	Related     []SongRelation `json:"related,omitempty"`
+	MatchedBy   MatchStage     `json:"-"` // how GetSong matched the name asked for; "" from other lookups
 }
 
+// MatchStage is the step of GetSong's name lookup that found a song. The
+// steps run in this order, most confident first, and the first to match wins.
+type MatchStage string
+
+const (
+	MatchExact   MatchStage = "exact"   // the song's name, ignoring case
+	MatchAlias   MatchStage = "alias"   // a song_aliases row
+	MatchVariant MatchStage = "variant" // the same name under NormalizeSongName
+	MatchTrimmed MatchStage = "trimmed" // the name without trailing dashes and spaces
+	MatchFuzzy   MatchStage = "fuzzy"   // a prefix or most of the name's words
+)
+
 // MarshalJSON omits zero-time fields entirely (Go's encoding/json renders zero
 // time as "0001-01-01T00:00:00Z" with omitempty, which isn't what we want).
 func (s Song) MarshalJSON() ([]byte, error) {
@@ -126,11 +168,12 @@ func (s Song) MarshalJSON() ([]byte, error) {
 		FirstPlayed string         `json:"first_played,omitempty"`
 		LastPlayed  string         `json:"last_played,omitempty"`
 		TimesPlayed int            `json:"times_played,omitempty"`
+		AvgLength   int            `json:"avg_length_seconds,omitempty"`
 		Related     []SongRelation `json:"related,omitempty"`
 	}
 	out := songOut{
 		ID: s.ID, Name: s.Name, ShortName: s.ShortName, Writers: s.Writers,
-		TimesPlayed: s.TimesPlayed, Related: s.Related,
+		TimesPlayed: s.TimesPlayed, AvgLength: s.AvgLength, Related: s.Related,
 	}
 	if !s.FirstPlayed.IsZero() {
 		out.FirstPlayed = s.FirstPlayed.Format("2006-01-02")
@@ -141,6 +184,43 @@ func (s Song) MarshalJSON() ([]byte, error) {
 	return jsonMarshal(out)
 }
 
+// Venue is a place the band played. ShowCount is set by VENUES queries
+// (number of shows there within the queried range); FirstShow and LastShow
+// by STATS VENUES.
+type Venue struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	City      string    `json:"city,omitempty"`
+	State     string    `json:"state,omitempty"`
+	Country   string    `json:"country,omitempty"`
+	ShowCount int       `json:"shows"`
+	FirstShow time.Time `json:"first_show,omitempty"`
+	LastShow  time.Time `json:"last_show,omitempty"`
+}
+
+// MarshalJSON writes FirstShow and LastShow as YYYY-MM-DD, omitting them when
+// unset.
+func (v Venue) MarshalJSON() ([]byte, error) {
+	type venueOut struct {
+		ID        int    `json:"id"`
+		Name      string `json:"name"`
+		City      string `json:"city,omitempty"`
+		State     string `json:"state,omitempty"`
+		Country   string `json:"country,omitempty"`
+		ShowCount int    `json:"shows"`
+		FirstShow string `json:"first_show,omitempty"`
+		LastShow  string `json:"last_show,omitempty"`
+	}
+	out := venueOut{ID: v.ID, Name: v.Name, City: v.City, State: v.State, Country: v.Country, ShowCount: v.ShowCount}
+	if !v.FirstShow.IsZero() {
+		out.FirstShow = v.FirstShow.Format("2006-01-02")
+	}
+	if !v.LastShow.IsZero() {
+		out.LastShow = v.LastShow.Format("2006-01-02")
+	}
+	return jsonMarshal(out)
+}
+
 // Performance is a song performed at a show.
 // SongName is set when the query joins with songs (e.g. setlist) for display.
 type Performance struct {
@@ -148,10 +228,19 @@ type Performance struct {
 	ShowID        int    `json:"show_id"`
 	SongID        int    `json:"song_id"`
 	SetNumber     int    `json:"set_number,omitempty"`
+	SetName       string `json:"set_name,omitempty"` // e.g. "Acoustic Set"; empty for plain numbered sets
 	Position      int    `json:"position,omitempty"`
 	SegueType     string `json:"segue,omitempty"`
 	LengthSeconds int    `json:"length_seconds,omitempty"`
 	SongName      string `json:"song,omitempty"`
+	SongShortName string `json:"song_short_name,omitempty"` // songs.short_name, e.g. "Scarlet"
 	Date          string `json:"date,omitempty"`
 	Venue         string `json:"venue,omitempty"`
+	Nth           int    `json:"nth,omitempty"` // chronological play count of the song (1 = debut); PERFORMANCES OF only
+	// PERFORMANCES ... WITH CONTEXT: the songs either side in the same set,
+	// and the previous song's segue marker (how it led into this one). Empty
+	// at a set boundary.
+	PrevSong  string `json:"prev_song,omitempty"`
+	PrevSegue string `json:"prev_segue,omitempty"`
+	NextSong  string `json:"next_song,omitempty"`
 }
@@ -4,9 +4,13 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"os"
 )
 
+// ErrCanonicalNotFound is returned by AddAlias when the canonical name matches no song.
+var ErrCanonicalNotFound = errors.New("canonical song not found")
+
 // AliasEntry is one row for gdql import aliases.
 type AliasEntry struct {
 	Alias     string `json:"alias"`
@@ -31,11 +35,7 @@ func LoadAliasesFromFile(ctx context.Context, db *sql.DB, path string) (loaded,
 			skipped++
 			continue
 		}
-		var songID int64
-		err := db.QueryRowContext(ctx, "SELECT id FROM songs WHERE name = ? OR LOWER(name) = LOWER(?) LIMIT 1", e.Canonical, e.Canonical).Scan(&songID)
-		if err == sql.ErrNoRows {
-			err = db.QueryRowContext(ctx, "SELECT id FROM songs WHERE LOWER(TRIM(name, '- ')) = LOWER(TRIM(?, '- ')) LIMIT 1", e.Canonical).Scan(&songID)
-		}
+		songID, err := canonicalSongID(ctx, db, e.Canonical)
 		if err == sql.ErrNoRows {
 			skipped++
 			continue
@@ -51,3 +51,57 @@ func LoadAliasesFromFile(ctx context.Context, db *sql.DB, path string) (loaded,
 	}
 	return loaded, skipped, nil
 }
+
+// canonicalSongID resolves a canonical song name to its id: exact or case-insensitive
+// match first, then ignoring trailing dashes/spaces. Returns sql.ErrNoRows when nothing matches.
+func canonicalSongID(ctx context.Context, db *sql.DB, name string) (int64, error) {
+	var songID int64
+	err := db.QueryRowContext(ctx, "SELECT id FROM songs WHERE name = ? OR LOWER(name) = LOWER(?) LIMIT 1", name, name).Scan(&songID)
+	if err == sql.ErrNoRows {
+		err = db.QueryRowContext(ctx, "SELECT id FROM songs WHERE LOWER(TRIM(name, '- ')) = LOWER(TRIM(?, '- ')) LIMIT 1", name).Scan(&songID)
+	}
+	return songID, err
+}
+
+// AddAlias maps alias to the song named canonical, replacing any existing mapping for alias.
+// Returns ErrCanonicalNotFound when canonical doesn't resolve to a song.
+func AddAlias(ctx context.Context, db *sql.DB, alias, canonical string) error {
+	songID, err := canonicalSongID(ctx, db, canonical)
+	if err == sql.ErrNoRows {
+		return ErrCanonicalNotFound
+	}
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, "INSERT OR REPLACE INTO song_aliases (alias, song_id) VALUES (?, ?)", alias, songID)
+	return err
+}
+
+// ListAliases returns all aliases with the name of the song each points at, sorted by alias.
+// Aliases whose song no longer exists are listed with an empty Canonical.
+func ListAliases(ctx context.Context, db *sql.DB) ([]AliasEntry, error) {
+	rows, err := db.QueryContext(ctx, "SELECT a.alias, COALESCE(s.name, '') FROM song_aliases a LEFT JOIN songs s ON s.id = a.song_id ORDER BY a.alias")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []AliasEntry
+	for rows.Next() {
+		var e AliasEntry
+		if err := rows.Scan(&e.Alias, &e.Canonical); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// RemoveAlias deletes alias. Reports whether it existed.
+func RemoveAlias(ctx context.Context, db *sql.DB, alias string) (bool, error) {
+	res, err := db.ExecContext(ctx, "DELETE FROM song_aliases WHERE alias = ?", alias)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
@@ -44,3 +44,32 @@ func TestLoadAliasesFromFile(t *testing.T) {
 	require.NotNil(t, song2)
 	require.Equal(t, "Fire on the Mountain", song2.Name)
 }
+
+func TestAddListRemoveAlias(t *testing.T) {
+	path, cleanup := fixtures.CreateTestDB(t)
+	defer cleanup()
+
+	db, err := Open(path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	require.NoError(t, AddAlias(ctx, db.DB(), "Scarlet", "scarlet begonias"))
+	require.ErrorIs(t, AddAlias(ctx, db.DB(), "Nope", "No Such Song In DB"), ErrCanonicalNotFound)
+
+	aliases, err := ListAliases(ctx, db.DB())
+	require.NoError(t, err)
+	require.Contains(t, aliases, AliasEntry{Alias: "Scarlet", Canonical: "Scarlet Begonias"})
+
+	song, err := db.GetSong(ctx, "Scarlet")
+	require.NoError(t, err)
+	require.NotNil(t, song)
+	require.Equal(t, "Scarlet Begonias", song.Name)
+
+	removed, err := RemoveAlias(ctx, db.DB(), "Scarlet")
+	require.NoError(t, err)
+	require.True(t, removed)
+	removed, err = RemoveAlias(ctx, db.DB(), "Scarlet")
+	require.NoError(t, err)
+	require.False(t, removed)
+}
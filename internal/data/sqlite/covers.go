@@ -0,0 +1,46 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"os"
+)
+
+// CoverEntry is one row for gdql import covers.
+type CoverEntry struct {
+	Song           string `json:"song"`
+	OriginalArtist string `json:"original_artist"`
+}
+
+// LoadCoversFromFile reads a curated JSON list of cover songs and sets songs.is_cover = 1
+// (and original_artist, when given) for each. Song is resolved via songs.name (exact or
+// case-insensitive). Format: [{"song": "Morning Dew", "original_artist": "Bonnie Dobson"}, ...]
+// Songs not listed are left untouched; entries whose song is not found are skipped.
+func LoadCoversFromFile(ctx context.Context, db *sql.DB, path string) (loaded, skipped int, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	var entries []CoverEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return 0, 0, err
+	}
+	for _, e := range entries {
+		if e.Song == "" {
+			skipped++
+			continue
+		}
+		res, err := db.ExecContext(ctx, "UPDATE songs SET is_cover = 1, original_artist = COALESCE(?, original_artist) WHERE name = ? OR LOWER(name) = LOWER(?)",
+			nullableStr(e.OriginalArtist), e.Song, e.Song)
+		if err != nil {
+			return loaded, skipped, err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			skipped++
+			continue
+		}
+		loaded++
+	}
+	return loaded, skipped, nil
+}
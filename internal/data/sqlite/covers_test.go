@@ -0,0 +1,42 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gdql/gdql/test/fixtures"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadCoversFromFile(t *testing.T) {
+	path, cleanup := fixtures.CreateTestDB(t)
+	defer cleanup()
+
+	db, err := Open(path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	coversPath := filepath.Join(t.TempDir(), "covers.json")
+	err = os.WriteFile(coversPath, []byte(`[
+		{"song": "morning dew", "original_artist": "Bonnie Dobson"},
+		{"song": "Dark Star"},
+		{"song": "No Such Song In DB"}
+	]`), 0644)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	loaded, skipped, err := LoadCoversFromFile(ctx, db.DB(), coversPath)
+	require.NoError(t, err)
+	require.Equal(t, 2, loaded)
+	require.Equal(t, 1, skipped)
+
+	var isCover int
+	var artist sql.NullString
+	err = db.DB().QueryRowContext(ctx, "SELECT is_cover, original_artist FROM songs WHERE name = 'Morning Dew'").Scan(&isCover, &artist)
+	require.NoError(t, err)
+	require.Equal(t, 1, isCover)
+	require.Equal(t, "Bonnie Dobson", artist.String)
+}
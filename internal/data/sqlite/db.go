@@ -5,70 +5,113 @@ import (
 	"database/sql"
 	"strings"
 	"time"
-	"unicode"
 
 	"github.com/gdql/gdql/internal/data"
 
 	_ "github.com/ncruces/go-sqlite3/driver"
 )
 
-// normalizeName strips punctuation, extra whitespace, and lowercases for fuzzy matching.
-// "Franklin's Tower" → "franklins tower", "Truckin'" → "truckin"
-// Also canonicalizes a small set of abbreviations so spelled-out forms match
-// the band's setlist convention (e.g. "Saint Stephen" → "St. Stephen").
-func normalizeName(s string) string {
-	var b strings.Builder
-	lastSpace := false
-	for _, r := range strings.ToLower(s) {
-		if unicode.IsLetter(r) || unicode.IsDigit(r) {
-			b.WriteRune(r)
-			lastSpace = false
-		} else if r == ' ' || r == '\t' {
-			if !lastSpace && b.Len() > 0 {
-				b.WriteRune(' ')
-				lastSpace = true
-			}
-		}
-		// Apostrophes, periods, commas, etc. are silently dropped
-	}
-	out := strings.TrimSpace(b.String())
-
-	// Whole-word abbreviation canonicalization. Both the query and the stored
-	// song name flow through here, so the substitution is symmetric — querying
-	// "Saint Stephen" or "St. Stephen" both reach "st stephen".
-	if out != "" {
-		words := strings.Fields(out)
-		for i, w := range words {
-			if canon, ok := nameAbbreviations[w]; ok {
-				words[i] = canon
-			}
-		}
-		out = strings.Join(words, " ")
-	}
-	return out
-}
-
-// nameAbbreviations maps spelled-out forms to the abbreviated form the
-// band's setlists use. Keep this list minimal — only add entries when there
-// is a real mismatch causing failed lookups.
-var nameAbbreviations = map[string]string{
-	"saint": "st",
-}
+// normalizeName is the matching key for song names (see data.NormalizeSongName).
+var normalizeName = data.NormalizeSongName
 
 // DB implements data.DataSource using SQLite.
 type DB struct {
-	conn *sql.DB
+	conn    *sql.DB
+	aliases bool // song_aliases exists; a read-only open of an older DB may lack it
+}
+
+// newDB wraps conn, noting which optional tables it has.
+func newDB(conn *sql.DB) (*DB, error) {
+	aliases, err := tableExists(conn, "song_aliases")
+	if err != nil {
+		return nil, err
+	}
+	return &DB{conn: conn, aliases: aliases}, nil
 }
 
-// Open opens a SQLite database at the given path (file path or ":memory:").
-// Ensures song_aliases exists on existing DBs (migration).
+// Open opens a SQLite database at the given path (file path or ":memory:") and
+// applies any pending schema migrations (see migrate.go), so older databases
+// pick up tables and columns added since they were built.
 func Open(path string) (*DB, error) {
 	conn, err := sql.Open("sqlite3", path)
 	if err != nil {
 		return nil, err
 	}
-	_, _ = conn.Exec("CREATE TABLE IF NOT EXISTS song_aliases (alias TEXT PRIMARY KEY, song_id INTEGER NOT NULL REFERENCES songs(id))")
-	return &DB{conn: conn}, nil
+	// Errors (e.g. read-only DB) are ignored: queries still work against the
+	// existing schema, and features that need newer columns fail on their own.
+	_ = migrate(conn)
+	db, err := newDB(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// OpenReadOnly opens an existing database with mode=ro. Nothing is written —
+// not even migrations — so query commands can't modify the file and can share
+// it with a concurrent writer. Use Open for init/import, or Upgrade first to
+// bring an older file up to date.
+func OpenReadOnly(path string) (*DB, error) {
+	conn, err := sql.Open("sqlite3", readOnlyDSN(path))
+	if err != nil {
+		return nil, err
+	}
+	// sql.Open is lazy; ping so a missing file fails here rather than on the first query.
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	db, err := newDB(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// Upgrade applies pending schema migrations to the database at path.
+func Upgrade(path string) error {
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return migrate(conn)
+}
+
+// Version returns the database's schema_version, the number of migrations
+// applied to it: 0 for a database from before schema_version existed. Compare
+// it with SchemaVersion to tell whether the file needs Upgrade.
+func (db *DB) Version() (int, error) {
+	ok, err := tableExists(db.conn, "schema_version")
+	if err != nil || !ok {
+		return 0, err
+	}
+	v, err := schemaVersion(db.conn)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return v, err
+}
+
+// readOnlyDSN turns a file path or "file:" URI into a URI with mode=ro.
+func readOnlyDSN(path string) string {
+	if strings.HasPrefix(path, "file:") {
+		if strings.Contains(path, "?") {
+			return path + "&mode=ro"
+		}
+		return path + "?mode=ro"
+	}
+	// Characters that would otherwise start a query string or fragment in a URI
+	escaped := strings.NewReplacer("%", "%25", "?", "%3f", "#", "%23").Replace(path)
+	return "file:" + escaped + "?mode=ro"
+}
+
+// HasAliases reports whether the database has song_aliases. Implements
+// data.AliasSource.
+func (db *DB) HasAliases() bool {
+	return db.aliases
 }
 
 // Close closes the database connection.
@@ -116,11 +159,10 @@ func (db *DB) ExecuteQuery(ctx context.Context, query string, args ...interface{
 		if err := rows.Scan(ptrs...); err != nil {
 			return nil, err
 		}
-		// Convert []byte to string for TEXT columns
+		// Normalize driver types so data.Row accessors see nil, int64,
+		// float64, or string.
 		for i := range vals {
-			if b, ok := vals[i].([]byte); ok {
-				vals[i] = string(b)
-			}
+			vals[i] = data.NormalizeValue(vals[i])
 		}
 		out = append(out, vals)
 	}
@@ -130,47 +172,134 @@ func (db *DB) ExecuteQuery(ctx context.Context, query string, args ...interface{
 	return &data.ResultSet{Columns: cols, Rows: out}, nil
 }
 
-// GetSong returns a song by name, trying in order: exact match, case-insensitive, alias,
-// trim trailing dash, then fuzzy (punctuation-stripped). Always prefers the variant with
-// the most performances to handle duplicates like "Franklins Tower" vs "Franklin's Tower".
+// PlanStep is one row of EXPLAIN QUERY PLAN. Parent is the ID of the step it
+// is nested under (0 at the top); Detail is SQLite's description of the step,
+// e.g. "SEARCH p USING INDEX idx_performances_song (song_id=?)".
+type PlanStep struct {
+	ID     int
+	Parent int
+	Detail string
+}
+
+// QueryPlan is the plan SQLite picked for a statement, steps in order.
+type QueryPlan []PlanStep
+
+// String draws the plan as a tree, the way the sqlite3 shell does.
+func (p QueryPlan) String() string {
+	var b strings.Builder
+	b.WriteString("QUERY PLAN\n")
+	var draw func(parent int, indent string)
+	draw = func(parent int, indent string) {
+		var children []PlanStep
+		for _, s := range p {
+			if s.Parent == parent {
+				children = append(children, s)
+			}
+		}
+		for i, s := range children {
+			branch, next := "|--", "|  "
+			if i == len(children)-1 {
+				branch, next = "`--", "   "
+			}
+			b.WriteString(indent + branch + s.Detail + "\n")
+			draw(s.ID, indent+next)
+		}
+	}
+	draw(0, "")
+	return b.String()
+}
+
+// ExplainPlan returns the plan SQLite would use for query, without running it.
+func (db *DB) ExplainPlan(ctx context.Context, query string, args ...interface{}) (QueryPlan, error) {
+	rows, err := db.conn.QueryContext(ctx, "EXPLAIN QUERY PLAN "+query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var plan QueryPlan
+	for rows.Next() {
+		var s PlanStep
+		var notUsed int
+		if err := rows.Scan(&s.ID, &s.Parent, &notUsed, &s.Detail); err != nil {
+			return nil, err
+		}
+		plan = append(plan, s)
+	}
+	return plan, rows.Err()
+}
+
+const (
+	songByNameSQL  = "SELECT s.id, s.name, s.short_name, s.writers, s.first_played, s.last_played, s.times_played FROM songs s WHERE s.name = ? OR LOWER(s.name) = LOWER(?) ORDER BY (SELECT count(*) FROM performances p WHERE p.song_id = s.id) DESC LIMIT 1"
+	songByAliasSQL = "SELECT s.id, s.name, s.short_name, s.writers, s.first_played, s.last_played, s.times_played FROM songs s JOIN song_aliases a ON s.id = a.song_id WHERE a.alias = ? OR LOWER(a.alias) = LOWER(?) LIMIT 1"
+)
+
+// GetSong returns a song by name. The lookup runs in a fixed order, most
+// confident first, and the first step to find a song decides; Song.MatchedBy
+// says which:
+//
+//  1. exact: the song's name, ignoring case
+//  2. alias: a song_aliases row
+//  3. variant: the same name under NormalizeSongName ("Samson & Delilah")
+//  4. trimmed: the name without trailing dashes ("Scarlet Begonias -")
+//  5. fuzzy: a prefix or most of the words
+//
+// So a name that is one song's title and another's alias is the titled song,
+// and an exact name is never traded for a more-played spelling. Within a
+// step, duplicates go to the most-played song. name is compared in NFC, the
+// form imports store titles in.
 func (db *DB) GetSong(ctx context.Context, name string) (*data.Song, error) {
-	// Try exact/case-insensitive, alias, and trim-dash lookups.
-	// Each query lists its own placeholder count so we can pass the right
-	// number of args — ncruces/go-sqlite3 strictly enforces the match.
-	queries := []struct {
-		sql  string
-		args []any
-	}{
-		{"SELECT s.id, s.name, s.short_name, s.writers, s.first_played, s.last_played, s.times_played FROM songs s WHERE s.name = ? OR LOWER(s.name) = LOWER(?) ORDER BY (SELECT count(*) FROM performances p WHERE p.song_id = s.id) DESC LIMIT 1", []any{name, name}},
-		{"SELECT s.id, s.name, s.short_name, s.writers, s.first_played, s.last_played, s.times_played FROM songs s JOIN song_aliases a ON s.id = a.song_id WHERE a.alias = ? OR LOWER(a.alias) = LOWER(?) LIMIT 1", []any{name, name}},
-		{"SELECT s.id, s.name, s.short_name, s.writers, s.first_played, s.last_played, s.times_played FROM songs s WHERE LOWER(TRIM(s.name, '- ')) = LOWER(TRIM(?, '- ')) ORDER BY (SELECT count(*) FROM performances p WHERE p.song_id = s.id) DESC LIMIT 1", []any{name}},
-	}
-	for _, q := range queries {
-		song, err := db.scanSong(ctx, q.sql, q.args...)
+	name = data.ComposeTitle(name)
+	song, err := db.scanSong(ctx, songByNameSQL, name, name)
+	if err != nil {
+		return nil, err
+	}
+	if song != nil {
+		return matched(song, data.MatchExact), nil
+	}
+	if db.aliases {
+		song, err = db.scanSong(ctx, songByAliasSQL, name, name)
 		if err != nil {
 			return nil, err
 		}
 		if song != nil {
-			// Check if fuzzy finds a better match (more performances).
-			// This handles "Franklins Tower" (5 plays) vs "Franklin's Tower" (213 plays).
-			fuzzy, ferr := db.getSongFuzzy(ctx, name)
-			if ferr != nil || fuzzy == nil {
-				return song, nil
-			}
-			if fuzzy.ID == song.ID {
-				return song, nil
-			}
-			// Prefer the one with more performances
-			var songPlays, fuzzyPlays int
-			db.conn.QueryRowContext(ctx, "SELECT count(*) FROM performances WHERE song_id = ?", song.ID).Scan(&songPlays)
-			db.conn.QueryRowContext(ctx, "SELECT count(*) FROM performances WHERE song_id = ?", fuzzy.ID).Scan(&fuzzyPlays)
-			if fuzzyPlays > songPlays {
-				return fuzzy, nil
-			}
-			return song, nil
+			return matched(song, data.MatchAlias), nil
 		}
 	}
-	return db.getSongFuzzy(ctx, name)
+	variant, fuzzy, err := db.matchSongs(ctx, name)
+	if err != nil || variant != nil {
+		return matched(variant, data.MatchVariant), err
+	}
+	song, err = db.scanSong(ctx, "SELECT s.id, s.name, s.short_name, s.writers, s.first_played, s.last_played, s.times_played FROM songs s WHERE LOWER(TRIM(s.name, '- ')) = LOWER(TRIM(?, '- ')) ORDER BY (SELECT count(*) FROM performances p WHERE p.song_id = s.id) DESC LIMIT 1", name)
+	if err != nil || song != nil {
+		return matched(song, data.MatchTrimmed), err
+	}
+	return matched(fuzzy, data.MatchFuzzy), nil
+}
+
+// matched sets song's MatchedBy, allowing a nil song.
+func matched(song *data.Song, stage data.MatchStage) *data.Song {
+	if song != nil {
+		song.MatchedBy = stage
+	}
+	return song
+}
+
+// GetSongStrict returns a song only when name matches its name or one of its
+// aliases, ignoring case. None of GetSong's heuristics apply, so "Scarlet
+// Begonias -" or "Samson + Delilah" resolve to nothing unless aliased.
+// Implements data.StrictSongSource.
+func (db *DB) GetSongStrict(ctx context.Context, name string) (*data.Song, error) {
+	name = data.ComposeTitle(name)
+	song, err := db.scanSong(ctx, songByNameSQL, name, name)
+	if err != nil || song != nil {
+		return matched(song, data.MatchExact), err
+	}
+	if !db.aliases {
+		return nil, nil
+	}
+	song, err = db.scanSong(ctx, songByAliasSQL, name, name)
+	return matched(song, data.MatchAlias), err
 }
 
 func (db *DB) scanSong(ctx context.Context, query string, args ...interface{}) (*data.Song, error) {
@@ -228,14 +357,15 @@ func (db *DB) GetSongVariantIDs(ctx context.Context, name string) ([]int, error)
 	return ids, rows.Err()
 }
 
-// getSongFuzzy finds a song by normalizing both the query and all song names
-// (stripping punctuation, lowercasing). When multiple variants match, prefers
-// the one with the most performances (via a subquery count).
-// Falls back to prefix/substring matching if no exact normalized match found.
-func (db *DB) getSongFuzzy(ctx context.Context, name string) (*data.Song, error) {
+// matchSongs scans every song once, most-played first, for GetSong's two
+// name-comparing steps: variant is the first song whose normalized name
+// equals name's, and fuzzy the best prefix or word-overlap match, used only
+// when there is no variant (nor a trimmed match, which GetSong checks in
+// between). Either may be nil.
+func (db *DB) matchSongs(ctx context.Context, name string) (variant, fuzzy *data.Song, err error) {
 	target := normalizeName(name)
 	if target == "" {
-		return nil, nil
+		return nil, nil, nil
 	}
 
 	type candidate struct {
@@ -248,16 +378,15 @@ func (db *DB) getSongFuzzy(ctx context.Context, name string) (*data.Song, error)
 	}
 
 	rows, err := db.conn.QueryContext(ctx, `
-		SELECT s.id, s.name, s.short_name, s.writers, s.first_played, s.last_played, s.times_played,
-		       (SELECT count(*) FROM performances p WHERE p.song_id = s.id) AS play_count
-		FROM songs s ORDER BY play_count DESC`)
+		SELECT s.id, s.name, s.short_name, s.writers, s.first_played, s.last_played, s.times_played
+		FROM songs s ORDER BY (SELECT count(*) FROM performances p WHERE p.song_id = s.id) DESC`)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer rows.Close()
 
 	// Track the best prefix match as fallback.
-	// Results are ordered by play_count DESC so the first match has the most performances.
+	// Results are ordered by play count so the first match has the most performances.
 	var prefixMatch *candidate
 
 	// Track best token-overlap match as last-resort fallback.
@@ -273,15 +402,14 @@ func (db *DB) getSongFuzzy(ctx context.Context, name string) (*data.Song, error)
 
 	for rows.Next() {
 		var c candidate
-		var playCount int
-		if err := rows.Scan(&c.id, &c.sname, &c.short, &c.writers, &c.first, &c.last, &c.times, &playCount); err != nil {
-			continue
+		if err := rows.Scan(&c.id, &c.sname, &c.short, &c.writers, &c.first, &c.last, &c.times); err != nil {
+			return nil, nil, err
 		}
 		norm := normalizeName(c.sname)
 
-		// Exact normalized match — return immediately.
+		// Exact normalized match — nothing fuzzy can beat it.
 		if norm == target {
-			return db.buildSong(c.id, c.sname, c.short, c.writers, c.first, c.last, c.times), nil
+			return db.buildSong(c.id, c.sname, c.short, c.writers, c.first, c.last, c.times), nil, nil
 		}
 
 		// Track the best prefix match. Require target to be at least 5 chars
@@ -317,18 +445,20 @@ func (db *DB) getSongFuzzy(ctx context.Context, name string) (*data.Song, error)
 			}
 		}
 	}
-
-	// Auto-resolve prefix match (first found = most performances).
-	if prefixMatch != nil {
-		return db.buildSong(prefixMatch.id, prefixMatch.sname, prefixMatch.short, prefixMatch.writers, prefixMatch.first, prefixMatch.last, prefixMatch.times), nil
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
 	}
 
-	// Last resort: token-overlap match.
-	if tokenMatch != nil {
-		return db.buildSong(tokenMatch.id, tokenMatch.sname, tokenMatch.short, tokenMatch.writers, tokenMatch.first, tokenMatch.last, tokenMatch.times), nil
+	// Prefer the prefix match (first found = most performances), then the
+	// token-overlap match.
+	best := prefixMatch
+	if best == nil {
+		best = tokenMatch
 	}
-
-	return nil, nil
+	if best == nil {
+		return nil, nil, nil
+	}
+	return nil, db.buildSong(best.id, best.sname, best.short, best.writers, best.first, best.last, best.times), nil
 }
 
 func (db *DB) buildSong(id int, name string, short, writers, first, last sql.NullString, times int) *data.Song {
@@ -383,8 +513,10 @@ func (db *DB) GetSongByID(ctx context.Context, id int) (*data.Song, error) {
 }
 
 // SearchSongs returns songs whose name contains the pattern (case-insensitive).
+// The pattern is literal text: % and _ in it match only themselves.
 func (db *DB) SearchSongs(ctx context.Context, pattern string) ([]*data.Song, error) {
-	rows, err := db.conn.QueryContext(ctx, "SELECT id, name, short_name, writers, first_played, last_played, times_played FROM songs WHERE name LIKE ? OR short_name LIKE ? ORDER BY name", "%"+pattern+"%", "%"+pattern+"%")
+	like := "%" + escapeLike(pattern) + "%"
+	rows, err := db.conn.QueryContext(ctx, "SELECT id, name, short_name, writers, first_played, last_played, times_played FROM songs WHERE name LIKE ? ESCAPE '\\' OR short_name LIKE ? ESCAPE '\\' ORDER BY name", like, like)
 	if err != nil {
 		return nil, err
 	}
@@ -419,3 +551,12 @@ func (db *DB) SearchSongs(ctx context.Context, pattern string) ([]*data.Song, er
 	}
 	return out, rows.Err()
 }
+
+// escapeLike escapes LIKE metacharacters (\, % and _) in s; the LIKE using it
+// needs ESCAPE '\'.
+func escapeLike(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, "%", "\\%")
+	s = strings.ReplaceAll(s, "_", "\\_")
+	return s
+}
@@ -2,8 +2,12 @@ package sqlite
 
 import (
 	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/gdql/gdql/internal/data"
 	"github.com/gdql/gdql/test/fixtures"
 	"github.com/stretchr/testify/require"
 )
@@ -45,6 +49,74 @@ func TestExecuteQuery_Parameterized(t *testing.T) {
 	require.GreaterOrEqual(t, len(rs.Rows), 1)
 }
 
+func TestExecuteQuery_NormalizesValues(t *testing.T) {
+	path, cleanup := fixtures.CreateTestDB(t)
+	defer cleanup()
+	db, err := Open(path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	// A REAL column holding a whole number, a fraction, and NULL, next to
+	// text stored as a blob.
+	ctx := context.Background()
+	rs, err := db.ExecuteQuery(ctx, `SELECT rating, CAST('Dark Star' AS BLOB) FROM (
+		SELECT 5 AS rating UNION ALL SELECT 4.5 UNION ALL SELECT NULL)`)
+	require.NoError(t, err)
+	require.Len(t, rs.Rows, 3)
+	for _, row := range rs.Rows {
+		require.Equal(t, "Dark Star", row[1])
+		switch row[0].(type) {
+		case nil, int64, float64:
+		default:
+			t.Fatalf("rating came back as %T", row[0])
+		}
+	}
+	require.Equal(t, 5.0, rs.Rows[0].Float(0))
+	require.Equal(t, 4.5, *rs.Rows[1].NullFloat(0))
+	require.Nil(t, rs.Rows[2].NullFloat(0))
+}
+
+func TestExplainPlan(t *testing.T) {
+	path, cleanup := fixtures.CreateTestDB(t)
+	defer cleanup()
+	db, err := Open(path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	plan, err := db.ExplainPlan(ctx, "SELECT s.id FROM shows s JOIN performances p ON p.show_id = s.id WHERE p.song_id = ?", 1)
+	require.NoError(t, err)
+	require.NotEmpty(t, plan)
+	out := plan.String()
+	require.True(t, strings.HasPrefix(out, "QUERY PLAN\n"), out)
+	require.Contains(t, out, "`--")
+
+	// Explaining must not run the statement.
+	_, err = db.ExplainPlan(ctx, "DELETE FROM shows")
+	require.NoError(t, err)
+	rs, err := db.ExecuteQuery(ctx, "SELECT count(*) FROM shows")
+	require.NoError(t, err)
+	require.NotEqual(t, int64(0), rs.Rows[0][0])
+
+	_, err = db.ExplainPlan(ctx, "SELECT * FROM no_such_table")
+	require.Error(t, err)
+}
+
+func TestQueryPlan_String(t *testing.T) {
+	plan := QueryPlan{
+		{ID: 2, Parent: 0, Detail: "SCAN s"},
+		{ID: 5, Parent: 0, Detail: "CORRELATED SCALAR SUBQUERY 1"},
+		{ID: 8, Parent: 5, Detail: "SEARCH p USING INDEX idx_performances_show (show_id=?)"},
+		{ID: 20, Parent: 0, Detail: "USE TEMP B-TREE FOR ORDER BY"},
+	}
+	want := "QUERY PLAN\n" +
+		"|--SCAN s\n" +
+		"|--CORRELATED SCALAR SUBQUERY 1\n" +
+		"|  `--SEARCH p USING INDEX idx_performances_show (show_id=?)\n" +
+		"`--USE TEMP B-TREE FOR ORDER BY\n"
+	require.Equal(t, want, plan.String())
+}
+
 func TestGetSong_ByName(t *testing.T) {
 	path, cleanup := fixtures.CreateTestDB(t)
 	defer cleanup()
@@ -124,10 +196,23 @@ func TestNormalizeName_StripsAllPunctuation(t *testing.T) {
 		{"Truckin'", "truckin"},
 		{"Help on the Way!", "help on the way"},
 		{"  extra   spaces  ", "extra spaces"},
-		{"Hyphen-Word", "hyphenword"},
+		{"Hyphen-Word", "hyphen word"}, // a hyphen separates words like a space
 		{"(Parens) Around", "parens around"},
 		{"Saint Stephen", "st stephen"},
 		{"saint stephen", "st stephen"},
+		{"Me & My Uncle", "me and my uncle"},
+		{"Me+My Uncle", "me and my uncle"},
+		{"Goin' Down the Road Feeling Bad", "goin down the road feelin bad"},
+		{"Going Down The Road Feelin' Bad", "goin down the road feelin bad"},
+		{"Mister Charlie", "mr charlie"},
+		{"Mr. Charlie", "mr charlie"},
+		{"Dancing in the Street", "dancin in the street"},
+		{"King Bee", "king bee"}, // -ing that isn't a suffix is left alone
+		{"The Thing", "the thing"},
+		{"Swing Low Sweet Chariot", "swing low sweet chariot"},
+		{"Spring Song", "spring song"},
+		{"Bein' Around", "bein around"},
+		{"Café Blues", "cafe blues"},
 	}
 	for _, tc := range cases {
 		t.Run(tc.input, func(t *testing.T) {
@@ -136,6 +221,12 @@ func TestNormalizeName_StripsAllPunctuation(t *testing.T) {
 	}
 }
 
+func TestNormalizeName_DroppedGOnlyOnSuffix(t *testing.T) {
+	require.NotEqual(t, normalizeName("Thin"), normalizeName("Thing"))
+	require.NotEqual(t, normalizeName("Brin"), normalizeName("Bring"))
+	require.Equal(t, normalizeName("Truckin'"), normalizeName("Trucking"))
+}
+
 func TestGetSong_FuzzyPunctuation(t *testing.T) {
 	path, cleanup := fixtures.CreateTestDB(t)
 	defer cleanup()
@@ -156,6 +247,75 @@ func TestGetSong_FuzzyPunctuation(t *testing.T) {
 	// The fixture doesn't have apostrophe songs, but we can test normalizeName directly
 }
 
+func TestGetSong_NormalizedVariants(t *testing.T) {
+	path, cleanup := fixtures.CreateTestDB(t)
+	defer cleanup()
+	db, err := Open(path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	for _, name := range []string{"Samson & Delilah", "samson  and   delilah", "Samson + Delilah"} {
+		song, err := db.GetSong(context.Background(), name)
+		require.NoError(t, err)
+		require.NotNil(t, song, name)
+		require.Equal(t, 4, song.ID, name)
+	}
+}
+
+func TestGetSong_MatchOrder(t *testing.T) {
+	path, cleanup := fixtures.CreateTestDB(t)
+	defer cleanup()
+	db, err := Open(path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	// "Help on the Way" is a song's title and, here, an alias of Dark Star;
+	// "Samson & Delilah" normalizes to Samson and Delilah but is aliased too.
+	_, err = db.DB().ExecContext(ctx, "INSERT INTO song_aliases (alias, song_id) VALUES ('Help on the Way', 6), ('Samson & Delilah', 6)")
+	require.NoError(t, err)
+
+	cases := []struct {
+		name  string
+		id    int
+		stage data.MatchStage
+	}{
+		{"Help on the Way", 3, data.MatchExact}, // the title beats the alias
+		{"HELP ON THE WAY", 3, data.MatchExact},
+		{"Scarlet Begonias-", 1, data.MatchAlias},
+		{"Samson & Delilah", 6, data.MatchAlias}, // the alias beats normalizing
+		{"Samson + Delilah", 4, data.MatchVariant},
+		{"Scarlet Begonias Jam", 1, data.MatchFuzzy},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			song, err := db.GetSong(ctx, tc.name)
+			require.NoError(t, err)
+			require.NotNil(t, song)
+			require.Equal(t, tc.id, song.ID)
+			require.Equal(t, tc.stage, song.MatchedBy)
+		})
+	}
+}
+
+func TestGetSong_ExactBeatsMorePlayedSpelling(t *testing.T) {
+	path, cleanup := fixtures.CreateTestDB(t)
+	defer cleanup()
+	db, err := Open(path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	// "Samson + Delilah" normalizes like song 4, which has performances; the
+	// exact title still wins.
+	ctx := context.Background()
+	_, err = db.DB().ExecContext(ctx, "INSERT INTO songs (id, name, times_played) VALUES (40, 'Samson + Delilah', 0)")
+	require.NoError(t, err)
+	song, err := db.GetSong(ctx, "Samson + Delilah")
+	require.NoError(t, err)
+	require.Equal(t, 40, song.ID)
+	require.Equal(t, data.MatchExact, song.MatchedBy)
+}
+
 func TestNormalizeName(t *testing.T) {
 	require.Equal(t, "franklins tower", normalizeName("Franklin's Tower"))
 	require.Equal(t, "franklins tower", normalizeName("Franklins Tower"))
@@ -212,3 +372,156 @@ func TestSearchSongs(t *testing.T) {
 	require.GreaterOrEqual(t, len(songs), 1)
 	require.Contains(t, songs[0].Name, "Scarlet")
 }
+
+func TestSearchSongs_LiteralWildcards(t *testing.T) {
+	path, cleanup := fixtures.CreateTestDB(t)
+	defer cleanup()
+	db, err := Open(path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	_, err = db.DB().ExecContext(ctx, "INSERT INTO songs (id, name, times_played) VALUES (7, 'Jam_1', 0), (8, '100% Jam', 0)")
+	require.NoError(t, err)
+
+	// Unescaped, "_" matches any character and "%" anything at all.
+	songs, err := db.SearchSongs(ctx, "_")
+	require.NoError(t, err)
+	require.Len(t, songs, 1)
+	require.Equal(t, "Jam_1", songs[0].Name)
+
+	songs, err = db.SearchSongs(ctx, "0%")
+	require.NoError(t, err)
+	require.Len(t, songs, 1)
+	require.Equal(t, "100% Jam", songs[0].Name)
+}
+
+func TestOpen_CreatesMissingLyricsTable(t *testing.T) {
+	path, cleanup := fixtures.CreateTestDB(t)
+	defer cleanup()
+	// Simulate a setlist.fm-only DB that never had lyrics imported
+	raw, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	_, err = raw.Exec("DROP TABLE lyrics")
+	require.NoError(t, err)
+	require.NoError(t, raw.Close())
+
+	db, err := Open(path)
+	require.NoError(t, err)
+	defer db.Close()
+	rs, err := db.ExecuteQuery(context.Background(), "SELECT id FROM songs WHERE EXISTS (SELECT 1 FROM lyrics l WHERE l.song_id = songs.id)")
+	require.NoError(t, err)
+	require.Empty(t, rs.Rows)
+}
+
+func TestOpenReadOnly_RejectsWrites(t *testing.T) {
+	path, cleanup := fixtures.CreateTestDB(t)
+	defer cleanup()
+	db, err := OpenReadOnly(path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	song, err := db.GetSong(context.Background(), "Scarlet Begonias")
+	require.NoError(t, err)
+	require.NotNil(t, song)
+	_, err = db.DB().Exec("DELETE FROM songs")
+	require.Error(t, err, "read-only handle must not write")
+}
+
+func TestOpenReadOnly_MissingFile(t *testing.T) {
+	_, err := OpenReadOnly(filepath.Join(t.TempDir(), "nope.db"))
+	require.Error(t, err)
+}
+
+func TestOpenReadOnly_WithoutSongAliases(t *testing.T) {
+	path, cleanup := fixtures.CreateTestDB(t)
+	defer cleanup()
+	raw, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	_, err = raw.Exec("DROP TABLE song_aliases")
+	require.NoError(t, err)
+	require.NoError(t, raw.Close())
+
+	// No migrations on the read-only path, so lookups skip the alias step
+	db, err := OpenReadOnly(path)
+	require.NoError(t, err)
+	defer db.Close()
+	require.False(t, db.HasAliases())
+	song, err := db.GetSong(context.Background(), "Scarlet Begonias-")
+	require.NoError(t, err)
+	require.NotNil(t, song)
+	require.Equal(t, 1, song.ID)
+	song, err = db.GetSongStrict(context.Background(), "Scarlet Begonias-")
+	require.NoError(t, err)
+	require.Nil(t, song)
+}
+
+func TestReadOnlyDSN(t *testing.T) {
+	require.Equal(t, "file:/tmp/a.db?mode=ro", readOnlyDSN("/tmp/a.db"))
+	require.Equal(t, "file:/tmp/what%3f.db?mode=ro", readOnlyDSN("/tmp/what?.db"))
+	require.Equal(t, "file:/x?vfs=memdb&mode=ro", readOnlyDSN("file:/x?vfs=memdb"))
+}
+
+func TestGetSongStrict_SkipsHeuristics(t *testing.T) {
+	path, cleanup := fixtures.CreateTestDB(t)
+	defer cleanup()
+	db, err := Open(path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	cases := []struct {
+		name    string
+		lenient int // song id GetSong resolves to
+		strict  int // song id GetSongStrict resolves to; 0 = not found
+	}{
+		{"Fire on the Mountain", 2, 2},
+		{"fire ON the mountain", 2, 2},
+		{"Scarlet Begonias-", 1, 1},     // alias
+		{"Scarlet Begonias -", 1, 0},    // trailing-dash trim
+		{"Samson & Delilah", 4, 0},      // normalized
+		{"Fire on the Mountain!", 2, 0}, // punctuation-stripped
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			song, err := db.GetSong(ctx, tc.name)
+			require.NoError(t, err)
+			require.NotNil(t, song)
+			require.Equal(t, tc.lenient, song.ID)
+
+			song, err = db.GetSongStrict(ctx, tc.name)
+			require.NoError(t, err)
+			if tc.strict == 0 {
+				require.Nil(t, song)
+				return
+			}
+			require.NotNil(t, song)
+			require.Equal(t, tc.strict, song.ID)
+		})
+	}
+}
+
+func TestGetSong_ComposesUnicode(t *testing.T) {
+	path, cleanup := fixtures.CreateTestDB(t)
+	defer cleanup()
+	db, err := Open(path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	const composed, decomposed = "Caf\u00e9 Blues", "Cafe\u0301 Blues"
+	_, err = db.DB().ExecContext(ctx, "INSERT INTO songs (id, name, times_played) VALUES (7, ?, 0)", composed)
+	require.NoError(t, err)
+
+	// Identical on screen, different bytes: only NFC makes the strict,
+	// exact-match lookup find it.
+	song, err := db.GetSongStrict(ctx, decomposed)
+	require.NoError(t, err)
+	require.NotNil(t, song)
+	require.Equal(t, 7, song.ID)
+
+	song, err = db.GetSong(ctx, decomposed)
+	require.NoError(t, err)
+	require.NotNil(t, song)
+	require.Equal(t, composed, song.Name)
+}
@@ -0,0 +1,101 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+)
+
+// duplicatePerformances selects every performance that repeats an earlier one
+// (lower id): the same song at the same show, set, and position. set_number is
+// compared with IS so rows with no set still match each other.
+const duplicatePerformances = `SELECT p.id, p.song_id FROM performances p WHERE EXISTS (
+	SELECT 1 FROM performances q
+	WHERE q.show_id = p.show_id AND q.song_id = p.song_id AND q.set_number IS p.set_number
+		AND q.position = p.position AND q.id < p.id)`
+
+// uniquePerformances backs INSERT OR IGNORE in the importers: a performance
+// that is already there is skipped rather than stored twice.
+//
+// schema.sql's UNIQUE(show_id, set_number, position) doesn't cover it: tables
+// created before that constraint don't have it (and SQLite can't add one to
+// an existing table), and NULLs are distinct there, so rows with no set never
+// collide. ifnull makes them collide here. song_id is part of the key because
+// only exact repeats are dropped before the index is built; an older database
+// with two different songs at one position would fail to build a narrower one.
+const uniquePerformances = "CREATE UNIQUE INDEX IF NOT EXISTS idx_perf_unique ON performances(show_id, song_id, ifnull(set_number, -1), position)"
+
+// DedupPerformances deletes duplicate performance rows (see
+// duplicatePerformances), keeping the first of each, and recomputes
+// times_played for the songs involved. It returns how many rows were removed.
+func (db *DB) DedupPerformances(ctx context.Context) (int, error) {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	n, err := dedupPerformancesTx(ctx, tx)
+	if err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
+
+func dedupPerformancesTx(ctx context.Context, tx *sql.Tx) (int, error) {
+	rows, err := tx.QueryContext(ctx, duplicatePerformances)
+	if err != nil {
+		return 0, err
+	}
+	var ids []int64
+	songs := make(map[int64]bool)
+	for rows.Next() {
+		var id, songID int64
+		if err := rows.Scan(&id, &songID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+		songs[songID] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	for _, id := range ids {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM performances WHERE id = ?", id); err != nil {
+			return 0, err
+		}
+	}
+	for songID := range songs {
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE songs SET times_played = (SELECT count(*) FROM performances WHERE song_id = ?) WHERE id = ?",
+			songID, songID); err != nil {
+			return 0, err
+		}
+	}
+	return len(ids), nil
+}
+
+// dedupAndIndexPerformances is the migration step: duplicates must go before
+// the unique index can be built. Deferred while performances doesn't exist.
+func dedupAndIndexPerformances(conn *sql.DB) error {
+	var n int
+	if err := conn.QueryRow("SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = 'performances'").Scan(&n); err != nil {
+		return err
+	}
+	if n == 0 {
+		return errMigrationDeferred
+	}
+	ctx := context.Background()
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := dedupPerformancesTx(ctx, tx); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, uniquePerformances); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,82 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// dupSchema predates the unique index: the same performance was imported twice,
+// once in a numbered set and once with no set at all.
+const dupSchema = oldSchema + `
+INSERT INTO songs (id, name, times_played) VALUES (2, 'St. Stephen', 4);
+INSERT INTO shows (id, date) VALUES (1, '1969-02-27');
+INSERT INTO performances (id, show_id, song_id, set_number, position) VALUES
+	(1, 1, 1, 1, 1), (2, 1, 2, 1, 2), (3, 1, 1, 1, 1), (4, 1, 2, NULL, 1), (5, 1, 2, NULL, 1);
+`
+
+func TestOpen_DedupsPerformances(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dups.db")
+	raw, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	_, err = raw.Exec(dupSchema)
+	require.NoError(t, err)
+	require.NoError(t, raw.Close())
+
+	db, err := Open(path)
+	require.NoError(t, err)
+	defer db.Close()
+	conn := db.DB()
+
+	var ids []int
+	rows, err := conn.Query("SELECT id FROM performances ORDER BY id")
+	require.NoError(t, err)
+	for rows.Next() {
+		var id int
+		require.NoError(t, rows.Scan(&id))
+		ids = append(ids, id)
+	}
+	require.NoError(t, rows.Close())
+	require.Equal(t, []int{1, 2, 4}, ids, "the first of each duplicate is kept")
+
+	var played int
+	require.NoError(t, conn.QueryRow("SELECT times_played FROM songs WHERE id = 2").Scan(&played))
+	require.Equal(t, 2, played, "times_played recomputed for songs that lost rows")
+
+	// The unique index now makes re-imports a no-op.
+	res, err := conn.Exec("INSERT OR IGNORE INTO performances (id, show_id, song_id, set_number, position) VALUES (10, 1, 2, NULL, 1)")
+	require.NoError(t, err)
+	n, err := res.RowsAffected()
+	require.NoError(t, err)
+	require.Zero(t, n)
+}
+
+func TestDedupPerformances(t *testing.T) {
+	db, err := OpenMemory()
+	require.NoError(t, err)
+	defer db.Close()
+	ctx := context.Background()
+	conn := db.DB()
+
+	_, err = conn.Exec(`INSERT INTO songs (id, name) VALUES (1, 'Dark Star');
+		INSERT INTO shows (id, date) VALUES (1, '1969-02-27')`)
+	require.NoError(t, err)
+	_, err = conn.Exec("INSERT INTO performances (id, show_id, song_id, set_number, position) VALUES (1, 1, 1, 1, 1)")
+	require.NoError(t, err)
+
+	n, err := db.DedupPerformances(ctx)
+	require.NoError(t, err)
+	require.Zero(t, n, "nothing to remove")
+
+	// Simulate a database that lost the index and picked up a duplicate.
+	_, err = conn.Exec("DROP INDEX idx_perf_unique")
+	require.NoError(t, err)
+	_, err = conn.Exec("INSERT INTO performances (id, show_id, song_id, set_number, position) VALUES (2, 1, 1, NULL, 1), (3, 1, 1, NULL, 1)")
+	require.NoError(t, err)
+	n, err = db.DedupPerformances(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+}
@@ -4,6 +4,7 @@ import (
 	_ "embed"
 	"database/sql"
 	"fmt"
+	"strings"
 
 	_ "github.com/ncruces/go-sqlite3/driver"
 )
@@ -17,6 +18,12 @@ var seedSQL string
 // Init creates a new database at path with the schema and optional seed data.
 // If path exists and has tables, Init is a no-op (safe to call multiple times).
 func Init(path string) error {
+	return InitWithSeed(path, seedSQL)
+}
+
+// InitWithSeed is Init with seed as the seed data: SQL statements run after
+// the schema, e.g. a user's own sample shows. An empty seed is InitSchema.
+func InitWithSeed(path, seed string) error {
 	db, err := sql.Open("sqlite3", path)
 	if err != nil {
 		return fmt.Errorf("open: %w", err)
@@ -26,12 +33,38 @@ func Init(path string) error {
 	if _, err := db.Exec(schemaSQL); err != nil {
 		return fmt.Errorf("schema: %w", err)
 	}
-	if _, err := db.Exec(seedSQL); err != nil {
+	if strings.TrimSpace(seed) == "" {
+		return nil
+	}
+	if _, err := db.Exec(seed); err != nil {
 		return fmt.Errorf("seed: %w", err)
 	}
 	return nil
 }
 
+// OpenMemory opens an empty in-memory database with the full schema. The pool
+// is pinned to one connection that never expires: every ":memory:" connection
+// is a separate database, so a second one would see no tables or data.
+func OpenMemory() (*DB, error) {
+	conn, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+	conn.SetMaxOpenConns(1)
+	conn.SetMaxIdleConns(1)
+	conn.SetConnMaxLifetime(0)
+	conn.SetConnMaxIdleTime(0)
+	if _, err := conn.Exec(schemaSQL); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("schema: %w", err)
+	}
+	if err := migrate(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &DB{conn: conn, aliases: true}, nil
+}
+
 // InitSchema creates the database with schema only (no seed). Use for import-from-API flows.
 func InitSchema(path string) error {
 	db, err := sql.Open("sqlite3", path)
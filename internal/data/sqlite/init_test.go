@@ -0,0 +1,41 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitWithSeed(t *testing.T) {
+	ctx := context.Background()
+	count := func(path, table string) int {
+		t.Helper()
+		db, err := Open(path)
+		require.NoError(t, err)
+		defer db.Close()
+		rs, err := db.ExecuteQuery(ctx, "SELECT count(*) FROM "+table)
+		require.NoError(t, err)
+		return rs.Rows[0].Int(0)
+	}
+	dir := t.TempDir()
+
+	sample := filepath.Join(dir, "sample.db")
+	require.NoError(t, Init(sample))
+	require.Positive(t, count(sample, "shows"))
+
+	empty := filepath.Join(dir, "empty.db")
+	require.NoError(t, InitWithSeed(empty, ""))
+	require.Zero(t, count(empty, "shows"))
+	require.Zero(t, count(empty, "songs"))
+
+	own := filepath.Join(dir, "own.db")
+	require.NoError(t, InitWithSeed(own, `
+		INSERT INTO venues (id, name, city, state) VALUES (1, 'Fillmore West', 'San Francisco', 'CA');
+		INSERT INTO shows (id, date, venue_id) VALUES (1, '1969-02-27', 1);`))
+	require.Equal(t, 1, count(own, "venues"))
+	require.Equal(t, 1, count(own, "shows"))
+
+	require.ErrorContains(t, InitWithSeed(filepath.Join(dir, "bad.db"), "INSERT INTO nowhere VALUES (1);"), "seed")
+}
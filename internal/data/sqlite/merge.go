@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 )
 
@@ -28,7 +29,7 @@ type MergeRecord struct {
 //   2. reattributes performances from -> to
 //   3. recomputes times_played / first_played / last_played on "to"
 //   4. inserts from_name as an alias of "to" so future imports normalize
-//   5. clears song_relations and song_aliases rows that reference the "from"
+//   5. repoints song_aliases and clears song_relations that reference the "from"
 //   6. deletes the "from" song row
 //
 // Idempotent: if the "from" song is already gone, the entry is silently
@@ -103,11 +104,59 @@ func applyOneMerge(ctx context.Context, db *sql.DB, fromName, toName string, mer
 		rec.FromLastPlayed = lp.String
 	}
 
+	if err := mergeSongTx(ctx, tx, toID, fromID, fromName); err != nil {
+		return MergeRecord{}, false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return MergeRecord{}, false, err
+	}
+	return rec, true, nil
+}
+
+// MergeSongs folds song dropID into keepID: performances, aliases, and lyrics
+// are repointed to keepID, its play aggregates are recomputed, the dropped
+// name becomes an alias, and the dropped row is deleted. All references are
+// handled explicitly since SQLite foreign keys are usually off.
+func (db *DB) MergeSongs(ctx context.Context, keepID, dropID int) error {
+	if keepID == dropID {
+		return fmt.Errorf("cannot merge song %d into itself", keepID)
+	}
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var dropName string
+	err = tx.QueryRowContext(ctx, "SELECT name FROM songs WHERE id = ?", dropID).Scan(&dropName)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("song %d not found", dropID)
+	}
+	if err != nil {
+		return err
+	}
+	var exists int
+	err = tx.QueryRowContext(ctx, "SELECT 1 FROM songs WHERE id = ?", keepID).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("song %d not found", keepID)
+	}
+	if err != nil {
+		return err
+	}
+	if err := mergeSongTx(ctx, tx, int64(keepID), int64(dropID), dropName); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// mergeSongTx moves everything that references fromID onto toID and deletes fromID.
+func mergeSongTx(ctx context.Context, tx *sql.Tx, toID, fromID int64, fromName string) error {
 	// Reattribute performances.
 	if _, err := tx.ExecContext(ctx,
 		"UPDATE performances SET song_id = ? WHERE song_id = ?",
 		toID, fromID); err != nil {
-		return MergeRecord{}, false, err
+		return err
 	}
 
 	// Recompute aggregates on the "to" song from its (now-combined) performances.
@@ -126,7 +175,7 @@ func applyOneMerge(ctx context.Context, db *sql.DB, fromName, toName string, mer
 		)
 		WHERE id = ?
 	`, toID, toID, toID, toID); err != nil {
-		return MergeRecord{}, false, err
+		return err
 	}
 
 	// Insert the from-name as an alias of the to song so future imports
@@ -135,18 +184,22 @@ func applyOneMerge(ctx context.Context, db *sql.DB, fromName, toName string, mer
 	if _, err := tx.ExecContext(ctx,
 		"INSERT OR REPLACE INTO song_aliases (alias, song_id) VALUES (?, ?)",
 		fromName, toID); err != nil {
-		return MergeRecord{}, false, err
+		return err
 	}
 
-	// Clean up rows that reference the doomed "from" song.
+	// Repoint the "from" song's own aliases rather than dropping them, so
+	// variants that resolved to it keep resolving after the merge.
 	if _, err := tx.ExecContext(ctx,
-		"DELETE FROM song_aliases WHERE song_id = ?", fromID); err != nil {
-		return MergeRecord{}, false, err
+		"UPDATE song_aliases SET song_id = ? WHERE song_id = ?", toID, fromID); err != nil {
+		return err
 	}
+
+	// Clean up rows that reference the doomed "from" song. Databases built
+	// before song_relations existed have none to clean up.
 	if _, err := tx.ExecContext(ctx,
 		"DELETE FROM song_relations WHERE from_song_id = ? OR to_song_id = ?",
-		fromID, fromID); err != nil {
-		return MergeRecord{}, false, err
+		fromID, fromID); err != nil && !strings.Contains(err.Error(), "no such table: song_relations") {
+		return err
 	}
 	// Preserve lyrics: if the "from" row has lyrics and the "to" row does
 	// not, hand them off before deleting the "from" entry. INSERT OR IGNORE
@@ -155,21 +208,17 @@ func applyOneMerge(ctx context.Context, db *sql.DB, fromName, toName string, mer
 		INSERT OR IGNORE INTO lyrics (song_id, lyrics, lyrics_fts)
 		SELECT ?, lyrics, lyrics_fts FROM lyrics WHERE song_id = ?
 	`, toID, fromID); err != nil {
-		return MergeRecord{}, false, err
+		return err
 	}
 	if _, err := tx.ExecContext(ctx,
 		"DELETE FROM lyrics WHERE song_id = ?", fromID); err != nil {
-		return MergeRecord{}, false, err
+		return err
 	}
 	if _, err := tx.ExecContext(ctx,
 		"DELETE FROM songs WHERE id = ?", fromID); err != nil {
-		return MergeRecord{}, false, err
+		return err
 	}
-
-	if err := tx.Commit(); err != nil {
-		return MergeRecord{}, false, err
-	}
-	return rec, true, nil
+	return nil
 }
 
 func resolveSongIDTx(ctx context.Context, tx *sql.Tx, name string) (int64, bool, error) {
@@ -0,0 +1,58 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gdql/gdql/test/fixtures"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeSongs_RepointsAliasesAndPerformances(t *testing.T) {
+	path, cleanup := fixtures.CreateTestDB(t)
+	defer cleanup()
+
+	db, err := Open(path)
+	require.NoError(t, err)
+	defer db.Close()
+	ctx := context.Background()
+	conn := db.DB()
+
+	// Fixture: alias "Scarlet Begonias-" -> Scarlet Begonias (1). Merge Scarlet into Fire (2).
+	var before int
+	require.NoError(t, conn.QueryRowContext(ctx, "SELECT count(*) FROM performances WHERE song_id IN (1, 2)").Scan(&before))
+
+	require.NoError(t, db.MergeSongs(ctx, 2, 1))
+
+	var n int
+	require.NoError(t, conn.QueryRowContext(ctx, "SELECT count(*) FROM songs WHERE id = 1").Scan(&n))
+	require.Equal(t, 0, n, "dropped song is deleted")
+	require.NoError(t, conn.QueryRowContext(ctx, "SELECT count(*) FROM performances WHERE song_id = 1").Scan(&n))
+	require.Equal(t, 0, n)
+	require.NoError(t, conn.QueryRowContext(ctx, "SELECT times_played FROM songs WHERE id = 2").Scan(&n))
+	require.Equal(t, before, n, "times_played recomputed from combined performances")
+
+	// Both the pre-existing alias and the dropped name resolve to the kept song.
+	for _, name := range []string{"Scarlet Begonias-", "Scarlet Begonias"} {
+		song, err := db.GetSong(ctx, name)
+		require.NoError(t, err)
+		require.NotNil(t, song, name)
+		require.Equal(t, 2, song.ID, name)
+	}
+	require.NoError(t, conn.QueryRowContext(ctx, "SELECT count(*) FROM song_aliases a LEFT JOIN songs s ON s.id = a.song_id WHERE s.id IS NULL").Scan(&n))
+	require.Equal(t, 0, n, "no orphaned aliases")
+}
+
+func TestMergeSongs_Errors(t *testing.T) {
+	path, cleanup := fixtures.CreateTestDB(t)
+	defer cleanup()
+
+	db, err := Open(path)
+	require.NoError(t, err)
+	defer db.Close()
+	ctx := context.Background()
+
+	require.Error(t, db.MergeSongs(ctx, 1, 1))
+	require.Error(t, db.MergeSongs(ctx, 1, 999))
+	require.Error(t, db.MergeSongs(ctx, 999, 1))
+}
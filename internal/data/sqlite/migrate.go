@@ -0,0 +1,145 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// migration is one schema upgrade step. Steps must be idempotent: a DB built
+// from schema.sql already has everything, and a step may be retried if it was
+// deferred on an earlier Open.
+type migration struct {
+	name string
+	up   func(conn *sql.DB) error
+}
+
+// migrations is the ordered upgrade path for existing databases. Append only —
+// schema_version stores how many of these have been applied, so never reorder
+// or remove entries. New tables/columns also belong in schema.sql.
+var migrations = []migration{
+	{"create song_aliases", createTable("CREATE TABLE IF NOT EXISTS song_aliases (alias TEXT PRIMARY KEY, song_id INTEGER NOT NULL REFERENCES songs(id))")},
+	{"create lyrics", createTable("CREATE TABLE IF NOT EXISTS lyrics (song_id INTEGER PRIMARY KEY REFERENCES songs(id), lyrics TEXT, lyrics_fts TEXT)")},
+	{"add songs.is_cover", addColumn("songs", "is_cover", "INTEGER")},
+	{"add songs.original_artist", addColumn("songs", "original_artist", "TEXT")},
+	{"add performances.tape", addColumn("performances", "tape", "INTEGER DEFAULT 0")},
+	// schema.sql already had these three; the steps only add them to
+	// databases built before it did.
+	{"ensure idx_perf_position", createIndex("idx_perf_position", "performances", "show_id, set_number, position")},
+	{"ensure idx_perf_song", createIndex("idx_perf_song", "performances", "song_id")},
+	{"ensure idx_shows_date", createIndex("idx_shows_date", "shows", "date")},
+	{"index song_aliases(song_id)", createIndex("idx_song_aliases_song", "song_aliases", "song_id")},
+	{"add performances.set_name", addColumn("performances", "set_name", "TEXT")},
+	{"dedup performances, unique index", dedupAndIndexPerformances},
+	{"create show_ratings", createTable("CREATE TABLE IF NOT EXISTS show_ratings (show_id INTEGER NOT NULL REFERENCES shows(id), source TEXT NOT NULL, rating REAL NOT NULL, votes INTEGER, PRIMARY KEY (show_id, source))")},
+	{"add shows.source_url", addColumn("shows", "source_url", "TEXT")},
+	{"refold lyrics_fts", refoldLyrics},
+	{"compose song names and aliases to NFC", composeSongTitles},
+	{"normalize venue states", normalizeVenueStates},
+}
+
+// errMigrationDeferred means a step's target table doesn't exist yet (e.g. Open on
+// an empty file before the caller creates its schema). The step and everything
+// after it are retried on the next Open.
+var errMigrationDeferred = errors.New("migration deferred")
+
+// migrate applies pending migrations and records progress in schema_version.
+func migrate(conn *sql.DB) error {
+	if _, err := conn.Exec("CREATE TABLE IF NOT EXISTS schema_version (version INTEGER NOT NULL)"); err != nil {
+		return err
+	}
+	version, err := schemaVersion(conn)
+	if err == sql.ErrNoRows {
+		if _, err := conn.Exec("INSERT INTO schema_version (version) VALUES (0)"); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+	for i := version; i < len(migrations); i++ {
+		m := migrations[i]
+		if err := m.up(conn); err != nil {
+			if err == errMigrationDeferred {
+				return nil
+			}
+			return fmt.Errorf("migration %d (%s): %w", i+1, m.name, err)
+		}
+		if _, err := conn.Exec("UPDATE schema_version SET version = ?", i+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SchemaVersion is the schema_version of a fully migrated database: the
+// number of migrations this build knows.
+func SchemaVersion() int { return len(migrations) }
+
+func schemaVersion(conn *sql.DB) (int, error) {
+	var v int
+	err := conn.QueryRow("SELECT version FROM schema_version").Scan(&v)
+	return v, err
+}
+
+// tableExists reports whether conn has a table called name.
+func tableExists(conn *sql.DB, name string) (bool, error) {
+	var n int
+	err := conn.QueryRow("SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = ?", name).Scan(&n)
+	return n > 0, err
+}
+
+func createTable(stmt string) func(*sql.DB) error {
+	return func(conn *sql.DB) error {
+		_, err := conn.Exec(stmt)
+		return err
+	}
+}
+
+// addColumn adds table.column unless it's already there.
+func addColumn(table, column, typ string) func(*sql.DB) error {
+	return func(conn *sql.DB) error {
+		rows, err := conn.Query("SELECT name FROM pragma_table_info(?)", table)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		found, exists := false, false
+		for rows.Next() {
+			exists = true
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				return err
+			}
+			if name == column {
+				found = true
+			}
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if !exists {
+			return errMigrationDeferred
+		}
+		if found {
+			return nil
+		}
+		_, err = conn.Exec("ALTER TABLE " + table + " ADD COLUMN " + column + " " + typ)
+		return err
+	}
+}
+
+// createIndex creates an index unless it's already there. Deferred while the
+// table doesn't exist yet, like addColumn.
+func createIndex(name, table, columns string) func(*sql.DB) error {
+	return func(conn *sql.DB) error {
+		var n int
+		if err := conn.QueryRow("SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&n); err != nil {
+			return err
+		}
+		if n == 0 {
+			return errMigrationDeferred
+		}
+		_, err := conn.Exec("CREATE INDEX IF NOT EXISTS " + name + " ON " + table + "(" + columns + ")")
+		return err
+	}
+}
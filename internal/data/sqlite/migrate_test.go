@@ -0,0 +1,86 @@
+package sqlite
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// oldSchema is a database from before aliases, lyrics, and cover flags existed.
+const oldSchema = `
+CREATE TABLE venues (id INTEGER PRIMARY KEY, name TEXT, city TEXT, state TEXT, country TEXT);
+CREATE TABLE shows (id INTEGER PRIMARY KEY, date TEXT, venue_id INTEGER, tour TEXT, notes TEXT, rating REAL);
+CREATE TABLE songs (id INTEGER PRIMARY KEY, name TEXT, short_name TEXT, writers TEXT, first_played TEXT, last_played TEXT, times_played INTEGER DEFAULT 0);
+CREATE TABLE performances (id INTEGER PRIMARY KEY, show_id INTEGER, song_id INTEGER, set_number INTEGER, position INTEGER, segue_type TEXT, length_seconds INTEGER DEFAULT 0);
+INSERT INTO songs (id, name) VALUES (1, 'Dark Star');
+`
+
+func columns(t *testing.T, conn *sql.DB, table string) []string {
+	t.Helper()
+	rows, err := conn.Query("SELECT name FROM pragma_table_info(?)", table)
+	require.NoError(t, err)
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var name string
+		require.NoError(t, rows.Scan(&name))
+		out = append(out, name)
+	}
+	return out
+}
+
+func TestOpen_MigratesOldSchemaForward(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.db")
+	raw, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	_, err = raw.Exec(oldSchema)
+	require.NoError(t, err)
+	require.NoError(t, raw.Close())
+
+	db, err := Open(path)
+	require.NoError(t, err)
+	v, err := schemaVersion(db.DB())
+	require.NoError(t, err)
+	require.Equal(t, len(migrations), v)
+	require.Contains(t, columns(t, db.DB(), "songs"), "is_cover")
+	require.Contains(t, columns(t, db.DB(), "songs"), "original_artist")
+	require.NotEmpty(t, columns(t, db.DB(), "song_aliases"))
+	require.NotEmpty(t, columns(t, db.DB(), "lyrics"))
+	for _, idx := range []string{"idx_perf_position", "idx_perf_song", "idx_shows_date", "idx_song_aliases_song"} {
+		var n int
+		require.NoError(t, db.DB().QueryRow("SELECT count(*) FROM sqlite_master WHERE type = 'index' AND name = ?", idx).Scan(&n))
+		require.Equal(t, 1, n, idx)
+	}
+	require.NoError(t, db.Close())
+
+	// Reopening is a no-op and data survives
+	db, err = Open(path)
+	require.NoError(t, err)
+	defer db.Close()
+	var name string
+	require.NoError(t, db.DB().QueryRow("SELECT name FROM songs WHERE id = 1").Scan(&name))
+	require.Equal(t, "Dark Star", name)
+}
+
+func TestOpen_DefersMigrationsUntilTableExists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.db")
+	db, err := Open(path)
+	require.NoError(t, err)
+	v, err := schemaVersion(db.DB())
+	require.NoError(t, err)
+	require.Less(t, v, len(migrations), "songs columns can't be added before songs exists")
+
+	_, err = db.DB().Exec(oldSchema)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	db, err = Open(path)
+	require.NoError(t, err)
+	defer db.Close()
+	v, err = schemaVersion(db.DB())
+	require.NoError(t, err)
+	require.Equal(t, len(migrations), v)
+	require.Contains(t, columns(t, db.DB(), "songs"), "is_cover")
+}
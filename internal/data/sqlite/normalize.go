@@ -0,0 +1,166 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/gdql/gdql/internal/data"
+)
+
+// refoldLyrics is the migration step that rewrites lyrics.lyrics_fts as
+// data.FoldText of the lyrics. Rows imported before lyric search folded
+// accents only have the lyrics lowercased, so "cafe" wouldn't find "Café".
+func refoldLyrics(conn *sql.DB) error {
+	ctx := context.Background()
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	rows, err := tx.QueryContext(ctx, "SELECT song_id, lyrics, lyrics_fts FROM lyrics")
+	if err != nil {
+		return err
+	}
+	folded := make(map[int64]string)
+	for rows.Next() {
+		var id int64
+		var lyrics, fts sql.NullString
+		if err := rows.Scan(&id, &lyrics, &fts); err != nil {
+			rows.Close()
+			return err
+		}
+		if f := data.FoldText(lyrics.String); lyrics.Valid && f != fts.String {
+			folded[id] = f
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for id, f := range folded {
+		if _, err := tx.ExecContext(ctx, "UPDATE lyrics SET lyrics_fts = ? WHERE song_id = ?", f, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// composeSongTitles is the migration step that stores song names and aliases
+// in NFC (data.ComposeTitle), the form lookups and imports now use, so a title
+// imported with decomposed accents matches exactly. It only renames: a song
+// whose composed name another song already has is the same song stored twice
+// and is left for songs merge, and an alias whose composed form is already
+// taken keeps its old spelling. Deferred while songs doesn't exist.
+func composeSongTitles(conn *sql.DB) error {
+	ok, err := tableExists(conn, "songs")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errMigrationDeferred
+	}
+	ctx := context.Background()
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	songs, err := decomposed(ctx, tx, "SELECT id, name FROM songs")
+	if err != nil {
+		return err
+	}
+	for _, s := range songs {
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE songs SET name = ?1 WHERE id = ?2 AND NOT EXISTS (SELECT 1 FROM songs WHERE name = ?1)",
+			s.composed, s.id); err != nil {
+			return err
+		}
+	}
+
+	aliases, err := decomposed(ctx, tx, "SELECT song_id, alias FROM song_aliases")
+	if err != nil {
+		return err
+	}
+	for _, a := range aliases {
+		if _, err := tx.ExecContext(ctx, "UPDATE OR IGNORE song_aliases SET alias = ? WHERE alias = ?", a.composed, a.name); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// decomposedName is a row whose name isn't in NFC.
+type decomposedName struct {
+	id             int64
+	name, composed string
+}
+
+// decomposed runs query, which selects (id, name), and returns the rows whose
+// name changes under data.ComposeTitle.
+func decomposed(ctx context.Context, tx *sql.Tx, query string) ([]decomposedName, error) {
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []decomposedName
+	for rows.Next() {
+		var d decomposedName
+		if err := rows.Scan(&d.id, &d.name); err != nil {
+			return nil, err
+		}
+		if d.composed = data.ComposeTitle(d.name); d.composed != d.name {
+			out = append(out, d)
+		}
+	}
+	return out, rows.Err()
+}
+
+// normalizeVenueStates is the migration step that stores venues.state as
+// data.NormalizeState gives it ("New York" → "NY"), the form importers now
+// write. Venues that then match one another (a venue imported once as
+// "New York" and once as "NY") are left as they are; MergeVenues folds them
+// together. Deferred while venues doesn't exist.
+func normalizeVenueStates(conn *sql.DB) error {
+	ok, err := tableExists(conn, "venues")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errMigrationDeferred
+	}
+	ctx := context.Background()
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, "SELECT id, state FROM venues WHERE state IS NOT NULL")
+	if err != nil {
+		return err
+	}
+	states := make(map[int64]string)
+	for rows.Next() {
+		var id int64
+		var state string
+		if err := rows.Scan(&id, &state); err != nil {
+			rows.Close()
+			return err
+		}
+		if s := data.NormalizeState(state); s != state {
+			states[id] = s
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for id, s := range states {
+		if _, err := tx.ExecContext(ctx, "UPDATE venues SET state = ? WHERE id = ?", s, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,96 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gdql/gdql/test/fixtures"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefoldLyrics(t *testing.T) {
+	path, cleanup := fixtures.CreateTestDB(t)
+	defer cleanup()
+	db, err := Open(path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	// As an import from before folding stored it: lowercased, accents kept.
+	ctx := context.Background()
+	_, err = db.DB().ExecContext(ctx, "INSERT OR REPLACE INTO lyrics (song_id, lyrics, lyrics_fts) VALUES (6, 'Café Naïve, reflections', 'café naïve, reflections')")
+	require.NoError(t, err)
+	require.NoError(t, refoldLyrics(db.DB()))
+
+	var fts string
+	require.NoError(t, db.DB().QueryRowContext(ctx, "SELECT lyrics_fts FROM lyrics WHERE song_id = 6").Scan(&fts))
+	require.Equal(t, "cafe naive reflections", fts)
+}
+
+func TestComposeSongTitles(t *testing.T) {
+	path, cleanup := fixtures.CreateTestDB(t)
+	defer cleanup()
+	db, err := Open(path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	// Stored decomposed, as an older import may have: one new title, one
+	// duplicate of song 6 whose composed name is taken, an alias, and an
+	// alias whose composed form another song already has.
+	ctx := context.Background()
+	_, err = db.DB().ExecContext(ctx, "UPDATE songs SET name = 'Dark Star Caf\u00e9' WHERE id = 6")
+	require.NoError(t, err)
+	_, err = db.DB().ExecContext(ctx, "INSERT INTO songs (id, name, times_played) VALUES (41, ?, 0), (42, ?, 0)", "Jose\u0301", "Dark Star Cafe\u0301")
+	require.NoError(t, err)
+	_, err = db.DB().ExecContext(ctx, "INSERT INTO performances (show_id, song_id, set_number, position) VALUES (1, 42, 3, 1)")
+	require.NoError(t, err)
+	_, err = db.DB().ExecContext(ctx, "INSERT INTO song_aliases (alias, song_id) VALUES (?, 41), (?, 41), (?, 6)", "Jose\u0301!", "Jose\u0301?", "Jos\u00e9?")
+	require.NoError(t, err)
+
+	require.NoError(t, composeSongTitles(db.DB()))
+
+	song, err := db.GetSongStrict(ctx, "Jos\u00e9")
+	require.NoError(t, err)
+	require.NotNil(t, song)
+	require.Equal(t, 41, song.ID)
+	song, err = db.GetSongStrict(ctx, "Jos\u00e9!")
+	require.NoError(t, err)
+	require.NotNil(t, song, "the alias is composed too")
+	require.Equal(t, 41, song.ID)
+
+	var n int
+	require.NoError(t, db.DB().QueryRowContext(ctx, "SELECT count(*) FROM songs WHERE id = 42").Scan(&n))
+	require.Equal(t, 1, n, "the duplicate is left for songs merge")
+	require.NoError(t, db.DB().QueryRowContext(ctx, "SELECT count(*) FROM performances WHERE song_id = 42").Scan(&n))
+	require.Equal(t, 1, n, "its performance stays with it")
+	require.NoError(t, db.DB().QueryRowContext(ctx, "SELECT count(*) FROM song_aliases WHERE alias IN (?, ?)", "Jose\u0301?", "Jos\u00e9?").Scan(&n))
+	require.Equal(t, 2, n, "a colliding alias keeps its old spelling")
+}
+
+func TestNormalizeVenueStates(t *testing.T) {
+	path, cleanup := fixtures.CreateTestDB(t)
+	defer cleanup()
+	db, err := Open(path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	// Barton Hall stored again with the state spelled out, as setlist.fm gave
+	// it before importers normalized.
+	ctx := context.Background()
+	_, err = db.DB().ExecContext(ctx, `
+		INSERT INTO venues (id, name, city, state, country) VALUES
+			(40, 'barton hall', 'Ithaca', 'New York', 'USA'),
+			(41, 'The Spectrum', 'Philadelphia', 'pennsylvania', 'USA');
+		INSERT INTO shows (id, date, venue_id) VALUES (40, '1977-05-08', 40);`)
+	require.NoError(t, err)
+
+	require.NoError(t, normalizeVenueStates(db.DB()))
+
+	var state string
+	require.NoError(t, db.DB().QueryRowContext(ctx, "SELECT state FROM venues WHERE id = 40").Scan(&state))
+	require.Equal(t, "NY", state)
+	require.NoError(t, db.DB().QueryRowContext(ctx, "SELECT state FROM venues WHERE id = 41").Scan(&state))
+	require.Equal(t, "PA", state)
+	var n int
+	require.NoError(t, db.DB().QueryRowContext(ctx, "SELECT count(*) FROM shows WHERE date = '1977-05-08'").Scan(&n))
+	require.Equal(t, 2, n, "the migration merges nothing; MergeVenues does")
+}
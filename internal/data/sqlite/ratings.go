@@ -0,0 +1,119 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"strings"
+)
+
+// RatingEntry is one source's rating of a show for gdql-import ratings.
+// Rating is on a 0-5 scale; Votes is how many ratings the source averaged
+// (nil when unknown, which counts as one).
+type RatingEntry struct {
+	Source string   `json:"source"`
+	Rating *float64 `json:"rating"`
+	Votes  *int     `json:"votes"`
+}
+
+// LoadRatingsFromFile reads a date -> [ratings] map, one entry per source:
+//
+//	{"1977-05-08": [{"source": "headyversion", "rating": 4.9, "votes": 412},
+//	                {"source": "deadbase", "rating": 4.7}]}
+//
+// and stores each in show_ratings, replacing that source's earlier rating of
+// the show. Afterwards every show with rows in show_ratings gets shows.rating
+// set to their vote-weighted average, so RATING queries (ORDER BY RATING,
+// BEST SHOWS, WHERE RATING >= 4.5) use the combined figure. A show's rating
+// from before it had any sources is kept in that average as source "legacy"
+// (seedLegacyRating). Entries without a source, with a rating outside 0-5, or
+// for dates with no show are skipped.
+func LoadRatingsFromFile(ctx context.Context, db *sql.DB, path string) (loaded, skipped int, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	var entries map[string][]RatingEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return 0, 0, err
+	}
+	dateToIDs, err := showIDsByDate(ctx, db)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for date, ratings := range entries {
+		ids, ok := dateToIDs[date]
+		if !ok {
+			skipped += len(ratings)
+			continue
+		}
+		for _, r := range ratings {
+			source := strings.ToLower(strings.TrimSpace(r.Source))
+			if source == "" || r.Rating == nil || *r.Rating < 0 || *r.Rating > 5 || (r.Votes != nil && *r.Votes < 1) {
+				skipped++
+				continue
+			}
+			for _, showID := range ids {
+				if err := seedLegacyRating(ctx, db, showID); err != nil {
+					return loaded, skipped, err
+				}
+				_, err = db.ExecContext(ctx, "INSERT OR REPLACE INTO show_ratings (show_id, source, rating, votes) VALUES (?, ?, ?, ?)",
+					showID, source, *r.Rating, r.Votes)
+				if err != nil {
+					return loaded, skipped, err
+				}
+			}
+			loaded++
+		}
+	}
+	return loaded, skipped, UpdateShowRatings(ctx, db)
+}
+
+// legacySource is the show_ratings source for a rating stored on the show
+// itself before any source was loaded for it.
+const legacySource = "legacy"
+
+// seedLegacyRating copies showID's rating into show_ratings as legacySource
+// if the show has a rating and no sources yet, so UpdateShowRatings averages
+// it in rather than overwriting it.
+func seedLegacyRating(ctx context.Context, db *sql.DB, showID int64) error {
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO show_ratings (show_id, source, rating)
+		SELECT id, ?, rating FROM shows
+		WHERE id = ? AND rating IS NOT NULL AND NOT EXISTS (SELECT 1 FROM show_ratings WHERE show_id = shows.id)`,
+		legacySource, showID)
+	return err
+}
+
+// UpdateShowRatings sets shows.rating to the vote-weighted average of its
+// show_ratings rows for every show that has any. Shows without sources keep
+// their rating.
+func UpdateShowRatings(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `
+		UPDATE shows SET rating = (
+			SELECT sum(r.rating * coalesce(r.votes, 1)) / sum(coalesce(r.votes, 1))
+			FROM show_ratings r WHERE r.show_id = shows.id)
+		WHERE id IN (SELECT show_id FROM show_ratings)`)
+	return err
+}
+
+// showIDsByDate maps each show date (YYYY-MM-DD) to the ids of its shows.
+func showIDsByDate(ctx context.Context, db *sql.DB) (map[string][]int64, error) {
+	rows, err := db.QueryContext(ctx, "SELECT id, date FROM shows")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[string][]int64{}
+	for rows.Next() {
+		var id int64
+		var date string
+		if err := rows.Scan(&id, &date); err != nil {
+			return nil, err
+		}
+		out[date] = append(out[date], id)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,79 @@
+package sqlite
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gdql/gdql/test/fixtures"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadRatingsFromFile(t *testing.T) {
+	path, cleanup := fixtures.CreateTestDB(t)
+	defer cleanup()
+	db, err := Open(path)
+	require.NoError(t, err)
+	defer db.Close()
+	ctx := context.Background()
+
+	ratingsPath := filepath.Join(t.TempDir(), "ratings.json")
+	write := func(body string) {
+		require.NoError(t, os.WriteFile(ratingsPath, []byte(body), 0644))
+	}
+	rating := func(showID int) float64 {
+		var r float64
+		require.NoError(t, db.DB().QueryRowContext(ctx, "SELECT rating FROM shows WHERE id = ?", showID).Scan(&r))
+		return r
+	}
+
+	write(`{
+		"1977-05-08": [
+			{"source": "Headyversion", "rating": 5.0, "votes": 300},
+			{"source": "deadbase", "rating": 4.6, "votes": 100},
+			{"source": "typo", "rating": 46}
+		],
+		"1977-02-26": [{"source": "deadbase", "rating": 4.0}],
+		"1999-01-01": [{"source": "deadbase", "rating": 3.0}]
+	}`)
+	loaded, skipped, err := LoadRatingsFromFile(ctx, db.DB(), ratingsPath)
+	require.NoError(t, err)
+	require.Equal(t, 3, loaded)
+	require.Equal(t, 2, skipped, "out-of-range rating and unknown date")
+
+	// The fixture's own ratings (4.9 and 4.5) count as one "legacy" vote each.
+	require.InDelta(t, (5.0*300+4.6*100+4.9)/401, rating(1), 1e-9, "weighted by votes")
+	require.InDelta(t, (4.0+4.5)/2, rating(2), 1e-9, "unknown votes count as one")
+	require.InDelta(t, 4.2, rating(3), 1e-9, "shows without sources keep their rating")
+
+	// Reloading a source replaces its earlier rating rather than adding one.
+	write(`{"1977-05-08": [{"source": "deadbase", "rating": 5.0, "votes": 100}]}`)
+	_, _, err = LoadRatingsFromFile(ctx, db.DB(), ratingsPath)
+	require.NoError(t, err)
+	require.InDelta(t, (5.0*400+4.9)/401, rating(1), 1e-9)
+	var n int
+	require.NoError(t, db.DB().QueryRowContext(ctx, "SELECT count(*) FROM show_ratings WHERE show_id = 1").Scan(&n))
+	require.Equal(t, 3, n, "legacy is seeded once")
+}
+
+func TestLoadRatingsFromFile_KeepsPreexistingRating(t *testing.T) {
+	path, cleanup := fixtures.CreateTestDB(t)
+	defer cleanup()
+	db, err := Open(path)
+	require.NoError(t, err)
+	defer db.Close()
+	ctx := context.Background()
+
+	// Show 3 was rated 4.2 before any source was loaded.
+	ratingsPath := filepath.Join(t.TempDir(), "ratings.json")
+	require.NoError(t, os.WriteFile(ratingsPath, []byte(`{"1978-04-24": [{"source": "deadbase", "rating": 3.8}]}`), 0644))
+	_, _, err = LoadRatingsFromFile(ctx, db.DB(), ratingsPath)
+	require.NoError(t, err)
+
+	var legacy, combined float64
+	require.NoError(t, db.DB().QueryRowContext(ctx, "SELECT rating FROM show_ratings WHERE show_id = 3 AND source = 'legacy'").Scan(&legacy))
+	require.InDelta(t, 4.2, legacy, 1e-9)
+	require.NoError(t, db.DB().QueryRowContext(ctx, "SELECT rating FROM shows WHERE id = 3").Scan(&combined))
+	require.InDelta(t, 4.0, combined, 1e-9, "averaged with the new source, not replaced by it")
+}
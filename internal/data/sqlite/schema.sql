@@ -19,7 +19,8 @@ CREATE TABLE IF NOT EXISTS shows (
     notes TEXT,
     soundboard INTEGER,
     archive_id TEXT,
-    rating REAL
+    rating REAL,
+    source_url TEXT -- the show's page at its import source (e.g. setlist.fm); NULL if unknown
 );
 
 CREATE TABLE IF NOT EXISTS songs (
@@ -48,6 +49,8 @@ CREATE TABLE IF NOT EXISTS performances (
     is_closer INTEGER,
     guest TEXT,
     notes TEXT,
+    tape INTEGER DEFAULT 0, -- 1 when the song was played from tape (setlist.fm)
+    set_name TEXT, -- the source's name for the set, e.g. "Acoustic Set"; NULL for plain numbered sets
     UNIQUE(show_id, set_number, position)
 );
 
@@ -62,6 +65,7 @@ CREATE TABLE IF NOT EXISTS song_aliases (
     alias TEXT PRIMARY KEY,
     song_id INTEGER NOT NULL REFERENCES songs(id)
 );
+CREATE INDEX IF NOT EXISTS idx_song_aliases_song ON song_aliases(song_id);
 
 -- Directed relations between two canonical songs. Distinct from song_aliases,
 -- which normalizes raw setlist text into one canonical name. A relation
@@ -118,9 +122,33 @@ CREATE TABLE IF NOT EXISTS show_recordings (
 );
 CREATE INDEX IF NOT EXISTS idx_show_recordings_show ON show_recordings(show_id);
 
+-- Community ratings per show, one row per source (e.g. headyversion, deadbase),
+-- each the source's own 0-5 average over `votes` ratings (NULL = unknown).
+-- Loaded with `gdql-import ratings`, which also sets shows.rating to the
+-- vote-weighted average across sources.
+CREATE TABLE IF NOT EXISTS show_ratings (
+    show_id INTEGER NOT NULL REFERENCES shows(id),
+    source TEXT NOT NULL,
+    rating REAL NOT NULL,
+    votes INTEGER,
+    PRIMARY KEY (show_id, source)
+);
+
+-- Resume point for paged API importers (one row per source, e.g. 'setlistfm').
+-- last_page is the last page fully written; newest_date is the most recent
+-- event date seen (YYYY-MM-DD). Lets an interrupted import skip ahead.
+CREATE TABLE IF NOT EXISTS import_state (
+    source TEXT PRIMARY KEY,
+    last_page INTEGER NOT NULL DEFAULT 0,
+    newest_date TEXT,
+    updated_at TEXT
+);
+
 CREATE INDEX IF NOT EXISTS idx_songs_name ON songs(name);
 CREATE INDEX IF NOT EXISTS idx_perf_song ON performances(song_id);
 CREATE INDEX IF NOT EXISTS idx_perf_show ON performances(show_id);
+-- One row per song per slot (NULL sets included); importers INSERT OR IGNORE against it.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_perf_unique ON performances(show_id, song_id, ifnull(set_number, -1), position);
 CREATE INDEX IF NOT EXISTS idx_perf_position ON performances(show_id, set_number, position);
 CREATE INDEX IF NOT EXISTS idx_shows_venue ON shows(venue_id);
 CREATE INDEX IF NOT EXISTS idx_venue_coords_latlon ON venue_coords(lat, lon);
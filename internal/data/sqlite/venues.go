@@ -0,0 +1,209 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// duplicateVenues pairs each venue with the lowest-id venue it matches the way
+// importers look venues up: name and city case-insensitively, state and
+// country exactly. Rows are (drop, keep, name).
+const duplicateVenues = `SELECT v.id, min(k.id), v.name FROM venues v JOIN venues k
+	ON lower(k.name) = lower(v.name) AND lower(COALESCE(k.city, '')) = lower(COALESCE(v.city, ''))
+	AND COALESCE(k.state, '') = COALESCE(v.state, '') AND COALESCE(k.country, '') = COALESCE(v.country, '')
+GROUP BY v.id HAVING min(k.id) < v.id ORDER BY v.id`
+
+// VenueMerge reports one duplicate venue found by MergeVenues.
+type VenueMerge struct {
+	KeepID, DropID int64
+	Name           string
+	Moved          int // shows only the duplicate had, repointed to KeepID
+	Merged         int // shows both venues had on one date, folded into KeepID's
+	// Conflict, when set, says why the pair was left alone: a show both
+	// venues have on one date has a different setlist under each, and
+	// picking one would lose the other.
+	Conflict string
+}
+
+// MergeVenues folds each duplicate venue (see duplicateVenues) into the
+// lowest-id venue it matches, so a venue imported once with its state spelled
+// out and once as a code stops being two venues with the same show under
+// each. A show both have on one date is the same show imported twice and is
+// merged (mergeShowTx). With dryRun nothing is written; the report says what
+// would be.
+//
+// times_played and first/last_played aren't adjusted here; callers run
+// shared.RecountSongs afterwards.
+func (db *DB) MergeVenues(ctx context.Context, dryRun bool) ([]VenueMerge, error) {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, duplicateVenues)
+	if err != nil {
+		return nil, err
+	}
+	var merges []VenueMerge
+	for rows.Next() {
+		var m VenueMerge
+		if err := rows.Scan(&m.DropID, &m.KeepID, &m.Name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		merges = append(merges, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for i := range merges {
+		if err := mergeVenueTx(ctx, tx, &merges[i]); err != nil {
+			return nil, err
+		}
+	}
+	if dryRun {
+		return merges, nil
+	}
+	return merges, tx.Commit()
+}
+
+// venueShow is one of a duplicate venue's shows, with the show the kept venue
+// has on the same date, if any.
+type venueShow struct {
+	id   int64
+	date string
+	keep sql.NullInt64
+}
+
+// mergeVenueTx moves m.DropID's shows onto m.KeepID and deletes m.DropID,
+// filling in m's counts. If any same-date pair conflicts nothing is changed
+// and m.Conflict says which.
+func mergeVenueTx(ctx context.Context, tx *sql.Tx, m *VenueMerge) error {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT s.id, s.date, (SELECT min(k.id) FROM shows k WHERE k.venue_id = ? AND k.date = s.date)
+		FROM shows s WHERE s.venue_id = ? ORDER BY s.date`, m.KeepID, m.DropID)
+	if err != nil {
+		return err
+	}
+	var shows []venueShow
+	for rows.Next() {
+		var s venueShow
+		if err := rows.Scan(&s.id, &s.date, &s.keep); err != nil {
+			rows.Close()
+			return err
+		}
+		shows = append(shows, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, s := range shows {
+		if !s.keep.Valid {
+			continue
+		}
+		ok, err := setlistsAgree(ctx, tx, s.keep.Int64, s.id)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			m.Conflict = fmt.Sprintf("shows %d and %d on %s have different setlists", s.keep.Int64, s.id, s.date)
+			return nil
+		}
+	}
+	for _, s := range shows {
+		if s.keep.Valid {
+			if err := mergeShowTx(ctx, tx, s.keep.Int64, s.id); err != nil {
+				return err
+			}
+			m.Merged++
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE shows SET venue_id = ? WHERE id = ?", m.KeepID, s.id); err != nil {
+			return err
+		}
+		m.Moved++
+	}
+	if err := moveOptional(ctx, tx, "venue_coords", "venue_id", m.KeepID, m.DropID); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM venues WHERE id = ?", m.DropID)
+	return err
+}
+
+// setlistsAgree reports whether shows a and b can be merged without losing a
+// setlist: one of them has none, or both list the same songs at the same
+// positions.
+func setlistsAgree(ctx context.Context, tx *sql.Tx, a, b int64) (bool, error) {
+	var na, nb, differ int
+	err := tx.QueryRowContext(ctx, `SELECT
+		(SELECT count(*) FROM performances WHERE show_id = ?1),
+		(SELECT count(*) FROM performances WHERE show_id = ?2),
+		(SELECT count(*) FROM (
+			SELECT song_id, ifnull(set_number, -1), position FROM performances WHERE show_id = ?1
+			EXCEPT SELECT song_id, ifnull(set_number, -1), position FROM performances WHERE show_id = ?2)) +
+		(SELECT count(*) FROM (
+			SELECT song_id, ifnull(set_number, -1), position FROM performances WHERE show_id = ?2
+			EXCEPT SELECT song_id, ifnull(set_number, -1), position FROM performances WHERE show_id = ?1))`,
+		a, b).Scan(&na, &nb, &differ)
+	if err != nil {
+		return false, err
+	}
+	return na == 0 || nb == 0 || differ == 0, nil
+}
+
+// mergeShowTx folds show fromID into toID and deletes fromID. The caller has
+// checked setlistsAgree: fromID's performances move if toID has none and are
+// otherwise the same setlist, which is dropped. Empty tour, notes, rating,
+// and source_url are filled from fromID, and per-show rows (ratings, weather,
+// recordings) move unless toID has its own.
+func mergeShowTx(ctx context.Context, tx *sql.Tx, toID, fromID int64) error {
+	var kept int
+	if err := tx.QueryRowContext(ctx, "SELECT count(*) FROM performances WHERE show_id = ?", toID).Scan(&kept); err != nil {
+		return err
+	}
+	if kept == 0 {
+		if _, err := tx.ExecContext(ctx, "UPDATE performances SET show_id = ? WHERE show_id = ?", toID, fromID); err != nil {
+			return err
+		}
+	} else if _, err := tx.ExecContext(ctx, "DELETE FROM performances WHERE show_id = ?", fromID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE shows SET
+			tour = COALESCE(NULLIF(tour, ''), (SELECT tour FROM shows WHERE id = ?2)),
+			notes = COALESCE(NULLIF(notes, ''), (SELECT notes FROM shows WHERE id = ?2)),
+			rating = COALESCE(rating, (SELECT rating FROM shows WHERE id = ?2)),
+			source_url = COALESCE(source_url, (SELECT source_url FROM shows WHERE id = ?2))
+		WHERE id = ?1`, toID, fromID); err != nil {
+		return err
+	}
+	for _, table := range []string{"show_ratings", "show_weather", "show_recordings"} {
+		if err := moveOptional(ctx, tx, table, "show_id", toID, fromID); err != nil {
+			return err
+		}
+	}
+	_, err := tx.ExecContext(ctx, "DELETE FROM shows WHERE id = ?", fromID)
+	return err
+}
+
+// moveOptional repoints table's rows from fromID to toID in column, dropping
+// those that would collide with toID's own. Databases built before table
+// existed have nothing to move.
+func moveOptional(ctx context.Context, tx *sql.Tx, table, column string, toID, fromID int64) error {
+	var n int
+	if err := tx.QueryRowContext(ctx, "SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&n); err != nil {
+		return err
+	}
+	if n == 0 {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE OR IGNORE "+table+" SET "+column+" = ? WHERE "+column+" = ?", toID, fromID); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE "+column+" = ?", fromID)
+	return err
+}
@@ -0,0 +1,90 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gdql/gdql/test/fixtures"
+	"github.com/stretchr/testify/require"
+)
+
+// addDuplicateBartonHall stores Barton Hall again, as an import from before
+// states were normalized left it: Cornell a second time with the same setlist
+// and a source link, and a show only under the duplicate.
+func addDuplicateBartonHall(t *testing.T, db *DB) {
+	t.Helper()
+	_, err := db.DB().ExecContext(context.Background(), `
+		INSERT INTO venues (id, name, city, state, country) VALUES (40, 'barton hall', 'Ithaca', 'NY', 'USA');
+		INSERT INTO shows (id, date, venue_id, source_url) VALUES
+			(40, '1977-05-08', 40, 'https://example.com/cornell'),
+			(41, '1980-05-07', 40, NULL);
+		INSERT INTO performances (id, show_id, song_id, set_number, position)
+			SELECT 400 + id, 40, song_id, set_number, position FROM performances WHERE show_id = 1;
+		INSERT INTO performances (id, show_id, song_id, set_number, position) VALUES (410, 41, 5, 1, 1);
+		INSERT INTO show_ratings (show_id, source, rating) VALUES (40, 'deadbase', 4.8);`)
+	require.NoError(t, err)
+}
+
+func TestMergeVenues(t *testing.T) {
+	path, cleanup := fixtures.CreateTestDB(t)
+	defer cleanup()
+	db, err := Open(path)
+	require.NoError(t, err)
+	defer db.Close()
+	addDuplicateBartonHall(t, db)
+	ctx := context.Background()
+
+	merges, err := db.MergeVenues(ctx, true)
+	require.NoError(t, err)
+	require.Equal(t, []VenueMerge{{KeepID: 1, DropID: 40, Name: "barton hall", Moved: 1, Merged: 1}}, merges)
+	var n int
+	require.NoError(t, db.DB().QueryRowContext(ctx, "SELECT count(*) FROM venues WHERE id = 40").Scan(&n))
+	require.Equal(t, 1, n, "a dry run writes nothing")
+
+	merges, err = db.MergeVenues(ctx, false)
+	require.NoError(t, err)
+	require.Len(t, merges, 1)
+	require.NoError(t, db.DB().QueryRowContext(ctx, "SELECT count(*) FROM venues WHERE id = 40").Scan(&n))
+	require.Zero(t, n, "the duplicate venue is merged away")
+
+	var venue int
+	require.NoError(t, db.DB().QueryRowContext(ctx, "SELECT venue_id FROM shows WHERE id = 41").Scan(&venue))
+	require.Equal(t, 1, venue, "a show only the duplicate had moves to the kept venue")
+	require.NoError(t, db.DB().QueryRowContext(ctx, "SELECT count(*) FROM shows WHERE date = '1977-05-08'").Scan(&n))
+	require.Equal(t, 1, n, "the same show under both venues is merged")
+	var url string
+	require.NoError(t, db.DB().QueryRowContext(ctx, "SELECT source_url FROM shows WHERE id = 1").Scan(&url))
+	require.Equal(t, "https://example.com/cornell", url)
+	require.NoError(t, db.DB().QueryRowContext(ctx, "SELECT count(*) FROM show_ratings WHERE show_id = 1 AND source = 'deadbase'").Scan(&n))
+	require.Equal(t, 1, n)
+	require.NoError(t, db.DB().QueryRowContext(ctx, "SELECT count(*) FROM performances WHERE show_id IN (1, 40)").Scan(&n))
+	require.Equal(t, 6, n, "the repeated setlist is stored once")
+
+	merges, err = db.MergeVenues(ctx, false)
+	require.NoError(t, err)
+	require.Empty(t, merges)
+}
+
+func TestMergeVenues_DifferentSetlistsConflict(t *testing.T) {
+	path, cleanup := fixtures.CreateTestDB(t)
+	defer cleanup()
+	db, err := Open(path)
+	require.NoError(t, err)
+	defer db.Close()
+	addDuplicateBartonHall(t, db)
+	ctx := context.Background()
+	_, err = db.DB().ExecContext(ctx, "UPDATE performances SET song_id = 3 WHERE id = 411")
+	require.NoError(t, err)
+
+	merges, err := db.MergeVenues(ctx, false)
+	require.NoError(t, err)
+	require.Len(t, merges, 1)
+	require.Equal(t, "shows 1 and 40 on 1977-05-08 have different setlists", merges[0].Conflict)
+	require.Zero(t, merges[0].Moved+merges[0].Merged)
+
+	var n int
+	require.NoError(t, db.DB().QueryRowContext(ctx, "SELECT count(*) FROM performances WHERE show_id = 40").Scan(&n))
+	require.Equal(t, 6, n, "neither setlist is dropped")
+	require.NoError(t, db.DB().QueryRowContext(ctx, "SELECT count(*) FROM venues WHERE id = 40").Scan(&n))
+	require.Equal(t, 1, n)
+}
@@ -0,0 +1,43 @@
+package data
+
+import "strings"
+
+// stateCodes maps full US state and Canadian province names (lowercase) to
+// their postal codes. setlist.fm gives both forms; other sources give either.
+var stateCodes = map[string]string{
+	"alabama": "AL", "alaska": "AK", "arizona": "AZ", "arkansas": "AR", "california": "CA",
+	"colorado": "CO", "connecticut": "CT", "delaware": "DE", "district of columbia": "DC", "florida": "FL",
+	"georgia": "GA", "hawaii": "HI", "idaho": "ID", "illinois": "IL", "indiana": "IN",
+	"iowa": "IA", "kansas": "KS", "kentucky": "KY", "louisiana": "LA", "maine": "ME",
+	"maryland": "MD", "massachusetts": "MA", "michigan": "MI", "minnesota": "MN", "mississippi": "MS",
+	"missouri": "MO", "montana": "MT", "nebraska": "NE", "nevada": "NV", "new hampshire": "NH",
+	"new jersey": "NJ", "new mexico": "NM", "new york": "NY", "north carolina": "NC", "north dakota": "ND",
+	"ohio": "OH", "oklahoma": "OK", "oregon": "OR", "pennsylvania": "PA", "rhode island": "RI",
+	"south carolina": "SC", "south dakota": "SD", "tennessee": "TN", "texas": "TX", "utah": "UT",
+	"vermont": "VT", "virginia": "VA", "washington": "WA", "west virginia": "WV", "wisconsin": "WI",
+	"wyoming": "WY",
+	"alberta": "AB", "british columbia": "BC", "manitoba": "MB", "new brunswick": "NB",
+	"newfoundland and labrador": "NL", "nova scotia": "NS", "ontario": "ON", "prince edward island": "PE",
+	"quebec": "QC", "québec": "QC", "saskatchewan": "SK",
+}
+
+// NormalizeState returns the postal code for a US state or Canadian province
+// ("New York" → "NY", "ny" → "NY"). Other values (e.g. UK counties) are
+// returned trimmed but otherwise unchanged. Importers store this form so the
+// same venue from different sources maps to one venues row, and a migration
+// rewrites venues stored before they did.
+func NormalizeState(state string) string {
+	s := strings.TrimSpace(state)
+	if code, ok := stateCodes[strings.ToLower(s)]; ok {
+		return code
+	}
+	if len(s) == 2 {
+		upper := strings.ToUpper(s)
+		for _, code := range stateCodes {
+			if code == upper {
+				return upper
+			}
+		}
+	}
+	return s
+}
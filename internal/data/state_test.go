@@ -0,0 +1,16 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeState(t *testing.T) {
+	require.Equal(t, "NY", NormalizeState("New York"))
+	require.Equal(t, "NY", NormalizeState(" new york "))
+	require.Equal(t, "NY", NormalizeState("ny"))
+	require.Equal(t, "ON", NormalizeState("Ontario"))
+	require.Equal(t, "Greater London", NormalizeState("Greater London"))
+	require.Equal(t, "", NormalizeState(""))
+}
@@ -36,6 +36,21 @@ func (e *ParseError) Error() string {
 	return b.String()
 }
 
+// ParseErrors collects the errors from a recovering parse (one per bad statement),
+// in source order.
+type ParseErrors []*ParseError
+
+func (e ParseErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+	parts := make([]string, len(e))
+	for i, pe := range e {
+		parts[i] = pe.Error()
+	}
+	return fmt.Sprintf("%d parse errors:\n\n%s", len(e), strings.Join(parts, "\n\n"))
+}
+
 // SuggestKeyword returns the closest matching keyword from the candidates list,
 // or "" if no good match exists. Uses Levenshtein distance with a max threshold.
 func SuggestKeyword(input string, candidates []string) string {
@@ -111,6 +126,11 @@ const (
 	ErrVenueNotFound
 	ErrAmbiguousSong
 	ErrNoDatabase
+	ErrNoLyrics
+	ErrSegueTooLong
+	ErrTimeout
+	ErrAmbiguousVenue
+	ErrSchemaOutdated
 )
 
 func (e *QueryError) Error() string {
@@ -131,6 +151,9 @@ func (e *QueryError) Error() string {
 	return b.String()
 }
 
+// Unwrap returns the cause, so errors.Is sees e.g. context.DeadlineExceeded.
+func (e *QueryError) Unwrap() error { return e.Cause }
+
 func (t ErrorType) String() string {
 	switch t {
 	case ErrSongNotFound:
@@ -143,6 +166,16 @@ func (t ErrorType) String() string {
 		return "ambiguous song"
 	case ErrNoDatabase:
 		return "no database"
+	case ErrNoLyrics:
+		return "no lyrics data"
+	case ErrSegueTooLong:
+		return "segue chain too long"
+	case ErrTimeout:
+		return "query timed out"
+	case ErrAmbiguousVenue:
+		return "ambiguous venue"
+	case ErrSchemaOutdated:
+		return "database schema out of date"
 	default:
 		return "query error"
 	}
@@ -0,0 +1,69 @@
+package executor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gdql/gdql/internal/data/sqlite"
+	"github.com/gdql/gdql/internal/planner/resolver"
+	"github.com/gdql/gdql/test/fixtures"
+)
+
+// benchShows is roughly the size of the real show catalog.
+const benchShows = 2500
+
+func BenchmarkExecute(b *testing.B) {
+	db, err := sqlite.Open(fixtures.CreateLargeTestDB(b, benchShows))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer db.Close()
+	ex := New(db)
+	ctx := context.Background()
+
+	for _, bc := range []struct{ name, query string }{
+		{"ShowsFromYear", `SHOWS FROM 1977;`},
+		{"Segue", `SHOWS WHERE "Scarlet Begonias" > "Fire on the Mountain";`},
+		{"PlayedOr", `SHOWS WHERE PLAYED "Dark Star" OR PLAYED "St. Stephen" AND PLAYED "Morning Dew";`},
+		{"Performances", `PERFORMANCES OF "Dark Star" WITH LENGTH > 15min;`},
+		{"SongsByTimesPlayed", `SONGS ORDER BY TIMES_PLAYED DESC LIMIT 20;`},
+		{"AsSetlist", `SHOWS FROM 1977 LIMIT 20 AS SETLIST;`},
+		{"Complete", `COUNT SHOWS WHERE COMPLETE;`},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			for b.Loop() {
+				if _, err := ex.Execute(ctx, bc.query); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkResolveSegue plans (without running) a long segue, comparing
+// per-query song resolution with a CachingResolver kept across queries.
+func BenchmarkResolveSegue(b *testing.B) {
+	db, err := sqlite.Open(fixtures.CreateLargeTestDB(b, benchShows))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer db.Close()
+	const query = `SHOWS WHERE "Help on the Way" > "Slipknot!" > "Franklin's Tower" AND PLAYED "Dark Star" AND PLAYED "St. Stephen" AND PLAYED "The Eleven";`
+	ctx := context.Background()
+
+	for _, bc := range []struct {
+		name string
+		ex   Executor
+	}{
+		{"Uncached", New(db)},
+		{"Cached", NewWithOptions(db, Options{Resolver: resolver.NewCachingResolver(resolver.NewDataSourceResolver(db))})},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			for b.Loop() {
+				if _, err := bc.ex.Compile(ctx, query); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gdql/gdql/internal/data"
+	"github.com/gdql/gdql/internal/ir"
+	"github.com/gdql/gdql/internal/planner/sqlgen"
+)
+
+// diagnoseEmptySegue explains why a segue search found no shows, when the
+// reason holds across the whole database: a song in the chain was never
+// played, or two neighbours in it never followed each other that way. It
+// returns "" when every step happened somewhere (other filters excluded them)
+// or when a probe fails; the hint is a courtesy, never an error.
+func diagnoseEmptySegue(ctx context.Context, ds data.DataSource, chain *ir.SegueChainIR) string {
+	if len(chain.SongIDs) < 2 {
+		return ""
+	}
+	placeholders := make([]string, len(chain.SongIDs))
+	args := make([]any, len(chain.SongIDs))
+	for i, id := range chain.SongIDs {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+	in := strings.Join(placeholders, ",")
+	rs, err := ds.ExecuteQuery(ctx, "SELECT id, name FROM songs WHERE id IN ("+in+")", args...)
+	if err != nil {
+		return ""
+	}
+	names := make(map[int]string, len(rs.Rows))
+	for _, row := range rs.Rows {
+		names[row.Int(0)] = fmt.Sprintf("%q", row.Text(1))
+	}
+	rs, err = ds.ExecuteQuery(ctx, "SELECT DISTINCT song_id FROM performances WHERE song_id IN ("+in+")", args...)
+	if err != nil {
+		return ""
+	}
+	played := make(map[int]bool, len(rs.Rows))
+	for _, row := range rs.Rows {
+		played[row.Int(0)] = true
+	}
+	for _, id := range chain.SongIDs {
+		if !played[id] {
+			return names[id] + " was never played, so no show has this segue."
+		}
+	}
+
+	for i := 0; i+1 < len(chain.SongIDs); i++ {
+		op := ir.SegueOpSegue
+		if i < len(chain.Operators) {
+			op = chain.Operators[i]
+		}
+		from, to := chain.SongIDs[i], chain.SongIDs[i+1]
+		sq := sqlgen.SegueStepSQL(from, to, op)
+		rs, err := ds.ExecuteQuery(ctx, sq.SQL, sq.Args...)
+		if err != nil {
+			return ""
+		}
+		if len(rs.Rows) == 0 {
+			return fmt.Sprintf("%s and %s were both played, but never %s %s %s.",
+				names[from], names[to], names[from], segueOpSymbol(op), names[to])
+		}
+	}
+	return ""
+}
+
+// segueOpSymbol is the query syntax for op.
+func segueOpSymbol(op ir.SegueOp) string {
+	switch op {
+	case ir.SegueOpBreak:
+		return ">>"
+	case ir.SegueOpTease:
+		return "~>"
+	}
+	return ">"
+}
@@ -2,14 +2,16 @@ package executor
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"strconv"
+	"regexp"
 	"strings"
 	"time"
 	"unicode"
 
 	"github.com/gdql/gdql/internal/ast"
 	"github.com/gdql/gdql/internal/data"
+	"github.com/gdql/gdql/internal/errors"
 	"github.com/gdql/gdql/internal/ir"
 	"github.com/gdql/gdql/internal/parser"
 	"github.com/gdql/gdql/internal/planner"
@@ -27,65 +29,202 @@ const (
 	ResultPerformances
 	ResultSetlist
 	ResultCount
+	ResultVenues
+	ResultRuns
+	ResultVenueStats
+	ResultCompare
+	ResultCoverage
+	ResultYears
+	ResultGaps
 )
 
 // CountResult is the result of a COUNT query.
+// SongName holds the song for COUNT "Song", or "shows"/"venues" for COUNT SHOWS/VENUES.
 type CountResult struct {
-	SongName string
-	Count    int
+	SongName string `json:"name"`
+	Count    int    `json:"count"`
+}
+
+// RunResult is one stretch of consecutive nights at a venue (RUNS query).
+// Nights counts distinct dates; Shows also counts early and late shows.
+type RunResult struct {
+	VenueID int       `json:"venue_id"`
+	Venue   string    `json:"venue"`
+	City    string    `json:"city,omitempty"`
+	State   string    `json:"state,omitempty"`
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	Nights  int       `json:"nights"`
+	Shows   int       `json:"shows"`
+}
+
+// MarshalJSON writes Start and End as YYYY-MM-DD.
+func (r RunResult) MarshalJSON() ([]byte, error) {
+	type runOut struct {
+		VenueID int    `json:"venue_id"`
+		Venue   string `json:"venue"`
+		City    string `json:"city,omitempty"`
+		State   string `json:"state,omitempty"`
+		Start   string `json:"start"`
+		End     string `json:"end"`
+		Nights  int    `json:"nights"`
+		Shows   int    `json:"shows"`
+	}
+	return json.Marshal(runOut{
+		VenueID: r.VenueID, Venue: r.Venue, City: r.City, State: r.State,
+		Start: r.Start.Format("2006-01-02"), End: r.End.Format("2006-01-02"),
+		Nights: r.Nights, Shows: r.Shows,
+	})
+}
+
+// CoverageResult is one year of a COVERAGE report: the shows the database has
+// against the approximate number played.
+type CoverageResult struct {
+	Year     int     `json:"year"`
+	Have     int     `json:"have"`
+	Expected int     `json:"expected"`
+	Percent  float64 `json:"percent"`
+}
+
+// GapResult is one run of consecutive days with no show, from GAPS.
+type GapResult struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	Days  int       `json:"days"`
+}
+
+// MarshalJSON writes Start and End as YYYY-MM-DD.
+func (g GapResult) MarshalJSON() ([]byte, error) {
+	type gapOut struct {
+		Start string `json:"start"`
+		End   string `json:"end"`
+		Days  int    `json:"days"`
+	}
+	return json.Marshal(gapOut{Start: g.Start.Format("2006-01-02"), End: g.End.Format("2006-01-02"), Days: g.Days})
+}
+
+// YearCount is one year of PERFORMANCES OF ... BY YEAR.
+type YearCount struct {
+	Year  int `json:"year"`
+	Count int `json:"count"`
 }
 
 // Result is the output of executing a query.
 type Result struct {
 	Type         ResultType
 	Shows        []*data.Show
-	Songs        []*data.Song
+	Songs        []*data.Song // also COMPARE, one per song in query order
 	Performances []*data.Performance
 	Setlist      *SetlistResult
-	Setlists     []*SetlistResult // AS SETLIST on SHOWS queries
+	Setlists     []*SetlistResult // AS SETLIST / AS CLASSIC on SHOWS queries
 	Count        *CountResult
+	Venues       []*data.Venue
+	Runs         []*RunResult
+	Coverage     []*CoverageResult
+	Years        []*YearCount // PERFORMANCES OF ... BY YEAR, first to last year played
+	Gaps         []*GapResult
 	OutputFmt    ir.OutputFormat
 	SQL          string
-	Duration     time.Duration
+	Args         []interface{}   // SQL bind arguments
+	Raw          *data.ResultSet // rows as the main query returned them, before mapping
+	Duration     time.Duration   // planning, SQL, and enrichment queries
+	Slow         bool            // Duration exceeded SlowQueryThreshold
+	Hint         string          // why an empty result is empty, when a follow-up probe can tell
+	SongMatches  []ir.SongMatch  // how the query's song names resolved (exact, alias, fuzzy, ...)
 }
 
+// SlowQueryThreshold is the execution time above which a Result is flagged
+// Slow. Callers surface the flag (the CLI prints a warning to stderr).
+var SlowQueryThreshold = time.Second
+
 // SetlistResult is the result of a SETLIST query.
 type SetlistResult struct {
-	Date         time.Time
-	ShowID       int
-	Venue        string `json:",omitempty"`
-	City         string `json:",omitempty"`
-	State        string `json:",omitempty"`
-	Performances []*data.Performance
+	Date         time.Time           `json:"date"`
+	ShowID       int                 `json:"show_id"`
+	Venue        string              `json:"venue,omitempty"`
+	City         string              `json:"city,omitempty"`
+	State        string              `json:"state,omitempty"`
+	SourceURL    string              `json:"source_url,omitempty"`
+	Performances []*data.Performance `json:"performances"`
+}
+
+// MarshalJSON renders Date as YYYY-MM-DD, matching data.Show.
+func (s SetlistResult) MarshalJSON() ([]byte, error) {
+	type setlistOut struct {
+		Date         string              `json:"date,omitempty"`
+		ShowID       int                 `json:"show_id"`
+		Venue        string              `json:"venue,omitempty"`
+		City         string              `json:"city,omitempty"`
+		State        string              `json:"state,omitempty"`
+		SourceURL    string              `json:"source_url,omitempty"`
+		Performances []*data.Performance `json:"performances"`
+	}
+	out := setlistOut{ShowID: s.ShowID, Venue: s.Venue, City: s.City, State: s.State, SourceURL: s.SourceURL, Performances: s.Performances}
+	if !s.Date.IsZero() {
+		out.Date = s.Date.Format("2006-01-02")
+	}
+	return json.Marshal(out)
 }
 
 // Executor runs a GDQL query end-to-end.
 type Executor interface {
 	Execute(ctx context.Context, query string) (*Result, error)
 	ExecuteAST(ctx context.Context, q ast.Query) (*Result, error)
+	Compile(ctx context.Context, query string) ([]*sqlgen.SQLQuery, error)
 }
 
 type executor struct {
 	planner    planner.Planner
 	sqlGen     sqlgen.SQLGenerator
 	dataSource data.DataSource
+	parseOpts  parser.Options
+	complete   bool
+}
+
+// Options tunes NewWithOptions.
+type Options struct {
+	// Strict resolves song names by exact (case-insensitive) name or alias
+	// only; see resolver.DataSourceResolver.Strict.
+	Strict bool
+	// Resolver, if set, resolves song names instead of a DataSourceResolver
+	// built from ds (Strict is then the resolver's business), e.g. a
+	// resolver.CachingResolver kept across the queries of a session.
+	Resolver resolver.SongResolver
+	// YearPivot sets the century of two-digit years; see
+	// parser.Options.YearPivot. Zero reads them all as 19xx.
+	YearPivot int
+	// ShowCompleteness fills data.Show.Complete on every SHOWS result. It
+	// costs a query per result, so it's otherwise only done for AS JSON.
+	ShowCompleteness bool
 }
 
 // New builds an Executor that uses the given DataSource for resolution and execution.
 func New(ds data.DataSource) Executor {
-	songResolver := resolver.NewDataSourceResolver(ds)
+	return NewWithOptions(ds, Options{})
+}
+
+// NewWithOptions is New with control over song resolution.
+func NewWithOptions(ds data.DataSource, opts Options) Executor {
+	songResolver := opts.Resolver
+	if songResolver == nil {
+		dsr := resolver.NewDataSourceResolver(ds)
+		dsr.Strict = opts.Strict
+		songResolver = dsr
+	}
 	dateExpander := expander.New()
 	pl := planner.New(songResolver, dateExpander)
 	return &executor{
 		planner:    pl,
 		sqlGen:     sqlgen.New(),
 		dataSource: ds,
+		parseOpts:  parser.Options{YearPivot: opts.YearPivot},
+		complete:   opts.ShowCompleteness,
 	}
 }
 
 // Execute parses the query string and runs it.
 func (e *executor) Execute(ctx context.Context, query string) (*Result, error) {
-	p := parser.NewFromString(query)
+	p := parser.NewFromStringWithOptions(query, e.parseOpts)
 	ast, err := p.Parse()
 	if err != nil {
 		return nil, err
@@ -93,13 +232,95 @@ func (e *executor) Execute(ctx context.Context, query string) (*Result, error) {
 	return e.ExecuteAST(ctx, ast)
 }
 
+// ExecuteAll runs every semicolon-separated statement in query on ex, in
+// order. All statements are parsed first, so syntax errors anywhere in a
+// script are reported together (as errors.ParseErrors) before anything runs.
+// If a statement fails to execute, the results of the ones before it are
+// returned along with the error.
+//
+// An ex with its own ExecuteAll method, such as one from New, runs the script
+// itself (with its parser options); any other is handed the statements one
+// at a time through ExecuteAST.
+func ExecuteAll(ctx context.Context, ex Executor, query string) ([]*Result, error) {
+	if sx, ok := ex.(interface {
+		ExecuteAll(ctx context.Context, query string) ([]*Result, error)
+	}); ok {
+		return sx.ExecuteAll(ctx, query)
+	}
+	qs, err := parser.ParseAll(query)
+	if err != nil {
+		return nil, err
+	}
+	return executeEach(ctx, ex, qs)
+}
+
+// ExecuteAll is the package-level ExecuteAll, parsed with e's options.
+func (e *executor) ExecuteAll(ctx context.Context, query string) ([]*Result, error) {
+	qs, err := parser.ParseAllWithOptions(query, e.parseOpts)
+	if err != nil {
+		return nil, err
+	}
+	return executeEach(ctx, e, qs)
+}
+
+func executeEach(ctx context.Context, ex Executor, qs []ast.Query) ([]*Result, error) {
+	results := make([]*Result, 0, len(qs))
+	for _, q := range qs {
+		r, err := ex.ExecuteAST(ctx, q)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+// Compile parses and plans every statement in query and returns the main SQL
+// each would run, without running it. Enrichment queries (weather, covers,
+// setlists) are not included.
+func (e *executor) Compile(ctx context.Context, query string) ([]*sqlgen.SQLQuery, error) {
+	qs, err := parser.ParseAllWithOptions(query, e.parseOpts)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*sqlgen.SQLQuery, 0, len(qs))
+	for _, q := range qs {
+		irQ, err := e.planner.Plan(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		sq, err := e.sqlGen.Generate(irQ)
+		if err != nil {
+			return nil, err
+		}
+		sq.SongMatches = irQ.SongMatches
+		out = append(out, sq)
+	}
+	return out, nil
+}
+
+// timeoutError reports err as ErrTimeout when ctx's deadline has passed: the
+// driver's own error for an interrupted query (or a song lookup cut short)
+// doesn't say why it stopped. Other errors are returned as-is.
+func timeoutError(ctx context.Context, err error) error {
+	if ctx.Err() != context.DeadlineExceeded {
+		return err
+	}
+	return &errors.QueryError{
+		Type:    errors.ErrTimeout,
+		Message: "stopped at the time limit",
+		Cause:   context.DeadlineExceeded,
+		Hint:    "Narrow the query (a date range, fewer segue steps) or raise the limit.",
+	}
+}
+
 // ExecuteAST plans, generates SQL, executes, and maps rows to Result.
 func (e *executor) ExecuteAST(ctx context.Context, q ast.Query) (*Result, error) {
 	start := time.Now()
 
 	irQ, err := e.planner.Plan(ctx, q)
 	if err != nil {
-		return nil, err
+		return nil, timeoutError(ctx, err)
 	}
 	sq, err := e.sqlGen.Generate(irQ)
 	if err != nil {
@@ -108,10 +329,25 @@ func (e *executor) ExecuteAST(ctx context.Context, q ast.Query) (*Result, error)
 
 	rs, err := e.dataSource.ExecuteQuery(ctx, sq.SQL, sq.Args...)
 	if err != nil {
+		if ctx.Err() != nil {
+			return nil, timeoutError(ctx, err)
+		}
+		// Read-only DBs imported without lyrics can't be migrated; explain instead of leaking SQL.
+		if strings.Contains(err.Error(), "no such table: lyrics") {
+			return nil, &errors.QueryError{
+				Type:    errors.ErrNoLyrics,
+				Message: "no lyrics data imported",
+				Cause:   err,
+				Hint:    "LYRICS(...) needs lyrics data. Import it with: gdql-import lyrics <file.json>",
+			}
+		}
+		if qe := missingColumnError(err); qe != nil {
+			return nil, qe
+		}
 		return nil, err
 	}
 
-	out := &Result{SQL: sq.SQL, Duration: time.Since(start), OutputFmt: irQ.OutputFmt}
+	out := &Result{SQL: sq.SQL, Args: sq.Args, Raw: rs, OutputFmt: irQ.OutputFmt, SongMatches: irQ.SongMatches}
 	switch irQ.Type {
 	case ir.QueryTypeShows:
 		out.Type = ResultShows
@@ -120,9 +356,15 @@ func (e *executor) ExecuteAST(ctx context.Context, q ast.Query) (*Result, error)
 			// Non-fatal enrichment: attach coords/weather/recordings when
 			// those extension tables exist. Silently no-ops on older DBs.
 			_ = attachShowEnrichments(ctx, e.dataSource, out.Shows)
+			if e.complete || irQ.OutputFmt == ir.OutputJSON {
+				_ = attachShowCompleteness(ctx, e.dataSource, out.Shows)
+			}
 		}
-		// AS SETLIST: expand each show into its full setlist
-		if err == nil && irQ.OutputFmt == ir.OutputSetlist && len(out.Shows) > 0 {
+		if err == nil && len(out.Shows) == 0 && irQ.SegueChain != nil && e.dataSource != nil {
+			out.Hint = diagnoseEmptySegue(ctx, e.dataSource, irQ.SegueChain)
+		}
+		// AS SETLIST / AS CLASSIC: expand each show into its full setlist
+		if err == nil && (irQ.OutputFmt == ir.OutputSetlist || irQ.OutputFmt == ir.OutputClassic) && len(out.Shows) > 0 {
 			var setlists []*SetlistResult
 			maxExpand := 20
 			if len(out.Shows) < maxExpand {
@@ -165,6 +407,18 @@ func (e *executor) ExecuteAST(ctx context.Context, q ast.Query) (*Result, error)
 		out.Performances, err = mapRowsToPerformances(rs)
 	case ir.QueryTypeSetlist:
 		out.Type = ResultSetlist
+		if irQ.SingleDate == nil && irQ.DateRange != nil {
+			// SETLIST FOR a month or year: one setlist per show, in date order
+			out.Setlists = mapRowsToSetlists(rs)
+			if len(out.Setlists) > 0 && e.dataSource != nil {
+				_ = attachSetlistVenues(ctx, e.dataSource, out.Setlists, irQ.DateRange)
+			}
+			break
+		}
+		if irQ.SingleDate == nil && irQ.VenueName != "" {
+			out.Setlist, err = e.venueSetlist(ctx, irQ.VenueName, mapRowsToSetlists(rs))
+			break
+		}
 		out.Setlist, err = mapRowsToSetlist(rs, irQ.SingleDate)
 		// Populate venue context so the sandbox setlist header can show
 		// "5/8/77 · Barton Hall, Ithaca NY" instead of bare date.
@@ -173,9 +427,9 @@ func (e *executor) ExecuteAST(ctx context.Context, q ast.Query) (*Result, error)
 				"SELECT v.name, v.city, v.state FROM shows s LEFT JOIN venues v ON s.venue_id = v.id WHERE s.id = ?",
 				out.Setlist.ShowID); verr == nil && len(vrs.Rows) > 0 {
 				row := vrs.Rows[0]
-				out.Setlist.Venue = strVal(row[0])
-				out.Setlist.City = strVal(row[1])
-				out.Setlist.State = strVal(row[2])
+				out.Setlist.Venue = row.Text(0)
+				out.Setlist.City = row.Text(1)
+				out.Setlist.State = row.Text(2)
 			}
 		}
 	case ir.QueryTypeCount:
@@ -192,21 +446,82 @@ func (e *executor) ExecuteAST(ctx context.Context, q ast.Query) (*Result, error)
 			var dateStr string
 			if e.dataSource != nil {
 				if drs, derr := e.dataSource.ExecuteQuery(ctx, "SELECT date FROM shows WHERE id = ?", out.Setlist.ShowID); derr == nil && len(drs.Rows) > 0 {
-					dateStr = strVal(drs.Rows[0][0])
+					dateStr = drs.Rows[0].Text(0)
 					t, _ := time.Parse("2006-01-02", dateStr)
 					out.Setlist.Date = t
 				}
 			}
 		}
+	case ir.QueryTypeVenues:
+		out.Type = ResultVenues
+		out.Venues, err = mapRowsToVenues(rs)
+	case ir.QueryTypeVenueStats:
+		out.Type = ResultVenueStats
+		out.Venues, err = mapRowsToVenues(rs)
+	case ir.QueryTypeCompare:
+		out.Type = ResultCompare
+		out.Songs, err = mapRowsToSongs(rs)
+	case ir.QueryTypeRuns:
+		out.Type = ResultRuns
+		out.Runs = mapRowsToRuns(rs)
+	case ir.QueryTypeCoverage:
+		out.Type = ResultCoverage
+		out.Coverage = mapRowsToCoverage(rs)
+	case ir.QueryTypePerformanceYears:
+		out.Type = ResultYears
+		out.Years = mapRowsToYears(rs)
+	case ir.QueryTypeGaps:
+		out.Type = ResultGaps
+		out.Gaps = mapRowsToGaps(rs)
 	default:
 		return nil, fmt.Errorf("unknown query type %d", irQ.Type)
 	}
 	if err != nil {
 		return nil, err
 	}
+	if e.dataSource != nil {
+		// Non-fatal: databases from before set names and source links were
+		// imported lack the columns.
+		_ = attachSetNames(ctx, e.dataSource, out.allSetlists())
+		_ = attachSetlistSources(ctx, e.dataSource, out.allSetlists())
+		_ = attachShortNames(ctx, e.dataSource, out.allPerformances())
+	}
+	out.Duration = time.Since(start)
+	out.Slow = out.Duration > SlowQueryThreshold
 	return out, nil
 }
 
+// mapRowsToShows reads the columns sqlgen's showColumns lists, then a segue
+// query's match_set and match_position when present.
+// addedColumns names the filter that needs each performances column added
+// after the first release. A read-only database that predates one can't be
+// migrated, so SQLite reports e.g. "no such column: p.tape".
+var addedColumns = map[string]string{
+	"tape":     "WHERE TAPE",
+	"set_name": "SET NAMED",
+}
+
+var noSuchColumn = regexp.MustCompile(`no such column: (?:\w+\.)?(\w+)`)
+
+// missingColumnError explains a query that failed on one of addedColumns, or
+// returns nil for any other error.
+func missingColumnError(err error) *errors.QueryError {
+	m := noSuchColumn.FindStringSubmatch(err.Error())
+	if m == nil {
+		return nil
+	}
+	filter, ok := addedColumns[m[1]]
+	if !ok {
+		return nil
+	}
+	return &errors.QueryError{
+		Type:    errors.ErrSchemaOutdated,
+		Message: fmt.Sprintf("%s needs performances.%s, which this database predates", filter, m[1]),
+		Cause:   err,
+		Hint:    "Queries don't migrate the database. Upgrade it with: gdql -db <path> upgrade",
+	}
+}
+
 func mapRowsToShows(rs *data.ResultSet) ([]*data.Show, error) {
 	out := make([]*data.Show, 0, len(rs.Rows))
 	for _, row := range rs.Rows {
@@ -214,14 +529,21 @@ func mapRowsToShows(rs *data.ResultSet) ([]*data.Show, error) {
 			continue
 		}
 		sh := &data.Show{
-			ID:      intVal(row[0]),
-			VenueID: intVal(row[2]),
-			Venue:   strVal(row[3]),
-			City:    strVal(row[4]),
-			State:   strVal(row[5]),
-			Tour:    strVal(row[6]),
-		}
-		sh.Date = timeVal(row[1])
+			ID:      row.Int(0),
+			VenueID: row.Int(2),
+			Venue:   row.Text(3),
+			City:    row.Text(4),
+			State:   row.Text(5),
+			Tour:    row.Text(6),
+		}
+		sh.Date = row.Time(1)
+		if len(row) >= 8 {
+			sh.LengthSeconds = row.Int(7)
+		}
+		if len(row) >= 10 {
+			sh.MatchSet = row.Int(8)
+			sh.MatchPosition = row.Int(9)
+		}
 		// If state is empty but city contains "City, ST" or "City, ST, Country", extract state
 		if sh.State == "" && sh.City != "" {
 			sh.City, sh.State = splitCityState(sh.City)
@@ -248,9 +570,10 @@ func splitCityState(s string) (city, state string) {
 	return city, state
 }
 
-// attachShowEnrichments fills in coords/weather/recordings on a batch of
-// shows via three lookups (venue_coords, show_weather, show_recordings).
-// Any table missing (older DB) causes the lookup to quietly no-op.
+// attachShowEnrichments fills in coords/weather/recordings/ratings on a batch
+// of shows via four lookups (venue_coords, show_weather, show_recordings,
+// show_ratings), plus the source link. Any table or column missing (older DB)
+// causes the lookup to quietly no-op.
 func attachShowEnrichments(ctx context.Context, ds data.DataSource, shows []*data.Show) error {
 	if len(shows) == 0 {
 		return nil
@@ -284,12 +607,12 @@ func attachShowEnrichments(ctx context.Context, ds data.DataSource, shows []*dat
 				if len(row) < 3 {
 					continue
 				}
-				vid := intVal(row[0])
+				vid := row.Int(0)
 				// A single venue_id can map to multiple shows; apply the
 				// same coords to every show with this venue.
 				for _, s := range shows {
 					if s.VenueID == vid {
-						s.Coords = &data.Coords{Lat: floatVal(row[1]), Lon: floatVal(row[2])}
+						s.Coords = &data.Coords{Lat: row.Float(1), Lon: row.Float(2)}
 					}
 				}
 			}
@@ -304,17 +627,17 @@ func attachShowEnrichments(ctx context.Context, ds data.DataSource, shows []*dat
 			if len(row) < 6 {
 				continue
 			}
-			sid := intVal(row[0])
+			sid := row.Int(0)
 			s, ok := byID[sid]
 			if !ok {
 				continue
 			}
 			s.Weather = &data.Weather{
-				HighC:       nullableFloat(row[1]),
-				LowC:        nullableFloat(row[2]),
-				PrecipMM:    nullableFloat(row[3]),
-				WindKPH:     nullableFloat(row[4]),
-				WeatherCode: nullableInt(row[5]),
+				HighC:       row.NullFloat(1),
+				LowC:        row.NullFloat(2),
+				PrecipMM:    row.NullFloat(3),
+				WindKPH:     row.NullFloat(4),
+				WeatherCode: row.NullInt(5),
 			}
 		}
 	}
@@ -327,37 +650,70 @@ func attachShowEnrichments(ctx context.Context, ds data.DataSource, shows []*dat
 			if len(row) < 6 {
 				continue
 			}
-			sid := intVal(row[0])
+			sid := row.Int(0)
 			s, ok := byID[sid]
 			if !ok {
 				continue
 			}
 			s.Recordings = append(s.Recordings, data.Recording{
-				ID:        strVal(row[1]),
-				Source:    strVal(row[2]),
-				Downloads: nullableInt(row[3]),
-				Rating:    nullableFloat(row[4]),
-				Title:     strVal(row[5]),
+				ID:        row.Text(1),
+				Source:    row.Text(2),
+				Downloads: row.NullInt(3),
+				Rating:    row.NullFloat(4),
+				Title:     row.Text(5),
 			})
 		}
 	}
-	return nil
-}
 
-func nullableFloat(v any) *float64 {
-	if v == nil {
-		return nil
+	// show_ratings — combined across sources, as stored in shows.rating
+	if rs, err := ds.ExecuteQuery(ctx,
+		"SELECT r.show_id, sum(r.rating * coalesce(r.votes, 1)) / sum(coalesce(r.votes, 1)), sum(coalesce(r.votes, 1)) FROM show_ratings r WHERE r.show_id IN ("+in+") GROUP BY r.show_id",
+		args...); err == nil {
+		for _, row := range rs.Rows {
+			if s, ok := byID[row.Int(0)]; ok {
+				s.Rating = row.NullFloat(1)
+				s.RatingCount = row.Int(2)
+			}
+		}
+	}
+
+	// shows.source_url — absent from databases built before it was imported
+	if rs, err := ds.ExecuteQuery(ctx,
+		"SELECT id, source_url FROM shows WHERE id IN ("+in+") AND source_url IS NOT NULL AND source_url != ''",
+		args...); err == nil {
+		for _, row := range rs.Rows {
+			if s, ok := byID[row.Int(0)]; ok {
+				s.SourceURL = row.Text(1)
+			}
+		}
 	}
-	f := floatVal(v)
-	return &f
+	return nil
 }
 
-func nullableInt(v any) *int {
-	if v == nil {
-		return nil
+// attachShowCompleteness sets Complete on each show using the same heuristic
+// as SHOWS WHERE COMPLETE. Shows are left nil if the lookup fails.
+func attachShowCompleteness(ctx context.Context, ds data.DataSource, shows []*data.Show) error {
+	placeholders := make([]string, 0, len(shows))
+	args := make([]any, 0, len(shows))
+	for _, s := range shows {
+		placeholders = append(placeholders, "?")
+		args = append(args, s.ID)
+	}
+	rs, err := ds.ExecuteQuery(ctx,
+		"SELECT s.id FROM shows s WHERE s.id IN ("+strings.Join(placeholders, ",")+") AND "+sqlgen.ShowComplete,
+		args...)
+	if err != nil {
+		return err
+	}
+	complete := make(map[int]bool, len(rs.Rows))
+	for _, row := range rs.Rows {
+		complete[row.Int(0)] = true
+	}
+	for _, s := range shows {
+		c := complete[s.ID]
+		s.Complete = &c
 	}
-	n := intVal(v)
-	return &n
+	return nil
 }
 
 // attachSongRelations enriches songs with entries from the song_relations
@@ -392,11 +748,11 @@ func attachSongRelations(ctx context.Context, ds data.DataSource, songs []*data.
 		if len(row) < 5 {
 			continue
 		}
-		fromID := intVal(row[0])
-		toID := intVal(row[1])
-		kind := strVal(row[2])
-		fromName := strVal(row[3])
-		toName := strVal(row[4])
+		fromID := row.Int(0)
+		toID := row.Int(1)
+		kind := row.Text(2)
+		fromName := row.Text(3)
+		toName := row.Text(4)
 		if s, ok := byID[fromID]; ok {
 			s.Related = append(s.Related, data.SongRelation{Kind: kind, Name: toName, Direction: "to"})
 		}
@@ -414,14 +770,17 @@ func mapRowsToSongs(rs *data.ResultSet) ([]*data.Song, error) {
 			continue
 		}
 		s := &data.Song{
-			ID:          intVal(row[0]),
-			Name:        normalizeSongName(strVal(row[1])),
-			ShortName:   strVal(row[2]),
-			Writers:     strVal(row[3]),
-			TimesPlayed: intVal(row[6]),
-		}
-		s.FirstPlayed = timeVal(row[4])
-		s.LastPlayed = timeVal(row[5])
+			ID:          row.Int(0),
+			Name:        normalizeSongName(row.Text(1)),
+			ShortName:   row.Text(2),
+			Writers:     row.Text(3),
+			TimesPlayed: row.Int(6),
+		}
+		if len(row) >= 8 {
+			s.AvgLength = row.Int(7) // ORDER BY AVG_LENGTH
+		}
+		s.FirstPlayed = row.Time(4)
+		s.LastPlayed = row.Time(5)
 		out = append(out, s)
 	}
 	return out, nil
@@ -434,26 +793,32 @@ func mapRowsToPerformances(rs *data.ResultSet) ([]*data.Performance, error) {
 			continue
 		}
 		perf := &data.Performance{
-			ID:            intVal(row[0]),
-			ShowID:        intVal(row[1]),
-			SongID:        intVal(row[2]),
-			SetNumber:     intVal(row[3]),
-			Position:      intVal(row[4]),
-			SegueType:     strVal(row[5]),
-			LengthSeconds: intVal(row[6]),
+			ID:            row.Int(0),
+			ShowID:        row.Int(1),
+			SongID:        row.Int(2),
+			SetNumber:     row.Int(3),
+			Position:      row.Int(4),
+			SegueType:     row.Text(5),
+			LengthSeconds: row.Int(6),
 		}
 		if len(row) >= 8 {
-			perf.SongName = strVal(row[7])
+			perf.SongName = row.Text(7)
 		}
 		if len(row) >= 9 {
-			d := strVal(row[8])
+			d := row.Text(8)
 			if len(d) >= 10 {
 				d = d[:10]
 			}
 			perf.Date = d
 		}
 		if len(row) >= 10 {
-			perf.Venue = strVal(row[9])
+			perf.Venue = row.Text(9)
+		}
+		if len(row) >= 11 {
+			perf.Nth = row.Int(10)
+		}
+		if len(row) >= 14 {
+			perf.PrevSong, perf.PrevSegue, perf.NextSong = row.Text(11), row.Text(12), row.Text(13)
 		}
 		out = append(out, perf)
 	}
@@ -476,59 +841,285 @@ func mapRowsToSetlist(rs *data.ResultSet, singleDate *time.Time) (*SetlistResult
 	}, nil
 }
 
-func mapRowsToCount(rs *data.ResultSet) *CountResult {
-	if len(rs.Rows) == 0 {
-		return &CountResult{}
+// mapRowsToSetlists splits performance rows (ordered by date and show, with
+// the show date as the ninth column) into one SetlistResult per show.
+func mapRowsToSetlists(rs *data.ResultSet) []*SetlistResult {
+	perfs, _ := mapRowsToPerformances(rs)
+	var out []*SetlistResult
+	for _, p := range perfs {
+		if len(out) == 0 || out[len(out)-1].ShowID != p.ShowID {
+			date, _ := time.Parse("2006-01-02", p.Date)
+			out = append(out, &SetlistResult{Date: date, ShowID: p.ShowID})
+		}
+		p.Date = "" // carried by the setlist
+		sl := out[len(out)-1]
+		sl.Performances = append(sl.Performances, p)
 	}
-	row := rs.Rows[0]
-	cr := &CountResult{Count: intVal(row[0])}
-	if len(row) >= 2 {
-		cr.SongName = strVal(row[1])
+	return out
+}
+
+// allSetlists returns every setlist in the result: the single SETLIST FOR
+// one, or the per-show ones from a period or AS SETLIST.
+func (r *Result) allSetlists() []*SetlistResult {
+	if r.Setlist != nil {
+		return append([]*SetlistResult{r.Setlist}, r.Setlists...)
 	}
-	return cr
+	return r.Setlists
+}
+
+// allPerformances returns the performances listed in the result and those in
+// its setlists.
+func (r *Result) allPerformances() []*data.Performance {
+	perfs := r.Performances
+	for _, sl := range r.allSetlists() {
+		perfs = append(perfs[:len(perfs):len(perfs)], sl.Performances...)
+	}
+	return perfs
+}
+
+// attachShortNames fills Performance.SongShortName from songs.short_name, with
+// one query for all the songs. Songs without a short name are left blank.
+func attachShortNames(ctx context.Context, ds data.DataSource, perfs []*data.Performance) error {
+	bySong := make(map[int][]*data.Performance)
+	placeholders := make([]string, 0, len(perfs))
+	args := make([]any, 0, len(perfs))
+	for _, p := range perfs {
+		if _, seen := bySong[p.SongID]; !seen {
+			placeholders = append(placeholders, "?")
+			args = append(args, p.SongID)
+		}
+		bySong[p.SongID] = append(bySong[p.SongID], p)
+	}
+	if len(placeholders) == 0 {
+		return nil
+	}
+	rs, err := ds.ExecuteQuery(ctx,
+		"SELECT id, short_name FROM songs WHERE id IN ("+strings.Join(placeholders, ",")+") AND short_name IS NOT NULL AND short_name != ''",
+		args...)
+	if err != nil {
+		return err
+	}
+	for _, row := range rs.Rows {
+		for _, p := range bySong[row.Int(0)] {
+			p.SongShortName = row.Text(1)
+		}
+	}
+	return nil
+}
+
+// attachSetNames fills Performance.SetName on setlists from
+// performances.set_name, with one query for all their shows.
+func attachSetNames(ctx context.Context, ds data.DataSource, setlists []*SetlistResult) error {
+	byID := make(map[int]*data.Performance)
+	placeholders := make([]string, 0, len(setlists))
+	args := make([]any, 0, len(setlists))
+	for _, sl := range setlists {
+		if len(sl.Performances) == 0 {
+			continue
+		}
+		for _, p := range sl.Performances {
+			byID[p.ID] = p
+		}
+		placeholders = append(placeholders, "?")
+		args = append(args, sl.ShowID)
+	}
+	if len(placeholders) == 0 {
+		return nil
+	}
+	rs, err := ds.ExecuteQuery(ctx,
+		"SELECT id, set_name FROM performances WHERE show_id IN ("+strings.Join(placeholders, ",")+") AND set_name IS NOT NULL AND set_name != ''",
+		args...)
+	if err != nil {
+		return err
+	}
+	for _, row := range rs.Rows {
+		if p := byID[row.Int(0)]; p != nil {
+			p.SetName = row.Text(1)
+		}
+	}
+	return nil
+}
+
+// attachSetlistSources fills SourceURL on setlists from shows.source_url,
+// with one query for all their shows.
+func attachSetlistSources(ctx context.Context, ds data.DataSource, setlists []*SetlistResult) error {
+	byShow := make(map[int]*SetlistResult, len(setlists))
+	placeholders := make([]string, 0, len(setlists))
+	args := make([]any, 0, len(setlists))
+	for _, sl := range setlists {
+		if sl.ShowID == 0 {
+			continue
+		}
+		byShow[sl.ShowID] = sl
+		placeholders = append(placeholders, "?")
+		args = append(args, sl.ShowID)
+	}
+	if len(placeholders) == 0 {
+		return nil
+	}
+	rs, err := ds.ExecuteQuery(ctx,
+		"SELECT id, source_url FROM shows WHERE id IN ("+strings.Join(placeholders, ",")+") AND source_url IS NOT NULL AND source_url != ''",
+		args...)
+	if err != nil {
+		return err
+	}
+	for _, row := range rs.Rows {
+		if sl := byShow[row.Int(0)]; sl != nil {
+			sl.SourceURL = row.Text(1)
+		}
+	}
+	return nil
 }
 
-func intVal(v interface{}) int {
-	switch x := v.(type) {
-	case int:
-		return x
-	case int64:
-		return int(x)
-	case float64:
-		return int(x)
-	case string:
-		if n, err := strconv.Atoi(x); err == nil {
-			return n
+// venueSetlist picks the SETLIST FOR "Venue" answer from the latest show at
+// each matching venue (newest first): it must be exactly one, otherwise the
+// error lists what matched so the user can pick by date.
+func (e *executor) venueSetlist(ctx context.Context, venue string, setlists []*SetlistResult) (*SetlistResult, error) {
+	if len(setlists) == 0 {
+		return nil, &errors.QueryError{
+			Type:    errors.ErrVenueNotFound,
+			Message: fmt.Sprintf("no setlists at a venue matching %q", venue),
+			Hint:    `SETLIST FOR "..." matches a venue name or city, e.g. SETLIST FOR "Winterland";`,
 		}
 	}
-	return 0
+	if e.dataSource != nil {
+		span := &ir.ResolvedDateRange{Start: setlists[len(setlists)-1].Date, End: setlists[0].Date}
+		_ = attachSetlistVenues(ctx, e.dataSource, setlists, span)
+	}
+	if len(setlists) == 1 {
+		return setlists[0], nil
+	}
+	matches := make([]string, len(setlists))
+	for i, sl := range setlists {
+		where := sl.Venue
+		if sl.City != "" {
+			where += ", " + sl.City
+		}
+		matches[i] = fmt.Sprintf("%s (last show %s)", where, sl.Date.Format("2006-01-02"))
+	}
+	return nil, &errors.QueryError{
+		Type:        errors.ErrAmbiguousVenue,
+		Message:     fmt.Sprintf("%q matches %d venues", venue, len(setlists)),
+		Suggestions: matches,
+		Hint:        "Name the venue more fully, or ask for a date: SETLIST FOR " + setlists[0].Date.Format("2006-01-02") + ";",
+	}
 }
 
-func floatVal(v interface{}) float64 {
-	switch x := v.(type) {
-	case float64:
-		return x
-	case int:
-		return float64(x)
-	case int64:
-		return float64(x)
+// attachSetlistVenues fills venue, city and state on setlists from one range
+// of dates, with a single query for the whole range.
+func attachSetlistVenues(ctx context.Context, ds data.DataSource, setlists []*SetlistResult, r *ir.ResolvedDateRange) error {
+	rs, err := ds.ExecuteQuery(ctx,
+		"SELECT s.id, v.name, v.city, v.state FROM shows s LEFT JOIN venues v ON s.venue_id = v.id WHERE s.date >= ? AND s.date <= ?",
+		r.Start.Format("2006-01-02"), r.End.Format("2006-01-02"))
+	if err != nil {
+		return err
 	}
-	return 0
+	byShow := make(map[int]*SetlistResult, len(setlists))
+	for _, sl := range setlists {
+		byShow[sl.ShowID] = sl
+	}
+	for _, row := range rs.Rows {
+		if sl := byShow[row.Int(0)]; sl != nil {
+			sl.Venue, sl.City, sl.State = row.Text(1), row.Text(2), row.Text(3)
+		}
+	}
+	return nil
 }
 
-func strVal(v interface{}) string {
-	if s, ok := v.(string); ok {
-		return s
+func mapRowsToVenues(rs *data.ResultSet) ([]*data.Venue, error) {
+	out := make([]*data.Venue, 0, len(rs.Rows))
+	for _, row := range rs.Rows {
+		if len(row) < 6 {
+			continue
+		}
+		out = append(out, &data.Venue{
+			ID:        row.Int(0),
+			Name:      row.Text(1),
+			City:      row.Text(2),
+			State:     row.Text(3),
+			Country:   row.Text(4),
+			ShowCount: row.Int(5),
+			FirstShow: row.Time(6),
+			LastShow:  row.Time(7),
+		})
 	}
-	return ""
+	return out, nil
 }
 
-func timeVal(v interface{}) time.Time {
-	if s, ok := v.(string); ok {
-		t, _ := time.Parse("2006-01-02", s)
-		return t
+func mapRowsToRuns(rs *data.ResultSet) []*RunResult {
+	out := make([]*RunResult, 0, len(rs.Rows))
+	for _, row := range rs.Rows {
+		if len(row) < 8 {
+			continue
+		}
+		out = append(out, &RunResult{
+			VenueID: row.Int(0),
+			Venue:   row.Text(1),
+			City:    row.Text(2),
+			State:   row.Text(3),
+			Start:   row.Time(4),
+			End:     row.Time(5),
+			Nights:  row.Int(6),
+			Shows:   row.Int(7),
+		})
 	}
-	return time.Time{}
+	return out
+}
+
+func mapRowsToCoverage(rs *data.ResultSet) []*CoverageResult {
+	out := make([]*CoverageResult, 0, len(rs.Rows))
+	for _, row := range rs.Rows {
+		if len(row) < 4 {
+			continue
+		}
+		out = append(out, &CoverageResult{
+			Year:     row.Int(0),
+			Have:     row.Int(1),
+			Expected: row.Int(2),
+			Percent:  row.Float(3),
+		})
+	}
+	return out
+}
+
+func mapRowsToGaps(rs *data.ResultSet) []*GapResult {
+	out := make([]*GapResult, 0, len(rs.Rows))
+	for _, row := range rs.Rows {
+		if len(row) < 3 {
+			continue
+		}
+		out = append(out, &GapResult{Start: row.Time(0), End: row.Time(1), Days: row.Int(2)})
+	}
+	return out
+}
+
+// mapRowsToYears fills in the years between the first and last performance
+// that have none, so gaps (1975–1978 for Dark Star) show as zeros.
+func mapRowsToYears(rs *data.ResultSet) []*YearCount {
+	out := make([]*YearCount, 0, len(rs.Rows))
+	for _, row := range rs.Rows {
+		if len(row) < 2 {
+			continue
+		}
+		year := row.Int(0)
+		for len(out) > 0 && out[len(out)-1].Year+1 < year {
+			out = append(out, &YearCount{Year: out[len(out)-1].Year + 1})
+		}
+		out = append(out, &YearCount{Year: year, Count: row.Int(1)})
+	}
+	return out
+}
+
+func mapRowsToCount(rs *data.ResultSet) *CountResult {
+	if len(rs.Rows) == 0 {
+		return &CountResult{}
+	}
+	row := rs.Rows[0]
+	cr := &CountResult{Count: row.Int(0)}
+	if len(row) >= 2 {
+		cr.SongName = row.Text(1)
+	}
+	return cr
 }
 
 // normalizeSongName converts ALL CAPS song names to title case for display.
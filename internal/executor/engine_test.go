@@ -2,11 +2,15 @@ package executor
 
 import (
 	"context"
+	stderrors "errors"
 	"testing"
+	"time"
 
 	"github.com/gdql/gdql/internal/ast"
 	"github.com/gdql/gdql/internal/data"
 	"github.com/gdql/gdql/internal/data/mock"
+	"github.com/gdql/gdql/internal/errors"
+	"github.com/gdql/gdql/internal/ir"
 	"github.com/gdql/gdql/internal/parser"
 	"github.com/stretchr/testify/require"
 )
@@ -18,6 +22,34 @@ func TestExecutor_Execute_ParseError(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestExecutor_Compile(t *testing.T) {
+	ds := &mock.DataSource{}
+	ds.ExecuteQueryFunc = func(ctx context.Context, sql string, args ...interface{}) (*data.ResultSet, error) {
+		t.Fatalf("Compile ran SQL: %s", sql)
+		return nil, nil
+	}
+	ex := New(ds)
+	qs, err := ex.Compile(context.Background(), "SHOWS FROM 1977; COUNT SHOWS FROM 1978")
+	require.NoError(t, err)
+	require.Len(t, qs, 2)
+	require.Contains(t, qs[0].SQL, "FROM shows")
+	require.Contains(t, qs[1].SQL, "count(*)")
+
+	_, err = ex.Compile(context.Background(), "NOT A VALID QUERY")
+	require.Error(t, err)
+}
+
+func TestExecutor_YearPivot(t *testing.T) {
+	ds := &mock.DataSource{}
+	qs, err := NewWithOptions(ds, Options{YearPivot: 30}).Compile(context.Background(), "SHOWS FROM 05")
+	require.NoError(t, err)
+	require.Contains(t, qs[0].Args, "2005-01-01")
+
+	qs, err = New(ds).Compile(context.Background(), "SHOWS FROM 05")
+	require.NoError(t, err)
+	require.Contains(t, qs[0].Args, "1905-01-01")
+}
+
 func TestExecutor_ExecuteAST_ShowQuery_NoDBRows(t *testing.T) {
 	ds := &mock.DataSource{}
 	ds.ExecuteQueryFunc = func(ctx context.Context, sql string, args ...interface{}) (*data.ResultSet, error) {
@@ -58,6 +90,8 @@ func TestExecutor_ExecuteAST_ShowQuery_WithRows(t *testing.T) {
 	require.Len(t, result.Shows, 1)
 	require.Equal(t, 1, result.Shows[0].ID)
 	require.Equal(t, "Barton Hall", result.Shows[0].Venue)
+	require.Equal(t, []string{"id", "date", "venue_id", "venue", "city", "state", "notes", "rating"}, result.Raw.Columns)
+	require.Equal(t, data.Row{1, "1977-05-08", 1, "Barton Hall", "Ithaca", "NY", "", 4.9}, result.Raw.Rows[0])
 }
 
 // === New query types ===
@@ -102,6 +136,51 @@ func TestExecutor_FirstLastQuery(t *testing.T) {
 	require.Len(t, result.Shows, 1)
 }
 
+func TestExecutor_StrictRejectsNearMisses(t *testing.T) {
+	ds := &mock.DataSource{}
+	ds.ExecuteQueryFunc = func(ctx context.Context, sql string, args ...interface{}) (*data.ResultSet, error) {
+		return &data.ResultSet{
+			Columns: []string{"id", "date", "venue_id", "venue", "city", "state", "notes", "rating"},
+			Rows:    []data.Row{{1, "1967-11-14", 1, "American Studios", "North Hollywood", "CA", "", 0.0}},
+		}, nil
+	}
+	// Like sqlite's GetSong, the mock trims a trailing dash.
+	ds.GetSongFunc = func(ctx context.Context, name string) (*data.Song, error) {
+		return &data.Song{ID: 10, Name: "Dark Star"}, nil
+	}
+	q := &ast.FirstLastQuery{Song: &ast.SongRef{Name: "Dark Star-"}}
+
+	_, err := New(ds).ExecuteAST(context.Background(), q)
+	require.NoError(t, err, "lenient resolution accepts the trimmed match")
+
+	strict := NewWithOptions(ds, Options{Strict: true})
+	_, err = strict.ExecuteAST(context.Background(), q)
+	var qe *errors.QueryError
+	require.ErrorAs(t, err, &qe)
+	require.Equal(t, errors.ErrSongNotFound, qe.Type)
+
+	_, err = strict.ExecuteAST(context.Background(), &ast.FirstLastQuery{Song: &ast.SongRef{Name: "dark star"}})
+	require.NoError(t, err, "strict still ignores case")
+}
+
+func TestExecutor_SongMatches(t *testing.T) {
+	ds := &mock.DataSource{}
+	ds.ExecuteQueryFunc = func(ctx context.Context, sql string, args ...interface{}) (*data.ResultSet, error) {
+		return &data.ResultSet{}, nil
+	}
+	ds.GetSongFunc = func(ctx context.Context, name string) (*data.Song, error) {
+		return &data.Song{ID: 10, Name: "Dark Star", MatchedBy: data.MatchTrimmed}, nil
+	}
+	r, err := New(ds).ExecuteAST(context.Background(), &ast.FirstLastQuery{Song: &ast.SongRef{Name: "Dark Star-"}})
+	require.NoError(t, err)
+	require.Equal(t, []ir.SongMatch{{Name: "Dark Star-", IDs: []int{10}, Song: "Dark Star", Stage: "trimmed"}}, r.SongMatches)
+
+	qs, err := New(ds).Compile(context.Background(), `FIRST "Dark Star-"`)
+	require.NoError(t, err)
+	require.Len(t, qs, 1)
+	require.Equal(t, r.SongMatches, qs[0].SongMatches)
+}
+
 func TestExecutor_SongsAsCount(t *testing.T) {
 	ds := &mock.DataSource{}
 	ds.ExecuteQueryFunc = func(ctx context.Context, sql string, args ...interface{}) (*data.ResultSet, error) {
@@ -127,42 +206,6 @@ func TestExecutor_SongsAsCount(t *testing.T) {
 
 // === Helper functions ===
 
-func TestIntVal(t *testing.T) {
-	require.Equal(t, 5, intVal(5))
-	require.Equal(t, 5, intVal(int64(5)))
-	require.Equal(t, 5, intVal(5.0))
-	require.Equal(t, 42, intVal("42"))
-	require.Equal(t, 0, intVal("not a number"))
-	require.Equal(t, 0, intVal(nil))
-	require.Equal(t, 0, intVal(struct{}{}))
-}
-
-func TestFloatVal(t *testing.T) {
-	require.Equal(t, 4.9, floatVal(4.9))
-	require.Equal(t, 5.0, floatVal(5))
-	require.Equal(t, 5.0, floatVal(int64(5)))
-	require.Equal(t, 0.0, floatVal(nil))
-}
-
-func TestStrVal(t *testing.T) {
-	require.Equal(t, "hello", strVal("hello"))
-	require.Equal(t, "", strVal(nil))
-	require.Equal(t, "", strVal(42))
-}
-
-func TestTimeVal(t *testing.T) {
-	tm := timeVal("1977-05-08")
-	require.Equal(t, 1977, tm.Year())
-	require.Equal(t, 5, int(tm.Month()))
-	require.Equal(t, 8, tm.Day())
-
-	zero := timeVal("not a date")
-	require.True(t, zero.IsZero())
-
-	zero = timeVal(nil)
-	require.True(t, zero.IsZero())
-}
-
 func TestMapRowsToCount(t *testing.T) {
 	rs := &data.ResultSet{
 		Columns: []string{"count", "name"},
@@ -199,3 +242,99 @@ func TestNormalizeSongName(t *testing.T) {
 		})
 	}
 }
+
+func TestExecutor_MissingLyricsTable_ReturnsHint(t *testing.T) {
+	ds := &mock.DataSource{}
+	ds.ExecuteQueryFunc = func(ctx context.Context, sql string, args ...interface{}) (*data.ResultSet, error) {
+		return nil, stderrors.New("sqlite3: SQL logic error: no such table: lyrics")
+	}
+	ex := New(ds)
+	_, err := ex.Execute(context.Background(), `SONGS WITH LYRICS("rose")`)
+	require.Error(t, err)
+	var qe *errors.QueryError
+	require.True(t, stderrors.As(err, &qe))
+	require.Equal(t, errors.ErrNoLyrics, qe.Type)
+	require.Contains(t, err.Error(), "no lyrics data imported")
+}
+
+func TestExecutor_ExecuteAll_ParseErrorsBeforeRunning(t *testing.T) {
+	ran := 0
+	ds := &mock.DataSource{}
+	ds.ExecuteQueryFunc = func(ctx context.Context, sql string, args ...interface{}) (*data.ResultSet, error) {
+		ran++
+		return &data.ResultSet{}, nil
+	}
+	ex := New(ds)
+	_, err := ExecuteAll(context.Background(), ex, "SHOWS FROM 1977; SHOWZ; COUNT SHOWS; SONGS ORDER;")
+	require.Error(t, err)
+	var errs errors.ParseErrors
+	require.True(t, stderrors.As(err, &errs))
+	require.Len(t, errs, 2)
+	require.Zero(t, ran, "nothing runs when any statement fails to parse")
+}
+
+func TestExecuteAll_OtherExecutor(t *testing.T) {
+	ran := 0
+	ds := &mock.DataSource{}
+	ds.ExecuteQueryFunc = func(ctx context.Context, sql string, args ...interface{}) (*data.ResultSet, error) {
+		ran++
+		return &data.ResultSet{}, nil
+	}
+	// Embedding the interface hides the concrete ExecuteAll method, as for
+	// an Executor implemented outside this package.
+	ex := struct{ Executor }{New(ds)}
+	results, err := ExecuteAll(context.Background(), ex, "COUNT SHOWS; COUNT VENUES;")
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	require.Equal(t, 2, ran)
+
+	_, err = ExecuteAll(context.Background(), ex, "COUNT SHOWS; SHOWZ;")
+	var errs errors.ParseErrors
+	require.True(t, stderrors.As(err, &errs))
+	require.Equal(t, 2, ran, "nothing runs when any statement fails to parse")
+}
+
+func TestExecutor_SlowQueryFlag(t *testing.T) {
+	ds := &mock.DataSource{}
+	ds.ExecuteQueryFunc = func(ctx context.Context, sql string, args ...interface{}) (*data.ResultSet, error) {
+		return &data.ResultSet{}, nil
+	}
+	ex := New(ds)
+
+	result, err := ex.Execute(context.Background(), "SHOWS FROM 1977")
+	require.NoError(t, err)
+	require.False(t, result.Slow)
+
+	defer func(old time.Duration) { SlowQueryThreshold = old }(SlowQueryThreshold)
+	SlowQueryThreshold = -1
+	result, err = ex.Execute(context.Background(), "SHOWS FROM 1977")
+	require.NoError(t, err)
+	require.True(t, result.Slow)
+}
+
+func TestExecutor_Timeout(t *testing.T) {
+	// A data source that only returns once the query is cancelled, like a
+	// runaway self-join interrupted by its deadline.
+	ds := &mock.DataSource{}
+	ds.ExecuteQueryFunc = func(ctx context.Context, sql string, args ...interface{}) (*data.ResultSet, error) {
+		<-ctx.Done()
+		return nil, stderrors.New("sqlite3: interrupted")
+	}
+	ex := New(ds)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := ex.Execute(ctx, "SHOWS FROM 1977")
+	require.Error(t, err)
+	require.True(t, stderrors.Is(err, context.DeadlineExceeded))
+	var qe *errors.QueryError
+	require.True(t, stderrors.As(err, &qe))
+	require.Equal(t, errors.ErrTimeout, qe.Type)
+	require.Contains(t, err.Error(), "query timed out")
+
+	// A cancelled (not timed-out) query keeps the driver's error.
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	_, err = ex.Execute(ctx, "SHOWS FROM 1977")
+	require.EqualError(t, err, "sqlite3: interrupted")
+}
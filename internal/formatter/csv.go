@@ -3,21 +3,29 @@ package formatter
 import (
 	"encoding/csv"
 	"fmt"
+	"io"
 	"strings"
+	"time"
 
 	"github.com/gdql/gdql/internal/executor"
 )
 
 func formatCSV(result *executor.Result) (string, error) {
 	var b strings.Builder
-	w := csv.NewWriter(&b)
+	err := writeCSV(&b, result)
+	return b.String(), err
+}
+
+// writeCSV streams the result to out as CSV, one record per row.
+func writeCSV(out io.Writer, result *executor.Result) error {
+	w := csv.NewWriter(out)
 	switch result.Type {
 	case executor.ResultShows:
-		w.Write([]string{"id", "date", "venue", "city", "state", "tour"})
+		w.Write([]string{"id", "date", "venue", "city", "state", "tour", "source_url"})
 		for _, s := range result.Shows {
 			w.Write([]string{
 				fmt.Sprint(s.ID), s.Date.Format("2006-01-02"),
-				s.Venue, s.City, s.State, s.Tour,
+				s.Venue, s.City, s.State, s.Tour, s.SourceURL,
 			})
 		}
 	case executor.ResultSongs:
@@ -26,15 +34,31 @@ func formatCSV(result *executor.Result) (string, error) {
 			w.Write([]string{fmt.Sprint(s.ID), s.Name, s.ShortName, s.Writers, fmt.Sprint(s.TimesPlayed)})
 		}
 	case executor.ResultPerformances:
-		w.Write([]string{"id", "show_id", "song_id", "set_number", "position", "segue_type", "length_seconds"})
+		header := []string{"id", "show_id", "song_id", "set_number", "position", "segue_type", "length_seconds"}
+		ctx := withContext(result.Performances)
+		if ctx {
+			header = append(header, "prev_song", "prev_segue", "next_song")
+		}
+		w.Write(header)
 		for _, p := range result.Performances {
-			w.Write([]string{
+			rec := []string{
 				fmt.Sprint(p.ID), fmt.Sprint(p.ShowID), fmt.Sprint(p.SongID),
 				fmt.Sprint(p.SetNumber), fmt.Sprint(p.Position), p.SegueType, fmt.Sprint(p.LengthSeconds),
-			})
+			}
+			if ctx {
+				rec = append(rec, p.PrevSong, p.PrevSegue, p.NextSong)
+			}
+			w.Write(rec)
 		}
 	case executor.ResultSetlist:
-		if result.Setlist != nil {
+		if result.Setlist == nil && len(result.Setlists) > 0 {
+			w.Write([]string{"show_id", "date", "set_number", "position", "segue_type", "length_seconds"})
+			for _, sl := range result.Setlists {
+				for _, p := range sl.Performances {
+					w.Write([]string{fmt.Sprint(sl.ShowID), sl.Date.Format("2006-01-02"), fmt.Sprint(p.SetNumber), fmt.Sprint(p.Position), p.SegueType, fmt.Sprint(p.LengthSeconds)})
+				}
+			}
+		} else if result.Setlist != nil {
 			w.Write([]string{"set_number", "position", "segue_type", "length_seconds"})
 			for _, p := range result.Setlist.Performances {
 				w.Write([]string{fmt.Sprint(p.SetNumber), fmt.Sprint(p.Position), p.SegueType, fmt.Sprint(p.LengthSeconds)})
@@ -45,7 +69,50 @@ func formatCSV(result *executor.Result) (string, error) {
 			w.Write([]string{"song", "count"})
 			w.Write([]string{result.Count.SongName, fmt.Sprint(result.Count.Count)})
 		}
+	case executor.ResultVenues:
+		w.Write([]string{"id", "name", "city", "state", "country", "shows"})
+		for _, v := range result.Venues {
+			w.Write([]string{fmt.Sprint(v.ID), v.Name, v.City, v.State, v.Country, fmt.Sprint(v.ShowCount)})
+		}
+	case executor.ResultVenueStats:
+		w.Write([]string{"id", "name", "city", "state", "country", "shows", "first_show", "last_show"})
+		for _, v := range result.Venues {
+			w.Write([]string{fmt.Sprint(v.ID), v.Name, v.City, v.State, v.Country, fmt.Sprint(v.ShowCount), v.FirstShow.Format("2006-01-02"), v.LastShow.Format("2006-01-02")})
+		}
+	case executor.ResultCompare:
+		w.Write([]string{"id", "name", "times_played", "first_played", "last_played", "avg_length_seconds"})
+		for _, s := range result.Songs {
+			w.Write([]string{fmt.Sprint(s.ID), s.Name, fmt.Sprint(s.TimesPlayed), dateCell(s.FirstPlayed), dateCell(s.LastPlayed), fmt.Sprint(s.AvgLength)})
+		}
+	case executor.ResultRuns:
+		w.Write([]string{"venue_id", "venue", "city", "state", "start", "end", "nights", "shows"})
+		for _, r := range result.Runs {
+			w.Write([]string{fmt.Sprint(r.VenueID), r.Venue, r.City, r.State, r.Start.Format("2006-01-02"), r.End.Format("2006-01-02"), fmt.Sprint(r.Nights), fmt.Sprint(r.Shows)})
+		}
+	case executor.ResultCoverage:
+		w.Write([]string{"year", "have", "expected", "percent"})
+		for _, y := range result.Coverage {
+			w.Write([]string{fmt.Sprint(y.Year), fmt.Sprint(y.Have), fmt.Sprint(y.Expected), fmt.Sprint(y.Percent)})
+		}
+	case executor.ResultYears:
+		w.Write([]string{"year", "count"})
+		for _, y := range result.Years {
+			w.Write([]string{fmt.Sprint(y.Year), fmt.Sprint(y.Count)})
+		}
+	case executor.ResultGaps:
+		w.Write([]string{"start", "end", "days"})
+		for _, g := range result.Gaps {
+			w.Write([]string{g.Start.Format("2006-01-02"), g.End.Format("2006-01-02"), fmt.Sprint(g.Days)})
+		}
 	}
 	w.Flush()
-	return b.String(), w.Error()
+	return w.Error()
+}
+
+// dateCell renders a date as YYYY-MM-DD, or an empty cell when unset.
+func dateCell(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02")
 }
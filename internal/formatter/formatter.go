@@ -2,7 +2,10 @@ package formatter
 
 import (
 	"fmt"
+	"io"
+	"strings"
 
+	"github.com/gdql/gdql/internal/data"
 	"github.com/gdql/gdql/internal/executor"
 	"github.com/gdql/gdql/internal/ir"
 )
@@ -17,35 +20,160 @@ const (
 	FormatTSV
 	FormatSetlist
 	FormatCalendar
+	FormatHTML     // HTML fragment (table or setlist)
+	FormatHTMLPage // complete, self-contained HTML document
+	FormatClassic  // compact setlist: one line per set, songs comma-separated
+	FormatRawJSON  // SQL and unmapped result rows, for debugging (gdql --raw-json)
+	FormatMarkdown // pipe table with the CSV columns
 )
 
-// Formatter renders a Result as a string.
+// formatNames are the names ParseFormat accepts, in the order errors list them.
+var formatNames = []struct {
+	name   string
+	format OutputFormat
+}{
+	{"table", FormatTable},
+	{"json", FormatJSON},
+	{"csv", FormatCSV},
+	{"tsv", FormatTSV},
+	{"setlist", FormatSetlist},
+	{"markdown", FormatMarkdown},
+	{"classic", FormatClassic},
+	{"html", FormatHTML},
+	{"html-full", FormatHTMLPage},
+}
+
+// ParseFormat maps a format name such as "csv" (case-insensitive) to an
+// OutputFormat, for gdql --format.
+func ParseFormat(name string) (OutputFormat, error) {
+	names := make([]string, len(formatNames))
+	for i, f := range formatNames {
+		if strings.EqualFold(name, f.name) {
+			return f.format, nil
+		}
+		names[i] = f.name
+	}
+	return 0, fmt.Errorf("unknown format %q (valid: %s)", name, strings.Join(names, ", "))
+}
+
+// Formatter renders a Result as a string, or straight to a writer.
 type Formatter interface {
 	Format(result *executor.Result, format OutputFormat) (string, error)
+	// FormatTo writes the result to w. CSV and TSV stream row by row, so a
+	// large export never sits in memory whole; other formats are built first.
+	FormatTo(w io.Writer, result *executor.Result, format OutputFormat) error
+}
+
+// DefaultSongWidth is the song column width of setlist tables.
+const DefaultSongWidth = 28
+
+// Options adjusts table output. The zero value gives the defaults.
+type Options struct {
+	SongWidth  int  // song column width in setlist tables; 0 means DefaultSongWidth
+	Wrap       bool // continue long song names on extra lines instead of truncating them
+	ShortNames bool // show songs by short name ("Scarlet") where they have one, except in JSON, CSV, and TSV
 }
 
-type formatter struct{}
+type formatter struct {
+	opts Options
+}
 
 // New returns a Formatter.
 func New() Formatter {
 	return &formatter{}
 }
 
-// Format dispatches to the appropriate formatter by format.
+// NewWithOptions returns a Formatter whose tables follow opts.
+func NewWithOptions(opts Options) Formatter {
+	return &formatter{opts: opts}
+}
+
+// Format returns what FormatTo would write.
 func (f *formatter) Format(result *executor.Result, format OutputFormat) (string, error) {
+	var b strings.Builder
+	err := f.FormatTo(&b, result, format)
+	return b.String(), err
+}
+
+// FormatTo streams the formats that can be written a row at a time and
+// dispatches the rest to render.
+func (f *formatter) FormatTo(w io.Writer, result *executor.Result, format OutputFormat) error {
 	switch format {
-	case FormatJSON:
-		return formatJSON(result)
 	case FormatCSV:
-		return formatCSV(result)
+		return writeCSV(w, result)
 	case FormatTSV:
-		return formatTSV(result)
+		return writeTSV(w, result)
+	}
+	if f.opts.ShortNames && format != FormatJSON && format != FormatRawJSON {
+		result = withShortNames(result)
+	}
+	s, err := f.render(result, format)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, s)
+	return err
+}
+
+// withShortNames returns a copy of result whose performances are named by
+// their songs' short names, falling back to the full name. result itself is
+// not changed.
+func withShortNames(result *executor.Result) *executor.Result {
+	short := func(perfs []*data.Performance) []*data.Performance {
+		if perfs == nil {
+			return nil
+		}
+		out := make([]*data.Performance, len(perfs))
+		for i, p := range perfs {
+			cp := *p
+			if cp.SongShortName != "" {
+				cp.SongName = cp.SongShortName
+			}
+			out[i] = &cp
+		}
+		return out
+	}
+	setlist := func(sl *executor.SetlistResult) *executor.SetlistResult {
+		if sl == nil {
+			return nil
+		}
+		cp := *sl
+		cp.Performances = short(sl.Performances)
+		return &cp
+	}
+	out := *result
+	out.Performances = short(result.Performances)
+	out.Setlist = setlist(result.Setlist)
+	if result.Setlists != nil {
+		out.Setlists = make([]*executor.SetlistResult, len(result.Setlists))
+		for i, sl := range result.Setlists {
+			out.Setlists[i] = setlist(sl)
+		}
+	}
+	return &out
+}
+
+// render dispatches to the appropriate formatter by format.
+func (f *formatter) render(result *executor.Result, format OutputFormat) (string, error) {
+	switch format {
+	case FormatJSON:
+		return formatJSON(result)
+	case FormatRawJSON:
+		return formatRawJSON(result)
 	case FormatSetlist:
 		return formatSetlist(result)
+	case FormatMarkdown:
+		return formatMarkdown(result)
+	case FormatClassic:
+		return formatClassic(result)
+	case FormatHTML:
+		return formatHTML(result, false)
+	case FormatHTMLPage:
+		return formatHTML(result, true)
 	case FormatCalendar:
 		return "", fmt.Errorf("CALENDAR output format is not yet implemented")
 	default:
-		return formatTable(result)
+		return formatTableWith(result, f.opts)
 	}
 }
 
@@ -66,6 +194,10 @@ func FromIR(o ir.OutputFormat) OutputFormat {
 		return FormatCalendar
 	case ir.OutputCount:
 		return FormatTable // count results use table formatter's count handler
+	case ir.OutputHTML:
+		return FormatHTML
+	case ir.OutputClassic:
+		return FormatClassic
 	}
 	return FormatTable
 }
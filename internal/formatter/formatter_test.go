@@ -0,0 +1,79 @@
+package formatter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdql/gdql/internal/data"
+	"github.com/gdql/gdql/internal/executor"
+	"github.com/stretchr/testify/require"
+)
+
+// failingWriter accepts n bytes, then fails every write.
+type failingWriter struct{ n int }
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		written := w.n
+		w.n = 0
+		return written, errors.New("disk full")
+	}
+	w.n -= len(p)
+	return len(p), nil
+}
+
+func TestFormatTo_MatchesFormat(t *testing.T) {
+	result := &executor.Result{Type: executor.ResultShows, Shows: []*data.Show{
+		{ID: 1, Date: time.Date(1977, 5, 8, 0, 0, 0, 0, time.UTC), Venue: "Barton Hall", City: "Ithaca", State: "NY"},
+		{ID: 2, Date: time.Date(1977, 5, 9, 0, 0, 0, 0, time.UTC), Venue: "War Memorial", City: "Buffalo", State: "NY"},
+	}}
+	f := New()
+	for _, format := range []OutputFormat{FormatTable, FormatJSON, FormatCSV, FormatTSV, FormatMarkdown} {
+		want, err := f.Format(result, format)
+		require.NoError(t, err)
+		var b strings.Builder
+		require.NoError(t, f.FormatTo(&b, result, format))
+		require.Equal(t, want, b.String(), "format %d", format)
+	}
+}
+
+func TestFormatTo_ReportsWriteErrors(t *testing.T) {
+	shows := make([]*data.Show, 500)
+	for i := range shows {
+		shows[i] = &data.Show{ID: i + 1, Date: time.Date(1977, 5, 8, 0, 0, 0, 0, time.UTC), Venue: "Barton Hall"}
+	}
+	result := &executor.Result{Type: executor.ResultShows, Shows: shows}
+	for _, format := range []OutputFormat{FormatCSV, FormatTSV, FormatTable} {
+		err := New().FormatTo(&failingWriter{n: 100}, result, format)
+		require.ErrorContains(t, err, "disk full", "format %d", format)
+	}
+}
+
+func TestFormat_ShortNames(t *testing.T) {
+	sl := &executor.SetlistResult{Date: time.Date(1977, 5, 8, 0, 0, 0, 0, time.UTC), ShowID: 1, Performances: []*data.Performance{
+		{SetNumber: 2, Position: 1, SongName: "Scarlet Begonias", SongShortName: "Scarlet"},
+		{SetNumber: 2, Position: 2, SongName: "Fire on the Mountain", SongShortName: "Fire", SegueType: ">"},
+		{SetNumber: 2, Position: 3, SongName: "Estimated Prophet", SegueType: ">"},
+	}}
+	result := &executor.Result{Type: executor.ResultSetlist, Setlists: []*executor.SetlistResult{sl}}
+	f := NewWithOptions(Options{ShortNames: true})
+
+	out, err := f.Format(result, FormatClassic)
+	require.NoError(t, err)
+	require.Contains(t, out, "S2: Scarlet > Fire > Estimated Prophet")
+	out, err = f.Format(result, FormatTable)
+	require.NoError(t, err)
+	require.Contains(t, out, "|   1 | -     | Scarlet\n")
+
+	// Data formats keep full names, and the result is left as it was.
+	out, err = f.Format(result, FormatJSON)
+	require.NoError(t, err)
+	require.Contains(t, out, `"Scarlet Begonias"`)
+	require.Equal(t, "Scarlet Begonias", sl.Performances[0].SongName)
+
+	out, err = New().Format(result, FormatClassic)
+	require.NoError(t, err)
+	require.Contains(t, out, "S2: Scarlet Begonias > Fire on the Mountain > Estimated Prophet")
+}
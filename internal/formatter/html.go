@@ -0,0 +1,116 @@
+package formatter
+
+import (
+	_ "embed"
+	"html/template"
+	"strings"
+
+	"github.com/gdql/gdql/internal/data"
+	"github.com/gdql/gdql/internal/executor"
+)
+
+//go:embed html.tmpl
+var htmlTmplSrc string
+
+// htmlTmpl is parsed once; all values go through html/template's contextual escaping.
+var htmlTmpl = template.Must(template.New("html").Funcs(template.FuncMap{
+	"length": formatLength,
+	"venue":  venueOrUnknown,
+	"sets":   groupSets,
+}).Parse(htmlTmplSrc))
+
+// htmlView is the template input. Exactly one of the result slices is set;
+// a single SETLIST result is passed as a one-element Setlists.
+type htmlView struct {
+	Title        string
+	Shows        []*data.Show
+	Songs        []*data.Song
+	Performances []*data.Performance
+	PerfContext  bool // WITH CONTEXT: before/after columns
+	Setlists     []*executor.SetlistResult
+	Venues       []*data.Venue
+	VenueStats   bool // show first/last show columns
+	Runs         []*executor.RunResult
+	Coverage     []*executor.CoverageResult
+	Years        []*executor.YearCount
+	Gaps         []*executor.GapResult
+	Compare      []*data.Song
+	Count        *executor.CountResult
+	Empty        string
+}
+
+// htmlSet is one set of a setlist, for rendering headings.
+type htmlSet struct {
+	Name         string
+	Performances []*data.Performance
+}
+
+// formatHTML renders the result as an HTML fragment, or a complete page when full is true.
+func formatHTML(result *executor.Result, full bool) (string, error) {
+	v := htmlView{Title: "GDQL results"}
+	switch {
+	case len(result.Setlists) > 0:
+		v.Setlists = result.Setlists
+	case result.Type == executor.ResultShows:
+		v.Shows = result.Shows
+		v.Empty = "No shows found."
+	case result.Type == executor.ResultSongs:
+		v.Songs = result.Songs
+		v.Empty = "No songs found."
+	case result.Type == executor.ResultPerformances:
+		v.Performances = result.Performances
+		v.PerfContext = withContext(result.Performances)
+		v.Empty = "No performances found."
+	case result.Type == executor.ResultSetlist:
+		if result.Setlist != nil && len(result.Setlist.Performances) > 0 {
+			v.Setlists = []*executor.SetlistResult{result.Setlist}
+			v.Title = "Setlist — " + result.Setlist.Date.Format("2006-01-02")
+		}
+		v.Empty = "No setlist."
+	case result.Type == executor.ResultVenues, result.Type == executor.ResultVenueStats:
+		v.Venues = result.Venues
+		v.VenueStats = result.Type == executor.ResultVenueStats
+		v.Empty = "No venues found."
+	case result.Type == executor.ResultCompare:
+		v.Compare = result.Songs
+		v.Empty = "No songs found."
+	case result.Type == executor.ResultRuns:
+		v.Runs = result.Runs
+		v.Empty = "No runs found."
+	case result.Type == executor.ResultCoverage:
+		v.Coverage = result.Coverage
+		v.Empty = "No years in range."
+	case result.Type == executor.ResultYears:
+		v.Years = result.Years
+		v.Empty = "No performances found."
+	case result.Type == executor.ResultGaps:
+		v.Gaps = result.Gaps
+		v.Empty = "No gaps found."
+	case result.Type == executor.ResultCount:
+		v.Count = result.Count
+		if v.Count == nil {
+			v.Count = &executor.CountResult{}
+		}
+	}
+	name := "fragment"
+	if full {
+		name = "page"
+	}
+	var b strings.Builder
+	if err := htmlTmpl.ExecuteTemplate(&b, name, v); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// groupSets splits an ordered setlist into consecutive sets.
+func groupSets(perfs []*data.Performance) []htmlSet {
+	var out []htmlSet
+	for _, p := range perfs {
+		if len(out) == 0 || out[len(out)-1].Performances[0].SetNumber != p.SetNumber {
+			out = append(out, htmlSet{Name: fmtSetName(p.SetNumber, p.SetName)})
+		}
+		out[len(out)-1].Performances = append(out[len(out)-1].Performances, p)
+	}
+	return out
+}
@@ -0,0 +1,63 @@
+package formatter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gdql/gdql/internal/data"
+	"github.com/gdql/gdql/internal/executor"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatHTML_ShowsEscapesContent(t *testing.T) {
+	result := &executor.Result{Type: executor.ResultShows, Shows: []*data.Show{
+		{ID: 1, Date: time.Date(1977, 5, 8, 0, 0, 0, 0, time.UTC), Venue: `Barton Hall <script>alert(1)</script>`, City: "Ithaca", State: "NY"},
+	}}
+	out, err := formatHTML(result, false)
+	require.NoError(t, err)
+	require.Contains(t, out, "<table>")
+	require.Contains(t, out, "1977-05-08")
+	require.Contains(t, out, "&lt;script&gt;")
+	require.NotContains(t, out, "<script>")
+	require.NotContains(t, out, "<html")
+}
+
+func TestFormatHTML_UnknownVenue(t *testing.T) {
+	result := &executor.Result{Type: executor.ResultShows, Shows: []*data.Show{{ID: 4, Date: time.Date(1969, 2, 27, 0, 0, 0, 0, time.UTC)}}}
+	out, err := formatHTML(result, false)
+	require.NoError(t, err)
+	require.Contains(t, out, "<td>(unknown venue)</td>")
+}
+
+func TestFormatHTML_FullPage(t *testing.T) {
+	result := &executor.Result{Type: executor.ResultSongs, Songs: []*data.Song{{Name: "Dark Star", TimesPlayed: 228}}}
+	out, err := formatHTML(result, true)
+	require.NoError(t, err)
+	require.True(t, len(out) > 0 && out[:15] == "<!DOCTYPE html>")
+	require.Contains(t, out, "Dark Star")
+	require.Contains(t, out, "228")
+}
+
+func TestFormatHTML_SetlistGroupsSets(t *testing.T) {
+	result := &executor.Result{Type: executor.ResultSetlist, Setlist: &executor.SetlistResult{
+		Date:  time.Date(1977, 5, 8, 0, 0, 0, 0, time.UTC),
+		Venue: "Barton Hall",
+		Performances: []*data.Performance{
+			{SetNumber: 1, Position: 1, SongName: "Minglewood Blues"},
+			{SetNumber: 2, Position: 1, SongName: "Scarlet Begonias", SegueType: ">", LengthSeconds: 580},
+			{SetNumber: 2, Position: 2, SongName: "Fire on the Mountain"},
+		},
+	}}
+	out, err := formatHTML(result, false)
+	require.NoError(t, err)
+	require.Contains(t, out, "<h3>Set 1</h3>")
+	require.Contains(t, out, "<h3>Set 2</h3>")
+	require.Contains(t, out, "Scarlet Begonias (9:40)")
+	require.Contains(t, out, `<span class="segue">&gt;</span>`)
+}
+
+func TestFormatHTML_Empty(t *testing.T) {
+	out, err := New().Format(&executor.Result{Type: executor.ResultShows}, FormatHTML)
+	require.NoError(t, err)
+	require.Contains(t, out, "No shows found.")
+}
@@ -2,6 +2,9 @@ package formatter
 
 import (
 	"encoding/json"
+	"strings"
+
+	"github.com/gdql/gdql/internal/data"
 	"github.com/gdql/gdql/internal/executor"
 )
 
@@ -10,6 +13,12 @@ func formatJSON(result *executor.Result) (string, error) {
 		"type":     resultTypeStr(result.Type),
 		"duration": result.Duration.String(),
 	}
+	if result.Slow {
+		out["slow"] = true
+	}
+	if result.Hint != "" {
+		out["hint"] = result.Hint
+	}
 	switch result.Type {
 	case executor.ResultShows:
 		if len(result.Setlists) > 0 {
@@ -20,12 +29,53 @@ func formatJSON(result *executor.Result) (string, error) {
 		}
 	case executor.ResultSongs:
 		out["songs"] = result.Songs
+	case executor.ResultCompare:
+		out["compare"] = result.Songs
 	case executor.ResultPerformances:
 		out["performances"] = result.Performances
 	case executor.ResultSetlist:
-		out["setlist"] = result.Setlist
+		if result.Setlist == nil {
+			// SETLIST FOR a month or year
+			setlists := result.Setlists
+			if setlists == nil {
+				setlists = []*executor.SetlistResult{}
+			}
+			out["setlists"] = setlists
+		} else {
+			out["setlist"] = result.Setlist
+		}
 	case executor.ResultCount:
 		out["count"] = result.Count
+	case executor.ResultVenues, executor.ResultVenueStats:
+		venues := result.Venues
+		if venues == nil {
+			venues = []*data.Venue{}
+		}
+		out["venues"] = venues
+	case executor.ResultRuns:
+		runs := result.Runs
+		if runs == nil {
+			runs = []*executor.RunResult{}
+		}
+		out["runs"] = runs
+	case executor.ResultCoverage:
+		years := result.Coverage
+		if years == nil {
+			years = []*executor.CoverageResult{}
+		}
+		out["coverage"] = years
+	case executor.ResultYears:
+		years := result.Years
+		if years == nil {
+			years = []*executor.YearCount{}
+		}
+		out["years"] = years
+	case executor.ResultGaps:
+		gaps := result.Gaps
+		if gaps == nil {
+			gaps = []*executor.GapResult{}
+		}
+		out["gaps"] = gaps
 	}
 	b, err := json.MarshalIndent(out, "", "  ")
 	if err != nil {
@@ -34,6 +84,41 @@ func formatJSON(result *executor.Result) (string, error) {
 	return string(b), nil
 }
 
+// formatRawJSON emits the generated SQL and the rows it returned, untouched by
+// mapRowsToShows and friends, so column-order and mapping bugs show up as-is.
+// Enrichment queries (venues, setlist expansion) are not included.
+func formatRawJSON(result *executor.Result) (string, error) {
+	args := result.Args
+	if args == nil {
+		args = []interface{}{}
+	}
+	cols, rows := []string{}, []data.Row{}
+	if rs := result.Raw; rs != nil {
+		if rs.Columns != nil {
+			cols = rs.Columns
+		}
+		if rs.Rows != nil {
+			rows = rs.Rows
+		}
+	}
+	out := map[string]interface{}{
+		"type":    resultTypeStr(result.Type),
+		"sql":     result.SQL,
+		"args":    args,
+		"columns": cols,
+		"rows":    rows,
+	}
+	// Keep SQL operators like > readable instead of \u003e.
+	var buf strings.Builder
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
 func resultTypeStr(t executor.ResultType) string {
 	switch t {
 	case executor.ResultShows:
@@ -46,6 +131,66 @@ func resultTypeStr(t executor.ResultType) string {
 		return "setlist"
 	case executor.ResultCount:
 		return "count"
+	case executor.ResultVenues:
+		return "venues"
+	case executor.ResultRuns:
+		return "runs"
+	case executor.ResultVenueStats:
+		return "venue_stats"
+	case executor.ResultCompare:
+		return "compare"
+	case executor.ResultCoverage:
+		return "coverage"
+	case executor.ResultYears:
+		return "years"
+	case executor.ResultGaps:
+		return "gaps"
 	}
 	return ""
 }
+
+// setlistDoc is the embeddable shape of one show's setlist: venue details up
+// front and songs grouped into named sets, without database ids.
+type setlistDoc struct {
+	Date  string       `json:"date"`
+	Venue string       `json:"venue,omitempty"`
+	City  string       `json:"city,omitempty"`
+	State string       `json:"state,omitempty"`
+	Sets  []setlistSet `json:"sets"`
+}
+
+type setlistSet struct {
+	Name  string        `json:"name"`
+	Songs []setlistSong `json:"songs"`
+}
+
+type setlistSong struct {
+	Name          string `json:"name"`
+	Segue         string `json:"segue,omitempty"`
+	LengthSeconds int    `json:"length_seconds,omitempty"`
+}
+
+// SetlistJSON renders one setlist as standalone JSON for embedding in a page
+// or app (gdql setlist <date> --json). Unlike the AS JSON result, which lists
+// raw performance rows, songs are grouped by set under the set's label.
+func SetlistJSON(sl *executor.SetlistResult) (string, error) {
+	doc := setlistDoc{Venue: sl.Venue, City: sl.City, State: sl.State, Sets: []setlistSet{}}
+	if !sl.Date.IsZero() {
+		doc.Date = sl.Date.Format("2006-01-02")
+	}
+	for _, set := range groupSets(sl.Performances) {
+		out := setlistSet{Name: set.Name}
+		for _, p := range set.Performances {
+			out.Songs = append(out.Songs, setlistSong{Name: p.SongName, Segue: p.SegueType, LengthSeconds: p.LengthSeconds})
+		}
+		doc.Sets = append(doc.Sets, out)
+	}
+	var buf strings.Builder
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
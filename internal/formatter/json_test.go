@@ -0,0 +1,114 @@
+package formatter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gdql/gdql/internal/data"
+	"github.com/gdql/gdql/internal/executor"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatJSON_SnakeCaseFields(t *testing.T) {
+	out, err := formatJSON(&executor.Result{Type: executor.ResultCount, Count: &executor.CountResult{SongName: "Dark Star", Count: 2}})
+	require.NoError(t, err)
+	require.Contains(t, out, `"name": "Dark Star"`)
+	require.Contains(t, out, `"count": 2`)
+
+	sl := &executor.SetlistResult{
+		Date:         time.Date(1977, 5, 8, 0, 0, 0, 0, time.UTC),
+		ShowID:       1,
+		Venue:        "Barton Hall",
+		Performances: []*data.Performance{{ShowID: 1, SongID: 1, SetNumber: 2, Position: 1}},
+	}
+	out, err = formatJSON(&executor.Result{Type: executor.ResultSetlist, Setlist: sl})
+	require.NoError(t, err)
+	require.Contains(t, out, `"date": "1977-05-08"`)
+	require.Contains(t, out, `"show_id": 1`)
+	require.Contains(t, out, `"set_number": 2`)
+	require.NotContains(t, out, "ShowID")
+}
+
+func TestFormatJSON_SetlistPeriod(t *testing.T) {
+	setlists := []*executor.SetlistResult{
+		{Date: time.Date(1977, 5, 7, 0, 0, 0, 0, time.UTC), ShowID: 1, Performances: []*data.Performance{{ShowID: 1, SongName: "Bertha"}}},
+		{Date: time.Date(1977, 5, 8, 0, 0, 0, 0, time.UTC), ShowID: 2, Performances: []*data.Performance{{ShowID: 2, SongName: "Dew"}}},
+	}
+	out, err := formatJSON(&executor.Result{Type: executor.ResultSetlist, Setlists: setlists})
+	require.NoError(t, err)
+	require.Contains(t, out, `"setlists": [`)
+	require.Contains(t, out, `"date": "1977-05-08"`)
+	require.NotContains(t, out, `"setlist":`)
+
+	// An empty month is an empty list, not null
+	out, err = formatJSON(&executor.Result{Type: executor.ResultSetlist})
+	require.NoError(t, err)
+	require.Contains(t, out, `"setlists": []`)
+
+	table, err := formatTable(&executor.Result{Type: executor.ResultSetlist, Setlists: setlists})
+	require.NoError(t, err)
+	require.Contains(t, table, "Setlist for 1977-05-07 (show_id=1)")
+	require.Contains(t, table, "Setlist for 1977-05-08 (show_id=2)")
+}
+
+func TestFormatRawJSON(t *testing.T) {
+	result := &executor.Result{
+		Type: executor.ResultShows,
+		SQL:  "SELECT s.id, s.date FROM shows s WHERE s.date >= ?",
+		Args: []interface{}{"1977-01-01"},
+		Raw: &data.ResultSet{
+			Columns: []string{"id", "date"},
+			Rows:    []data.Row{{int64(1), "1977-05-08"}, {int64(2), nil}},
+		},
+		Shows: []*data.Show{{ID: 1, Venue: "Barton Hall"}},
+	}
+	out, err := New().Format(result, FormatRawJSON)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"type": "shows",
+		"sql": "SELECT s.id, s.date FROM shows s WHERE s.date >= ?",
+		"args": ["1977-01-01"],
+		"columns": ["id", "date"],
+		"rows": [[1, "1977-05-08"], [2, null]]
+	}`, out)
+
+	out, err = formatRawJSON(&executor.Result{Type: executor.ResultShows, SQL: "SELECT 1 WHERE 2 > 1"})
+	require.NoError(t, err)
+	require.Contains(t, out, `"sql": "SELECT 1 WHERE 2 > 1"`)
+
+	out, err = formatRawJSON(&executor.Result{Type: executor.ResultSongs})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"type": "songs", "sql": "", "args": [], "columns": [], "rows": []}`, out)
+}
+
+func TestFormatJSON_VenueStats(t *testing.T) {
+	out, err := formatJSON(&executor.Result{Type: executor.ResultVenueStats, Venues: []*data.Venue{{
+		ID: 2, Name: "Winterland Arena", ShowCount: 2,
+		FirstShow: time.Date(1977, 2, 26, 0, 0, 0, 0, time.UTC), LastShow: time.Date(1977, 12, 31, 0, 0, 0, 0, time.UTC),
+	}}})
+	require.NoError(t, err)
+	require.Contains(t, out, `"type": "venue_stats"`)
+	require.Contains(t, out, `"first_show": "1977-02-26"`)
+	require.Contains(t, out, `"last_show": "1977-12-31"`)
+
+	// Plain VENUES leaves the span out.
+	out, err = formatJSON(&executor.Result{Type: executor.ResultVenues, Venues: []*data.Venue{{ID: 2, Name: "Winterland Arena", ShowCount: 2}}})
+	require.NoError(t, err)
+	require.NotContains(t, out, "first_show")
+}
+
+func TestFormatJSON_Runs(t *testing.T) {
+	out, err := formatJSON(&executor.Result{Type: executor.ResultRuns, Runs: []*executor.RunResult{{
+		VenueID: 2, Venue: "Winterland Arena",
+		Start: time.Date(1977, 12, 29, 0, 0, 0, 0, time.UTC), End: time.Date(1977, 12, 31, 0, 0, 0, 0, time.UTC),
+		Nights: 3, Shows: 3,
+	}}})
+	require.NoError(t, err)
+	require.Contains(t, out, `"type": "runs"`)
+	require.Contains(t, out, `"start": "1977-12-29"`)
+	require.Contains(t, out, `"nights": 3`)
+
+	out, err = formatJSON(&executor.Result{Type: executor.ResultRuns})
+	require.NoError(t, err)
+	require.Contains(t, out, `"runs": []`)
+}
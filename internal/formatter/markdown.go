@@ -0,0 +1,44 @@
+package formatter
+
+import (
+	"strings"
+
+	"github.com/gdql/gdql/internal/executor"
+)
+
+// formatMarkdown renders a GitHub-flavored pipe table with the same columns as
+// formatTSV, for pasting into issues, wikis, and forum posts.
+func formatMarkdown(result *executor.Result) (string, error) {
+	tsv, err := formatTSV(result)
+	if err != nil {
+		return "", err
+	}
+	lines := strings.Split(strings.TrimSuffix(tsv, "\n"), "\n")
+	if len(lines) == 0 || lines[0] == "" {
+		return "", nil
+	}
+	var b strings.Builder
+	for i, line := range lines {
+		cells := strings.Split(line, "\t")
+		writeMarkdownRow(&b, cells)
+		if i == 0 {
+			sep := make([]string, len(cells))
+			for j := range sep {
+				sep[j] = "---"
+			}
+			writeMarkdownRow(&b, sep)
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// writeMarkdownRow escapes pipes so a song like "Help>Slip>Frank" can't split a cell.
+func writeMarkdownRow(b *strings.Builder, cells []string) {
+	b.WriteString("|")
+	for _, c := range cells {
+		b.WriteString(" ")
+		b.WriteString(strings.ReplaceAll(c, "|", `\|`))
+		b.WriteString(" |")
+	}
+	b.WriteString("\n")
+}
@@ -0,0 +1,41 @@
+package formatter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gdql/gdql/internal/data"
+	"github.com/gdql/gdql/internal/executor"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatMarkdown(t *testing.T) {
+	out, err := formatMarkdown(&executor.Result{
+		Type: executor.ResultShows,
+		Shows: []*data.Show{
+			{ID: 1, Date: time.Date(1977, 5, 8, 0, 0, 0, 0, time.UTC), Venue: "Barton Hall", City: "Ithaca", State: "NY", Tour: "Spring|77", SourceURL: "https://www.setlist.fm/setlist/x.html"},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "| id | date | venue | city | state | tour | source_url |\n"+
+		"| --- | --- | --- | --- | --- | --- | --- |\n"+
+		`| 1 | 1977-05-08 | Barton Hall | Ithaca | NY | Spring\|77 | https://www.setlist.fm/setlist/x.html |`, out)
+
+	out, err = formatMarkdown(&executor.Result{Type: executor.ResultCount})
+	require.NoError(t, err)
+	require.Empty(t, out)
+}
+
+func TestParseFormat(t *testing.T) {
+	for name, want := range map[string]OutputFormat{
+		"table": FormatTable, "JSON": FormatJSON, "csv": FormatCSV, "tsv": FormatTSV,
+		"setlist": FormatSetlist, "Markdown": FormatMarkdown, "classic": FormatClassic,
+		"html": FormatHTML, "html-full": FormatHTMLPage,
+	} {
+		got, err := ParseFormat(name)
+		require.NoError(t, err, name)
+		require.Equal(t, want, got, name)
+	}
+	_, err := ParseFormat("xml")
+	require.EqualError(t, err, `unknown format "xml" (valid: table, json, csv, tsv, setlist, markdown, classic, html, html-full)`)
+}
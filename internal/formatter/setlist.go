@@ -23,7 +23,7 @@ func formatSetlist(result *executor.Result) (string, error) {
 	for _, p := range sl.Performances {
 		if p.SetNumber != set {
 			set = p.SetNumber
-			b.WriteString(fmtSetName(set))
+			b.WriteString(fmtSetName(set, p.SetName))
 			b.WriteString("\n")
 		}
 		seg := ""
@@ -36,6 +36,7 @@ func formatSetlist(result *executor.Result) (string, error) {
 		}
 		fmt.Fprintf(&b, "  %d.%s%s%s\n", p.Position, seg, name, fmtPerformance(p))
 	}
+	writeSourceFooter(&b, sl.SourceURL)
 	return strings.TrimRight(b.String(), "\n"), nil
 }
 
@@ -50,7 +51,7 @@ func formatMultiSetlist(setlists []*executor.SetlistResult) (string, error) {
 		for _, p := range sl.Performances {
 			if p.SetNumber != set {
 				set = p.SetNumber
-				b.WriteString(fmtSetName(set))
+				b.WriteString(fmtSetName(set, p.SetName))
 				b.WriteString("\n")
 			}
 			name := p.SongName
@@ -63,11 +64,116 @@ func formatMultiSetlist(setlists []*executor.SetlistResult) (string, error) {
 			}
 			fmt.Fprintf(&b, "  %d.%s%s%s\n", p.Position, seg, name, fmtPerformance(p))
 		}
+		writeSourceFooter(&b, sl.SourceURL)
 	}
 	return strings.TrimRight(b.String(), "\n"), nil
 }
 
-func fmtSetName(setNum int) string {
+// writeSourceFooter credits the page a setlist was imported from, after a
+// blank line. Setlists without a known source get no footer.
+func writeSourceFooter(b *strings.Builder, url string) {
+	if url != "" {
+		fmt.Fprintf(b, "\nSource: %s\n", url)
+	}
+}
+
+// formatClassic renders setlists in the compact form used on tape trading
+// lists and jerrygarcia.com: a header line per show, then one line per set
+// with songs separated by commas and segues shown as " > ".
+//
+//	1977-05-08 Barton Hall, Ithaca, NY
+//	S1: New Minglewood Blues, Loser
+//	S2: Scarlet Begonias > Fire on the Mountain
+func formatClassic(result *executor.Result) (string, error) {
+	setlists := result.Setlists
+	if len(setlists) == 0 {
+		if result.Type != executor.ResultSetlist || result.Setlist == nil {
+			return formatTable(result)
+		}
+		setlists = []*executor.SetlistResult{result.Setlist}
+	}
+	var b strings.Builder
+	for i, sl := range setlists {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(sl.Date.Format("2006-01-02"))
+		if place := joinNonEmpty(sl.Venue, sl.City, sl.State); place != "" {
+			b.WriteString(" " + place)
+		}
+		b.WriteString("\n")
+		lastSet := 0
+		for _, p := range sl.Performances {
+			lastSet = max(lastSet, p.SetNumber)
+		}
+		set := -1
+		for _, p := range sl.Performances {
+			name := p.SongName
+			if name == "" {
+				name = "?"
+			}
+			switch {
+			case p.SetNumber != set:
+				if set != -1 {
+					b.WriteString("\n")
+				}
+				set = p.SetNumber
+				b.WriteString(classicSetLabel(set, p.SetName, lastSet) + ": ")
+			case p.SegueType != "":
+				b.WriteString(" " + p.SegueType + " ")
+			default:
+				b.WriteString(", ")
+			}
+			b.WriteString(name)
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+// classicSetLabel abbreviates a set for the classic format. An imported set
+// name wins: "Encore" and "Encore 2" become E and E2, "Set 2" S2, and any
+// other name ("Acoustic Set") is kept whole. Unnamed sets follow ENCORE
+// queries, which take a show's last set or any set past the third (setlist.fm
+// numbers encores 4, 5), except that the second set of a two-set show stays S2.
+func classicSetLabel(setNum int, name string, lastSet int) string {
+	if name = strings.TrimSuffix(strings.TrimSpace(name), ":"); name != "" {
+		fields := strings.Fields(name)
+		switch {
+		case strings.EqualFold(fields[0], "Encore") && len(fields) <= 2:
+			return "E" + strings.Join(fields[1:], "")
+		case strings.EqualFold(fields[0], "Set") && len(fields) == 2:
+			return "S" + fields[1]
+		}
+		return name
+	}
+	switch {
+	case setNum == 0:
+		return "SC"
+	case setNum > 4:
+		return fmt.Sprintf("E%d", setNum-3)
+	case setNum == 4, setNum == lastSet && setNum >= 3:
+		return "E"
+	}
+	return fmt.Sprintf("S%d", setNum)
+}
+
+func joinNonEmpty(parts ...string) string {
+	var out []string
+	for _, s := range parts {
+		if s != "" {
+			out = append(out, s)
+		}
+	}
+	return strings.Join(out, ", ")
+}
+
+// fmtSetName labels a set: its imported name (e.g. "Acoustic Set") when it
+// has one, else a generic label from its number.
+func fmtSetName(setNum int, name string) string {
+	if name != "" {
+		return name
+	}
 	switch setNum {
 	case 1:
 		return "Set 1"
@@ -0,0 +1,86 @@
+package formatter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gdql/gdql/internal/data"
+	"github.com/gdql/gdql/internal/executor"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatClassic_Setlist(t *testing.T) {
+	result := &executor.Result{Type: executor.ResultSetlist, Setlist: &executor.SetlistResult{
+		Date:  time.Date(1977, 5, 8, 0, 0, 0, 0, time.UTC),
+		Venue: "Barton Hall",
+		City:  "Ithaca",
+		State: "NY",
+		Performances: []*data.Performance{
+			{SetNumber: 1, Position: 1, SongName: "New Minglewood Blues"},
+			{SetNumber: 1, Position: 2, SongName: "Loser"},
+			{SetNumber: 2, Position: 1, SongName: "Scarlet Begonias"},
+			{SetNumber: 2, Position: 2, SongName: "Fire on the Mountain", SegueType: ">"},
+			{SetNumber: 2, Position: 3, SongName: "Estimated Prophet"},
+			{SetNumber: 3, Position: 1, SongName: "One More Saturday Night"},
+		},
+	}}
+	out, err := New().Format(result, FormatClassic)
+	require.NoError(t, err)
+	require.Equal(t, "1977-05-08 Barton Hall, Ithaca, NY\n"+
+		"S1: New Minglewood Blues, Loser\n"+
+		"S2: Scarlet Begonias > Fire on the Mountain, Estimated Prophet\n"+
+		"E: One More Saturday Night", out)
+}
+
+func TestClassicSetLabel(t *testing.T) {
+	for _, tc := range []struct {
+		set     int
+		name    string
+		lastSet int
+		want    string
+	}{
+		{1, "", 3, "S1"},
+		{3, "", 3, "E"},       // last of three sets
+		{3, "", 4, "S3"},      // a third set with an encore after it
+		{2, "", 2, "S2"},      // two sets and no encore
+		{4, "", 5, "E"},       // setlist.fm's first encore
+		{5, "", 5, "E2"},      // and its second
+		{2, "Encore", 2, "E"}, // named by the import
+		{4, "Encore 2:", 4, "E2"},
+		{3, "Set 3", 3, "S3"},
+		{1, "Acoustic Set", 3, "Acoustic Set"},
+		{0, "", 2, "SC"},
+	} {
+		require.Equal(t, tc.want, classicSetLabel(tc.set, tc.name, tc.lastSet), "%+v", tc)
+	}
+}
+
+func TestFormatClassic_SetNames(t *testing.T) {
+	result := &executor.Result{Type: executor.ResultSetlist, Setlist: &executor.SetlistResult{
+		Date: time.Date(1970, 5, 15, 0, 0, 0, 0, time.UTC),
+		Performances: []*data.Performance{
+			{SetNumber: 1, Position: 1, SongName: "Don't Ease Me In", SetName: "Acoustic Set"},
+			{SetNumber: 2, Position: 1, SongName: "Dancing in the Street"},
+			{SetNumber: 3, Position: 1, SongName: "Dark Star"},
+			{SetNumber: 4, Position: 1, SongName: "Uncle John's Band"},
+		},
+	}}
+	out, err := New().Format(result, FormatClassic)
+	require.NoError(t, err)
+	require.Equal(t, "1970-05-15\nAcoustic Set: Don't Ease Me In\nS2: Dancing in the Street\nS3: Dark Star\nE: Uncle John's Band", out)
+}
+
+func TestFormatClassic_MultipleShows(t *testing.T) {
+	result := &executor.Result{Type: executor.ResultShows, Setlists: []*executor.SetlistResult{
+		{Date: time.Date(1977, 5, 8, 0, 0, 0, 0, time.UTC), Venue: "Barton Hall", Performances: []*data.Performance{
+			{SetNumber: 1, Position: 1, SongName: "Bertha"},
+		}},
+		{Date: time.Date(1977, 5, 9, 0, 0, 0, 0, time.UTC), Performances: []*data.Performance{
+			{SetNumber: 1, Position: 1, SongName: "Help on the Way"},
+			{SetNumber: 1, Position: 2, SongName: "Slipknot!", SegueType: ">"},
+		}},
+	}}
+	out, err := New().Format(result, FormatClassic)
+	require.NoError(t, err)
+	require.Equal(t, "1977-05-08 Barton Hall\nS1: Bertha\n\n1977-05-09\nS1: Help on the Way > Slipknot!", out)
+}
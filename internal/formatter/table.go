@@ -3,12 +3,18 @@ package formatter
 import (
 	"fmt"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/gdql/gdql/internal/data"
 	"github.com/gdql/gdql/internal/executor"
 )
 
 func formatTable(result *executor.Result) (string, error) {
+	return formatTableWith(result, Options{})
+}
+
+func formatTableWith(result *executor.Result, opts Options) (string, error) {
 	switch result.Type {
 	case executor.ResultShows:
 		return tableShows(result.Shows), nil
@@ -17,9 +23,30 @@ func formatTable(result *executor.Result) (string, error) {
 	case executor.ResultPerformances:
 		return tablePerformances(result.Performances), nil
 	case executor.ResultSetlist:
-		return tableSetlist(result.Setlist), nil
+		if result.Setlist == nil && len(result.Setlists) > 0 {
+			parts := make([]string, len(result.Setlists))
+			for i, sl := range result.Setlists {
+				parts[i] = tableSetlist(sl, opts)
+			}
+			return strings.Join(parts, "\n"), nil
+		}
+		return tableSetlist(result.Setlist, opts), nil
 	case executor.ResultCount:
 		return tableCount(result.Count), nil
+	case executor.ResultVenues:
+		return tableVenues(result.Venues), nil
+	case executor.ResultVenueStats:
+		return tableVenueStats(result.Venues), nil
+	case executor.ResultRuns:
+		return tableRuns(result.Runs), nil
+	case executor.ResultCompare:
+		return tableCompare(result.Songs), nil
+	case executor.ResultCoverage:
+		return tableCoverage(result.Coverage), nil
+	case executor.ResultYears:
+		return tableYears(result.Years), nil
+	case executor.ResultGaps:
+		return tableGaps(result.Gaps), nil
 	default:
 		return "", nil
 	}
@@ -39,24 +66,119 @@ func tableShows(shows []*data.Show) string {
 	if len(shows) == 0 {
 		return "No shows found."
 	}
+	hasLength, hasMatch, hasRating := false, false, false
+	for _, s := range shows {
+		if s.LengthSeconds > 0 {
+			hasLength = true
+		}
+		if s.Rating != nil {
+			hasRating = true
+		}
+		if s.MatchPosition > 0 {
+			hasMatch = true
+		}
+	}
 	var b strings.Builder
-	b.WriteString("DATE       | VENUE                          | CITY                     | STATE\n")
-	b.WriteString("-----------+--------------------------------+--------------------------+------\n")
+	header := "DATE       | VENUE                          | CITY                     | STATE"
+	rule := "-----------+--------------------------------+--------------------------+------"
+	if hasLength {
+		header += " | LENGTH  "
+		rule += "+---------"
+	}
+	if hasRating {
+		header += " | RATING     "
+		rule += "+-------------"
+	}
+	if hasMatch {
+		header += " | MATCH"
+		rule += "+------------"
+	}
+	b.WriteString(strings.TrimRight(header, " ") + "\n")
+	b.WriteString(rule + "\n")
 	for _, s := range shows {
 		date := s.Date.Format("2006-01-02")
-		venue := truncate(s.Venue, 30)
+		venue := truncate(venueOrUnknown(s.Venue), 30)
 		city := truncate(s.City, 24)
 		state := truncate(s.State, 5)
-		fmt.Fprintf(&b, "%-10s | %-30s | %-24s | %-5s\n", date, venue, city, state)
+		line := fmt.Sprintf("%-10s | %-30s | %-24s | %-5s", date, venue, city, state)
+		if hasLength {
+			line += fmt.Sprintf(" | %-7s", formatShowLength(s.LengthSeconds))
+		}
+		if hasRating {
+			line += fmt.Sprintf(" | %-11s", formatRating(s))
+		}
+		if hasMatch {
+			line += " | " + formatMatch(s)
+		}
+		b.WriteString(strings.TrimRight(line, " ") + "\n")
 	}
+	b.WriteString(showsSummary(shows) + "\n")
 	return b.String()
 }
 
+// showsSummary is the footer under a shows table, e.g. "— 42 shows".
+func showsSummary(shows []*data.Show) string {
+	return "— " + plural(len(shows), "show", "shows")
+}
+
+// perfsSummary is the footer under a performances table. When lengths are
+// known it adds their average, min and max, e.g.
+// "— 15 performances, avg 18:32 (min 9:40, max 31:05)".
+func perfsSummary(perfs []*data.Performance) string {
+	s := "— " + plural(len(perfs), "performance", "performances")
+	total, timed, lo, hi := 0, 0, 0, 0
+	for _, p := range perfs {
+		if p.LengthSeconds <= 0 {
+			continue
+		}
+		if timed == 0 || p.LengthSeconds < lo {
+			lo = p.LengthSeconds
+		}
+		if p.LengthSeconds > hi {
+			hi = p.LengthSeconds
+		}
+		total += p.LengthSeconds
+		timed++
+	}
+	if timed == 0 {
+		return s
+	}
+	s += ", avg " + formatLength((total+timed/2)/timed)
+	if timed > 1 {
+		s += fmt.Sprintf(" (min %s, max %s)", formatLength(lo), formatLength(hi))
+	}
+	return s
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return "1 " + one
+	}
+	return fmt.Sprintf("%d %s", n, many)
+}
+
+// formatMatch renders where a segue chain started, e.g. "set 2, #3".
+func formatMatch(s *data.Show) string {
+	if s.MatchPosition == 0 {
+		return ""
+	}
+	return fmt.Sprintf("set %d, #%d", s.MatchSet, s.MatchPosition)
+}
+
 func tableSongs(songs []*data.Song) string {
 	if len(songs) == 0 {
 		return "No songs found."
 	}
 	var b strings.Builder
+	if songs[0].AvgLength > 0 {
+		// ORDER BY AVG_LENGTH: every row has a timed average.
+		b.WriteString("NAME                 | TIMES_PLAYED | AVG_LENGTH\n")
+		b.WriteString("---------------------+--------------+-----------\n")
+		for _, s := range songs {
+			fmt.Fprintf(&b, "%-20s | %-12d | %s\n", truncate(s.Name, 19), s.TimesPlayed, formatLength(s.AvgLength))
+		}
+		return b.String()
+	}
 	b.WriteString("NAME                 | TIMES_PLAYED\n")
 	b.WriteString("---------------------+-------------\n")
 	for _, s := range songs {
@@ -66,43 +188,303 @@ func tableSongs(songs []*data.Song) string {
 	return b.String()
 }
 
+// tableCompare lays COMPARE songs out side by side, one column per song.
+func tableCompare(songs []*data.Song) string {
+	if len(songs) == 0 {
+		return "No songs found."
+	}
+	date := func(t time.Time) string {
+		if t.IsZero() {
+			return "-"
+		}
+		return t.Format("2006-01-02")
+	}
+	rows := [][]string{{""}, {"PLAYS"}, {"FIRST PLAYED"}, {"LAST PLAYED"}, {"AVG LENGTH"}}
+	for _, s := range songs {
+		rows[0] = append(rows[0], truncate(s.Name, 30))
+		rows[1] = append(rows[1], fmt.Sprint(s.TimesPlayed))
+		rows[2] = append(rows[2], date(s.FirstPlayed))
+		rows[3] = append(rows[3], date(s.LastPlayed))
+		rows[4] = append(rows[4], formatLength(s.AvgLength))
+	}
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+	var b strings.Builder
+	for r, row := range rows {
+		for i, cell := range row {
+			if i > 0 {
+				b.WriteString(" | ")
+			}
+			if i == len(row)-1 {
+				b.WriteString(cell)
+			} else {
+				fmt.Fprintf(&b, "%-*s", widths[i], cell)
+			}
+		}
+		b.WriteString("\n")
+		if r == 0 {
+			for i, w := range widths {
+				if i > 0 {
+					b.WriteString("-+-")
+				}
+				b.WriteString(strings.Repeat("-", w))
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+func tableVenues(venues []*data.Venue) string {
+	if len(venues) == 0 {
+		return "No venues found."
+	}
+	var b strings.Builder
+	b.WriteString("VENUE                          | CITY                     | STATE | SHOWS\n")
+	b.WriteString("-------------------------------+--------------------------+-------+------\n")
+	for _, v := range venues {
+		fmt.Fprintf(&b, "%-30s | %-24s | %-5s | %d\n", truncate(v.Name, 30), truncate(v.City, 24), truncate(v.State, 5), v.ShowCount)
+	}
+	return b.String()
+}
+
+func tableVenueStats(venues []*data.Venue) string {
+	if len(venues) == 0 {
+		return "No venues found."
+	}
+	var b strings.Builder
+	b.WriteString("VENUE                          | CITY                     | STATE | SHOWS | FIRST      | LAST\n")
+	b.WriteString("-------------------------------+--------------------------+-------+-------+------------+-----------\n")
+	for _, v := range venues {
+		fmt.Fprintf(&b, "%-30s | %-24s | %-5s | %-5d | %s | %s\n",
+			truncate(v.Name, 30), truncate(v.City, 24), truncate(v.State, 5), v.ShowCount, v.FirstShow.Format("2006-01-02"), v.LastShow.Format("2006-01-02"))
+	}
+	fmt.Fprintf(&b, "— %s", plural(len(venues), "venue", "venues"))
+	return b.String()
+}
+
+func tableRuns(runs []*executor.RunResult) string {
+	if len(runs) == 0 {
+		return "No runs found."
+	}
+	var b strings.Builder
+	b.WriteString("FROM       | TO         | NIGHTS | SHOWS | VENUE                          | CITY\n")
+	b.WriteString("-----------+------------+--------+-------+--------------------------------+-------------------------\n")
+	for _, r := range runs {
+		fmt.Fprintf(&b, "%s | %s | %-6d | %-5d | %-30s | %s\n",
+			r.Start.Format("2006-01-02"), r.End.Format("2006-01-02"), r.Nights, r.Shows, truncate(r.Venue, 30), truncate(r.City, 24))
+	}
+	fmt.Fprintf(&b, "— %s", plural(len(runs), "run", "runs"))
+	return b.String()
+}
+
+// tableCoverage lists each year's shows against the expected total, with the
+// whole range summed in the footer.
+func tableCoverage(years []*executor.CoverageResult) string {
+	if len(years) == 0 {
+		return "No years in range."
+	}
+	var b strings.Builder
+	b.WriteString("YEAR | HAVE | EXPECTED | PERCENT\n")
+	b.WriteString("-----+------+----------+--------\n")
+	have, expected := 0, 0
+	for _, y := range years {
+		fmt.Fprintf(&b, "%d | %4d | %8d | %6.1f%%\n", y.Year, y.Have, y.Expected, y.Percent)
+		have += y.Have
+		expected += y.Expected
+	}
+	fmt.Fprintf(&b, "— %s, %d of %d shows", plural(len(years), "year", "years"), have, expected)
+	if expected > 0 {
+		fmt.Fprintf(&b, " (%.1f%%)", 100*float64(have)/float64(expected))
+	}
+	return b.String()
+}
+
+// tableGaps lists the runs of days with no show, with the missing days summed
+// in the footer.
+func tableGaps(gaps []*executor.GapResult) string {
+	if len(gaps) == 0 {
+		return "No gaps found."
+	}
+	var b strings.Builder
+	b.WriteString("FROM       | TO         | DAYS\n")
+	b.WriteString("-----------+------------+-----\n")
+	days := 0
+	for _, g := range gaps {
+		fmt.Fprintf(&b, "%s | %s | %4d\n", g.Start.Format("2006-01-02"), g.End.Format("2006-01-02"), g.Days)
+		days += g.Days
+	}
+	fmt.Fprintf(&b, "— %s, %s without a show", plural(len(gaps), "gap", "gaps"), plural(days, "day", "days"))
+	return b.String()
+}
+
+// tableYears lists performances per year, with a sparkline of the whole span
+// in the footer.
+func tableYears(years []*executor.YearCount) string {
+	if len(years) == 0 {
+		return "No performances found."
+	}
+	var b strings.Builder
+	b.WriteString("YEAR | COUNT\n")
+	b.WriteString("-----+------\n")
+	total := 0
+	counts := make([]int, len(years))
+	for i, y := range years {
+		fmt.Fprintf(&b, "%d | %5d\n", y.Year, y.Count)
+		total += y.Count
+		counts[i] = y.Count
+	}
+	fmt.Fprintf(&b, "— %s, %d–%d %s", plural(total, "performance", "performances"), years[0].Year, years[len(years)-1].Year, sparkline(counts))
+	return b.String()
+}
+
+// sparkBlocks are the sparkline bar heights, lowest first; zero is a space.
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline draws one bar per value, scaled so the largest is a full block.
+// Zero values are blank, so gaps stand out.
+func sparkline(values []int) string {
+	max := 0
+	for _, v := range values {
+		if v > max {
+			max = v
+		}
+	}
+	out := make([]rune, len(values))
+	for i, v := range values {
+		switch {
+		case v <= 0 || max == 0:
+			out[i] = ' '
+		default:
+			out[i] = sparkBlocks[(v*len(sparkBlocks)-1)/max]
+		}
+	}
+	return string(out)
+}
+
 func tablePerformances(perfs []*data.Performance) string {
 	if len(perfs) == 0 {
 		return "No performances found."
 	}
-	// Check if any performance has length data
-	hasLength := false
+	// Check if any performance has length / nth-time-played data, or if several songs are mixed
+	hasLength, hasNth, hasSongs, hasContext := false, false, false, withContext(perfs)
 	for _, p := range perfs {
+		if p.SongID != perfs[0].SongID {
+			hasSongs = true
+		}
 		if p.LengthSeconds > 0 {
 			hasLength = true
-			break
 		}
+		if p.Nth > 0 {
+			hasNth = true
+		}
+	}
+	// nthCol prefixes each row with "#112 | " when the query numbered performances
+	nthCol := func(p *data.Performance) string {
+		if !hasNth {
+			return ""
+		}
+		return fmt.Sprintf("%6s | ", fmt.Sprintf("#%d", p.Nth))
+	}
+	// songCol prefixes each row with the song name when performances span several songs
+	songCol := func(p *data.Performance) string {
+		if !hasSongs {
+			return ""
+		}
+		return fmt.Sprintf("%-20s | ", truncate(p.SongName, 20))
+	}
+	// ctxCols appends " | Scarlet Begonias >   | >> Help on the Way" for WITH
+	// CONTEXT: the songs before and after, with the segues joining them
+	ctxHead, ctxRule := "", ""
+	if hasContext {
+		ctxHead, ctxRule = " | BEFORE               | AFTER", "-+----------------------+----------------------"
+	}
+	ctxCols := func(p *data.Performance) string {
+		if !hasContext {
+			return ""
+		}
+		prev, next := orDash(p.PrevSong), orDash(p.NextSong)
+		if p.PrevSegue != "" && p.PrevSong != "" {
+			prev = truncate(p.PrevSong, 20-len(p.PrevSegue)-1) + " " + p.PrevSegue
+		}
+		if p.SegueType != "" && p.NextSong != "" {
+			next = p.SegueType + " " + p.NextSong
+		}
+		return fmt.Sprintf(" | %-20s | %s", truncate(prev, 20), truncate(next, 22))
 	}
 	var b strings.Builder
+	if hasSongs {
+		b.WriteString("SONG                 | ")
+	}
+	if hasNth {
+		b.WriteString("     # | ")
+	}
 	if hasLength {
-		b.WriteString("SHOW_ID | SET | POS | SEGUE | LENGTH\n")
-		b.WriteString("--------+-----+-----+-------+-------\n")
+		b.WriteString("SHOW_ID | SET | POS | SEGUE | LENGTH" + ctxHead + "\n")
+		if hasSongs {
+			b.WriteString("---------------------+-")
+		}
+		if hasNth {
+			b.WriteString("-------+-")
+		}
+		b.WriteString("--------+-----+-----+-------+-------" + ctxRule + "\n")
 		for _, p := range perfs {
 			seg := p.SegueType
 			if seg == "" {
 				seg = "-"
 			}
-			fmt.Fprintf(&b, "%7d | %3d | %3d | %-5s | %s\n", p.ShowID, p.SetNumber, p.Position, seg, formatLength(p.LengthSeconds))
+			length := formatLength(p.LengthSeconds)
+			if hasContext {
+				length = fmt.Sprintf("%-6s", length)
+			}
+			fmt.Fprintf(&b, "%s%s%7d | %3d | %3d | %-5s | %s%s\n", songCol(p), nthCol(p), p.ShowID, p.SetNumber, p.Position, seg, length, ctxCols(p))
 		}
 	} else {
-		b.WriteString("SHOW_ID | SET | POS | SEGUE\n")
-		b.WriteString("--------+-----+-----+------\n")
+		b.WriteString("SHOW_ID | SET | POS | SEGUE" + ctxHead + "\n")
+		if hasSongs {
+			b.WriteString("---------------------+-")
+		}
+		if hasNth {
+			b.WriteString("-------+-")
+		}
+		b.WriteString("--------+-----+-----+------" + ctxRule + "\n")
 		for _, p := range perfs {
 			seg := p.SegueType
 			if seg == "" {
 				seg = "-"
 			}
-			fmt.Fprintf(&b, "%7d | %3d | %3d | %s\n", p.ShowID, p.SetNumber, p.Position, seg)
+			if hasContext {
+				seg = fmt.Sprintf("%-5s", seg)
+			}
+			fmt.Fprintf(&b, "%s%s%7d | %3d | %3d | %s%s\n", songCol(p), nthCol(p), p.ShowID, p.SetNumber, p.Position, seg, ctxCols(p))
 		}
 	}
+	b.WriteString(perfsSummary(perfs) + "\n")
 	return b.String()
 }
 
+// withContext reports whether perfs carry WITH CONTEXT neighbours.
+func withContext(perfs []*data.Performance) bool {
+	for _, p := range perfs {
+		if p.PrevSong != "" || p.NextSong != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// orDash is s, or "-" when s is empty.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
 func formatLength(seconds int) string {
 	if seconds <= 0 {
 		return "-"
@@ -110,14 +492,38 @@ func formatLength(seconds int) string {
 	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
 }
 
-func tableSetlist(sl *executor.SetlistResult) string {
+// formatRating renders a show's combined rating and its vote count, e.g.
+// "4.8 (412)"; "-" when the show has no rating sources.
+func formatRating(s *data.Show) string {
+	if s.Rating == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f (%d)", *s.Rating, s.RatingCount)
+}
+
+// formatShowLength renders a show's total length as h:mm:ss ("-" when unknown).
+func formatShowLength(seconds int) string {
+	if seconds <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds%3600/60, seconds%60)
+}
+
+// tableSetlist lays a setlist out one song per row. Song names longer than
+// the column are cut with an ellipsis, or with opts.Wrap continued on extra
+// rows that leave the other columns blank.
+func tableSetlist(sl *executor.SetlistResult, opts Options) string {
 	if sl == nil || len(sl.Performances) == 0 {
 		return "No setlist."
 	}
+	width := opts.SongWidth
+	if width <= 0 {
+		width = DefaultSongWidth
+	}
 	var b strings.Builder
 	fmt.Fprintf(&b, "Setlist for %s (show_id=%d)\n\n", sl.Date.Format("2006-01-02"), sl.ShowID)
 	b.WriteString("SET | POS | SEGUE | SONG\n")
-	b.WriteString("----+-----+-------+----------------------------\n")
+	b.WriteString("----+-----+-------+" + strings.Repeat("-", width) + "\n")
 	for _, p := range sl.Performances {
 		seg := p.SegueType
 		if seg == "" {
@@ -127,12 +533,32 @@ func tableSetlist(sl *executor.SetlistResult) string {
 		if name == "" {
 			name = "?"
 		}
-		name = truncate(name, 28)
-		fmt.Fprintf(&b, "%3d | %3d | %-5s | %s\n", p.SetNumber, p.Position, seg, name)
+		lines := []string{name}
+		switch {
+		case opts.Wrap:
+			lines = wrapText(name, width)
+		case utf8.RuneCountInString(name) > width:
+			lines[0] = truncate(name, width-1) + "…"
+		}
+		fmt.Fprintf(&b, "%3d | %3d | %-5s | %s\n", p.SetNumber, p.Position, seg, lines[0])
+		for _, l := range lines[1:] {
+			fmt.Fprintf(&b, "    |     |       | %s\n", l)
+		}
 	}
 	return b.String()
 }
 
+// unknownVenue stands in for a show with no venue (NULL venue_id) in table
+// and HTML columns; CSV, TSV, and JSON leave the field empty.
+const unknownVenue = "(unknown venue)"
+
+func venueOrUnknown(name string) string {
+	if name == "" {
+		return unknownVenue
+	}
+	return name
+}
+
 func truncate(s string, max int) string {
 	runes := []rune(s)
 	if len(runes) <= max {
@@ -140,3 +566,35 @@ func truncate(s string, max int) string {
 	}
 	return string(runes[:max])
 }
+
+// wrapText splits s into lines of at most width runes, breaking between words
+// where it can and inside a word only when the word alone is too long.
+func wrapText(s string, width int) []string {
+	var lines []string
+	var line []rune
+	for _, word := range strings.Fields(s) {
+		w := []rune(word)
+		for len(w) > width {
+			if len(line) > 0 {
+				lines = append(lines, string(line))
+				line = nil
+			}
+			lines = append(lines, string(w[:width]))
+			w = w[width:]
+		}
+		switch {
+		case len(w) == 0:
+		case len(line) == 0:
+			line = w
+		case len(line)+1+len(w) <= width:
+			line = append(append(line, ' '), w...)
+		default:
+			lines = append(lines, string(line))
+			line = w
+		}
+	}
+	if len(line) > 0 || len(lines) == 0 {
+		lines = append(lines, string(line))
+	}
+	return lines
+}
@@ -1,6 +1,7 @@
 package formatter
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -35,6 +36,122 @@ func TestTablePerformances_ShowsLengthWhenPresent(t *testing.T) {
 	require.Contains(t, out, "-") // zero length shown as "-"
 }
 
+func TestTablePerformances_ShowsNthWhenPresent(t *testing.T) {
+	perfs := []*data.Performance{
+		{ShowID: 2, SetNumber: 1, Position: 1, Nth: 1},
+		{ShowID: 1, SetNumber: 1, Position: 1, Nth: 112},
+	}
+	out, err := formatTable(&executor.Result{Type: executor.ResultPerformances, Performances: perfs})
+	require.NoError(t, err)
+	require.Contains(t, out, "  #112 | ")
+	require.Contains(t, out, "    #1 | ")
+}
+
+func TestTablePerformances_WithContext(t *testing.T) {
+	perfs := []*data.Performance{
+		{ShowID: 1, SetNumber: 2, Position: 2, PrevSong: "Scarlet Begonias", PrevSegue: ">", NextSong: "Estimated Prophet"},
+		{ShowID: 2, SetNumber: 2, Position: 3, SegueType: ">", PrevSong: "Scarlet Begonias", NextSong: "Help on the Way"},
+		{ShowID: 3, SetNumber: 1, Position: 1},
+	}
+	out, err := formatTable(&executor.Result{Type: executor.ResultPerformances, Performances: perfs})
+	require.NoError(t, err)
+	require.Contains(t, out, "BEFORE")
+	require.Contains(t, out, "Scarlet Begonias >")
+	require.Contains(t, out, "| Estimated Prophet")
+	require.Contains(t, out, "> Help on the Way")
+
+	out, err = formatTable(&executor.Result{Type: executor.ResultPerformances, Performances: perfs[2:]})
+	require.NoError(t, err)
+	require.NotContains(t, out, "BEFORE", "no context columns without WITH CONTEXT")
+}
+
+func TestTablePerformances_ShowsSongWhenMixed(t *testing.T) {
+	perfs := []*data.Performance{
+		{ShowID: 1, SongID: 1, SongName: "Scarlet Begonias", SetNumber: 2, Position: 1},
+		{ShowID: 1, SongID: 2, SongName: "Fire on the Mountain", SetNumber: 2, Position: 2},
+	}
+	out, err := formatTable(&executor.Result{Type: executor.ResultPerformances, Performances: perfs})
+	require.NoError(t, err)
+	require.Contains(t, out, "SONG ")
+	require.Contains(t, out, "Fire on the Mountain | ")
+
+	// Single-song results keep the compact layout
+	out, err = formatTable(&executor.Result{Type: executor.ResultPerformances, Performances: perfs[:1]})
+	require.NoError(t, err)
+	require.NotContains(t, out, "SONG ")
+}
+
+func TestTableShows_LengthColumnWhenKnown(t *testing.T) {
+	shows := []*data.Show{{Venue: "Barton Hall", LengthSeconds: 3980}, {Venue: "Capital Centre"}}
+	out, err := formatTable(&executor.Result{Type: executor.ResultShows, Shows: shows})
+	require.NoError(t, err)
+	require.Contains(t, out, "| LENGTH")
+	require.Contains(t, out, "| 1:06:20")
+
+	out, err = formatTable(&executor.Result{Type: executor.ResultShows, Shows: shows[1:]})
+	require.NoError(t, err)
+	require.NotContains(t, out, "LENGTH")
+}
+
+func TestTableShows_UnknownVenue(t *testing.T) {
+	shows := []*data.Show{{Date: time.Date(1969, 2, 27, 0, 0, 0, 0, time.UTC)}}
+	out, err := formatTable(&executor.Result{Type: executor.ResultShows, Shows: shows})
+	require.NoError(t, err)
+	require.Contains(t, out, "1969-02-27 | (unknown venue)")
+
+	// Machine-readable formats keep the field empty.
+	out, err = formatCSV(&executor.Result{Type: executor.ResultShows, Shows: shows})
+	require.NoError(t, err)
+	require.NotContains(t, out, unknownVenue)
+}
+
+func TestTableShows_MatchColumnForSegues(t *testing.T) {
+	shows := []*data.Show{{Venue: "Barton Hall", MatchSet: 2, MatchPosition: 1}}
+	out, err := formatTable(&executor.Result{Type: executor.ResultShows, Shows: shows})
+	require.NoError(t, err)
+	require.Contains(t, out, "| MATCH")
+	require.Contains(t, out, "| set 2, #1")
+
+	out, err = formatTable(&executor.Result{Type: executor.ResultShows, Shows: []*data.Show{{Venue: "Barton Hall"}}})
+	require.NoError(t, err)
+	require.NotContains(t, out, "MATCH")
+}
+
+func TestTableShows_SummaryFooter(t *testing.T) {
+	shows := []*data.Show{{Venue: "Barton Hall"}, {Venue: "Capital Centre"}}
+	out, err := formatTable(&executor.Result{Type: executor.ResultShows, Shows: shows})
+	require.NoError(t, err)
+	require.True(t, strings.HasSuffix(out, "— 2 shows\n"), out)
+
+	out, err = formatTable(&executor.Result{Type: executor.ResultShows, Shows: shows[:1]})
+	require.NoError(t, err)
+	require.True(t, strings.HasSuffix(out, "— 1 show\n"), out)
+}
+
+func TestTablePerformances_SummaryFooter(t *testing.T) {
+	perfs := []*data.Performance{
+		{ShowID: 1, LengthSeconds: 580},
+		{ShowID: 2, LengthSeconds: 0}, // unknown lengths don't drag the average down
+		{ShowID: 3, LengthSeconds: 1640},
+	}
+	out, err := formatTable(&executor.Result{Type: executor.ResultPerformances, Performances: perfs})
+	require.NoError(t, err)
+	require.True(t, strings.HasSuffix(out, "— 3 performances, avg 18:30 (min 9:40, max 27:20)\n"), out)
+
+	out, err = formatTable(&executor.Result{Type: executor.ResultPerformances, Performances: perfs[1:2]})
+	require.NoError(t, err)
+	require.True(t, strings.HasSuffix(out, "— 1 performance\n"), out)
+}
+
+func TestTableSongs_AvgLengthColumn(t *testing.T) {
+	out := tableSongs([]*data.Song{{Name: "Dark Star", TimesPlayed: 228}})
+	require.NotContains(t, out, "AVG_LENGTH")
+
+	out = tableSongs([]*data.Song{{Name: "Dark Star", TimesPlayed: 228, AvgLength: 1410}})
+	require.Contains(t, out, "AVG_LENGTH")
+	require.Contains(t, out, "23:30")
+}
+
 func TestFormatLength(t *testing.T) {
 	require.Equal(t, "-", formatLength(0))
 	require.Equal(t, "9:40", formatLength(580))
@@ -130,6 +247,17 @@ func TestFormatCSV_Shows(t *testing.T) {
 	require.Contains(t, out, "Ithaca")
 }
 
+func TestFormatCSV_ShowsSourceURL(t *testing.T) {
+	result := &executor.Result{
+		Type:  executor.ResultShows,
+		Shows: []*data.Show{{ID: 1, Date: time.Date(1977, 5, 8, 0, 0, 0, 0, time.UTC), Venue: "Barton Hall", SourceURL: "https://www.setlist.fm/setlist/x.html"}},
+	}
+	out, err := formatCSV(result)
+	require.NoError(t, err)
+	require.Equal(t, "id,date,venue,city,state,tour,source_url\n"+
+		"1,1977-05-08,Barton Hall,,,,https://www.setlist.fm/setlist/x.html\n", out)
+}
+
 func TestFormat_CalendarReturnsError(t *testing.T) {
 	f := New()
 	result := &executor.Result{Type: executor.ResultShows}
@@ -256,6 +384,21 @@ func TestFormatSetlist_Basic(t *testing.T) {
 	require.Contains(t, out, "Scarlet Begonias")
 	require.Contains(t, out, "Fire on the Mountain")
 	require.Contains(t, out, "1977")
+	require.NotContains(t, out, "Source:")
+}
+
+func TestFormatSetlist_SourceFooter(t *testing.T) {
+	result := &executor.Result{
+		Type: executor.ResultSetlist,
+		Setlist: &executor.SetlistResult{
+			Date:         time.Date(1977, 5, 8, 0, 0, 0, 0, time.UTC),
+			SourceURL:    "https://www.setlist.fm/setlist/x.html",
+			Performances: []*data.Performance{{SetNumber: 1, Position: 1, SongName: "Loser"}},
+		},
+	}
+	out, err := formatSetlist(result)
+	require.NoError(t, err)
+	require.True(t, strings.HasSuffix(out, "  1.Loser\n\nSource: https://www.setlist.fm/setlist/x.html"), out)
 }
 
 func TestFormatSetlist_FallsBackForNonSetlist(t *testing.T) {
@@ -266,11 +409,12 @@ func TestFormatSetlist_FallsBackForNonSetlist(t *testing.T) {
 }
 
 func TestFmtSetName(t *testing.T) {
-	require.Equal(t, "Set 1", fmtSetName(1))
-	require.Equal(t, "Set 2", fmtSetName(2))
-	require.Equal(t, "Set 3 / Encore", fmtSetName(3))
-	require.Equal(t, "Soundcheck", fmtSetName(0))
-	require.Equal(t, "Set 7", fmtSetName(7))
+	require.Equal(t, "Set 1", fmtSetName(1, ""))
+	require.Equal(t, "Set 2", fmtSetName(2, ""))
+	require.Equal(t, "Set 3 / Encore", fmtSetName(3, ""))
+	require.Equal(t, "Soundcheck", fmtSetName(0, ""))
+	require.Equal(t, "Set 7", fmtSetName(7, ""))
+	require.Equal(t, "Acoustic Set", fmtSetName(1, "Acoustic Set"))
 }
 
 // === Table setlist ===
@@ -293,6 +437,49 @@ func TestTableSetlist(t *testing.T) {
 	require.Contains(t, out, "Minglewood Blues")
 }
 
+// longTitle is longer than any sensible song column.
+const longTitle = "Good Lovin' (reprise) with the Mighty Quinn Jam and Lovelight Tease"
+
+func TestTableSetlist_LongTitles(t *testing.T) {
+	result := &executor.Result{Type: executor.ResultSetlist, Setlist: &executor.SetlistResult{
+		Date: time.Date(1987, 7, 4, 0, 0, 0, 0, time.UTC),
+		Performances: []*data.Performance{
+			{SetNumber: 2, Position: 1, SongName: longTitle},
+			{SetNumber: 2, Position: 2, SongName: "Morning Dew"},
+		},
+	}}
+
+	out, err := formatTable(result)
+	require.NoError(t, err)
+	require.Contains(t, out, "  2 |   1 | -     | Good Lovin' (reprise) with …\n")
+	require.Contains(t, out, "  2 |   2 | -     | Morning Dew\n")
+
+	out, err = NewWithOptions(Options{SongWidth: 40}).Format(result, FormatTable)
+	require.NoError(t, err)
+	require.Contains(t, out, "| Good Lovin' (reprise) with the Mighty Q…\n")
+	require.Contains(t, out, "-------+"+strings.Repeat("-", 40)+"\n")
+
+	out, err = NewWithOptions(Options{SongWidth: 24, Wrap: true}).Format(result, FormatTable)
+	require.NoError(t, err)
+	require.Contains(t, out, "  2 |   1 | -     | Good Lovin' (reprise)\n"+
+		"    |     |       | with the Mighty Quinn\n"+
+		"    |     |       | Jam and Lovelight Tease\n"+
+		"  2 |   2 | -     | Morning Dew\n")
+
+	// The plain setlist never shortens names.
+	out, err = formatSetlist(result)
+	require.NoError(t, err)
+	require.Contains(t, out, "1."+longTitle+"\n")
+}
+
+func TestWrapText(t *testing.T) {
+	require.Equal(t, []string{"Dark Star"}, wrapText("Dark Star", 20))
+	require.Equal(t, []string{"Scarlet", "Begonias"}, wrapText("Scarlet Begonias", 10))
+	require.Equal(t, []string{"Supercalifr", "agilistic"}, wrapText("Supercalifragilistic", 11))
+	require.Equal(t, []string{"Ça Très", "Bien"}, wrapText("Ça Très Bien", 7), "widths count runes, not bytes")
+	require.Equal(t, []string{""}, wrapText("", 10))
+}
+
 func TestTableSongs(t *testing.T) {
 	result := &executor.Result{
 		Type: executor.ResultSongs,
@@ -354,3 +541,70 @@ func TestFormatSetlist_MultiShow(t *testing.T) {
 	require.Contains(t, out, "Set 2")
 	require.Contains(t, out, "---") // separator between shows
 }
+
+func TestTableCompare(t *testing.T) {
+	require.Equal(t, "No songs found.", tableCompare(nil))
+	out := tableCompare([]*data.Song{
+		{Name: "Dark Star", TimesPlayed: 2, AvgLength: 1410,
+			FirstPlayed: time.Date(1977, 2, 26, 0, 0, 0, 0, time.UTC), LastPlayed: time.Date(1977, 5, 8, 0, 0, 0, 0, time.UTC)},
+		{Name: "Unplayed"},
+	})
+	require.Equal(t, ""+
+		"             | Dark Star  | Unplayed\n"+
+		"-------------+------------+---------\n"+
+		"PLAYS        | 2          | 0\n"+
+		"FIRST PLAYED | 1977-02-26 | -\n"+
+		"LAST PLAYED  | 1977-05-08 | -\n"+
+		"AVG LENGTH   | 23:30      | -\n", out)
+}
+
+func TestTableVenueStats(t *testing.T) {
+	require.Equal(t, "No venues found.", tableVenueStats(nil))
+	out := tableVenueStats([]*data.Venue{{
+		Name: "Winterland Arena", City: "San Francisco", State: "CA", ShowCount: 48,
+		FirstShow: time.Date(1971, 11, 7, 0, 0, 0, 0, time.UTC), LastShow: time.Date(1978, 12, 31, 0, 0, 0, 0, time.UTC),
+	}})
+	require.Contains(t, out, "Winterland Arena")
+	require.Contains(t, out, "| 48    | 1971-11-07 | 1978-12-31")
+	require.True(t, strings.HasSuffix(out, "— 1 venue"))
+}
+
+func TestTableRuns(t *testing.T) {
+	require.Equal(t, "No runs found.", tableRuns(nil))
+	out := tableRuns([]*executor.RunResult{{
+		Venue: "Winterland Arena", City: "San Francisco",
+		Start: time.Date(1977, 12, 29, 0, 0, 0, 0, time.UTC), End: time.Date(1977, 12, 31, 0, 0, 0, 0, time.UTC),
+		Nights: 3, Shows: 3,
+	}})
+	require.Contains(t, out, "1977-12-29 | 1977-12-31 | 3")
+	require.Contains(t, out, "Winterland Arena")
+	require.True(t, strings.HasSuffix(out, "— 1 run"))
+}
+
+func TestTableYears(t *testing.T) {
+	require.Equal(t, "No performances found.", tableYears(nil))
+	out := tableYears([]*executor.YearCount{{Year: 1973, Count: 8}, {Year: 1974, Count: 4}, {Year: 1975}, {Year: 1976, Count: 1}})
+	require.Contains(t, out, "1973 |     8\n1974 |     4\n1975 |     0\n")
+	require.True(t, strings.HasSuffix(out, "— 13 performances, 1973–1976 █▄ ▁"), out)
+}
+
+func TestTableGaps(t *testing.T) {
+	require.Equal(t, "No gaps found.", tableGaps(nil))
+	out := tableGaps([]*executor.GapResult{
+		{Start: time.Date(1977, 5, 9, 0, 0, 0, 0, time.UTC), End: time.Date(1977, 5, 10, 0, 0, 0, 0, time.UTC), Days: 2},
+		{Start: time.Date(1977, 5, 12, 0, 0, 0, 0, time.UTC), End: time.Date(1977, 5, 12, 0, 0, 0, 0, time.UTC), Days: 1},
+	})
+	require.Contains(t, out, "1977-05-09 | 1977-05-10 |    2")
+	require.True(t, strings.HasSuffix(out, "— 2 gaps, 3 days without a show"), out)
+}
+
+func TestTableCoverage(t *testing.T) {
+	require.Equal(t, "No years in range.", tableCoverage(nil))
+	out := tableCoverage([]*executor.CoverageResult{
+		{Year: 1977, Have: 57, Expected: 60, Percent: 95},
+		{Year: 1978, Have: 20, Expected: 80, Percent: 25},
+	})
+	require.Contains(t, out, "1977 |   57 |       60 |   95.0%")
+	require.Contains(t, out, "1978 |   20 |       80 |   25.0%")
+	require.True(t, strings.HasSuffix(out, "— 2 years, 77 of 140 shows (55.0%)"), out)
+}
@@ -1,7 +1,9 @@
 package formatter
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/gdql/gdql/internal/executor"
@@ -12,47 +14,105 @@ import (
 // Google Sheets, or any tool that prefers tabs over comma escaping.
 func formatTSV(result *executor.Result) (string, error) {
 	var b strings.Builder
+	err := writeTSV(&b, result)
+	return b.String(), err
+}
+
+// writeTSV streams the result to out as TSV, one line per row.
+func writeTSV(out io.Writer, result *executor.Result) error {
+	b := bufio.NewWriter(out)
 	switch result.Type {
 	case executor.ResultShows:
-		writeTSVRow(&b, "id", "date", "venue", "city", "state", "tour")
+		writeTSVRow(b, "id", "date", "venue", "city", "state", "tour", "source_url")
 		for _, s := range result.Shows {
-			writeTSVRow(&b,
+			writeTSVRow(b,
 				fmt.Sprint(s.ID), s.Date.Format("2006-01-02"),
-				s.Venue, s.City, s.State, s.Tour,
+				s.Venue, s.City, s.State, s.Tour, s.SourceURL,
 			)
 		}
 	case executor.ResultSongs:
-		writeTSVRow(&b, "id", "name", "short_name", "writers", "times_played")
+		writeTSVRow(b, "id", "name", "short_name", "writers", "times_played")
 		for _, s := range result.Songs {
-			writeTSVRow(&b, fmt.Sprint(s.ID), s.Name, s.ShortName, s.Writers, fmt.Sprint(s.TimesPlayed))
+			writeTSVRow(b, fmt.Sprint(s.ID), s.Name, s.ShortName, s.Writers, fmt.Sprint(s.TimesPlayed))
 		}
 	case executor.ResultPerformances:
-		writeTSVRow(&b, "id", "show_id", "song_id", "set_number", "position", "segue_type", "length_seconds")
+		header := []string{"id", "show_id", "song_id", "set_number", "position", "segue_type", "length_seconds"}
+		ctx := withContext(result.Performances)
+		if ctx {
+			header = append(header, "prev_song", "prev_segue", "next_song")
+		}
+		writeTSVRow(b, header...)
 		for _, p := range result.Performances {
-			writeTSVRow(&b,
+			rec := []string{
 				fmt.Sprint(p.ID), fmt.Sprint(p.ShowID), fmt.Sprint(p.SongID),
 				fmt.Sprint(p.SetNumber), fmt.Sprint(p.Position), p.SegueType, fmt.Sprint(p.LengthSeconds),
-			)
+			}
+			if ctx {
+				rec = append(rec, p.PrevSong, p.PrevSegue, p.NextSong)
+			}
+			writeTSVRow(b, rec...)
 		}
 	case executor.ResultSetlist:
-		if result.Setlist != nil {
-			writeTSVRow(&b, "set_number", "position", "segue_type", "length_seconds")
+		if result.Setlist == nil && len(result.Setlists) > 0 {
+			writeTSVRow(b, "show_id", "date", "set_number", "position", "segue_type", "length_seconds")
+			for _, sl := range result.Setlists {
+				for _, p := range sl.Performances {
+					writeTSVRow(b, fmt.Sprint(sl.ShowID), sl.Date.Format("2006-01-02"), fmt.Sprint(p.SetNumber), fmt.Sprint(p.Position), p.SegueType, fmt.Sprint(p.LengthSeconds))
+				}
+			}
+		} else if result.Setlist != nil {
+			writeTSVRow(b, "set_number", "position", "segue_type", "length_seconds")
 			for _, p := range result.Setlist.Performances {
-				writeTSVRow(&b, fmt.Sprint(p.SetNumber), fmt.Sprint(p.Position), p.SegueType, fmt.Sprint(p.LengthSeconds))
+				writeTSVRow(b, fmt.Sprint(p.SetNumber), fmt.Sprint(p.Position), p.SegueType, fmt.Sprint(p.LengthSeconds))
 			}
 		}
 	case executor.ResultCount:
 		if result.Count != nil {
-			writeTSVRow(&b, "song", "count")
-			writeTSVRow(&b, result.Count.SongName, fmt.Sprint(result.Count.Count))
+			writeTSVRow(b, "song", "count")
+			writeTSVRow(b, result.Count.SongName, fmt.Sprint(result.Count.Count))
+		}
+	case executor.ResultVenues:
+		writeTSVRow(b, "id", "name", "city", "state", "country", "shows")
+		for _, v := range result.Venues {
+			writeTSVRow(b, fmt.Sprint(v.ID), v.Name, v.City, v.State, v.Country, fmt.Sprint(v.ShowCount))
+		}
+	case executor.ResultVenueStats:
+		writeTSVRow(b, "id", "name", "city", "state", "country", "shows", "first_show", "last_show")
+		for _, v := range result.Venues {
+			writeTSVRow(b, fmt.Sprint(v.ID), v.Name, v.City, v.State, v.Country, fmt.Sprint(v.ShowCount), v.FirstShow.Format("2006-01-02"), v.LastShow.Format("2006-01-02"))
+		}
+	case executor.ResultCompare:
+		writeTSVRow(b, "id", "name", "times_played", "first_played", "last_played", "avg_length_seconds")
+		for _, s := range result.Songs {
+			writeTSVRow(b, fmt.Sprint(s.ID), s.Name, fmt.Sprint(s.TimesPlayed), dateCell(s.FirstPlayed), dateCell(s.LastPlayed), fmt.Sprint(s.AvgLength))
+		}
+	case executor.ResultRuns:
+		writeTSVRow(b, "venue_id", "venue", "city", "state", "start", "end", "nights", "shows")
+		for _, r := range result.Runs {
+			writeTSVRow(b, fmt.Sprint(r.VenueID), r.Venue, r.City, r.State, r.Start.Format("2006-01-02"), r.End.Format("2006-01-02"), fmt.Sprint(r.Nights), fmt.Sprint(r.Shows))
+		}
+	case executor.ResultCoverage:
+		writeTSVRow(b, "year", "have", "expected", "percent")
+		for _, y := range result.Coverage {
+			writeTSVRow(b, fmt.Sprint(y.Year), fmt.Sprint(y.Have), fmt.Sprint(y.Expected), fmt.Sprint(y.Percent))
+		}
+	case executor.ResultYears:
+		writeTSVRow(b, "year", "count")
+		for _, y := range result.Years {
+			writeTSVRow(b, fmt.Sprint(y.Year), fmt.Sprint(y.Count))
+		}
+	case executor.ResultGaps:
+		writeTSVRow(b, "start", "end", "days")
+		for _, g := range result.Gaps {
+			writeTSVRow(b, g.Start.Format("2006-01-02"), g.End.Format("2006-01-02"), fmt.Sprint(g.Days))
 		}
 	}
-	return b.String(), nil
+	return b.Flush()
 }
 
 // writeTSVRow strips tabs and newlines from each cell so the row stays on one
 // line and one delimiter. Keeps the format brain-dead-simple to parse.
-func writeTSVRow(b *strings.Builder, cells ...string) {
+func writeTSVRow(b *bufio.Writer, cells ...string) {
 	for i, c := range cells {
 		if i > 0 {
 			b.WriteByte('\t')
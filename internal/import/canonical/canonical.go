@@ -3,8 +3,10 @@ package canonical
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"strings"
 
+	"github.com/gdql/gdql/internal/data"
 	"github.com/gdql/gdql/internal/import/shared"
 )
 
@@ -26,26 +28,89 @@ type Venue struct {
 	Country string `json:"country"`
 }
 
-// Set is one set (first set, second set, encore). Songs in order.
+// Set is one set (first set, second set, encore). Songs in order. Name is
+// the source's own name for the set (e.g. "Acoustic Set"), if it has one.
 type Set struct {
+	Name  string      `json:"name,omitempty"`
 	Songs []SongInSet `json:"songs"`
 }
 
 // SongInSet is one song in a set. SegueBefore true means ">" from previous.
+// An entry with a blank name is a song the source couldn't name; it isn't
+// stored, and the set is numbered without it (see Gap).
 type SongInSet struct {
 	Name          string `json:"name"`
 	SegueBefore   bool   `json:"segue_before"`
 	LengthSeconds int    `json:"length_seconds,omitempty"`
 }
 
+// Options tunes WriteShowsWithOptions.
+type Options struct {
+	// Progress (if non-nil) is called after each show is examined.
+	Progress shared.ProgressFunc
+}
+
 // WriteShows inserts shows into the DB. It creates venues and songs as needed,
 // skips shows that already exist (same date + venue), and returns (showsAdded, songsAdded).
 // Use this from setlist.fm, Archive.org, scrapers, or JSON/CSV import.
 func WriteShows(ctx context.Context, db *sql.DB, shows []Show) (showsAdded, songsAdded int, err error) {
+	return WriteShowsWithProgress(ctx, db, shows, nil)
+}
+
+// WriteShowsWithProgress is WriteShows with progress reporting: progress (if non-nil)
+// is called after each show is examined.
+func WriteShowsWithProgress(ctx context.Context, db *sql.DB, shows []Show, progress shared.ProgressFunc) (showsAdded, songsAdded int, err error) {
+	return WriteShowsWithOptions(ctx, db, shows, Options{Progress: progress})
+}
+
+// WriteShowsWithOptions is WriteShows with Options. Songs are numbered 1..n
+// within each set in array order.
+func WriteShowsWithOptions(ctx context.Context, db *sql.DB, shows []Show, opts Options) (showsAdded, songsAdded int, err error) {
+	sum, err := WriteShowsDetailed(ctx, db, shows, opts)
+	return sum.Shows, sum.Songs, err
+}
+
+// Summary is what WriteShowsDetailed did with a batch. Its counts mirror
+// Preview's, so a dry run and the real import can be compared.
+type Summary struct {
+	Shows      int // shows added
+	Songs      int // songs created
+	Venues     int // venues created
+	Aliases    int // raw names resolved to an existing song by a heuristic (case, punctuation, trailing "-"), each saved as a song alias
+	Duplicates int // shows already in the DB (same date and venue), not written
+	Skipped    int // shows with no usable date, not written
+	Gaps       []Gap
+}
+
+// Gap is a blank song entry found while numbering a set. Leaving its
+// position empty would break the set's sequence (segues are found by
+// position - 1), so the songs after it are numbered down to close it.
+type Gap struct {
+	Date     string // the show's date, as stored
+	Set      int    // set number as stored (extra encores fold into 3)
+	Position int    // the blank entry's position in the set as the source gave it
+}
+
+func (g Gap) String() string {
+	return fmt.Sprintf("%s set %d: no song at position %d, later songs renumbered", g.Date, g.Set, g.Position)
+}
+
+// WriteShowsDetailed is WriteShowsWithOptions returning the full breakdown.
+// The summary is never nil: on error it counts what was written before it.
+func WriteShowsDetailed(ctx context.Context, db *sql.DB, shows []Show, opts Options) (*Summary, error) {
+	return writeShows(ctx, db, shows, opts, nil)
+}
+
+// writeShows is the import loop behind WriteShowsDetailed and PreviewShows.
+// db may be a transaction. rec, if non-nil, also gets the names of the songs
+// created and of those resolved through a new alias.
+func writeShows(ctx context.Context, db shared.Conn, shows []Show, opts Options, rec *Preview) (*Summary, error) {
+	sum := &Summary{}
+	progress := opts.Progress
 	venueByKey := make(map[string]int64)
 	songByName, err := shared.LoadSongByName(db)
 	if err != nil {
-		return 0, 0, err
+		return sum, err
 	}
 	venueMax, _ := shared.MaxID(db, "venues")
 	showMax, _ := shared.MaxID(db, "shows")
@@ -56,137 +121,197 @@ func WriteShows(ctx context.Context, db *sql.DB, shows []Show) (showsAdded, song
 	nextSongID := songMax + 1
 	startSongID := nextSongID
 	nextPerfID := perfMax + 1
+	// finish fills in the songs count, which is kept as the ID range used.
+	finish := func(err error) (*Summary, error) {
+		sum.Songs = int(nextSongID - startSongID)
+		return sum, err
+	}
 
 	for i := range shows {
 		select {
 		case <-ctx.Done():
-			return showsAdded, int(nextSongID - startSongID), ctx.Err()
+			return finish(ctx.Err())
 		default:
 		}
+		if progress != nil && i > 0 {
+			progress(shared.Progress{Processed: i, Total: len(shows), ShowsAdded: sum.Shows, SongsAdded: int(nextSongID - startSongID)})
+		}
 		s := &shows[i]
+		venue := s.Venue
+		venue.State = data.NormalizeState(venue.State)
 		dateStr := normalizeDate(s.Date)
 		if dateStr == "" {
+			sum.Skipped++
 			continue
 		}
-		vkey := venueKey(s.Venue)
-		if shared.ShowExists(db, dateStr, s.Venue.Name, s.Venue.City, s.Venue.State, s.Venue.Country) {
+		vkey := venueKey(venue)
+		if shared.ShowExists(db, dateStr, venue.Name, venue.City, venue.State, venue.Country) {
+			sum.Duplicates++
 			continue
 		}
 		venueID, ok := venueByKey[vkey]
 		if !ok {
-			_, execErr := db.ExecContext(ctx, "INSERT INTO venues (id, name, city, state, country) VALUES (?, ?, ?, ?, ?)",
-				nextVenueID, s.Venue.Name, s.Venue.City, s.Venue.State, s.Venue.Country)
-			if execErr != nil {
-				return showsAdded, int(nextSongID - startSongID), execErr
+			venueID, ok = shared.FindVenueID(db, venue.Name, venue.City, venue.State, venue.Country)
+			if !ok {
+				_, execErr := db.ExecContext(ctx, "INSERT INTO venues (id, name, city, state, country) VALUES (?, ?, ?, ?, ?)",
+					nextVenueID, venue.Name, venue.City, venue.State, venue.Country)
+				if execErr != nil {
+					return finish(execErr)
+				}
+				venueID = nextVenueID
+				nextVenueID++
+				sum.Venues++
 			}
-			venueID = nextVenueID
 			venueByKey[vkey] = venueID
-			nextVenueID++
 		}
 		var exist int
 		if db.QueryRowContext(ctx, "SELECT 1 FROM shows WHERE date = ? AND venue_id = ? LIMIT 1", dateStr, venueID).Scan(&exist) == nil {
+			sum.Duplicates++
 			continue
 		}
 		_, err := db.ExecContext(ctx, "INSERT INTO shows (id, date, venue_id, tour, notes) VALUES (?, ?, ?, ?, ?)",
 			nextShowID, dateStr, venueID, shared.NullStr(s.Tour), shared.NullStr(s.Notes))
 		if err != nil {
-			return showsAdded, songsAdded, err
+			return finish(err)
 		}
 		showID := nextShowID
 		nextShowID++
-		showsAdded++
+		sum.Shows++
 
-		setNumber := 0
-		for _, set := range s.Sets {
-			setNumber++
-			if setNumber > 3 {
-				setNumber = 3
+		setNumber, position, sourcePos := 0, 0, 0
+		for si, set := range s.Sets {
+			// Sets past the third are extra encores folded into set 3; they
+			// continue its numbering so positions stay unique, and only the
+			// first song and the last of the folded set open and close it.
+			opensSet := setNumber < 3
+			if opensSet {
+				setNumber++
+				position, sourcePos = 0, 0
 			}
-			position := 0
-			for j, song := range set.Songs {
+			closesSet := setNumber < 3 || si == len(s.Sets)-1
+			songs := make([]SongInSet, 0, len(set.Songs))
+			for _, song := range set.Songs {
+				sourcePos++
+				if strings.TrimSpace(song.Name) == "" {
+					sum.Gaps = append(sum.Gaps, Gap{Date: dateStr, Set: setNumber, Position: sourcePos})
+					continue
+				}
+				songs = append(songs, song)
+			}
+			for j, song := range songs {
 				position++
-				rawName := strings.TrimSpace(song.Name)
-				songID, ok := resolveSong(ctx, db, rawName, songByName, nextSongID)
+				rawName := data.ComposeTitle(strings.TrimSpace(song.Name))
+				songID, viaAlias, ok := resolveSong(ctx, db, rawName, songByName)
+				if viaAlias {
+					sum.Aliases++
+					if rec != nil {
+						rec.Aliases[rawName] = songName(ctx, db, songID)
+					}
+				}
 				if !ok {
 					_, execErr := db.ExecContext(ctx, "INSERT INTO songs (id, name, times_played) VALUES (?, ?, 0)", nextSongID, rawName)
 					if execErr != nil {
-						return showsAdded, int(nextSongID - startSongID), execErr
+						return finish(execErr)
 					}
 					songID = nextSongID
 					songByName[rawName] = songID
 					nextSongID++
+					if rec != nil {
+						rec.NewSongs = append(rec.NewSongs, rawName)
+					}
 				}
 				segueType := ""
 				if song.SegueBefore {
 					segueType = ">"
 				}
 				isOpener := 0
-				if position == 1 {
+				if opensSet && j == 0 {
 					isOpener = 1
 				}
 				isCloser := 0
-				if j == len(set.Songs)-1 {
+				if closesSet && j == len(songs)-1 {
 					isCloser = 1
 				}
 				var lengthSec interface{}
 				if song.LengthSeconds > 0 {
 					lengthSec = song.LengthSeconds
 				}
-				_, execErr := db.ExecContext(ctx, "INSERT OR IGNORE INTO performances (id, show_id, song_id, set_number, position, segue_type, is_opener, is_closer, length_seconds) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
-					nextPerfID, showID, songID, setNumber, position, shared.NullStr(segueType), isOpener, isCloser, lengthSec)
+				res, execErr := db.ExecContext(ctx, "INSERT OR IGNORE INTO performances (id, show_id, song_id, set_number, position, segue_type, is_opener, is_closer, length_seconds, set_name) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+					nextPerfID, showID, songID, setNumber, position, shared.NullStr(segueType), isOpener, isCloser, lengthSec, shared.NullStr(strings.TrimSpace(set.Name)))
 				if execErr != nil {
-					return showsAdded, int(nextSongID - startSongID), execErr
+					return finish(execErr)
 				}
 				nextPerfID++
+				if n, _ := res.RowsAffected(); n == 0 {
+					taken, err := shared.PositionTaken(ctx, db, showID, songID, setNumber, position)
+					if err != nil {
+						return finish(err)
+					}
+					if taken {
+						return finish(fmt.Errorf("show %s (%s): set %d position %d already taken", dateStr, s.Venue.Name, setNumber, position))
+					}
+					continue
+				}
+				if err := shared.CountPerformance(ctx, db, songID, dateStr); err != nil {
+					return finish(err)
+				}
 			}
 		}
 	}
-	// Update song stats from actual performance data
-	_, _ = db.ExecContext(ctx, `
-		UPDATE songs SET
-			times_played = (SELECT count(*) FROM performances WHERE performances.song_id = songs.id),
-			first_played = (SELECT min(s.date) FROM performances p JOIN shows s ON p.show_id = s.id WHERE p.song_id = songs.id),
-			last_played = (SELECT max(s.date) FROM performances p JOIN shows s ON p.show_id = s.id WHERE p.song_id = songs.id)
-	`)
-
-	return showsAdded, int(nextSongID - startSongID), nil
+	if progress != nil {
+		progress(shared.Progress{Processed: len(shows), Total: len(shows), ShowsAdded: sum.Shows, SongsAdded: int(nextSongID - startSongID)})
+	}
+	return finish(nil)
 }
 
 // resolveSong resolves rawName to an existing song_id using the name+alias map, or heuristics
 // (case-insensitive match, trim trailing " -"). When a heuristic matches, it inserts the variant
-// into song_aliases so future lookups are exact. Returns (id, true) when resolved, (0, false)
+// into song_aliases so future lookups are exact, and reports viaAlias. Returns ok=false
 // when the caller should create a new song with rawName.
-func resolveSong(ctx context.Context, db *sql.DB, rawName string, songByName map[string]int64, _ int64) (int64, bool) {
+func resolveSong(ctx context.Context, db shared.Conn, rawName string, songByName map[string]int64) (id int64, viaAlias, ok bool) {
+	id, viaAlias, ok = matchSong(rawName, songByName)
+	if ok && viaAlias {
+		_, _ = db.ExecContext(ctx, "INSERT OR IGNORE INTO song_aliases (alias, song_id) VALUES (?, ?)", rawName, id)
+	}
+	return id, viaAlias, ok
+}
+
+// matchSong is resolveSong without the write: ok reports a match, and viaAlias
+// that it came from a heuristic and rawName needs a new song_aliases row. On a
+// heuristic match rawName is added to songByName.
+func matchSong(rawName string, songByName map[string]int64) (id int64, viaAlias, ok bool) {
 	if id, ok := songByName[rawName]; ok {
-		return id, true
+		return id, false, true
 	}
 	// Case-insensitive match
 	lowerRaw := strings.ToLower(rawName)
 	for name, id := range songByName {
 		if strings.ToLower(name) == lowerRaw {
-			_, _ = db.ExecContext(ctx, "INSERT OR IGNORE INTO song_aliases (alias, song_id) VALUES (?, ?)", rawName, id)
 			songByName[rawName] = id
-			return id, true
+			return id, true, true
 		}
 	}
+	// Punctuation/spacing variants ("Saint Stephen", "Me & My Uncle"), as GetSong matches them
+	if id, ok := shared.MatchNormalized(rawName, songByName); ok {
+		songByName[rawName] = id
+		return id, true, true
+	}
 	// Trim trailing segue marker and retry
 	trimmed := trimTrailingSegue(rawName)
 	if trimmed != rawName {
 		if id, ok := songByName[trimmed]; ok {
-			_, _ = db.ExecContext(ctx, "INSERT OR IGNORE INTO song_aliases (alias, song_id) VALUES (?, ?)", rawName, id)
 			songByName[rawName] = id
-			return id, true
+			return id, true, true
 		}
 		lowerTrimmed := strings.ToLower(trimmed)
 		for name, id := range songByName {
 			if strings.ToLower(name) == lowerTrimmed {
-				_, _ = db.ExecContext(ctx, "INSERT OR IGNORE INTO song_aliases (alias, song_id) VALUES (?, ?)", rawName, id)
 				songByName[rawName] = id
-				return id, true
+				return id, true, true
 			}
 		}
 	}
-	return 0, false
+	return 0, false, false
 }
 
 // trimTrailingSegue removes trailing " -" / "-" from source-style names (e.g. "Scarlet Begonias-").
@@ -3,8 +3,10 @@ package canonical
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"testing"
 
+	"github.com/gdql/gdql/internal/import/shared"
 	"github.com/gdql/gdql/test/fixtures"
 	"github.com/stretchr/testify/require"
 )
@@ -159,6 +161,32 @@ func TestWriteShows_DeduplicatesCaseVariants(t *testing.T) {
 	require.Equal(t, 2, ids[0])
 }
 
+func TestWriteShows_MatchesPunctuationVariants(t *testing.T) {
+	path, cleanup := fixtures.CreateTestDB(t)
+	defer cleanup()
+	conn, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ctx := context.Background()
+	// Fixture has "Samson and Delilah" (id=4)
+	shows := []Show{{
+		Date:  "1977-04-22",
+		Venue: Venue{Name: "The Spectrum", City: "Philadelphia", State: "PA", Country: "USA"},
+		Sets:  []Set{{Songs: []SongInSet{{Name: "Samson & Delilah"}}}},
+	}}
+	_, songsAdded, err := WriteShows(ctx, conn, shows)
+	require.NoError(t, err)
+	require.Equal(t, 0, songsAdded)
+
+	var songID int
+	require.NoError(t, conn.QueryRowContext(ctx, "SELECT song_id FROM performances p JOIN shows s ON p.show_id = s.id WHERE s.date = '1977-04-22'").Scan(&songID))
+	require.Equal(t, 4, songID)
+	var aliased int
+	require.NoError(t, conn.QueryRowContext(ctx, "SELECT song_id FROM song_aliases WHERE alias = 'Samson & Delilah'").Scan(&aliased))
+	require.Equal(t, 4, aliased, "the variant is recorded as an alias")
+}
+
 func TestWriteShows_NewSongStoredWithRawName(t *testing.T) {
 	path, cleanup := fixtures.CreateTestDB(t)
 	defer cleanup()
@@ -188,3 +216,260 @@ func TestWriteShows_NewSongStoredWithRawName(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, "Unknown Song XYZ", name)
 }
+
+func TestWriteShows_ComposesUnicodeTitles(t *testing.T) {
+	path, cleanup := fixtures.CreateTestDB(t)
+	defer cleanup()
+
+	conn, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ctx := context.Background()
+	// The same title from two sources: decomposed ("e" + combining acute),
+	// then precomposed ("é").
+	const decomposed, composed = "Cafe\u0301 Blues", "Caf\u00e9 Blues"
+	show := func(date, song string) Show {
+		return Show{
+			Date:  date,
+			Venue: Venue{Name: "Rainbow Theatre", City: "London", Country: "UK"},
+			Sets:  []Set{{Songs: []SongInSet{{Name: song}}}},
+		}
+	}
+	_, songsAdded, err := WriteShows(ctx, conn, []Show{show("1981-08-10", decomposed), show("1981-08-11", composed)})
+	require.NoError(t, err)
+	require.Equal(t, 1, songsAdded, "one song, not one per normalization form")
+
+	var name string
+	require.NoError(t, conn.QueryRowContext(ctx, "SELECT name FROM songs WHERE id = (SELECT MAX(id) FROM songs)").Scan(&name))
+	require.Equal(t, composed, name, "stored in NFC")
+	var aliases int
+	require.NoError(t, conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM song_aliases WHERE alias IN (?, ?)", decomposed, composed).Scan(&aliases))
+	require.Zero(t, aliases, "an exact match needs no alias")
+}
+
+func TestWriteShows_RecordsSetNames(t *testing.T) {
+	path, cleanup := fixtures.CreateTestDB(t)
+	defer cleanup()
+	conn, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ctx := context.Background()
+	shows := []Show{{
+		Date:  "1970-05-06",
+		Venue: Venue{Name: "Kresge Plaza", City: "Cambridge", State: "MA"},
+		Sets: []Set{
+			{Name: " Acoustic Set ", Songs: []SongInSet{{Name: "Deep Elem Blues"}}},
+			{Songs: []SongInSet{{Name: "Dancing in the Street"}}},
+		},
+	}}
+	_, _, err = WriteShows(ctx, conn, shows)
+	require.NoError(t, err)
+
+	rows, err := conn.QueryContext(ctx, "SELECT p.set_number, p.set_name FROM performances p JOIN shows s ON p.show_id = s.id WHERE s.date = '1970-05-06' ORDER BY p.set_number")
+	require.NoError(t, err)
+	defer rows.Close()
+	got := map[int]sql.NullString{}
+	for rows.Next() {
+		var set int
+		var name sql.NullString
+		require.NoError(t, rows.Scan(&set, &name))
+		got[set] = name
+	}
+	require.NoError(t, rows.Err())
+	require.Equal(t, map[int]sql.NullString{1: {String: "Acoustic Set", Valid: true}, 2: {}}, got)
+}
+
+func TestWriteShows_MaintainsSongStats(t *testing.T) {
+	path, cleanup := fixtures.CreateTestDB(t)
+	defer cleanup()
+	conn, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	defer conn.Close()
+	ctx := context.Background()
+
+	show := func(date, venue string, songs ...string) Show {
+		s := Show{Date: date, Venue: Venue{Name: venue, City: "Somewhere", State: "CA", Country: "USA"}}
+		set := Set{}
+		for _, name := range songs {
+			set.Songs = append(set.Songs, SongInSet{Name: name})
+		}
+		s.Sets = []Set{set}
+		return s
+	}
+	stats := func(name string) (times int, first, last string) {
+		t.Helper()
+		require.NoError(t, conn.QueryRowContext(ctx, "SELECT times_played, first_played, last_played FROM songs WHERE name = ?", name).Scan(&times, &first, &last))
+		return times, first, last
+	}
+
+	// Out of date order, so first/last can't just follow the insert order.
+	_, _, err = WriteShows(ctx, conn, []Show{
+		show("1981-05-06", "Nassau", "Alabama Getaway", "Morning Dew"),
+		show("1980-05-15", "Sportatorium", "Alabama Getaway"),
+		show("1982-08-07", "Alpine Valley", "Alabama Getaway"),
+	})
+	require.NoError(t, err)
+	times, first, last := stats("Alabama Getaway")
+	require.Equal(t, 3, times)
+	require.Equal(t, "1980-05-15", first)
+	require.Equal(t, "1982-08-07", last)
+
+	// An existing song keeps its earlier debut and gains one play.
+	times, first, last = stats("Morning Dew")
+	require.Equal(t, 233, times, "fixture's 232 plus one")
+	require.Equal(t, "1967-03-18", first)
+	require.Equal(t, "1995-06-25", last)
+
+	// Re-importing a show writes nothing and counts nothing.
+	_, _, err = WriteShows(ctx, conn, []Show{show("1980-05-15", "Sportatorium", "Alabama Getaway")})
+	require.NoError(t, err)
+	times, _, _ = stats("Alabama Getaway")
+	require.Equal(t, 3, times)
+}
+
+func TestWriteShowsWithProgress_ReportsEachShow(t *testing.T) {
+	path, cleanup := fixtures.CreateTestDB(t)
+	defer cleanup()
+
+	conn, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	shows := []Show{
+		{Date: "1980-05-15", Venue: Venue{Name: "Sportatorium", City: "Pembroke Pines", State: "FL"}, Sets: []Set{{Songs: []SongInSet{{Name: "Alabama Getaway"}}}}},
+		{Date: "1980-05-16", Venue: Venue{Name: "Lakeland Civic Center", City: "Lakeland", State: "FL"}, Sets: []Set{{Songs: []SongInSet{{Name: "Scarlet Begonias"}}}}},
+	}
+	var got []shared.Progress
+	_, _, err = WriteShowsWithProgress(context.Background(), conn, shows, func(p shared.Progress) {
+		got = append(got, p)
+	})
+	require.NoError(t, err)
+	require.Equal(t, []shared.Progress{
+		{Processed: 1, Total: 2, ShowsAdded: 1, SongsAdded: 1},
+		{Processed: 2, Total: 2, ShowsAdded: 2, SongsAdded: 1},
+	}, got)
+}
+
+func TestWriteShows_PositionsFollowArrayOrder(t *testing.T) {
+	path, cleanup := fixtures.CreateTestDB(t)
+	defer cleanup()
+
+	conn, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ctx := context.Background()
+	shows := []Show{{
+		Date:  "1981-03-01",
+		Venue: Venue{Name: "Gap Hall", City: "Ithaca", State: "NY", Country: "USA"},
+		Sets: []Set{
+			{Songs: []SongInSet{{Name: "Scarlet Begonias"}, {Name: "Fire on the Mountain", SegueBefore: true}}},
+			{Songs: []SongInSet{{Name: "Dark Star"}}},
+		},
+	}}
+	_, _, err = WriteShows(ctx, conn, shows)
+	require.NoError(t, err)
+
+	rows, err := conn.QueryContext(ctx, "SELECT p.set_number, p.position FROM performances p JOIN shows s ON p.show_id = s.id WHERE s.date = '1981-03-01' ORDER BY p.set_number, p.position")
+	require.NoError(t, err)
+	defer rows.Close()
+	var got []string
+	for rows.Next() {
+		var set, pos int
+		require.NoError(t, rows.Scan(&set, &pos))
+		got = append(got, fmt.Sprintf("%d.%d", set, pos))
+	}
+	require.NoError(t, rows.Err())
+	require.Equal(t, []string{"1.1", "1.2", "2.1"}, got)
+
+	// The segue join (position = previous + 1) finds the pair.
+	var segues int
+	require.NoError(t, conn.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM performances a JOIN performances b
+			ON b.show_id = a.show_id AND b.set_number = a.set_number AND b.position = a.position + 1
+		JOIN shows s ON s.id = a.show_id
+		WHERE s.date = '1981-03-01' AND a.song_id = 1 AND b.song_id = 2 AND b.segue_type = '>'`).Scan(&segues))
+	require.Equal(t, 1, segues)
+}
+
+func TestWriteShows_ExtraEncoresContinuePositions(t *testing.T) {
+	path, cleanup := fixtures.CreateTestDB(t)
+	defer cleanup()
+
+	conn, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ctx := context.Background()
+	shows := []Show{{
+		Date:  "1981-03-03",
+		Venue: Venue{Name: "Encore Hall", City: "Ithaca", State: "NY", Country: "USA"},
+		Sets: []Set{
+			{Songs: []SongInSet{{Name: "Bertha"}}},
+			{Songs: []SongInSet{{Name: "Estimated Prophet"}}},
+			{Songs: []SongInSet{{Name: "U.S. Blues"}}},
+			{Songs: []SongInSet{{Name: "Brokedown Palace"}}},
+		},
+	}}
+	_, _, err = WriteShows(ctx, conn, shows)
+	require.NoError(t, err)
+
+	var n, distinct int
+	require.NoError(t, conn.QueryRowContext(ctx, `
+		SELECT COUNT(*), COUNT(DISTINCT p.position) FROM performances p JOIN shows s ON p.show_id = s.id
+		WHERE s.date = '1981-03-03' AND p.set_number = 3`).Scan(&n, &distinct))
+	require.Equal(t, 2, n)
+	require.Equal(t, 2, distinct, "folded encores must not repeat positions")
+
+	// The folded set opens with the first encore and closes with the last.
+	var opener, closer string
+	require.NoError(t, conn.QueryRowContext(ctx, `
+		SELECT (SELECT so.name FROM performances p JOIN songs so ON p.song_id = so.id WHERE p.show_id = s.id AND p.set_number = 3 AND p.is_opener = 1),
+		       (SELECT so.name FROM performances p JOIN songs so ON p.song_id = so.id WHERE p.show_id = s.id AND p.set_number = 3 AND p.is_closer = 1)
+		FROM shows s WHERE s.date = '1981-03-03'`).Scan(&opener, &closer))
+	require.Equal(t, "U.S. Blues", opener)
+	require.Equal(t, "Brokedown Palace", closer)
+}
+
+func TestWriteShows_BlankSongLeavesNoGap(t *testing.T) {
+	path, cleanup := fixtures.CreateTestDB(t)
+	defer cleanup()
+
+	conn, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// The source lost the song between Scarlet and Fire, and the last song of
+	// the set.
+	ctx := context.Background()
+	shows := []Show{{
+		Date:  "1981-03-05",
+		Venue: Venue{Name: "Gap Hall", City: "Ithaca", State: "NY", Country: "USA"},
+		Sets: []Set{{Songs: []SongInSet{
+			{Name: "Scarlet Begonias"}, {Name: " "}, {Name: "Fire on the Mountain", SegueBefore: true}, {Name: ""},
+		}}},
+	}}
+	sum, err := WriteShowsDetailed(ctx, conn, shows, Options{})
+	require.NoError(t, err)
+	require.Equal(t, []Gap{{Date: "1981-03-05", Set: 1, Position: 2}, {Date: "1981-03-05", Set: 1, Position: 4}}, sum.Gaps)
+	require.Zero(t, sum.Songs, "a blank entry isn't stored as a song")
+
+	var n, top int
+	var closer string
+	require.NoError(t, conn.QueryRowContext(ctx, `
+		SELECT COUNT(*), MAX(p.position), (SELECT so.name FROM performances q JOIN songs so ON q.song_id = so.id WHERE q.show_id = s.id AND q.is_closer = 1)
+		FROM performances p JOIN shows s ON p.show_id = s.id WHERE s.date = '1981-03-05'`).Scan(&n, &top, &closer))
+	require.Equal(t, 2, n)
+	require.Equal(t, 2, top, "positions stay contiguous")
+	require.Equal(t, "Fire on the Mountain", closer)
+
+	var segues int
+	require.NoError(t, conn.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM performances a JOIN performances b
+			ON b.show_id = a.show_id AND b.set_number = a.set_number AND b.position = a.position + 1
+		JOIN shows s ON s.id = a.show_id
+		WHERE s.date = '1981-03-05' AND a.song_id = 1 AND b.song_id = 2`).Scan(&segues))
+	require.Equal(t, 1, segues, "the segue join still pairs the songs either side of the gap")
+}
@@ -7,7 +7,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"strings"
+
+	"github.com/gdql/gdql/internal/data"
 )
 
 // SongLyrics is a single song's lyrics for import.
@@ -19,16 +20,16 @@ type SongLyrics struct {
 // ImportLyrics reads a JSON file of [{song, lyrics}] and inserts into the lyrics table.
 // Songs are matched by name (case-insensitive). Returns (loaded, skipped).
 func ImportLyrics(ctx context.Context, db *sql.DB, path string) (loaded, skipped int, err error) {
-	data, err := os.ReadFile(path)
+	raw, err := os.ReadFile(path)
 	if err != nil {
 		return 0, 0, fmt.Errorf("reading %s: %w", path, err)
 	}
-	data = bytes.TrimSpace(data)
-	if len(data) == 0 {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
 		return 0, 0, nil
 	}
 	var entries []SongLyrics
-	if err := json.Unmarshal(data, &entries); err != nil {
+	if err := json.Unmarshal(raw, &entries); err != nil {
 		return 0, 0, fmt.Errorf("parsing JSON: %w\nExpected format: [{\"song\": \"Song Name\", \"lyrics\": \"...\"}]", err)
 	}
 	for _, e := range entries {
@@ -42,8 +43,8 @@ func ImportLyrics(ctx context.Context, db *sql.DB, path string) (loaded, skipped
 			skipped++
 			continue
 		}
-		// Normalize lyrics for FTS: lowercase, strip punctuation
-		fts := strings.ToLower(e.Lyrics)
+		// Normalize lyrics for search: fold case and diacritics, strip punctuation
+		fts := data.FoldText(e.Lyrics)
 		_, err = db.ExecContext(ctx, "INSERT OR REPLACE INTO lyrics (song_id, lyrics, lyrics_fts) VALUES (?, ?, ?)", songID, e.Lyrics, fts)
 		if err != nil {
 			return loaded, skipped, err
@@ -48,6 +48,26 @@ func TestImportLyrics(t *testing.T) {
 	require.Contains(t, fts, "long distance runner")
 }
 
+func TestImportLyrics_FoldsAccentsAndApostrophes(t *testing.T) {
+	path, cleanup := fixtures.CreateTestDB(t)
+	defer cleanup()
+
+	conn, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	lyricsJSON := `[{"song": "Dark Star", "lyrics": "Señor, the Café’s closin’"}]`
+	lyricsPath := filepath.Join(t.TempDir(), "lyrics.json")
+	require.NoError(t, os.WriteFile(lyricsPath, []byte(lyricsJSON), 0644))
+
+	ctx := context.Background()
+	_, _, err = ImportLyrics(ctx, conn, lyricsPath)
+	require.NoError(t, err)
+	var fts string
+	require.NoError(t, conn.QueryRowContext(ctx, "SELECT lyrics_fts FROM lyrics WHERE song_id = 6").Scan(&fts))
+	require.Equal(t, "senor the cafe s closin", fts)
+}
+
 func TestImportLyrics_CaseInsensitiveMatch(t *testing.T) {
 	path, cleanup := fixtures.CreateTestDB(t)
 	defer cleanup()
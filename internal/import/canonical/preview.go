@@ -0,0 +1,48 @@
+package canonical
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/gdql/gdql/internal/import/shared"
+)
+
+// Preview is what WriteShows would do with a batch, computed without writing.
+type Preview struct {
+	Shows      int // shows that would be added
+	Duplicates int // shows already in the DB or repeated in the batch
+	Skipped    int // shows with no usable date (WriteShows skips these too)
+	Venues     int // venues that would be created
+	// NewSongs are names that match no song or alias and would become new
+	// songs, in first-seen order. Adding aliases for them first avoids duplicates.
+	NewSongs []string
+	// Aliases maps raw names resolved by a heuristic (case, trailing "-")
+	// to the existing song name they'd be aliased to.
+	Aliases map[string]string
+	Gaps    []Gap // blank song entries; the import renumbers around them
+}
+
+// PreviewShows runs WriteShows on db inside a transaction and rolls it back,
+// for dry runs: the preview is exactly what the import would do, and nothing
+// is committed. db must be writable.
+func PreviewShows(ctx context.Context, db *sql.DB, shows []Show) (*Preview, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	out := &Preview{Aliases: map[string]string{}}
+	sum, err := writeShows(ctx, tx, shows, Options{}, out)
+	if err != nil {
+		return nil, err
+	}
+	out.Shows, out.Duplicates, out.Skipped, out.Venues, out.Gaps = sum.Shows, sum.Duplicates, sum.Skipped, sum.Venues, sum.Gaps
+	return out, nil
+}
+
+// songName returns the name of song id, or "" if there is none.
+func songName(ctx context.Context, db shared.Conn, id int64) string {
+	var name string
+	_ = db.QueryRowContext(ctx, "SELECT name FROM songs WHERE id = ?", id).Scan(&name)
+	return name
+}
@@ -0,0 +1,57 @@
+package canonical
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/gdql/gdql/test/fixtures"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviewShows_MatchesWriteShows(t *testing.T) {
+	path, cleanup := fixtures.CreateTestDB(t)
+	defer cleanup()
+	conn, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	defer conn.Close()
+	ctx := context.Background()
+
+	barton := Venue{Name: "Barton Hall", City: "Ithaca", State: "New York", Country: "USA"}
+	shows := []Show{
+		// Already in the fixture (state normalized to NY)
+		{Date: "1977-05-08", Venue: barton, Sets: []Set{{Songs: []SongInSet{{Name: "Brand New Song"}}}}},
+		{Date: "1980-05-15", Venue: Venue{Name: "Sportatorium", City: "Pembroke Pines", State: "FL", Country: "USA"}, Sets: []Set{
+			{Songs: []SongInSet{{Name: "scarlet begonias"}, {Name: "Loser"}, {Name: "Loser-"}}},
+		}},
+		{Date: "1980-05-16", Venue: Venue{Name: "Sportatorium", City: "Pembroke Pines", State: "FL", Country: "USA"}},
+		{Date: "1980-05-16", Venue: Venue{Name: "Sportatorium", City: "Pembroke Pines", State: "FL", Country: "USA"}},
+		{Venue: barton},
+	}
+
+	var before int
+	require.NoError(t, conn.QueryRow("SELECT count(*) FROM songs").Scan(&before))
+	preview, err := PreviewShows(ctx, conn, shows)
+	require.NoError(t, err)
+	require.Equal(t, 2, preview.Shows)
+	require.Equal(t, 2, preview.Duplicates)
+	require.Equal(t, 1, preview.Skipped)
+	require.Equal(t, 1, preview.Venues)
+	require.Equal(t, []string{"Loser"}, preview.NewSongs)
+	require.Equal(t, map[string]string{"scarlet begonias": "Scarlet Begonias", "Loser-": "Loser"}, preview.Aliases)
+
+	var after int
+	require.NoError(t, conn.QueryRow("SELECT count(*) FROM songs").Scan(&after))
+	require.Equal(t, before, after, "preview must not write")
+
+	sum, err := WriteShowsDetailed(ctx, conn, shows, Options{})
+	require.NoError(t, err)
+	require.Equal(t, &Summary{
+		Shows:      preview.Shows,
+		Songs:      len(preview.NewSongs),
+		Venues:     preview.Venues,
+		Aliases:    len(preview.Aliases),
+		Duplicates: preview.Duplicates,
+		Skipped:    preview.Skipped,
+	}, sum)
+}
@@ -0,0 +1,114 @@
+package canonical
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Reasons a show is skipped by Validate.
+const (
+	ReasonMissingDate = "missing date"
+	ReasonBadDate     = "unrecognized date (want YYYY-MM-DD)"
+	ReasonNoVenue     = "empty venue name"
+)
+
+// Problem is one show that failed validation and won't be imported.
+type Problem struct {
+	Index  int    // position in the input array, 0-based
+	Date   string // as given, may be empty
+	Reason string
+}
+
+func (p Problem) String() string {
+	if p.Date == "" {
+		return fmt.Sprintf("show #%d: %s", p.Index+1, p.Reason)
+	}
+	return fmt.Sprintf("show #%d (%s): %s", p.Index+1, p.Date, p.Reason)
+}
+
+// Validate splits shows into those WriteShows can import and a Problem for
+// each of the rest. WriteShows silently skips undated shows; calling Validate
+// first lets the caller say which ones and why.
+func Validate(shows []Show) (valid []Show, problems []Problem) {
+	for i, s := range shows {
+		reason := ""
+		switch {
+		case strings.TrimSpace(s.Date) == "":
+			reason = ReasonMissingDate
+		case normalizeDate(s.Date) == "":
+			reason = ReasonBadDate
+		case strings.TrimSpace(s.Venue.Name) == "":
+			reason = ReasonNoVenue
+		}
+		if reason != "" {
+			problems = append(problems, Problem{Index: i, Date: s.Date, Reason: reason})
+			continue
+		}
+		valid = append(valid, s)
+	}
+	return valid, problems
+}
+
+// DecodeShows parses a canonical JSON array of shows. Syntax and type errors
+// include the line and column. With strict, unknown field names (typos such
+// as "segue" for "segue_before") are errors instead of being ignored.
+func DecodeShows(data []byte, strict bool) ([]Show, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if strict {
+		dec.DisallowUnknownFields()
+	}
+	var shows []Show
+	if err := dec.Decode(&shows); err != nil {
+		offset := dec.InputOffset()
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &syntaxErr):
+			offset = syntaxErr.Offset
+		case errors.As(err, &typeErr):
+			offset = typeErr.Offset
+			if typeErr.Field != "" {
+				err = fmt.Errorf("field %s: expected %s, got %s", typeErr.Field, typeErr.Type, typeErr.Value)
+			}
+		case strings.HasPrefix(err.Error(), "json: unknown field "):
+			// The decoder reports no offset for these; point at the key's first use.
+			if i := keyOffset(data, strings.TrimPrefix(err.Error(), "json: unknown field ")); i >= 0 {
+				offset = int64(i)
+			}
+		}
+		line, col := lineCol(data, offset)
+		return nil, fmt.Errorf("line %d, column %d: %w", line, col, err)
+	}
+	return shows, nil
+}
+
+// lineCol converts a byte offset into a 1-based line and column.
+func lineCol(data []byte, offset int64) (line, col int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line = bytes.Count(before, []byte("\n")) + 1
+	col = len(before) - bytes.LastIndexByte(before, '\n')
+	return line, col
+}
+
+// keyOffset returns the offset of the first object key quotedKey (including
+// quotes) in data, or -1.
+func keyOffset(data []byte, quotedKey string) int {
+	for from := 0; ; {
+		i := bytes.Index(data[from:], []byte(quotedKey))
+		if i < 0 {
+			return -1
+		}
+		i += from
+		rest := bytes.TrimLeft(data[i+len(quotedKey):], " \t\r\n")
+		if len(rest) > 0 && rest[0] == ':' {
+			return i
+		}
+		from = i + len(quotedKey)
+	}
+}
@@ -0,0 +1,45 @@
+package canonical
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	shows := []Show{
+		{Date: "1977-05-08", Venue: Venue{Name: "Barton Hall"}},
+		{Venue: Venue{Name: "Winterland"}},
+		{Date: "May 8", Venue: Venue{Name: "Barton Hall"}},
+		{Date: "08-05-1977", Venue: Venue{Name: "  "}},
+	}
+	valid, problems := Validate(shows)
+	require.Len(t, valid, 1)
+	require.Equal(t, []Problem{
+		{Index: 1, Reason: ReasonMissingDate},
+		{Index: 2, Date: "May 8", Reason: ReasonBadDate},
+		{Index: 3, Date: "08-05-1977", Reason: ReasonNoVenue},
+	}, problems)
+	require.Equal(t, "show #3 (May 8): "+ReasonBadDate, problems[1].String())
+}
+
+func TestDecodeShows(t *testing.T) {
+	shows, err := DecodeShows([]byte(`[{"date": "1977-05-08", "venue": {"name": "Barton Hall"}, "sets": [{"songs": [{"name": "Loser", "segue": true}]}]}]`), false)
+	require.NoError(t, err)
+	require.Len(t, shows, 1)
+	require.Equal(t, "Loser", shows[0].Sets[0].Songs[0].Name)
+
+	_, err = DecodeShows([]byte("[\n  {\"date\": \"1977-05-08\", \"sets\": [{\"songs\": [{\"name\": \"Loser\", \"segue\": true}]}]}\n]"), true)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `unknown field "segue"`)
+	require.Contains(t, err.Error(), "line 2")
+
+	_, err = DecodeShows([]byte("[\n  {\"date\": \"1977-05-08\"},\n  {\"date\": 1977}\n]"), false)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "line 3")
+	require.Contains(t, err.Error(), "date: expected string, got number")
+
+	_, err = DecodeShows([]byte("[\n  {\"date\": \"1977-05-08\",}\n]"), false)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "line 2")
+}
@@ -3,8 +3,10 @@ package setlistfm
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"strings"
 
+	"github.com/gdql/gdql/internal/data"
 	"github.com/gdql/gdql/internal/data/sqlite"
 	"github.com/gdql/gdql/internal/import/shared"
 
@@ -14,14 +16,46 @@ import (
 // Import fetches Grateful Dead setlists from the API and writes them to the SQLite DB at path.
 // Schema is applied if the DB is new. API key must be set on the client.
 func Import(ctx context.Context, dbPath string, client *Client) (showsAdded, songsAdded int, err error) {
-	if err := sqlite.InitSchema(dbPath); err != nil {
-		return 0, 0, err
+	return ImportWithOptions(ctx, dbPath, client, Options{})
+}
+
+// Options tunes ImportWithOptions.
+type Options struct {
+	// Progress (if non-nil) is called after each setlist is examined, with the
+	// current API page and the total reported by the API.
+	Progress shared.ProgressFunc
+	// Resume starts after the last page recorded in import_state instead of page 1.
+	Resume bool
+	// DryRun runs the whole import in one transaction and rolls it back: the
+	// counts are what a real import would add, and nothing is written, not
+	// even import_state. The database must already have the schema.
+	DryRun bool
+}
+
+// ImportWithOptions is Import with progress reporting and resume support. Every
+// completed page is recorded in import_state, so an interrupted import (crash,
+// daily 429 cap) can pick up where it left off with Options.Resume.
+func ImportWithOptions(ctx context.Context, dbPath string, client *Client, opts Options) (showsAdded, songsAdded int, err error) {
+	progress := opts.Progress
+	if !opts.DryRun {
+		if err := sqlite.InitSchema(dbPath); err != nil {
+			return 0, 0, err
+		}
 	}
-	db, err := sql.Open("sqlite3", dbPath)
+	sqlDB, err := sql.Open("sqlite3", dbPath)
 	if err != nil {
 		return 0, 0, err
 	}
-	defer db.Close()
+	defer sqlDB.Close()
+	var db shared.Conn = sqlDB
+	if opts.DryRun {
+		tx, err := sqlDB.BeginTx(ctx, nil)
+		if err != nil {
+			return 0, 0, err
+		}
+		defer tx.Rollback()
+		db = tx
+	}
 
 	venueByKey := make(map[string]int64)
 	songByName, loadErr := shared.LoadSongByName(db)
@@ -37,8 +71,28 @@ func Import(ctx context.Context, dbPath string, client *Client) (showsAdded, son
 	nextSongID := songMax + 1
 	nextPerfID := perfMax + 1
 	songsBefore := len(songByName)
+	processed := 0
+	report := func(page, total int) {
+		if progress == nil {
+			return
+		}
+		added := len(songByName) - songsBefore
+		if added < 0 {
+			added = 0
+		}
+		progress(shared.Progress{Processed: processed, Total: total, ShowsAdded: showsAdded, SongsAdded: added, Page: page})
+	}
 
 	page := 1
+	var newestDate string
+	if opts.Resume {
+		lastPage, newest, err := loadState(db)
+		if err != nil {
+			return 0, 0, err
+		}
+		page = lastPage + 1
+		newestDate = newest
+	}
 	for {
 		select {
 		case <-ctx.Done():
@@ -53,11 +107,18 @@ func Import(ctx context.Context, dbPath string, client *Client) (showsAdded, son
 			break
 		}
 		for i := range resp.Setlist {
+			if i > 0 {
+				report(page, resp.Total)
+			}
+			processed++
 			sl := &resp.Setlist[i]
 			dateStr, ok := parseEventDate(sl.EventDate)
 			if !ok {
 				continue
 			}
+			if dateStr > newestDate {
+				newestDate = dateStr
+			}
 			venueName, city, state, country := venueFields(&sl.Venue)
 			if shared.ShowExists(db, dateStr, venueName, city, state, country) {
 				continue // already have this show; skip so we can resume later
@@ -83,6 +144,10 @@ func Import(ctx context.Context, dbPath string, client *Client) (showsAdded, son
 				showsAdded++
 			}
 		}
+		if err := saveState(db, page, newestDate); err != nil {
+			return showsAdded, songsAdded, err
+		}
+		report(page, resp.Total)
 		if page*resp.ItemsPerPage >= resp.Total {
 			break
 		}
@@ -104,11 +169,18 @@ func parseEventDate(eventDate string) (string, bool) {
 	return parts[2] + "-" + parts[1] + "-" + parts[0], true
 }
 
+// venueFields returns the venue columns as stored. State is always the postal
+// code (falling back to the full State name normalized via data.NormalizeState)
+// so venues match those written by the canonical importer.
 func venueFields(v *Venue) (name, city, state, country string) {
 	name = v.Name
 	if v.City != nil {
 		city = v.City.Name
 		state = v.City.StateCode
+		if state == "" {
+			state = v.City.State
+		}
+		state = data.NormalizeState(state)
 		if v.City.Country != nil {
 			country = v.City.Country.Code
 		}
@@ -116,19 +188,15 @@ func venueFields(v *Venue) (name, city, state, country string) {
 	return name, city, state, country
 }
 
-
 func venueKey(v *Venue) string {
-	city := ""
-	if v.City != nil {
-		city = v.City.Name + "|" + v.City.StateCode
-		if v.City.Country != nil {
-			city += "|" + v.City.Country.Code
-		}
+	name, city, state, country := venueFields(v)
+	if v.City == nil {
+		return name + "\t"
 	}
-	return v.Name + "\t" + city
+	return name + "\t" + city + "|" + state + "|" + country
 }
 
-func upsertShow(db *sql.DB, sl *Setlist, venueByKey map[string]int64, songByName map[string]int64, nextVenueID, nextShowID, nextSongID, nextPerfID *int64) (bool, error) {
+func upsertShow(db shared.Conn, sl *Setlist, venueByKey map[string]int64, songByName map[string]int64, nextVenueID, nextShowID, nextSongID, nextPerfID *int64) (bool, error) {
 	// Parse date dd-MM-yyyy -> yyyy-MM-dd
 	parts := strings.Split(sl.EventDate, "-")
 	if len(parts) != 3 {
@@ -141,26 +209,28 @@ func upsertShow(db *sql.DB, sl *Setlist, venueByKey map[string]int64, songByName
 	key := venueKey(v)
 	venueID, ok := venueByKey[key]
 	if !ok {
-		city, state, country := "", "", ""
-		if v.City != nil {
-			city = v.City.Name
-			state = v.City.StateCode
-			if v.City.Country != nil {
-				country = v.City.Country.Code
+		name, city, state, country := venueFields(v)
+		venueID, ok = shared.FindVenueID(db, name, city, state, country)
+		if !ok {
+			_, err := db.Exec("INSERT INTO venues (id, name, city, state, country) VALUES (?, ?, ?, ?, ?)", *nextVenueID, name, city, state, country)
+			if err != nil {
+				return false, err
 			}
+			venueID = *nextVenueID
+			*nextVenueID++
 		}
-		_, err := db.Exec("INSERT INTO venues (id, name, city, state, country) VALUES (?, ?, ?, ?, ?)", *nextVenueID, v.Name, city, state, country)
-		if err != nil {
-			return false, err
-		}
-		venueID = *nextVenueID
 		venueByKey[key] = venueID
-		*nextVenueID++
 	}
 
 	// Avoid duplicate show (e.g. when resuming after 429)
 	var exist int
 	if db.QueryRow("SELECT 1 FROM shows WHERE date = ? AND venue_id = ? LIMIT 1", dateStr, venueID).Scan(&exist) == nil {
+		// Backfill the link on shows imported before source_url existed.
+		if sl.URL != "" {
+			if _, err := db.Exec("UPDATE shows SET source_url = ? WHERE date = ? AND venue_id = ? AND source_url IS NULL", sl.URL, dateStr, venueID); err != nil {
+				return false, err
+			}
+		}
 		return false, nil
 	}
 
@@ -168,7 +238,7 @@ func upsertShow(db *sql.DB, sl *Setlist, venueByKey map[string]int64, songByName
 	if sl.Tour != nil {
 		tour = sl.Tour.Name
 	}
-	res, err := db.Exec("INSERT OR IGNORE INTO shows (id, date, venue_id, tour, notes) VALUES (?, ?, ?, ?, ?)", *nextShowID, dateStr, venueID, tour, sl.Info)
+	res, err := db.Exec("INSERT OR IGNORE INTO shows (id, date, venue_id, tour, notes, source_url) VALUES (?, ?, ?, ?, ?, ?)", *nextShowID, dateStr, venueID, tour, sl.Info, shared.NullStr(sl.URL))
 	if err != nil {
 		return false, err
 	}
@@ -179,10 +249,13 @@ func upsertShow(db *sql.DB, sl *Setlist, venueByKey map[string]int64, songByName
 	}
 	*nextShowID++
 
-	// If the API gave us a single set with many songs, infer set breaks
+	// If the API gave us a single set with many songs, infer set breaks.
+	// Inferred sets carry our own "Set N" names, so only real ones are kept.
 	sets := sl.Set
+	inferred := false
 	if len(sets) == 1 && len(sets[0].Songs) > 8 {
 		sets = InferSetBreaks(sets[0].Songs)
+		inferred = true
 	}
 
 	setNumber := 0
@@ -192,6 +265,10 @@ func upsertShow(db *sql.DB, sl *Setlist, venueByKey map[string]int64, songByName
 		} else {
 			setNumber++
 		}
+		setName := ""
+		if !inferred {
+			setName = strings.TrimSpace(set.Name)
+		}
 		position := 0
 		for i, song := range set.Songs {
 			names, segueAfter := splitSongName(song.Name)
@@ -213,6 +290,13 @@ func upsertShow(db *sql.DB, sl *Setlist, venueByKey map[string]int64, songByName
 						}
 					}
 				}
+				if !ok {
+					// Punctuation/spacing variants, matched the way GetSong matches them
+					songID, ok = shared.MatchNormalized(name, songByName)
+					if ok {
+						songByName[name] = songID
+					}
+				}
 				if !ok {
 					_, err := db.Exec("INSERT INTO songs (id, name, times_played) VALUES (?, ?, 0)", *nextSongID, name)
 					if err != nil {
@@ -230,7 +314,7 @@ func upsertShow(db *sql.DB, sl *Setlist, venueByKey map[string]int64, songByName
 					segueType = ">"
 				}
 				isOpener := 0
-				if position == 1 && setNumber == 1 {
+				if position == 1 {
 					isOpener = 1
 				}
 				isCloser := 0
@@ -238,12 +322,31 @@ func upsertShow(db *sql.DB, sl *Setlist, venueByKey map[string]int64, songByName
 				if lastSongInSet {
 					isCloser = 1
 				}
-				_, err = db.Exec("INSERT INTO performances (id, show_id, song_id, set_number, position, segue_type, is_opener, is_closer) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
-					*nextPerfID, showID, songID, setNumber, position, shared.NullStr(segueType), isOpener, isCloser)
+				tape := 0
+				if song.Tape {
+					tape = 1
+				}
+				res, err := db.Exec("INSERT OR IGNORE INTO performances (id, show_id, song_id, set_number, position, segue_type, is_opener, is_closer, tape, set_name) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+					*nextPerfID, showID, songID, setNumber, position, shared.NullStr(segueType), isOpener, isCloser, tape, shared.NullStr(setName))
 				if err != nil {
 					return false, err
 				}
 				*nextPerfID++
+				if n, _ := res.RowsAffected(); n == 0 {
+					// Two sets sharing a number (a fourth set and the first
+					// encore are both 4) would otherwise lose a song here.
+					taken, err := shared.PositionTaken(context.Background(), db, showID, songID, setNumber, position)
+					if err != nil {
+						return false, err
+					}
+					if taken {
+						return false, fmt.Errorf("show %s (%s): set %d position %d already taken", dateStr, sl.Venue.Name, setNumber, position)
+					}
+					continue
+				}
+				if err := shared.CountPerformance(context.Background(), db, songID, dateStr); err != nil {
+					return false, err
+				}
 			}
 		}
 	}
@@ -329,11 +432,15 @@ func InferSetBreaks(songs []Song) []Set {
 	return sets
 }
 
+// splitSongName splits a setlist.fm name holding a segue chain ("A > B" or
+// "A -> B", with or without spaces around the arrow) into its songs.
 func splitSongName(s string) (names []string, segueAfter []bool) {
-	s = strings.TrimSpace(s)
+	s = data.ComposeTitle(strings.TrimSpace(s))
 	if s == "" {
 		return nil, nil
 	}
+	// -> is the same segue as the query language's ->; no title contains it.
+	s = strings.ReplaceAll(s, "->", " > ")
 	if !strings.Contains(s, " > ") {
 		return []string{s}, []bool{false}
 	}
@@ -1,11 +1,16 @@
 package setlistfm
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"net/http"
 	"testing"
 
 	"github.com/gdql/gdql/internal/data/sqlite"
+	"github.com/gdql/gdql/internal/executor"
+	"github.com/gdql/gdql/internal/import/canonical"
+	"github.com/gdql/gdql/internal/import/shared"
 	"github.com/stretchr/testify/require"
 
 	_ "github.com/ncruces/go-sqlite3/driver"
@@ -91,6 +96,108 @@ func TestUpsertShow_PreservesSetBoundaries(t *testing.T) {
 	require.Equal(t, perf{"One More Saturday Night", 4, 1, ""}, perfs[4])
 }
 
+func TestUpsertShow_FlagsOpenersAndClosersInEverySet(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := dir + "/test.db"
+	require.NoError(t, sqlite.InitSchema(dbPath))
+	db, err := sql.Open("sqlite3", dbPath)
+	require.NoError(t, err)
+	defer db.Close()
+
+	var nextVenueID, nextShowID, nextSongID, nextPerfID int64 = 1, 1, 1, 1
+	sl := &Setlist{
+		EventDate: "08-05-1977",
+		Venue:     Venue{Name: "Barton Hall"},
+		Set: []Set{
+			{Songs: []Song{{Name: "Minglewood Blues"}, {Name: "Loser"}}},
+			{Songs: []Song{{Name: "Scarlet Begonias"}, {Name: "Fire on the Mountain"}}},
+			{Encore: 1, Songs: []Song{{Name: "U.S. Blues"}, {Name: "Brokedown Palace"}}},
+			{Encore: 2, Songs: []Song{{Name: "One More Saturday Night"}}},
+		},
+	}
+	_, err = upsertShow(db, sl, map[string]int64{}, map[string]int64{}, &nextVenueID, &nextShowID, &nextSongID, &nextPerfID)
+	require.NoError(t, err)
+
+	rows, err := db.Query(`
+		SELECT s.name, p.is_opener, p.is_closer FROM performances p JOIN songs s ON p.song_id = s.id
+		WHERE p.is_opener = 1 OR p.is_closer = 1 ORDER BY p.set_number, p.position`)
+	require.NoError(t, err)
+	defer rows.Close()
+	var got []string
+	for rows.Next() {
+		var name string
+		var opener, closer int
+		require.NoError(t, rows.Scan(&name, &opener, &closer))
+		got = append(got, fmt.Sprintf("%s %d%d", name, opener, closer))
+	}
+	require.NoError(t, rows.Err())
+	require.Equal(t, []string{
+		"Minglewood Blues 10", "Loser 01",
+		"Scarlet Begonias 10", "Fire on the Mountain 01",
+		"U.S. Blues 10", "Brokedown Palace 01",
+		"One More Saturday Night 11",
+	}, got)
+}
+
+func TestUpsertShow_RejectsCollidingSetPositions(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := dir + "/test.db"
+	require.NoError(t, sqlite.InitSchema(dbPath))
+	db, err := sql.Open("sqlite3", dbPath)
+	require.NoError(t, err)
+	defer db.Close()
+
+	// A fourth regular set is numbered 4, the same as the first encore, so
+	// both songs land at set 4 position 1.
+	var nextVenueID, nextShowID, nextSongID, nextPerfID int64 = 1, 1, 1, 1
+	sl := &Setlist{
+		EventDate: "31-12-1978",
+		Venue:     Venue{Name: "Winterland"},
+		Set: []Set{
+			{Songs: []Song{{Name: "Sugar Magnolia"}}},
+			{Songs: []Song{{Name: "Scarlet Begonias"}}},
+			{Songs: []Song{{Name: "Dark Star"}}},
+			{Songs: []Song{{Name: "Casey Jones"}}},
+			{Encore: 1, Songs: []Song{{Name: "We Bid You Goodnight"}}},
+		},
+	}
+	_, err = upsertShow(db, sl, map[string]int64{}, map[string]int64{}, &nextVenueID, &nextShowID, &nextSongID, &nextPerfID)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "show 1978-12-31 (Winterland): set 4 position 1 already taken")
+}
+
+func TestUpsertShow_StoresTapeFlag(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := dir + "/test.db"
+	require.NoError(t, sqlite.InitSchema(dbPath))
+	db, err := sql.Open("sqlite3", dbPath)
+	require.NoError(t, err)
+	defer db.Close()
+
+	var nextVenueID, nextShowID, nextSongID, nextPerfID int64 = 1, 1, 1, 1
+	sl := &Setlist{
+		EventDate: "31-12-1978",
+		Venue:     Venue{Name: "Winterland"},
+		Set: []Set{{Songs: []Song{
+			{Name: "Sugar Magnolia"},
+			{Name: "Ode to Joy", Tape: true},
+		}}},
+	}
+	_, err = upsertShow(db, sl, map[string]int64{}, map[string]int64{}, &nextVenueID, &nextShowID, &nextSongID, &nextPerfID)
+	require.NoError(t, err)
+
+	var tapes []int
+	rows, err := db.Query("SELECT tape FROM performances ORDER BY position")
+	require.NoError(t, err)
+	defer rows.Close()
+	for rows.Next() {
+		var tape int
+		require.NoError(t, rows.Scan(&tape))
+		tapes = append(tapes, tape)
+	}
+	require.Equal(t, []int{0, 1}, tapes)
+}
+
 func TestUpsertShow_DeduplicatesCaseVariants(t *testing.T) {
 	dir := t.TempDir()
 	dbPath := dir + "/test.db"
@@ -128,6 +235,29 @@ func TestUpsertShow_DeduplicatesCaseVariants(t *testing.T) {
 	require.Equal(t, 1, songCount, "case variants should not create duplicate songs")
 }
 
+func TestUpsertShow_DeduplicatesPunctuationVariants(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := dir + "/test.db"
+	require.NoError(t, sqlite.InitSchema(dbPath))
+	db, err := sql.Open("sqlite3", dbPath)
+	require.NoError(t, err)
+	defer db.Close()
+
+	venueByKey := make(map[string]int64)
+	songByName := make(map[string]int64)
+	var nextVenueID, nextShowID, nextSongID, nextPerfID int64 = 1, 1, 1, 1
+	venue := Venue{Name: "Winterland", City: &City{Name: "San Francisco", StateCode: "CA", Country: &Country{Code: "US"}}}
+	for i, name := range []string{"Goin' Down the Road Feeling Bad", "Going Down The Road Feelin' Bad", "Saint Stephen", "St. Stephen"} {
+		sl := &Setlist{EventDate: fmt.Sprintf("%02d-03-1977", i+1), Venue: venue, Set: []Set{{Songs: []Song{{Name: name}}}}}
+		_, err = upsertShow(db, sl, venueByKey, songByName, &nextVenueID, &nextShowID, &nextSongID, &nextPerfID)
+		require.NoError(t, err)
+	}
+
+	var songCount int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM songs").Scan(&songCount))
+	require.Equal(t, 2, songCount, "each pair is one song")
+}
+
 func TestParseEventDate(t *testing.T) {
 	cases := []struct {
 		input string
@@ -168,6 +298,48 @@ func TestVenueFields(t *testing.T) {
 	require.Equal(t, "US", country)
 }
 
+func TestVenueFields_FullStateName(t *testing.T) {
+	v := &Venue{Name: "Barton Hall", City: &City{Name: "Ithaca", State: "New York", Country: &Country{Code: "US"}}}
+	_, _, state, _ := venueFields(v)
+	require.Equal(t, "NY", state)
+}
+
+// The same venue arriving as "New York" from a canonical JSON file and as
+// stateCode "NY" from setlist.fm must share one venues row.
+func TestImport_SameVenueAcrossSources(t *testing.T) {
+	dbPath := t.TempDir() + "/test.db"
+	require.NoError(t, sqlite.InitSchema(dbPath))
+	db, err := sql.Open("sqlite3", dbPath)
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, _, err = canonical.WriteShows(context.Background(), db, []canonical.Show{{
+		Date:  "1977-05-07",
+		Venue: canonical.Venue{Name: "Boston Garden", City: "Boston", State: "Massachusetts", Country: "US"},
+		Sets:  []canonical.Set{{Songs: []canonical.SongInSet{{Name: "Bertha"}}}},
+	}})
+	require.NoError(t, err)
+
+	var nextVenueID, nextShowID, nextSongID, nextPerfID int64 = 100, 100, 100, 100
+	sl := &Setlist{
+		EventDate: "05-06-1978",
+		Venue:     Venue{Name: "Boston Garden", City: &City{Name: "Boston", StateCode: "MA", State: "Massachusetts", Country: &Country{Code: "US"}}},
+		Set:       []Set{{Songs: []Song{{Name: "Bertha"}}}},
+	}
+	songByName, err := shared.LoadSongByName(db)
+	require.NoError(t, err)
+	added, err := upsertShow(db, sl, map[string]int64{}, songByName, &nextVenueID, &nextShowID, &nextSongID, &nextPerfID)
+	require.NoError(t, err)
+	require.True(t, added)
+
+	var venues int
+	require.NoError(t, db.QueryRow("SELECT count(*) FROM venues").Scan(&venues))
+	require.Equal(t, 1, venues)
+	var state string
+	require.NoError(t, db.QueryRow("SELECT state FROM venues").Scan(&state))
+	require.Equal(t, "MA", state)
+}
+
 func TestVenueFields_NoCity(t *testing.T) {
 	v := &Venue{Name: "Madison Square Garden"}
 	name, city, state, country := venueFields(v)
@@ -226,6 +398,15 @@ func TestSplitSongName(t *testing.T) {
 	require.True(t, segue[0])
 	require.False(t, segue[1])
 
+	// -> is a segue too, spaced or not
+	names, segue = splitSongName("Help on the Way -> Slipknot!->Franklin's Tower")
+	require.Equal(t, []string{"Help on the Way", "Slipknot!", "Franklin's Tower"}, names)
+	require.Equal(t, []bool{true, true, false}, segue)
+
+	// Hyphenated titles are left alone
+	names, _ = splitSongName("Mississippi Half-Step Uptown Toodeloo")
+	require.Equal(t, []string{"Mississippi Half-Step Uptown Toodeloo"}, names)
+
 	// Empty string
 	names, segue = splitSongName("")
 	require.Nil(t, names)
@@ -328,6 +509,113 @@ func TestUpsertShow_InfersSetBreaks(t *testing.T) {
 	require.Greater(t, len(setMap), 1, "should have multiple sets, got: %v", setMap)
 	require.Contains(t, setMap, 1, "should have set 1")
 	require.Contains(t, setMap, 2, "should have set 2")
+
+	var named int
+	require.NoError(t, db.QueryRow("SELECT count(*) FROM performances WHERE set_name IS NOT NULL").Scan(&named))
+	require.Zero(t, named, "inferred sets are numbered, not named")
+}
+
+func TestUpsertShow_StoresSetNames(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := dir + "/test.db"
+	require.NoError(t, sqlite.InitSchema(dbPath))
+	db, err := sql.Open("sqlite3", dbPath)
+	require.NoError(t, err)
+	defer db.Close()
+
+	var nextVenueID, nextShowID, nextSongID, nextPerfID int64 = 1, 1, 1, 1
+	sl := &Setlist{
+		EventDate: "05-06-1970",
+		Venue:     Venue{Name: "Kresge Plaza"},
+		Set: []Set{
+			{Name: "Acoustic Set", Songs: []Song{{Name: "Deep Elem Blues"}, {Name: "Friend of the Devil"}}},
+			{Songs: []Song{{Name: "Dancing in the Street"}}},
+		},
+	}
+	_, err = upsertShow(db, sl, map[string]int64{}, map[string]int64{}, &nextVenueID, &nextShowID, &nextSongID, &nextPerfID)
+	require.NoError(t, err)
+
+	var names []string
+	rows, err := db.Query("SELECT COALESCE(set_name, '') FROM performances ORDER BY set_number, position")
+	require.NoError(t, err)
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		require.NoError(t, rows.Scan(&name))
+		names = append(names, name)
+	}
+	require.Equal(t, []string{"Acoustic Set", "Acoustic Set", ""}, names)
+}
+
+func TestUpsertShow_ReimportDoesNotDuplicatePerformances(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := dir + "/test.db"
+	require.NoError(t, sqlite.InitSchema(dbPath))
+	db, err := sql.Open("sqlite3", dbPath)
+	require.NoError(t, err)
+	defer db.Close()
+
+	var nextVenueID, nextShowID, nextSongID, nextPerfID int64 = 1, 1, 1, 1
+	sl := &Setlist{
+		EventDate: "08-05-1977",
+		Venue:     Venue{Name: "Barton Hall"},
+		Set:       []Set{{Songs: []Song{{Name: "New Minglewood Blues"}, {Name: "Loser"}}}},
+	}
+	songByName := map[string]int64{}
+	added, err := upsertShow(db, sl, map[string]int64{}, songByName, &nextVenueID, &nextShowID, &nextSongID, &nextPerfID)
+	require.NoError(t, err)
+	require.True(t, added)
+
+	// Drop the show row but leave its performances behind, as an interrupted
+	// cleanup would, so the show-level check no longer catches the re-import.
+	// One connection keeps the pragma in effect for the delete.
+	db.SetMaxOpenConns(1)
+	_, err = db.Exec("PRAGMA foreign_keys = OFF")
+	require.NoError(t, err)
+	_, err = db.Exec("DELETE FROM shows")
+	require.NoError(t, err)
+	nextShowID = 1
+	added, err = upsertShow(db, sl, map[string]int64{}, songByName, &nextVenueID, &nextShowID, &nextSongID, &nextPerfID)
+	require.NoError(t, err)
+	require.True(t, added)
+
+	var n int
+	require.NoError(t, db.QueryRow("SELECT count(*) FROM performances").Scan(&n))
+	require.Equal(t, 2, n)
+}
+
+func TestUpsertShow_SourceURLRoundTrips(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := dir + "/test.db"
+	require.NoError(t, sqlite.InitSchema(dbPath))
+	db, err := sql.Open("sqlite3", dbPath)
+	require.NoError(t, err)
+	defer db.Close()
+
+	const url = "https://www.setlist.fm/setlist/grateful-dead/1977/barton-hall-cornell-university-ithaca-ny-53d6e3b5.html"
+	var nextVenueID, nextShowID, nextSongID, nextPerfID int64 = 1, 1, 1, 1
+	sl := &Setlist{
+		EventDate: "08-05-1977",
+		URL:       url,
+		Venue:     Venue{Name: "Barton Hall"},
+		Set:       []Set{{Songs: []Song{{Name: "New Minglewood Blues"}}}},
+	}
+	_, err = upsertShow(db, sl, map[string]int64{}, map[string]int64{}, &nextVenueID, &nextShowID, &nextSongID, &nextPerfID)
+	require.NoError(t, err)
+
+	sdb, err := sqlite.Open(dbPath)
+	require.NoError(t, err)
+	defer sdb.Close()
+	ex := executor.New(sdb)
+	res, err := ex.Execute(context.Background(), "SHOWS FROM 1977;")
+	require.NoError(t, err)
+	require.Len(t, res.Shows, 1)
+	require.Equal(t, url, res.Shows[0].SourceURL)
+
+	res, err = ex.Execute(context.Background(), "SETLIST FOR 5/8/77;")
+	require.NoError(t, err)
+	require.NotNil(t, res.Setlist)
+	require.Equal(t, url, res.Setlist.SourceURL)
 }
 
 func TestUpsertShow_SingleSetAllInSet1(t *testing.T) {
@@ -367,3 +655,135 @@ func TestUpsertShow_SingleSetAllInSet1(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, 1, setNum, "single set should be set_number 1")
 }
+
+func TestImportWithProgress_ReportsPageAndTotal(t *testing.T) {
+	body := `{"total": 2, "page": 1, "itemsPerPage": 20, "setlist": [
+		{"id":"a","eventDate":"08-05-1977","venue":{"name":"Barton Hall","city":{"name":"Ithaca","stateCode":"NY","country":{"code":"US"}}},
+		 "sets":{"set":[{"song":[{"name":"Minglewood Blues"},{"name":"Loser"}]}]}},
+		{"id":"b","eventDate":"09-05-1977","venue":{"name":"War Memorial","city":{"name":"Buffalo","stateCode":"NY","country":{"code":"US"}}},
+		 "sets":{"set":[{"song":[{"name":"Help on the Way"}]}]}}
+	]}`
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	})
+	dbPath := t.TempDir() + "/test.db"
+
+	var got []shared.Progress
+	showsAdded, songsAdded, err := ImportWithOptions(context.Background(), dbPath, c, Options{Progress: func(p shared.Progress) {
+		got = append(got, p)
+	}})
+	require.NoError(t, err)
+	require.Equal(t, 2, showsAdded)
+	require.Equal(t, 3, songsAdded)
+	require.NotEmpty(t, got)
+	last := got[len(got)-1]
+	require.Equal(t, shared.Progress{Processed: 2, Total: 2, ShowsAdded: 2, SongsAdded: 3, Page: 1}, last)
+}
+
+func TestImportWithOptions_MaintainsSongStats(t *testing.T) {
+	body := `{"total": 2, "page": 1, "itemsPerPage": 20, "setlist": [
+		{"id":"a","eventDate":"08-05-1977","venue":{"name":"Barton Hall","city":{"name":"Ithaca","stateCode":"NY","country":{"code":"US"}}},
+		 "sets":{"set":[{"song":[{"name":"Minglewood Blues"},{"name":"Loser"}]}]}},
+		{"id":"b","eventDate":"09-05-1977","venue":{"name":"War Memorial","city":{"name":"Buffalo","stateCode":"NY","country":{"code":"US"}}},
+		 "sets":{"set":[{"song":[{"name":"Loser"}]}]}}
+	]}`
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	})
+	dbPath := t.TempDir() + "/test.db"
+	_, _, err := ImportWithOptions(context.Background(), dbPath, c, Options{})
+	require.NoError(t, err)
+
+	db, err := sql.Open("sqlite3", dbPath)
+	require.NoError(t, err)
+	defer db.Close()
+	var times int
+	var first, last string
+	require.NoError(t, db.QueryRow("SELECT times_played, first_played, last_played FROM songs WHERE name = 'Loser'").Scan(&times, &first, &last))
+	require.Equal(t, 2, times)
+	require.Equal(t, "1977-05-08", first)
+	require.Equal(t, "1977-05-09", last)
+	require.NoError(t, db.QueryRow("SELECT times_played FROM songs WHERE name = 'Minglewood Blues'").Scan(&times))
+	require.Equal(t, 1, times)
+}
+
+func TestImportWithOptions_DryRunWritesNothing(t *testing.T) {
+	body := `{"total": 2, "page": 1, "itemsPerPage": 20, "setlist": [
+		{"id":"a","eventDate":"08-05-1977","venue":{"name":"Barton Hall","city":{"name":"Ithaca","stateCode":"NY","country":{"code":"US"}}},
+		 "sets":{"set":[{"song":[{"name":"Minglewood Blues"},{"name":"Loser"}]}]}},
+		{"id":"b","eventDate":"09-05-1977","venue":{"name":"War Memorial","city":{"name":"Buffalo","stateCode":"NY","country":{"code":"US"}}},
+		 "sets":{"set":[{"song":[{"name":"Loser"}]}]}}
+	]}`
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	})
+	dbPath := t.TempDir() + "/test.db"
+	require.NoError(t, sqlite.InitSchema(dbPath))
+
+	showsAdded, songsAdded, err := ImportWithOptions(context.Background(), dbPath, c, Options{DryRun: true})
+	require.NoError(t, err)
+	require.Equal(t, 2, showsAdded)
+	require.Equal(t, 2, songsAdded)
+
+	db, err := sql.Open("sqlite3", dbPath)
+	require.NoError(t, err)
+	defer db.Close()
+	for _, table := range []string{"shows", "songs", "venues", "performances", "import_state"} {
+		var n int
+		require.NoError(t, db.QueryRow("SELECT count(*) FROM "+table).Scan(&n))
+		require.Zero(t, n, table)
+	}
+}
+
+func TestImportWithOptions_ResumesAfterInterruption(t *testing.T) {
+	pages := map[string]string{
+		"1": `{"total": 3, "page": 1, "itemsPerPage": 1, "setlist": [{"id":"a","eventDate":"10-05-1977","venue":{"name":"Fox Theatre","city":{"name":"St. Louis","stateCode":"MO"}},"sets":{"set":[{"song":[{"name":"Bertha"}]}]}}]}`,
+		"2": `{"total": 3, "page": 2, "itemsPerPage": 1, "setlist": [{"id":"b","eventDate":"09-05-1977","venue":{"name":"War Memorial","city":{"name":"Buffalo","stateCode":"NY"}},"sets":{"set":[{"song":[{"name":"Help on the Way"}]}]}}]}`,
+		"3": `{"total": 3, "page": 3, "itemsPerPage": 1, "setlist": [{"id":"c","eventDate":"08-05-1977","venue":{"name":"Barton Hall","city":{"name":"Ithaca","stateCode":"NY"}},"sets":{"set":[{"song":[{"name":"Loser"}]}]}}]}`,
+	}
+	failPage2 := true
+	var requested []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		p := r.URL.Query().Get("p")
+		requested = append(requested, p)
+		if p == "2" && failPage2 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(pages[p]))
+	})
+	dbPath := t.TempDir() + "/test.db"
+
+	// First run dies on page 2.
+	showsAdded, _, err := ImportWithOptions(context.Background(), dbPath, c, Options{})
+	require.Error(t, err)
+	require.Equal(t, 1, showsAdded)
+
+	db, err := sql.Open("sqlite3", dbPath)
+	require.NoError(t, err)
+	defer db.Close()
+	lastPage, newest, err := loadState(db)
+	require.NoError(t, err)
+	require.Equal(t, 1, lastPage)
+	require.Equal(t, "1977-05-10", newest)
+
+	// Resumed run starts at page 2, not page 1.
+	failPage2 = false
+	requested = nil
+	showsAdded, _, err = ImportWithOptions(context.Background(), dbPath, c, Options{Resume: true})
+	require.NoError(t, err)
+	require.Equal(t, 2, showsAdded)
+	require.Equal(t, []string{"2", "3"}, requested)
+
+	var n int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM shows").Scan(&n))
+	require.Equal(t, 3, n)
+	lastPage, newest, err = loadState(db)
+	require.NoError(t, err)
+	require.Equal(t, 3, lastPage)
+	require.Equal(t, "1977-05-10", newest)
+}
@@ -0,0 +1,38 @@
+package setlistfm
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gdql/gdql/internal/import/shared"
+)
+
+// stateSource is this importer's key in the import_state table.
+const stateSource = "setlistfm"
+
+// loadState returns the last fully imported page and the newest event date seen.
+// A DB with no recorded state returns (0, "", nil), i.e. start from page 1.
+func loadState(db shared.Conn) (lastPage int, newestDate string, err error) {
+	var newest sql.NullString
+	err = db.QueryRow("SELECT last_page, newest_date FROM import_state WHERE source = ?", stateSource).Scan(&lastPage, &newest)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, "", nil
+	}
+	if err != nil {
+		return 0, "", fmt.Errorf("loading import state: %w", err)
+	}
+	return lastPage, newest.String, nil
+}
+
+// saveState records page as fully imported.
+func saveState(db shared.Conn, page int, newestDate string) error {
+	_, err := db.Exec(`INSERT INTO import_state (source, last_page, newest_date, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(source) DO UPDATE SET last_page = excluded.last_page, newest_date = excluded.newest_date, updated_at = excluded.updated_at`,
+		stateSource, page, shared.NullStr(newestDate), time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("saving import state: %w", err)
+	}
+	return nil
+}
@@ -2,12 +2,39 @@
 package shared
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+
+	"github.com/gdql/gdql/internal/data"
 )
 
+// Progress is a snapshot of a running import, passed to a ProgressFunc.
+type Progress struct {
+	Processed  int // shows examined so far (added or skipped)
+	Total      int // estimated number of shows to examine; 0 if unknown
+	ShowsAdded int
+	SongsAdded int
+	Page       int // current API page for paged sources; 0 otherwise
+}
+
+// ProgressFunc is called periodically during long-running imports.
+// A nil ProgressFunc is allowed and means no reporting.
+type ProgressFunc func(Progress)
+
+// Conn is the part of *sql.DB the import helpers use. A *sql.Tx has it too,
+// so an import can run inside a transaction that a dry run rolls back.
+type Conn interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
 // MaxID returns the maximum id in the given table. Only allows known table names.
-func MaxID(db *sql.DB, table string) (int64, error) {
+func MaxID(db Conn, table string) (int64, error) {
 	switch table {
 	case "venues", "shows", "songs", "performances":
 		// allowed
@@ -25,8 +52,10 @@ func MaxID(db *sql.DB, table string) (int64, error) {
 	return 0, nil
 }
 
-// LoadSongByName returns a map from song name (and alias) to song_id.
-func LoadSongByName(db *sql.DB) (map[string]int64, error) {
+// LoadSongByName returns a map from song name (and alias) to song_id. Keys are
+// in NFC (data.ComposeTitle), the form importers look names up in, so a song
+// stored with decomposed accents is still found.
+func LoadSongByName(db Conn) (map[string]int64, error) {
 	out := make(map[string]int64)
 	rows, err := db.Query("SELECT id, name FROM songs")
 	if err != nil {
@@ -39,7 +68,7 @@ func LoadSongByName(db *sql.DB) (map[string]int64, error) {
 		if err := rows.Scan(&id, &name); err != nil {
 			return nil, fmt.Errorf("scanning song: %w", err)
 		}
-		out[name] = id
+		out[data.ComposeTitle(name)] = id
 	}
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("iterating songs: %w", err)
@@ -55,6 +84,7 @@ func LoadSongByName(db *sql.DB) (map[string]int64, error) {
 		if err := rows2.Scan(&alias, &songID); err != nil {
 			return nil, fmt.Errorf("scanning alias: %w", err)
 		}
+		alias = data.ComposeTitle(alias)
 		if _, exists := out[alias]; !exists {
 			out[alias] = songID
 		}
@@ -65,8 +95,27 @@ func LoadSongByName(db *sql.DB) (map[string]int64, error) {
 	return out, nil
 }
 
+// MatchNormalized finds a song in songByName (as LoadSongByName builds it)
+// whose data.NormalizeSongName key equals name's, so "Saint Stephen" reuses
+// an existing "St. Stephen". Several spellings can share a key; the oldest
+// (lowest id) wins, so repeated imports pick the same one whichever importer
+// runs.
+func MatchNormalized(name string, songByName map[string]int64) (int64, bool) {
+	target := data.NormalizeSongName(name)
+	if target == "" {
+		return 0, false
+	}
+	var best int64
+	for existing, id := range songByName {
+		if (best == 0 || id < best) && data.NormalizeSongName(existing) == target {
+			best = id
+		}
+	}
+	return best, best != 0
+}
+
 // ShowExists checks if a show already exists by date and venue details.
-func ShowExists(db *sql.DB, dateStr, venueName, city, state, country string) bool {
+func ShowExists(db Conn, dateStr, venueName, city, state, country string) bool {
 	var n int
 	err := db.QueryRow(
 		"SELECT 1 FROM shows s JOIN venues v ON s.venue_id = v.id WHERE s.date = ? AND v.name = ? AND COALESCE(v.city,'') = ? AND COALESCE(v.state,'') = ? AND COALESCE(v.country,'') = ? LIMIT 1",
@@ -75,6 +124,58 @@ func ShowExists(db *sql.DB, dateStr, venueName, city, state, country string) boo
 	return err == nil
 }
 
+// CountPerformance records one more play of songID on date (YYYY-MM-DD):
+// times_played goes up by one and first_played/last_played widen to take the
+// date in. Importers call it for each performance row they insert, so the
+// song's aggregates are right as soon as the import finishes.
+func CountPerformance(ctx context.Context, db Conn, songID int64, date string) error {
+	_, err := db.ExecContext(ctx, `
+		UPDATE songs SET
+			times_played = COALESCE(times_played, 0) + 1,
+			first_played = min(COALESCE(NULLIF(first_played, ''), ?1), ?1),
+			last_played = max(COALESCE(NULLIF(last_played, ''), ?1), ?1)
+		WHERE id = ?2
+	`, date, songID)
+	if err != nil {
+		return fmt.Errorf("counting performance of song %d: %w", songID, err)
+	}
+	return nil
+}
+
+// PositionTaken reports whether another song's performance already holds set
+// and position in showID. Importers insert performances with INSERT OR
+// IGNORE, which quietly keeps an identical row but would also drop a
+// different song at the same spot; they call this when a row is ignored.
+func PositionTaken(ctx context.Context, db Conn, showID, songID int64, set, position int) (bool, error) {
+	var held int64
+	err := db.QueryRowContext(ctx, "SELECT song_id FROM performances WHERE show_id = ? AND set_number = ? AND position = ?", showID, set, position).Scan(&held)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return held != songID, nil
+}
+
+// RecountSongs recomputes every song's times_played, first_played and
+// last_played from performances, and returns how many songs it updated.
+// Imports keep these current (CountPerformance); this repairs a database
+// edited by hand. Songs with no performances get 0 plays and no dates.
+func RecountSongs(ctx context.Context, db Conn) (int, error) {
+	res, err := db.ExecContext(ctx, `
+		UPDATE songs SET
+			times_played = (SELECT count(*) FROM performances WHERE performances.song_id = songs.id),
+			first_played = (SELECT min(s.date) FROM performances p JOIN shows s ON p.show_id = s.id WHERE p.song_id = songs.id),
+			last_played = (SELECT max(s.date) FROM performances p JOIN shows s ON p.show_id = s.id WHERE p.song_id = songs.id)
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("recounting songs: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
 // NullStr returns nil for empty strings (for SQL NULL insertion).
 func NullStr(s string) interface{} {
 	if s == "" {
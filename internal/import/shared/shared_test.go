@@ -1,6 +1,7 @@
 package shared
 
 import (
+	"context"
 	"testing"
 
 	"github.com/gdql/gdql/test/fixtures"
@@ -86,3 +87,47 @@ func TestNullStr(t *testing.T) {
 	assert.Equal(t, "hello", NullStr("hello"))
 	assert.Equal(t, " ", NullStr(" "), "whitespace is not empty")
 }
+
+func TestRecountSongs(t *testing.T) {
+	db := fixtures.OpenTestDB(t)
+	defer db.Close()
+
+	// The fixture's times_played are real-world totals; its performances are a handful.
+	n, err := RecountSongs(context.Background(), db)
+	require.NoError(t, err)
+	require.Equal(t, 6, n)
+
+	var times int
+	var first, last string
+	require.NoError(t, db.QueryRow("SELECT times_played, first_played, last_played FROM songs WHERE id = 1").Scan(&times, &first, &last))
+	assert.Equal(t, 3, times, "Scarlet Begonias at Cornell, Winterland and Landover")
+	assert.Equal(t, "1977-02-26", first)
+	assert.Equal(t, "1978-04-24", last)
+}
+
+func TestPositionTaken(t *testing.T) {
+	db := fixtures.OpenTestDB(t)
+	defer db.Close()
+	ctx := context.Background()
+
+	// Show 1, set 2, position 1 is Scarlet Begonias (song 1).
+	taken, err := PositionTaken(ctx, db, 1, 1, 2, 1)
+	require.NoError(t, err)
+	assert.False(t, taken)
+	taken, err = PositionTaken(ctx, db, 1, 5, 2, 1)
+	require.NoError(t, err)
+	assert.True(t, taken)
+	taken, err = PositionTaken(ctx, db, 1, 5, 2, 99)
+	require.NoError(t, err)
+	assert.False(t, taken)
+}
+
+func TestMatchNormalized(t *testing.T) {
+	songs := map[string]int64{"St. Stephen": 9, "Saint Stephen": 4, "Thin Man": 2}
+	id, ok := MatchNormalized("saint stephen!", songs)
+	require.True(t, ok)
+	require.Equal(t, int64(4), id, "the oldest spelling wins")
+
+	_, ok = MatchNormalized("Thing Man", songs)
+	require.False(t, ok, "a non-suffix -ing keeps its g")
+}
@@ -0,0 +1,13 @@
+package shared
+
+// FindVenueID returns the id of an existing venue with the same name, city,
+// state, and country (name and city compared case-insensitively), so imports
+// reuse venue rows written by earlier runs or other sources.
+func FindVenueID(db Conn, name, city, state, country string) (int64, bool) {
+	var id int64
+	err := db.QueryRow(
+		"SELECT id FROM venues WHERE LOWER(name) = LOWER(?) AND LOWER(COALESCE(city,'')) = LOWER(?) AND COALESCE(state,'') = ? AND COALESCE(country,'') = ? ORDER BY id LIMIT 1",
+		name, city, state, country,
+	).Scan(&id)
+	return id, err == nil
+}
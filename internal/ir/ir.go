@@ -13,6 +13,13 @@ const (
 	QueryTypeCount
 	QueryTypeFirstLast
 	QueryTypeRandomShow
+	QueryTypeVenues
+	QueryTypeRuns
+	QueryTypeVenueStats
+	QueryTypeCompare
+	QueryTypeCoverage
+	QueryTypePerformanceYears
+	QueryTypeGaps
 )
 
 // QueryIR is the resolved, expanded representation ready for SQL generation.
@@ -21,19 +28,36 @@ type QueryIR struct {
 	DateRange  *ResolvedDateRange
 	SingleDate *time.Time // for SETLIST FOR date
 	SongID     *int       // for PERFORMANCES OF song
+	SongIDs    []int      // for PERFORMANCES OF "A", "B" (all songs, SongID is the first) and COMPARE
 	VenueName  string     // for SHOWS AT "venue"
 	TourName   string     // for SHOWS TOUR "name"
+	RatedOnly  bool       // BEST SHOWS: only shows with a rating
 	IsLast     bool       // for FIRST/LAST
+	CountVenues bool      // for COUNT VENUES
 	PlayedRange    *ResolvedDateRange // for SONGS FROM/PLAYED IN (date songs were performed)
+	EveryYear      bool               // SONGS PLAYED EVERY YEAR: a performance in each year of PlayedRange
+	InSet          SetPosition        // SONGS IN SET2: count only performances in that set
+	DebutRange     *ResolvedDateRange // for SONGS DEBUTED FROM (songs.first_played)
 	SegueChain     *SegueChainIR
 	Conditions     []ConditionIR
-	ConditionOps   []LogicOp // AND/OR between conditions (len = len(Conditions)-1)
+	ConditionOps   []LogicOp // AND/OR between conditions (len = len(Conditions)-1); AND binds tighter than OR
 	OrderBy        *OrderByIR
+	WithContext    bool // PERFORMANCES ... WITH CONTEXT: the neighbouring songs in the set
 	Limit      *int
 	OutputFmt  OutputFormat
+	SongMatches []SongMatch // how each song name in the query resolved, for explain output
 }
 
-// ResolvedDateRange has concrete dates (no eras).
+// SongMatch records how one song name in a query resolved.
+type SongMatch struct {
+	Name  string // as written in the query
+	IDs   []int
+	Song  string // the song's stored name when the name resolved to one song
+	Stage string // data.MatchStage for one song; "variants" or "pattern" for several
+}
+
+// ResolvedDateRange has concrete dates (no eras). Both ends are inclusive days:
+// End is the last second of its day, and SQL compares only the date part.
 type ResolvedDateRange struct {
 	Start time.Time
 	End   time.Time
@@ -63,10 +87,23 @@ func (*PositionConditionIR) conditionIRNode()  {}
 func (*LyricsConditionIR) conditionIRNode()   {}
 func (*LengthConditionIR) conditionIRNode()   {}
 func (*PlayedConditionIR) conditionIRNode()   {}
+func (*PlayedSetConditionIR) conditionIRNode() {}
 func (*GuestConditionIR) conditionIRNode()    {}
 func (*SegueIntoConditionIR) conditionIRNode()    {}
 func (*NegatedSegueConditionIR) conditionIRNode() {}
 func (*SegueChainConditionIR) conditionIRNode() {}
+func (*NotesConditionIR) conditionIRNode()      {}
+func (*CoverConditionIR) conditionIRNode()      {}
+func (*TimesPlayedConditionIR) conditionIRNode() {}
+func (*MissingFieldConditionIR) conditionIRNode() {}
+func (*PlayedOnceConditionIR) conditionIRNode()   {}
+func (*RatingConditionIR) conditionIRNode()       {}
+func (*SongCountConditionIR) conditionIRNode()    {}
+func (*TapeConditionIR) conditionIRNode()       {}
+func (*SourceConditionIR) conditionIRNode()     {}
+func (*CompleteConditionIR) conditionIRNode()   {}
+func (*WeekdayConditionIR) conditionIRNode()    {}
+func (*SetNameConditionIR) conditionIRNode()    {}
 
 // SegueChainConditionIR wraps a SegueChainIR for use as a regular WHERE condition.
 // The first segue chain in a WHERE is lifted to QueryIR.SegueChain (so the SQL
@@ -107,11 +144,85 @@ type PlayedConditionIR struct {
 	Negated bool
 }
 
+// PlayedSetConditionIR: PLAYED ALL (...) or PLAYED ANY (...). SongIDs holds
+// each song's variant IDs, in query order.
+type PlayedSetConditionIR struct {
+	SongIDs [][]int
+	All     bool
+	Negated bool
+}
+
 // GuestConditionIR: GUEST "Name"
 type GuestConditionIR struct {
 	Name string
 }
 
+// NotesConditionIR: NOTES CONTAINS "text"
+// Matched against shows.notes with a case-insensitive substring LIKE.
+type NotesConditionIR struct {
+	Text string
+}
+
+// CoverConditionIR: SONGS WHERE COVER (Cover=true) or SONGS WHERE ORIGINAL.
+type CoverConditionIR struct {
+	Cover bool
+}
+
+// RatingConditionIR: RATING >= 4.5 — compared against shows.rating; unrated
+// shows never match.
+type RatingConditionIR struct {
+	Operator CompOp
+	Value    float64
+}
+
+// SongCountConditionIR: SHOWS WHERE SONGS > 20 — compared against the show's
+// performance count; shows without a setlist never match.
+type SongCountConditionIR struct {
+	Operator CompOp
+	Count    int
+}
+
+// PlayedOnceConditionIR: SONGS PLAYED ONCE — the song has exactly one row in
+// performances. Counted there rather than read from songs.times_played, which
+// is only as fresh as the last import's recount.
+type PlayedOnceConditionIR struct{}
+
+// MissingFieldConditionIR: SONGS WHERE NO SHORT_NAME / NO WRITERS — the song
+// column is NULL or empty. Field is "SHORT_NAME" or "WRITERS".
+type MissingFieldConditionIR struct {
+	Field string
+}
+
+// TimesPlayedConditionIR: SONGS WHERE TIMES_PLAYED > 100 — compared against
+// songs.times_played, or the in-range count for SONGS PLAYED IN.
+type TimesPlayedConditionIR struct {
+	Operator CompOp
+	Count    int
+}
+
+// TapeConditionIR: TAPE — some performance in the show has tape = 1.
+type TapeConditionIR struct{}
+
+// SourceConditionIR: SOURCE = "SBD" — matched case-insensitively against show_recordings.source.
+type SourceConditionIR struct {
+	Source string
+}
+
+// CompleteConditionIR: COMPLETE / NOT COMPLETE — the setlist completeness heuristic.
+type CompleteConditionIR struct {
+	Negated bool
+}
+
+// WeekdayConditionIR: WEEKDAY = "Saturday" — the show date's day of week (0 = Sunday).
+type WeekdayConditionIR struct {
+	Day int
+}
+
+// SetNameConditionIR: SET NAMED "Acoustic" — substring LIKE on performances.set_name.
+type SetNameConditionIR struct {
+	Name string
+}
+
 // NegatedSegueConditionIR: "Song A" NOT > "Song B"
 // Matches shows where Song A was played and the next adjacent song was NOT Song B.
 type NegatedSegueConditionIR struct {
@@ -184,4 +295,6 @@ const (
 	OutputCalendar
 	OutputTable
 	OutputCount
+	OutputHTML
+	OutputClassic // compact one-line-per-set setlist
 )
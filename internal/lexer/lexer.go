@@ -347,6 +347,15 @@ func (l *lexer) readNumberOrDuration(start token.Position) token.Token {
 		b.WriteRune(l.ch)
 		l.readChar()
 	}
+	// Decimal part, e.g. RATING >= 4.5
+	if l.ch == '.' && unicode.IsDigit(l.peekChar()) {
+		b.WriteRune(l.ch)
+		l.readChar()
+		for unicode.IsDigit(l.ch) {
+			b.WriteRune(l.ch)
+			l.readChar()
+		}
+	}
 	numLit := b.String()
 	// Check for duration: 20min, 20 min, 15sec, etc.
 	if l.ch == ' ' {
@@ -410,6 +419,8 @@ func lookupIdent(ident string) token.TokenType {
 		return token.WITH
 	case "WRITTEN":
 		return token.WRITTEN
+	case "DEBUTED", "DEBUT":
+		return token.DEBUTED
 	case "ORDER":
 		return token.ORDER
 	case "BY":
@@ -482,6 +493,36 @@ func lookupIdent(ident string) token.TokenType {
 		return token.ASC
 	case "DESC":
 		return token.DESC
+	case "NOTES":
+		return token.NOTES
+	case "CONTAINS":
+		return token.CONTAINS
+	case "COVER", "COVERS":
+		return token.COVER
+	case "ORIGINAL", "ORIGINALS":
+		return token.ORIGINAL
+	case "VENUES", "VENUE":
+		return token.VENUES
+	case "TAPE":
+		return token.TAPE
+	case "SOURCE":
+		return token.SOURCE
+	case "COMPLETE":
+		return token.COMPLETE
+	case "WEEKDAY":
+		return token.WEEKDAY
+	case "RUNS", "RUN":
+		return token.RUNS
+	case "STATS":
+		return token.STATS
+	case "COMPARE":
+		return token.COMPARE
+	case "BEST":
+		return token.BEST
+	case "COVERAGE":
+		return token.COVERAGE
+	case "GAPS":
+		return token.GAPS
 	default:
 		return token.ILLEGAL
 	}
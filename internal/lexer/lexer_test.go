@@ -61,6 +61,12 @@ func TestLexer_NextToken_Duration(t *testing.T) {
 	require.Equal(t, token.EOF, l.NextToken().Type)
 }
 
+func TestLexer_NextToken_Decimal(t *testing.T) {
+	l := New("4.5 4. 12")
+	require.Equal(t, token.Token{Type: token.NUMBER, Literal: "4.5"}, tokenWithoutPos(l.NextToken()))
+	require.Equal(t, token.Token{Type: token.NUMBER, Literal: "4"}, tokenWithoutPos(l.NextToken()), "a dot needs a digit after it")
+}
+
 func TestLexer_NextToken_Comment(t *testing.T) {
 	l := New("SHOWS -- comment\nFROM 1977;")
 	require.Equal(t, token.SHOWS, l.NextToken().Type)
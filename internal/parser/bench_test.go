@@ -0,0 +1,21 @@
+package parser
+
+import "testing"
+
+var benchQueries = []string{
+	`SHOWS FROM 1977;`,
+	`SHOWS FROM 77-80 WHERE "Scarlet Begonias" > "Fire on the Mountain" ORDER BY DATE DESC LIMIT 10;`,
+	`SHOWS WHERE PLAYED "Dark Star" OR PLAYED "St. Stephen" AND SET2 OPENED "Samson and Delilah";`,
+	`PERFORMANCES OF "Dark Star" FROM 1972 WITH LENGTH > 20min;`,
+	`SONGS WITH LYRICS("rose", "garden") WRITTEN 1968-1970;`,
+}
+
+func BenchmarkParse(b *testing.B) {
+	for b.Loop() {
+		for _, q := range benchQueries {
+			if _, err := NewFromString(q).Parse(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
@@ -22,6 +22,18 @@ type parser struct {
 	cur   token.Token
 	peek  token.Token
 	query string
+	multi bool // ParseAll: a semicolon ends the statement, more may follow
+	opts  Options
+}
+
+// Options tunes how dates in a query are read.
+type Options struct {
+	// YearPivot decides the century of two-digit years: below it they are
+	// 20xx, from it up 19xx. The zero value reads every two-digit year as
+	// 19xx, so 69 is 1969 and 05 is 1905 — the band played 1965 to 1995, and
+	// a pivot like Go's 69 would put 5/8/05 and 12/31/69 in different
+	// centuries.
+	YearPivot int
 }
 
 // New creates a parser that reads from the given lexer.
@@ -34,8 +46,14 @@ func New(l lexer.Lexer) Parser {
 
 // NewFromString creates a parser for the given query string.
 func NewFromString(input string) Parser {
+	return NewFromStringWithOptions(input, Options{})
+}
+
+// NewFromStringWithOptions is NewFromString reading dates per opts.
+func NewFromStringWithOptions(input string, opts Options) Parser {
 	p := New(lexer.New(input)).(*parser)
 	p.query = input
+	p.opts = opts
 	return p
 }
 
@@ -48,6 +66,54 @@ func NewFromReader(r io.Reader) (Parser, error) {
 	return New(lexer.New(string(b))), nil
 }
 
+// ParseAll parses semicolon-separated statements, recovering after a bad one
+// by skipping to the next semicolon. It returns every query that parsed and,
+// if any failed, an errors.ParseErrors listing each failure with its position.
+func ParseAll(input string) ([]ast.Query, error) {
+	return ParseAllWithOptions(input, Options{})
+}
+
+// ParseAllWithOptions is ParseAll reading dates per opts.
+func ParseAllWithOptions(input string, opts Options) ([]ast.Query, error) {
+	p := NewFromStringWithOptions(input, opts).(*parser)
+	p.multi = true
+	var queries []ast.Query
+	var errs errors.ParseErrors
+	for {
+		for p.curIs(token.SEMICOLON) {
+			p.advance()
+		}
+		if p.curIs(token.EOF) {
+			break
+		}
+		q, err := p.Parse()
+		if err != nil {
+			pe, ok := err.(*errors.ParseError)
+			if !ok {
+				pe = &errors.ParseError{Pos: p.cur.Pos, Message: err.Error(), Query: p.query}
+			}
+			errs = append(errs, pe)
+			p.synchronize()
+			continue
+		}
+		queries = append(queries, q)
+	}
+	if len(errs) > 0 {
+		return queries, errs
+	}
+	return queries, nil
+}
+
+// synchronize skips to just past the next semicolon (or to EOF).
+func (p *parser) synchronize() {
+	for !p.curIs(token.EOF) && !p.curIs(token.SEMICOLON) {
+		p.advance()
+	}
+	for p.curIs(token.SEMICOLON) {
+		p.advance()
+	}
+}
+
 func (p *parser) advance() {
 	p.cur = p.peek
 	p.peek = p.lex.NextToken()
@@ -94,11 +160,41 @@ func (p *parser) Parse() (ast.Query, error) {
 		return p.parseFirstLastQuery()
 	case token.RANDOM:
 		return p.parseRandomShowQuery()
+	case token.VENUES:
+		return p.parseVenueQuery()
+	case token.RUNS:
+		return p.parseRunQuery()
+	case token.COMPARE:
+		return p.parseCompareQuery()
+	case token.COVERAGE:
+		return p.parseCoverageQuery()
+	case token.GAPS:
+		return p.parseGapsQuery()
+	case token.STATS:
+		if !p.peekIs(token.VENUES) {
+			return nil, &errors.ParseError{Pos: p.peek.Pos, Message: "expected VENUES after STATS", Query: p.query, Hint: "Try: STATS VENUES FROM 1977 LIMIT 10;"}
+		}
+		p.advance() // consume STATS
+		q, err := p.parseVenueQuery()
+		if q != nil {
+			q.Stats = true
+		}
+		return q, err
+	case token.BEST:
+		if !p.peekIs(token.SHOWS) {
+			return nil, &errors.ParseError{Pos: p.peek.Pos, Message: "expected SHOWS after BEST", Query: p.query, Hint: "Try: BEST SHOWS FROM 1977 LIMIT 10;"}
+		}
+		p.advance() // consume BEST
+		q, err := p.parseShowQuery()
+		if q != nil {
+			q.Best = true
+		}
+		return q, err
 	default:
 		// Suggest closest matching top-level keyword
-		topLevel := []string{"SHOWS", "SONGS", "PERFORMANCES", "SETLIST", "COUNT", "FIRST", "LAST", "RANDOM"}
+		topLevel := []string{"SHOWS", "SONGS", "PERFORMANCES", "SETLIST", "COUNT", "FIRST", "LAST", "RANDOM", "VENUES", "RUNS", "STATS", "COMPARE", "BEST", "COVERAGE", "GAPS"}
 		suggestion := errors.SuggestKeyword(p.cur.Literal, topLevel)
-		hint := "Queries start with SHOWS, SONGS, PERFORMANCES, SETLIST, COUNT, FIRST, LAST, or RANDOM."
+		hint := "Queries start with SHOWS, SONGS, PERFORMANCES, SETLIST, COUNT, FIRST, LAST, RANDOM, VENUES, RUNS, STATS, COMPARE, BEST, COVERAGE, or GAPS."
 		return nil, &errors.ParseError{
 			Pos:        p.cur.Pos,
 			Message:    fmt.Sprintf("unexpected %q, expected a query keyword", p.cur.Literal),
@@ -149,7 +245,7 @@ func (p *parser) parseShowQuery() (*ast.ShowQuery, error) {
 		q.Where = wc
 	}
 
-	if err := p.parseModifiers(q, nil, nil); err != nil {
+	if err := p.parseModifiers(q, nil, nil, nil, nil); err != nil {
 		return nil, err
 	}
 
@@ -165,7 +261,7 @@ func (p *parser) parseDateRangeWithDirection() (*ast.DateRange, error) {
 		if err != nil {
 			return nil, err
 		}
-		return &ast.DateRange{Start: start, End: &ast.Date{Year: 2100}}, nil
+		return &ast.DateRange{Start: start, End: &ast.Date{Year: ast.OpenEndYear}}, nil
 	}
 	if p.curIs(token.BEFORE) {
 		p.advance()
@@ -173,7 +269,7 @@ func (p *parser) parseDateRangeWithDirection() (*ast.DateRange, error) {
 		if err != nil {
 			return nil, err
 		}
-		return &ast.DateRange{Start: &ast.Date{Year: 1900}, End: end}, nil
+		return &ast.DateRange{Start: &ast.Date{Year: ast.OpenStartYear}, End: end}, nil
 	}
 	// FROM
 	p.advance()
@@ -182,6 +278,15 @@ func (p *parser) parseDateRangeWithDirection() (*ast.DateRange, error) {
 
 func (p *parser) parseDateRange() (*ast.DateRange, error) {
 	dr := &ast.DateRange{}
+	// -1972: open lower bound
+	if p.curIs(token.MINUS) && p.peekIs(token.NUMBER) {
+		p.advance() // consume -
+		end, _, err := p.parseDate()
+		if err != nil {
+			return nil, err
+		}
+		return &ast.DateRange{Start: &ast.Date{Year: ast.OpenStartYear}, End: end}, nil
+	}
 	start, era, err := p.parseDate()
 	if err != nil {
 		return nil, err
@@ -199,6 +304,10 @@ func (p *parser) parseDateRange() (*ast.DateRange, error) {
 			return nil, err
 		}
 		dr.End = end
+	} else if p.curIs(token.MINUS) && era == nil {
+		// 1977-: open upper bound
+		p.advance()
+		dr.End = &ast.Date{Year: ast.OpenEndYear}
 	}
 
 	return dr, nil
@@ -213,21 +322,26 @@ func (p *parser) parseDate() (*ast.Date, *ast.EraAlias, error) {
 	switch p.cur.Type {
 	case token.NUMBER:
 		y, _ := strconv.Atoi(p.cur.Literal)
-		// Two-digit years map to 19xx (the Grateful Dead were active 1965-1995).
-		// 65-99 → 1965-1999. 00-64 → assume nothing (Dead history doesn't extend).
-		if y < 100 {
-			y += 1900
-		}
-		d := &ast.Date{Year: y}
 		p.advance()
-		return d, nil, nil
+		// 5/8/77 is a single day, 5/77 a whole month
+		if p.curIs(token.SLASH) {
+			d, err := p.parseSlashDate(y)
+			return d, nil, err
+		}
+		// 1977-05-08 is a single day, 1977-05 a whole month; 1977-80 stays
+		// a range of years
+		if p.curIs(token.MINUS) && y >= 1900 && isISOMonth(p.peek) {
+			d, err := p.parseISODate(y)
+			return d, nil, err
+		}
+		return &ast.Date{Year: p.fullYear(y)}, nil, nil
 	default:
 		break
 	}
 	// Suggest closest era alias if input looks like an attempted era
 	eras := []string{"PRIMAL", "EUROPE72", "WALLOFSOUND", "HIATUS", "BRENT_ERA", "VINCE_ERA"}
 	suggestion := errors.SuggestKeyword(p.cur.Literal, eras)
-	hint := "Use a year (1977), range (1977-1980), date (5/8/77), or era alias (PRIMAL, EUROPE72, BRENT_ERA, etc.)."
+	hint := "Use a year (1977), range (1977-1980), date (5/8/77 or 1977-05-08), or era alias (PRIMAL, EUROPE72, BRENT_ERA, etc.)."
 	return nil, nil, &errors.ParseError{
 		Pos:        p.cur.Pos,
 		Message:    fmt.Sprintf("expected date or era, got %q", p.cur.Literal),
@@ -268,6 +382,8 @@ func (p *parser) parseWhereClause() (*ast.WhereClause, error) {
 	if err != nil {
 		return nil, err
 	}
+	// AND binds tighter than OR: each OR starts a new AND-group.
+	group := []ast.Condition{cond}
 	wc.Conditions = append(wc.Conditions, cond)
 	// PLAYED "A" > "B" — after PLAYED we may have a segue; parse it and add as second condition
 	if playCond, ok := cond.(*ast.PlayedCondition); ok && p.parseSegueOp() != nil {
@@ -276,6 +392,8 @@ func (p *parser) parseWhereClause() (*ast.WhereClause, error) {
 			return nil, segErr
 		}
 		wc.Conditions = append(wc.Conditions, segCond)
+		wc.Operators = append(wc.Operators, ast.OpAnd)
+		group = append(group, segCond)
 	}
 
 	for p.curIs(token.AND) || p.curIs(token.OR) {
@@ -283,6 +401,8 @@ func (p *parser) parseWhereClause() (*ast.WhereClause, error) {
 			wc.Operators = append(wc.Operators, ast.OpAnd)
 		} else {
 			wc.Operators = append(wc.Operators, ast.OpOr)
+			wc.Groups = append(wc.Groups, group)
+			group = nil
 		}
 		p.advance()
 		next, err := p.parseCondition()
@@ -290,7 +410,9 @@ func (p *parser) parseWhereClause() (*ast.WhereClause, error) {
 			return nil, err
 		}
 		wc.Conditions = append(wc.Conditions, next)
+		group = append(group, next)
 	}
+	wc.Groups = append(wc.Groups, group)
 
 	return wc, nil
 }
@@ -329,9 +451,21 @@ func (p *parser) parseCondition() (ast.Condition, error) {
 			}
 			return &ast.PositionCondition{Set: ast.Encore, Operator: ast.PosEquals, Song: ref, Negated: true}, nil
 		}
+		// NOT COMPLETE — partial setlists
+		if p.curIs(token.COMPLETE) {
+			p.advance()
+			return &ast.CompleteCondition{Negated: true}, nil
+		}
 		// Optional PLAYED keyword: NOT PLAYED "X" === NOT "X"
 		if p.curIs(token.PLAYED) {
 			p.advance()
+			if isWord(p.cur, "ALL") || isWord(p.cur, "ANY") {
+				c, err := p.parsePlayedSet()
+				if c != nil {
+					c.Negated = true
+				}
+				return c, err
+			}
 		}
 		ref, err := p.parseSongRef()
 		if err != nil {
@@ -341,7 +475,7 @@ func (p *parser) parseCondition() (ast.Condition, error) {
 	}
 
 	// SET1 OPENED "Song" / ENCORE = "Song"
-	if p.cur.Type == token.SET1 || p.cur.Type == token.SET2 || p.cur.Type == token.SET3 || p.cur.Type == token.ENCORE {
+	if isSetToken(p.cur) {
 		set := p.parseSetPosition()
 		p.advance()
 		op := ast.PosOpened
@@ -418,6 +552,9 @@ func (p *parser) parseCondition() (ast.Condition, error) {
 	// PLAYED "Song" [> "Song" ...] — optional segue after PLAYED
 	if p.curIs(token.PLAYED) {
 		p.advance()
+		if isWord(p.cur, "ALL") || isWord(p.cur, "ANY") {
+			return p.parsePlayedSet()
+		}
 		ref, err := p.parseSongRef()
 		if err != nil {
 			return nil, err
@@ -436,6 +573,75 @@ func (p *parser) parseCondition() (ast.Condition, error) {
 		return &ast.GuestCondition{Name: name}, nil
 	}
 
+	// NOTES CONTAINS "text"
+	if p.curIs(token.NOTES) {
+		p.advance()
+		if !p.curIs(token.CONTAINS) {
+			return nil, &errors.ParseError{Pos: p.cur.Pos, Message: "expected CONTAINS after NOTES", Query: p.query, Hint: "Try: SHOWS WHERE NOTES CONTAINS \"soundboard\";"}
+		}
+		p.advance()
+		if !p.curIs(token.STRING) {
+			return nil, &errors.ParseError{Pos: p.cur.Pos, Message: "expected string after NOTES CONTAINS", Query: p.query}
+		}
+		text := p.cur.Literal
+		p.advance()
+		return &ast.NotesCondition{Text: text}, nil
+	}
+
+	// TAPE
+	if p.curIs(token.TAPE) {
+		p.advance()
+		return &ast.TapeCondition{}, nil
+	}
+
+	// COMPLETE
+	if p.curIs(token.COMPLETE) {
+		p.advance()
+		return &ast.CompleteCondition{}, nil
+	}
+
+	// SOURCE = "SBD"
+	if p.curIs(token.SOURCE) {
+		p.advance()
+		if !p.curIs(token.EQ) {
+			return nil, &errors.ParseError{Pos: p.cur.Pos, Message: "expected = after SOURCE", Query: p.query, Hint: "Try: SHOWS WHERE SOURCE = \"SBD\";"}
+		}
+		p.advance()
+		if !p.curIs(token.STRING) {
+			return nil, &errors.ParseError{Pos: p.cur.Pos, Message: "expected string after SOURCE =", Query: p.query, Hint: "Sources are SBD, MATRIX, FM, and AUD"}
+		}
+		src := p.cur.Literal
+		p.advance()
+		return &ast.SourceCondition{Source: src}, nil
+	}
+
+	// WEEKDAY = "Saturday"
+	if p.curIs(token.WEEKDAY) {
+		return p.parseWeekday()
+	}
+
+	// SET NAMED "Acoustic"
+	if isWord(p.cur, "SET") && isWord(p.peek, "NAMED") {
+		p.advance()
+		p.advance()
+		if !p.curIs(token.STRING) {
+			return nil, &errors.ParseError{Pos: p.cur.Pos, Message: "expected string after SET NAMED", Query: p.query, Hint: "Try: SHOWS WHERE SET NAMED \"Acoustic\";"}
+		}
+		name := p.cur.Literal
+		p.advance()
+		return &ast.SetNameCondition{Name: name}, nil
+	}
+
+	// RATING >= 4.5
+	if isWord(p.cur, "RATING") {
+		return p.parseRating()
+	}
+
+	// SONGS > 20
+	if p.curIs(token.SONGS) {
+		return p.parseSongCount()
+	}
+
 	// LENGTH ( "Song" ) > 20min or LENGTH > 20min
 	if p.curIs(token.LENGTH) {
 		p.advance()
@@ -670,6 +876,116 @@ func (p *parser) parseCompOp() *ast.CompOp {
 	}
 }
 
+// isOpenRange reports whether dr is open-ended (FROM 1977-, FROM -1972).
+func isOpenRange(dr *ast.DateRange) bool {
+	return (dr.Start != nil && dr.Start.Year == ast.OpenStartYear) || (dr.End != nil && dr.End.Year == ast.OpenEndYear)
+}
+
+func isTimesPlayed(t token.Token) bool {
+	return isWord(t, "TIMES_PLAYED")
+}
+
+// isWord reports whether t is the bare word w. Words that aren't keywords
+// lex as ILLEGAL, like ORDER BY fields.
+func isWord(t token.Token, w string) bool {
+	return t.Type == token.ILLEGAL && strings.EqualFold(t.Literal, w)
+}
+
+// parseTimesPlayed parses TIMES_PLAYED <op> N; cur is TIMES_PLAYED.
+func (p *parser) parseTimesPlayed() (*ast.TimesPlayedCondition, error) {
+	p.advance()
+	op := p.parseCompOp()
+	if op == nil {
+		return nil, &errors.ParseError{Pos: p.cur.Pos, Message: "expected comparison after TIMES_PLAYED", Query: p.query, Hint: "Try: TIMES_PLAYED > 100"}
+	}
+	p.advance()
+	if !p.curIs(token.NUMBER) {
+		return nil, &errors.ParseError{Pos: p.cur.Pos, Message: "expected number after TIMES_PLAYED comparison", Query: p.query}
+	}
+	n, err := strconv.Atoi(p.cur.Literal)
+	if err != nil {
+		return nil, &errors.ParseError{Pos: p.cur.Pos, Message: "invalid number " + p.cur.Literal, Query: p.query}
+	}
+	p.advance()
+	return &ast.TimesPlayedCondition{Operator: *op, Count: n}, nil
+}
+
+// parseRating parses RATING <op> N, where N may be a decimal; cur is RATING.
+func (p *parser) parseRating() (*ast.RatingCondition, error) {
+	p.advance()
+	op := p.parseCompOp()
+	if op == nil {
+		return nil, &errors.ParseError{Pos: p.cur.Pos, Message: "expected comparison after RATING", Query: p.query, Hint: "Try: SHOWS WHERE RATING >= 4.5;"}
+	}
+	p.advance()
+	if !p.curIs(token.NUMBER) {
+		return nil, &errors.ParseError{Pos: p.cur.Pos, Message: "expected number after RATING comparison", Query: p.query, Hint: "Ratings run from 0 to 5, e.g. RATING >= 4.5"}
+	}
+	v, err := strconv.ParseFloat(p.cur.Literal, 64)
+	if err != nil {
+		return nil, &errors.ParseError{Pos: p.cur.Pos, Message: "invalid number " + p.cur.Literal, Query: p.query}
+	}
+	p.advance()
+	return &ast.RatingCondition{Operator: *op, Value: v}, nil
+}
+
+// parseSongCount parses SONGS <op> N; cur is SONGS.
+func (p *parser) parseSongCount() (*ast.SongCountCondition, error) {
+	p.advance()
+	op := p.parseCompOp()
+	if op == nil {
+		return nil, &errors.ParseError{Pos: p.cur.Pos, Message: "expected comparison after SONGS", Query: p.query, Hint: "Try: SHOWS FROM 1977 WHERE SONGS > 20;"}
+	}
+	p.advance()
+	if !p.curIs(token.NUMBER) {
+		return nil, &errors.ParseError{Pos: p.cur.Pos, Message: "expected number after SONGS comparison", Query: p.query}
+	}
+	n, err := strconv.Atoi(p.cur.Literal)
+	if err != nil {
+		return nil, &errors.ParseError{Pos: p.cur.Pos, Message: "invalid number " + p.cur.Literal, Query: p.query}
+	}
+	p.advance()
+	return &ast.SongCountCondition{Operator: *op, Count: n}, nil
+}
+
+// weekdays lists day names in strftime('%w') order (0 = Sunday).
+var weekdays = []string{"SUNDAY", "MONDAY", "TUESDAY", "WEDNESDAY", "THURSDAY", "FRIDAY", "SATURDAY"}
+
+// parseWeekday parses WEEKDAY = "Saturday". Names are case-insensitive and
+// may be abbreviated to three letters ("Sat").
+func (p *parser) parseWeekday() (*ast.WeekdayCondition, error) {
+	p.advance()
+	if !p.curIs(token.EQ) {
+		return nil, &errors.ParseError{Pos: p.cur.Pos, Message: "expected = after WEEKDAY", Query: p.query, Hint: "Try: SHOWS WHERE WEEKDAY = \"Saturday\";"}
+	}
+	p.advance()
+	if !p.curIs(token.STRING) {
+		return nil, &errors.ParseError{Pos: p.cur.Pos, Message: "expected day name after WEEKDAY =", Query: p.query, Hint: "Try: SHOWS WHERE WEEKDAY = \"Saturday\";"}
+	}
+	name := strings.ToUpper(strings.TrimSpace(p.cur.Literal))
+	for i, d := range weekdays {
+		if name == d || name == d[:3] {
+			p.advance()
+			return &ast.WeekdayCondition{Day: i}, nil
+		}
+	}
+	suggestion := errors.SuggestKeyword(name, weekdays)
+	if suggestion != "" {
+		suggestion = suggestion[:1] + strings.ToLower(suggestion[1:])
+	}
+	return nil, &errors.ParseError{
+		Pos:        p.cur.Pos,
+		Message:    fmt.Sprintf("unknown weekday %q", p.cur.Literal),
+		Query:      p.query,
+		Hint:       "Days are Sunday through Saturday (or Sun, Mon, ... Sat).",
+		DidYouMean: suggestion,
+	}
+}
+
+func isSetToken(t token.Token) bool {
+	return t.Type == token.SET1 || t.Type == token.SET2 || t.Type == token.SET3 || t.Type == token.ENCORE
+}
+
 func (p *parser) parseSetPosition() ast.SetPosition {
 	switch p.cur.Type {
 	case token.SET1:
@@ -684,6 +1000,34 @@ func (p *parser) parseSetPosition() ast.SetPosition {
 	return ast.SetAny
 }
 
+// parsePlayedSet parses ALL|ANY ( "Song", "Song" ... ) after PLAYED; cur is
+// ALL or ANY.
+func (p *parser) parsePlayedSet() (*ast.PlayedSetCondition, error) {
+	c := &ast.PlayedSetCondition{All: isWord(p.cur, "ALL")}
+	p.advance()
+	if !p.curIs(token.LPAREN) {
+		return nil, &errors.ParseError{Pos: p.cur.Pos, Message: "expected ( after PLAYED ALL or ANY", Query: p.query,
+			Hint: `Try: SHOWS WHERE PLAYED ALL ("Scarlet Begonias", "Fire on the Mountain");`}
+	}
+	p.advance()
+	for {
+		ref, err := p.parseSongRef()
+		if err != nil {
+			return nil, err
+		}
+		c.Songs = append(c.Songs, ref)
+		if !p.curIs(token.COMMA) {
+			break
+		}
+		p.advance()
+	}
+	if !p.curIs(token.RPAREN) {
+		return nil, &errors.ParseError{Pos: p.cur.Pos, Message: "expected , or ) in song list", Query: p.query}
+	}
+	p.advance()
+	return c, nil
+}
+
 func (p *parser) parseSongRef() (*ast.SongRef, error) {
 	if !p.curIs(token.STRING) {
 		msg := "expected quoted song name"
@@ -695,7 +1039,7 @@ func (p *parser) parseSongRef() (*ast.SongRef, error) {
 	return ref, nil
 }
 
-func (p *parser) parseModifiers(show *ast.ShowQuery, song *ast.SongQuery, perf *ast.PerformanceQuery) error {
+func (p *parser) parseModifiers(show *ast.ShowQuery, song *ast.SongQuery, perf *ast.PerformanceQuery, venue *ast.VenueQuery, run *ast.RunQuery) error {
 	for {
 		if p.curIs(token.ORDER) {
 			p.advance()
@@ -708,10 +1052,18 @@ func (p *parser) parseModifiers(show *ast.ShowQuery, song *ast.SongQuery, perf *
 					Pos:     p.cur.Pos,
 					Message: "expected field name after ORDER BY",
 					Query:   p.query,
-					Hint:    "Allowed fields: DATE, LENGTH, NAME, TIMES_PLAYED, POSITION",
+					Hint:    "Allowed fields: DATE, LENGTH, NAME, TIMES_PLAYED, POSITION, AVG_LENGTH, RATING, SHOWS (venues)",
 				}
 			}
-			field := p.cur.Literal
+			field := strings.ToUpper(p.cur.Literal)
+			// TIMES_PLAYED counts a song's performances; a venue's number is
+			// shows, and SHOWS is only that.
+			if venue != nil && field == "TIMES_PLAYED" {
+				return &errors.ParseError{Pos: p.cur.Pos, Message: "VENUES can't be ordered by TIMES_PLAYED", Query: p.query, Hint: "Order venues by how many shows they hosted: VENUES FROM 1977 ORDER BY SHOWS DESC"}
+			}
+			if venue == nil && field == "SHOWS" {
+				return &errors.ParseError{Pos: p.cur.Pos, Message: "ORDER BY SHOWS only applies to VENUES", Query: p.query, Hint: "Songs are ordered by TIMES_PLAYED: SONGS ORDER BY TIMES_PLAYED DESC"}
+			}
 			p.advance()
 			desc := false
 			if p.curIs(token.DESC) {
@@ -730,6 +1082,9 @@ func (p *parser) parseModifiers(show *ast.ShowQuery, song *ast.SongQuery, perf *
 			if perf != nil {
 				perf.OrderBy = oc
 			}
+			if venue != nil {
+				venue.OrderBy = oc
+			}
 			continue
 		}
 		if p.curIs(token.LIMIT) {
@@ -756,6 +1111,12 @@ func (p *parser) parseModifiers(show *ast.ShowQuery, song *ast.SongQuery, perf *
 			if perf != nil {
 				perf.Limit = &n
 			}
+			if venue != nil {
+				venue.Limit = &n
+			}
+			if run != nil {
+				run.Limit = &n
+			}
 			continue
 		}
 		if p.curIs(token.AS) {
@@ -768,6 +1129,15 @@ func (p *parser) parseModifiers(show *ast.ShowQuery, song *ast.SongQuery, perf *
 			if song != nil {
 				song.OutputFmt = fmt
 			}
+			if perf != nil {
+				perf.OutputFmt = fmt
+			}
+			if venue != nil {
+				venue.OutputFmt = fmt
+			}
+			if run != nil {
+				run.OutputFmt = fmt
+			}
 			continue
 		}
 		break
@@ -780,7 +1150,7 @@ func (p *parser) parseModifiers(show *ast.ShowQuery, song *ast.SongQuery, perf *
 // because the field name was concatenated into the generated SQL.
 func isOrderField(t token.Token) bool {
 	s := strings.ToUpper(t.Literal)
-	return s == "DATE" || s == "LENGTH" || s == "NAME" || s == "TIMES_PLAYED" || s == "POSITION"
+	return s == "DATE" || s == "LENGTH" || s == "NAME" || s == "TIMES_PLAYED" || s == "POSITION" || s == "AVG_LENGTH" || s == "RATING" || s == "SHOWS"
 }
 
 func (p *parser) parseOutputFormat() ast.OutputFormat {
@@ -797,18 +1167,20 @@ func (p *parser) parseOutputFormat() ast.OutputFormat {
 		return ast.OutputTable
 	case "COUNT":
 		return ast.OutputCount
+	case "HTML":
+		return ast.OutputHTML
+	case "CLASSIC":
+		return ast.OutputClassic
 	}
 	return ast.OutputDefault
 }
 
 func (p *parser) optionalSemicolon() error {
-	if p.curIs(token.SEMICOLON) {
-		p.advance()
-	}
+	terminated := p.curIs(token.SEMICOLON)
 	for p.curIs(token.SEMICOLON) {
 		p.advance()
 	}
-	if p.curIs(token.EOF) {
+	if p.curIs(token.EOF) || (p.multi && terminated) {
 		return nil
 	}
 
@@ -837,7 +1209,7 @@ func (p *parser) optionalSemicolon() error {
 	}
 	if msg == "" {
 		// Generic — try to suggest a closest keyword
-		clauseKeywords := []string{"FROM", "WHERE", "AT", "TOUR", "ORDER", "LIMIT", "AS", "WITH", "WRITTEN"}
+		clauseKeywords := []string{"FROM", "WHERE", "AT", "TOUR", "ORDER", "LIMIT", "AS", "WITH", "WRITTEN", "DEBUTED"}
 		suggestion := errors.SuggestKeyword(p.cur.Literal, clauseKeywords)
 		msg = fmt.Sprintf("unexpected %q after query", p.cur.Literal)
 		if suggestion == "" {
@@ -852,6 +1224,13 @@ func (p *parser) parseSongQuery() (*ast.SongQuery, error) {
 	q := &ast.SongQuery{}
 	p.advance()
 
+	// SONGS IN SET2 [FROM 1977]: songs ranked by plays in that set
+	if p.curIs(token.IN) && isSetToken(p.peek) {
+		p.advance()
+		q.InSet = p.parseSetPosition()
+		p.advance()
+	}
+
 	// SONGS FROM 1977 / SONGS PLAYED IN 1977
 	if p.curIs(token.FROM) || p.curIs(token.IN) || p.curIs(token.AFTER) || p.curIs(token.BEFORE) {
 		dr, err := p.parseDateRangeWithDirection()
@@ -860,38 +1239,127 @@ func (p *parser) parseSongQuery() (*ast.SongQuery, error) {
 		}
 		q.From = dr
 	}
-	if p.curIs(token.PLAYED) {
+	if p.curIs(token.PLAYED) && isWord(p.peek, "ONCE") {
+		// SONGS PLAYED ONCE: one-offs over the whole history, so no range or set
+		if q.From != nil || q.InSet != ast.SetAny {
+			return nil, &errors.ParseError{Pos: p.cur.Pos, Message: "PLAYED ONCE counts every performance and takes no date range or set", Query: p.query, Hint: "Try: SONGS PLAYED ONCE; or SONGS PLAYED ONCE DEBUTED FROM 1977;"}
+		}
+		p.advance()
+		p.advance()
+		q.Once = true
+	} else if p.curIs(token.PLAYED) {
 		p.advance()
+		// PLAYED EVERY YEAR FROM 1977-1980 (EVERY/YEAR are bare words, not keywords)
+		if p.cur.Type == token.ILLEGAL && strings.EqualFold(p.cur.Literal, "EVERY") {
+			p.advance()
+			if p.cur.Type != token.ILLEGAL || !strings.EqualFold(p.cur.Literal, "YEAR") {
+				return nil, &errors.ParseError{Pos: p.cur.Pos, Message: "expected YEAR after PLAYED EVERY", Query: p.query, Hint: "Try: SONGS PLAYED EVERY YEAR FROM 1977-1980;"}
+			}
+			p.advance()
+			q.EveryYear = true
+		}
 		// Skip optional FROM/IN keyword
 		if p.curIs(token.FROM) || p.curIs(token.IN) {
 			p.advance()
 		}
+		pos := p.cur.Pos
 		dr, err := p.parseDateRange()
 		if err != nil {
 			return nil, err
 		}
+		if q.EveryYear && isOpenRange(dr) {
+			return nil, &errors.ParseError{Pos: pos, Message: "PLAYED EVERY YEAR needs a closed date range", Query: p.query, Hint: "Try: SONGS PLAYED EVERY YEAR FROM 1977-1980;"}
+		}
 		q.From = dr
 	}
 
-	if p.curIs(token.WITH) {
+	// SONGS WHERE COVER / ORIGINAL / NO WRITERS / TIMES_PLAYED > 100, joined by AND
+	if p.curIs(token.WHERE) {
 		p.advance()
-		wc, err := p.parseWithClause()
+		q.Where = &ast.WhereClause{}
+		for {
+			var cond ast.Condition
+			switch {
+			case p.curIs(token.COVER) || p.curIs(token.ORIGINAL):
+				cond = &ast.CoverCondition{Cover: p.curIs(token.COVER)}
+				p.advance()
+			case isTimesPlayed(p.cur):
+				tp, err := p.parseTimesPlayed()
+				if err != nil {
+					return nil, err
+				}
+				cond = tp
+			case isWord(p.cur, "NO"):
+				p.advance()
+				if !isWord(p.cur, "SHORT_NAME") && !isWord(p.cur, "WRITERS") {
+					return nil, &errors.ParseError{Pos: p.cur.Pos, Message: "expected SHORT_NAME or WRITERS after NO", Query: p.query, Hint: "Try: SONGS WHERE NO WRITERS;"}
+				}
+				cond = &ast.MissingFieldCondition{Field: strings.ToUpper(p.cur.Literal)}
+				p.advance()
+			default:
+				return nil, &errors.ParseError{Pos: p.cur.Pos, Message: "expected COVER or ORIGINAL, NO SHORT_NAME or NO WRITERS, or a TIMES_PLAYED comparison, after SONGS WHERE", Query: p.query, Hint: "Try: SONGS WHERE COVER FROM 1977; or SONGS WHERE TIMES_PLAYED < 10;"}
+			}
+			if len(q.Where.Conditions) > 0 {
+				q.Where.Operators = append(q.Where.Operators, ast.OpAnd)
+			}
+			q.Where.Conditions = append(q.Where.Conditions, cond)
+			if !p.curIs(token.AND) {
+				break
+			}
+			p.advance()
+		}
+	}
+
+	// SONGS WHERE COVER FROM 1977 reads naturally too
+	if q.From == nil && (p.curIs(token.FROM) || p.curIs(token.IN) || p.curIs(token.AFTER) || p.curIs(token.BEFORE)) {
+		dr, err := p.parseDateRangeWithDirection()
 		if err != nil {
 			return nil, err
 		}
-		q.With = wc
+		q.From = dr
 	}
 
-	if p.curIs(token.WRITTEN) {
+	// WITH, WRITTEN, and DEBUTED in any order, each at most once; they AND together.
+	for p.curIs(token.WITH) || p.curIs(token.WRITTEN) || p.curIs(token.DEBUTED) {
+		kw := p.cur
+		if (kw.Type == token.WITH && q.With != nil) || (kw.Type == token.WRITTEN && q.Written != nil) || (kw.Type == token.DEBUTED && q.Debuted != nil) {
+			return nil, &errors.ParseError{Pos: kw.Pos, Message: strings.ToUpper(kw.Literal) + " given twice", Query: p.query}
+		}
 		p.advance()
-		dr, err := p.parseDateRange()
-		if err != nil {
-			return nil, err
+		switch kw.Type {
+		case token.WITH:
+			pos := p.cur.Pos
+			wc, err := p.parseWithClause()
+			if err != nil {
+				return nil, err
+			}
+			if wc.Context {
+				return nil, &errors.ParseError{Pos: pos, Message: "WITH CONTEXT applies to PERFORMANCES, not SONGS", Query: p.query, Hint: `Try: PERFORMANCES OF "Fire on the Mountain" WITH CONTEXT;`}
+			}
+			q.With = wc
+		case token.WRITTEN:
+			dr, err := p.parseDateRange()
+			if err != nil {
+				return nil, err
+			}
+			q.Written = dr
+		case token.DEBUTED:
+			// DEBUTED FROM 1977 / DEBUTED IN 1977 / DEBUTED 1977 / DEBUTED AFTER 1990
+			var dr *ast.DateRange
+			var err error
+			if p.curIs(token.FROM) || p.curIs(token.IN) || p.curIs(token.AFTER) || p.curIs(token.BEFORE) {
+				dr, err = p.parseDateRangeWithDirection()
+			} else {
+				dr, err = p.parseDateRange()
+			}
+			if err != nil {
+				return nil, err
+			}
+			q.Debuted = dr
 		}
-		q.Written = dr
 	}
 
-	if err := p.parseModifiers(nil, q, nil); err != nil {
+	if err := p.parseModifiers(nil, q, nil, nil, nil); err != nil {
 		return nil, err
 	}
 
@@ -946,6 +1414,28 @@ func (p *parser) parseWithClause() (*ast.WithClause, error) {
 			}
 			break
 		}
+		if isTimesPlayed(p.cur) {
+			tp, err := p.parseTimesPlayed()
+			if err != nil {
+				return nil, err
+			}
+			wc.Conditions = append(wc.Conditions, tp)
+			if p.curIs(token.COMMA) || p.curIs(token.AND) || p.curIs(token.OR) {
+				p.advance()
+				continue
+			}
+			break
+		}
+		// CONTEXT: PERFORMANCES list the songs before and after each one
+		if isWord(p.cur, "CONTEXT") {
+			wc.Context = true
+			p.advance()
+			if p.curIs(token.COMMA) || p.curIs(token.AND) || p.curIs(token.OR) {
+				p.advance()
+				continue
+			}
+			break
+		}
 		if p.curIs(token.GUEST) {
 			p.advance()
 			if !p.curIs(token.STRING) {
@@ -976,6 +1466,14 @@ func (p *parser) parsePerformanceQuery() (*ast.PerformanceQuery, error) {
 		return nil, err
 	}
 	q.Song = ref
+	for p.curIs(token.COMMA) {
+		p.advance()
+		ref, err := p.parseSongRef()
+		if err != nil {
+			return nil, err
+		}
+		q.More = append(q.More, ref)
+	}
 
 	if p.curIs(token.FROM) || p.curIs(token.IN) || p.curIs(token.AFTER) || p.curIs(token.BEFORE) {
 		dr, err := p.parseDateRangeWithDirection()
@@ -987,14 +1485,37 @@ func (p *parser) parsePerformanceQuery() (*ast.PerformanceQuery, error) {
 
 	if p.curIs(token.WITH) {
 		p.advance()
+		pos := p.cur.Pos
 		wc, err := p.parseWithClause()
 		if err != nil {
 			return nil, err
 		}
+		// TIMES_PLAYED filters songs; a performance has no count to compare.
+		for _, c := range wc.Conditions {
+			if _, ok := c.(*ast.TimesPlayedCondition); ok {
+				return nil, &errors.ParseError{Pos: pos, Message: "WITH TIMES_PLAYED applies to SONGS, not PERFORMANCES", Query: p.query, Hint: `Try: SONGS WITH TIMES_PLAYED > 5; or PERFORMANCES OF "Dark Star" WITH LENGTH > 20min;`}
+			}
+		}
 		q.With = wc
 	}
 
-	if err := p.parseModifiers(nil, nil, q); err != nil {
+	if p.curIs(token.BY) {
+		p.advance()
+		if !isWord(p.cur, "YEAR") {
+			return nil, &errors.ParseError{Pos: p.cur.Pos, Message: "expected YEAR after BY", Query: p.query, Hint: "Try: PERFORMANCES OF \"Dark Star\" BY YEAR;"}
+		}
+		p.advance()
+		q.ByYear = true
+		// The generic trailing-clause errors would blame WHERE here
+		switch {
+		case p.curIs(token.FROM), p.curIs(token.AFTER), p.curIs(token.BEFORE):
+			return nil, &errors.ParseError{Pos: p.cur.Pos, Message: fmt.Sprintf("%s must come before BY YEAR", p.cur.Literal), Query: p.query, Hint: "Try: PERFORMANCES OF \"Dark Star\" FROM 1972-1974 BY YEAR;"}
+		case p.curIs(token.WITH):
+			return nil, &errors.ParseError{Pos: p.cur.Pos, Message: "WITH must come before BY YEAR", Query: p.query, Hint: "Try: PERFORMANCES OF \"Dark Star\" WITH LENGTH > 20min BY YEAR;"}
+		}
+	}
+
+	if err := p.parseModifiers(nil, nil, q, nil, nil); err != nil {
 		return nil, err
 	}
 	return q, p.optionalSemicolon()
@@ -1026,13 +1547,17 @@ func (p *parser) parseCountQuery() (*ast.CountQuery, error) {
 	if p.curIs(token.SHOWS) {
 		q.CountShows = true
 		p.advance()
+	} else if p.curIs(token.VENUES) {
+		// COUNT VENUES [FROM ...] — distinct venues played
+		q.CountVenues = true
+		p.advance()
 	} else if p.curIs(token.STRING) {
 		ref, _ := p.parseSongRef()
 		q.Song = ref
 	} else {
 		return nil, &errors.ParseError{
 			Pos:     p.cur.Pos,
-			Message: "expected song name or SHOWS after COUNT",
+			Message: "expected song name or SHOWS/VENUES after COUNT",
 			Query:   p.query,
 			Hint:    "Try: COUNT \"Dark Star\" or COUNT SHOWS FROM 1977;",
 		}
@@ -1045,6 +1570,9 @@ func (p *parser) parseCountQuery() (*ast.CountQuery, error) {
 		q.From = dr
 	}
 	if p.curIs(token.WHERE) {
+		if q.CountVenues {
+			return nil, &errors.ParseError{Pos: p.cur.Pos, Message: "COUNT VENUES does not support WHERE", Query: p.query, Hint: "Try: COUNT VENUES FROM 1977;"}
+		}
 		p.advance()
 		wc, err := p.parseWhereClause()
 		if err != nil {
@@ -1055,6 +1583,46 @@ func (p *parser) parseCountQuery() (*ast.CountQuery, error) {
 	return q, p.optionalSemicolon()
 }
 
+func (p *parser) parseVenueQuery() (*ast.VenueQuery, error) {
+	q := &ast.VenueQuery{}
+	p.advance() // consume VENUES
+	if p.curIs(token.FROM) || p.curIs(token.IN) || p.curIs(token.AFTER) || p.curIs(token.BEFORE) {
+		dr, err := p.parseDateRangeWithDirection()
+		if err != nil {
+			return nil, err
+		}
+		q.From = dr
+	}
+	if err := p.parseModifiers(nil, nil, nil, q, nil); err != nil {
+		return nil, err
+	}
+	return q, p.optionalSemicolon()
+}
+
+func (p *parser) parseRunQuery() (*ast.RunQuery, error) {
+	q := &ast.RunQuery{}
+	p.advance() // consume RUNS
+	if p.curIs(token.AT) {
+		p.advance()
+		if !p.curIs(token.STRING) {
+			return nil, &errors.ParseError{Pos: p.cur.Pos, Message: "expected venue name after AT", Query: p.query, Hint: "Try: RUNS AT \"Winterland\" FROM 1977;"}
+		}
+		q.At = p.cur.Literal
+		p.advance()
+	}
+	if p.curIs(token.FROM) || p.curIs(token.IN) || p.curIs(token.AFTER) || p.curIs(token.BEFORE) {
+		dr, err := p.parseDateRangeWithDirection()
+		if err != nil {
+			return nil, err
+		}
+		q.From = dr
+	}
+	if err := p.parseModifiers(nil, nil, nil, nil, q); err != nil {
+		return nil, err
+	}
+	return q, p.optionalSemicolon()
+}
+
 func (p *parser) parseRandomShowQuery() (*ast.RandomShowQuery, error) {
 	q := &ast.RandomShowQuery{}
 	p.advance() // consume RANDOM
@@ -1082,6 +1650,153 @@ func (p *parser) parseFirstLastQuery() (*ast.FirstLastQuery, error) {
 	return q, p.optionalSemicolon()
 }
 
+func (p *parser) parseCompareQuery() (*ast.CompareQuery, error) {
+	q := &ast.CompareQuery{}
+	p.advance() // consume COMPARE
+	for len(q.Songs) < 2 {
+		if len(q.Songs) == 1 && p.curIs(token.COMMA) {
+			p.advance()
+		}
+		if !p.curIs(token.STRING) {
+			return nil, &errors.ParseError{Pos: p.cur.Pos, Message: "COMPARE needs two quoted song names", Query: p.query, Hint: "Try: COMPARE \"Dark Star\" \"Playing in the Band\";"}
+		}
+		ref, err := p.parseSongRef()
+		if err != nil {
+			return nil, err
+		}
+		q.Songs = append(q.Songs, ref)
+	}
+	if p.curIs(token.AS) {
+		p.advance()
+		q.OutputFmt = p.parseOutputFormat()
+		p.advance()
+	}
+	return q, p.optionalSemicolon()
+}
+
+func (p *parser) parseCoverageQuery() (*ast.CoverageQuery, error) {
+	q := &ast.CoverageQuery{}
+	p.advance() // consume COVERAGE
+	if p.curIs(token.FROM) || p.curIs(token.IN) || p.curIs(token.AFTER) || p.curIs(token.BEFORE) {
+		dr, err := p.parseDateRangeWithDirection()
+		if err != nil {
+			return nil, err
+		}
+		q.From = dr
+	}
+	if p.curIs(token.AS) {
+		p.advance()
+		q.OutputFmt = p.parseOutputFormat()
+		p.advance()
+	}
+	return q, p.optionalSemicolon()
+}
+
+func (p *parser) parseGapsQuery() (*ast.GapsQuery, error) {
+	q := &ast.GapsQuery{}
+	p.advance() // consume GAPS
+	// GAPS IN TOUR "name" reads better than GAPS TOUR "name"; take either.
+	if p.curIs(token.IN) && p.peekIs(token.TOUR) {
+		p.advance()
+	}
+	if p.curIs(token.TOUR) {
+		p.advance()
+		if !p.curIs(token.STRING) {
+			return nil, &errors.ParseError{Pos: p.cur.Pos, Message: "expected tour name after TOUR", Query: p.query}
+		}
+		q.Tour = p.cur.Literal
+		p.advance()
+	}
+	if p.curIs(token.FROM) || p.curIs(token.IN) || p.curIs(token.AFTER) || p.curIs(token.BEFORE) {
+		dr, err := p.parseDateRangeWithDirection()
+		if err != nil {
+			return nil, err
+		}
+		q.From = dr
+	}
+	if q.Tour == "" && q.From == nil {
+		return nil, &errors.ParseError{
+			Pos:     p.cur.Pos,
+			Message: "GAPS needs a tour or a date range",
+			Query:   p.query,
+			Hint:    `Try: GAPS IN TOUR "Spring 1990"; or GAPS FROM 1977-05-01 - 1977-05-31;`,
+		}
+	}
+	if p.curIs(token.AS) {
+		p.advance()
+		q.OutputFmt = p.parseOutputFormat()
+		p.advance()
+	}
+	return q, p.optionalSemicolon()
+}
+
+// parseSlashDate parses the rest of M/D/YY or M/YY; the month m has been
+// consumed and p.cur is the first slash.
+func (p *parser) parseSlashDate(m int) (*ast.Date, error) {
+	p.advance()
+	if !p.curIs(token.NUMBER) {
+		return nil, &errors.ParseError{Pos: p.cur.Pos, Message: "expected day in M/D/YY", Query: p.query}
+	}
+	day, _ := strconv.Atoi(p.cur.Literal)
+	p.advance()
+	if !p.curIs(token.SLASH) {
+		// M/YY: no day can be past 31, so a larger number is the year of a whole month
+		if day > 31 {
+			return &ast.Date{Year: p.fullYear(day), Month: m}, nil
+		}
+		return nil, &errors.ParseError{Pos: p.cur.Pos, Message: "expected / and year in M/D/YY", Query: p.query}
+	}
+	p.advance()
+	if !p.curIs(token.NUMBER) {
+		return nil, &errors.ParseError{Pos: p.cur.Pos, Message: "expected year", Query: p.query}
+	}
+	y, _ := strconv.Atoi(p.cur.Literal)
+	p.advance()
+	return &ast.Date{Year: p.fullYear(y), Month: m, Day: day}, nil
+}
+
+// parseISODate parses the -MM or -MM-DD after an ISO year; cur is the first
+// -. A day must be at most two digits, so in 1977-05-1978 the - starts the
+// end of a range instead.
+func (p *parser) parseISODate(year int) (*ast.Date, error) {
+	p.advance()
+	if !p.curIs(token.NUMBER) {
+		return nil, &errors.ParseError{Pos: p.cur.Pos, Message: "expected month in YYYY-MM-DD", Query: p.query}
+	}
+	month, _ := strconv.Atoi(p.cur.Literal)
+	p.advance()
+	// YYYY-MM: the whole month
+	if !p.curIs(token.MINUS) || !p.peekIs(token.NUMBER) || len(p.peek.Literal) > 2 {
+		return &ast.Date{Year: year, Month: month}, nil
+	}
+	p.advance()
+	day, _ := strconv.Atoi(p.cur.Literal)
+	p.advance()
+	return &ast.Date{Year: year, Month: month, Day: day}, nil
+}
+
+// isISOMonth reports whether tok, following "YYYY-" in a date range, is a
+// month (two digits, 01 to 12) rather than the year ending the range.
+func isISOMonth(tok token.Token) bool {
+	if tok.Type != token.NUMBER || len(tok.Literal) != 2 {
+		return false
+	}
+	m, _ := strconv.Atoi(tok.Literal)
+	return m >= 1 && m <= 12
+}
+
+// fullYear expands a two-digit year by Options.YearPivot; longer years are
+// returned as written.
+func (p *parser) fullYear(y int) int {
+	switch {
+	case y >= 100:
+		return y
+	case y < p.opts.YearPivot:
+		return 2000 + y
+	}
+	return 1900 + y
+}
+
 func (p *parser) parseDateForSetlist() (*ast.Date, error) {
 	if p.curIs(token.STRING) {
 		lit := p.cur.Literal
@@ -1093,54 +1808,14 @@ func (p *parser) parseDateForSetlist() (*ast.Date, error) {
 		p.advance()
 		// YYYY-MM-DD format (e.g. 1974-10-19)
 		if p.curIs(token.MINUS) && m >= 1900 {
-			p.advance()
-			if !p.curIs(token.NUMBER) {
-				return nil, &errors.ParseError{Pos: p.cur.Pos, Message: "expected month in YYYY-MM-DD", Query: p.query}
-			}
-			month, _ := strconv.Atoi(p.cur.Literal)
-			p.advance()
-			if !p.curIs(token.MINUS) {
-				return nil, &errors.ParseError{Pos: p.cur.Pos, Message: "expected - and day in YYYY-MM-DD", Query: p.query}
-			}
-			p.advance()
-			if !p.curIs(token.NUMBER) {
-				return nil, &errors.ParseError{Pos: p.cur.Pos, Message: "expected day in YYYY-MM-DD", Query: p.query}
-			}
-			day, _ := strconv.Atoi(p.cur.Literal)
-			p.advance()
-			return &ast.Date{Year: m, Month: month, Day: day}, nil
+			return p.parseISODate(m)
 		}
 		// M/D/YY format
 		if p.curIs(token.SLASH) {
-			p.advance()
-			if !p.curIs(token.NUMBER) {
-				return nil, &errors.ParseError{Pos: p.cur.Pos, Message: "expected day in M/D/YY", Query: p.query}
-			}
-			day, _ := strconv.Atoi(p.cur.Literal)
-			p.advance()
-			if !p.curIs(token.SLASH) {
-				return nil, &errors.ParseError{Pos: p.cur.Pos, Message: "expected / and year in M/D/YY", Query: p.query}
-			}
-			p.advance()
-			if !p.curIs(token.NUMBER) {
-				return nil, &errors.ParseError{Pos: p.cur.Pos, Message: "expected year", Query: p.query}
-			}
-			y, _ := strconv.Atoi(p.cur.Literal)
-			if y < 100 {
-				y += 1900
-			}
-			p.advance()
-			return &ast.Date{Year: y, Month: m, Day: day}, nil
+			return p.parseSlashDate(m)
 		}
 		// Just a year
-		if m >= 1900 || m < 100 {
-			yr := m
-			if yr < 100 {
-				yr += 1900
-			}
-			return &ast.Date{Year: yr}, nil
-		}
-		return &ast.Date{Year: m}, nil
+		return &ast.Date{Year: p.fullYear(m)}, nil
 	}
 	return nil, &errors.ParseError{Pos: p.cur.Pos, Message: "expected date or string for SETLIST FOR", Query: p.query}
 }
@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	"github.com/gdql/gdql/internal/ast"
+	"github.com/gdql/gdql/internal/errors"
+	"github.com/gdql/gdql/internal/planner/expander"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -162,6 +164,129 @@ func TestParseSongQuery_WithLyrics(t *testing.T) {
 	assert.Equal(t, []string{"train", "road"}, lyr.Words)
 }
 
+func TestParseSongQuery_WhereCover(t *testing.T) {
+	p := NewFromString(`SONGS WHERE COVER FROM 1977;`)
+	q, err := p.Parse()
+	require.NoError(t, err)
+	sq := q.(*ast.SongQuery)
+	require.NotNil(t, sq.Where)
+	cc, ok := sq.Where.Conditions[0].(*ast.CoverCondition)
+	require.True(t, ok)
+	assert.True(t, cc.Cover)
+	require.NotNil(t, sq.From)
+
+	p = NewFromString(`SONGS WHERE ORIGINAL;`)
+	q, err = p.Parse()
+	require.NoError(t, err)
+	cc = q.(*ast.SongQuery).Where.Conditions[0].(*ast.CoverCondition)
+	assert.False(t, cc.Cover)
+}
+
+func TestParseSongQuery_WhereNoField(t *testing.T) {
+	q, err := NewFromString(`SONGS WHERE NO WRITERS AND no short_name ORDER BY TIMES_PLAYED DESC LIMIT 5;`).Parse()
+	require.NoError(t, err)
+	sq := q.(*ast.SongQuery)
+	require.Len(t, sq.Where.Conditions, 2)
+	assert.Equal(t, "WRITERS", sq.Where.Conditions[0].(*ast.MissingFieldCondition).Field)
+	assert.Equal(t, "SHORT_NAME", sq.Where.Conditions[1].(*ast.MissingFieldCondition).Field)
+	require.NotNil(t, sq.OrderBy)
+	require.NotNil(t, sq.Limit)
+
+	_, err = NewFromString(`SONGS WHERE NO LYRICS;`).Parse()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expected SHORT_NAME or WRITERS after NO")
+}
+
+func TestParseSongQuery_TimesPlayed(t *testing.T) {
+	q, err := NewFromString(`SONGS WHERE TIMES_PLAYED > 100 AND COVER;`).Parse()
+	require.NoError(t, err)
+	w := q.(*ast.SongQuery).Where
+	require.Len(t, w.Conditions, 2)
+	tp := w.Conditions[0].(*ast.TimesPlayedCondition)
+	assert.Equal(t, ast.CompGT, tp.Operator)
+	assert.Equal(t, 100, tp.Count)
+	assert.True(t, w.Conditions[1].(*ast.CoverCondition).Cover)
+
+	q, err = NewFromString(`SONGS WITH TIMES_PLAYED < 10;`).Parse()
+	require.NoError(t, err)
+	tp = q.(*ast.SongQuery).With.Conditions[0].(*ast.TimesPlayedCondition)
+	assert.Equal(t, ast.CompLT, tp.Operator)
+	assert.Equal(t, 10, tp.Count)
+
+	_, err = NewFromString(`SONGS WHERE TIMES_PLAYED > lots;`).Parse()
+	require.Error(t, err)
+}
+
+func TestParsePerformanceQuery_WithTimesPlayedRejected(t *testing.T) {
+	_, err := NewFromString(`PERFORMANCES OF "Dark Star" WITH TIMES_PLAYED > 5;`).Parse()
+	var pe *errors.ParseError
+	require.ErrorAs(t, err, &pe)
+	assert.Contains(t, pe.Message, "WITH TIMES_PLAYED applies to SONGS")
+	assert.Contains(t, pe.Hint, "SONGS WITH TIMES_PLAYED > 5")
+}
+
+func TestParseSongQuery_InSet(t *testing.T) {
+	q, err := NewFromString(`SONGS IN SET2 FROM 1977 LIMIT 5;`).Parse()
+	require.NoError(t, err)
+	sq := q.(*ast.SongQuery)
+	assert.Equal(t, ast.Set2, sq.InSet)
+	assert.Equal(t, 1977, sq.From.Start.Year)
+	require.NotNil(t, sq.Limit)
+	assert.Equal(t, 5, *sq.Limit)
+
+	q, err = NewFromString(`SONGS IN ENCORE;`).Parse()
+	require.NoError(t, err)
+	sq = q.(*ast.SongQuery)
+	assert.Equal(t, ast.Encore, sq.InSet)
+	assert.Nil(t, sq.From)
+
+	// IN followed by a date is still a date range
+	q, err = NewFromString(`SONGS IN 1977;`).Parse()
+	require.NoError(t, err)
+	sq = q.(*ast.SongQuery)
+	assert.Equal(t, ast.SetAny, sq.InSet)
+	assert.Equal(t, 1977, sq.From.Start.Year)
+}
+
+func TestParseSongQuery_PlayedEveryYear(t *testing.T) {
+	q, err := NewFromString(`SONGS PLAYED EVERY YEAR FROM 1977-1980;`).Parse()
+	require.NoError(t, err)
+	sq := q.(*ast.SongQuery)
+	assert.True(t, sq.EveryYear)
+	assert.Equal(t, 1977, sq.From.Start.Year)
+	assert.Equal(t, 1980, sq.From.End.Year)
+
+	_, err = NewFromString(`SONGS PLAYED EVERY 1977-1980;`).Parse()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expected YEAR")
+
+	_, err = NewFromString(`SONGS PLAYED EVERY YEAR FROM 1977-;`).Parse()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "closed date range")
+}
+
+func TestParseSongQuery_PlayedOnce(t *testing.T) {
+	q, err := NewFromString(`SONGS PLAYED ONCE DEBUTED FROM 1977 LIMIT 5;`).Parse()
+	require.NoError(t, err)
+	sq := q.(*ast.SongQuery)
+	assert.True(t, sq.Once)
+	assert.Nil(t, sq.From)
+	require.NotNil(t, sq.Debuted)
+	require.NotNil(t, sq.Limit)
+
+	_, err = NewFromString(`SONGS FROM 1977 PLAYED ONCE;`).Parse()
+	require.ErrorContains(t, err, "takes no date range")
+	_, err = NewFromString(`SONGS IN SET2 PLAYED ONCE;`).Parse()
+	require.ErrorContains(t, err, "takes no date range or set")
+}
+
+func TestParseSongQuery_WhereRequiresCoverOrOriginal(t *testing.T) {
+	p := NewFromString(`SONGS WHERE PLAYED "Dark Star";`)
+	_, err := p.Parse()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "COVER or ORIGINAL")
+}
+
 func TestParseSongQuery_Written(t *testing.T) {
 	p := NewFromString("SONGS WRITTEN 1968-1970;")
 	q, err := p.Parse()
@@ -172,6 +297,40 @@ func TestParseSongQuery_Written(t *testing.T) {
 	assert.Equal(t, 1970, sq.Written.End.Year)
 }
 
+func TestParseSongQuery_Debuted(t *testing.T) {
+	for _, in := range []string{"SONGS DEBUTED FROM 1976-1977;", "SONGS DEBUTED 1976-1977;"} {
+		q, err := NewFromString(in).Parse()
+		require.NoError(t, err, in)
+		sq := q.(*ast.SongQuery)
+		require.NotNil(t, sq.Debuted, in)
+		assert.Equal(t, 1976, sq.Debuted.Start.Year)
+		assert.Equal(t, 1977, sq.Debuted.End.Year)
+		assert.Nil(t, sq.From, "DEBUTED FROM is not a played range")
+	}
+}
+
+func TestParseSongQuery_ClausesInAnyOrder(t *testing.T) {
+	for _, in := range []string{
+		`SONGS WITH LYRICS("rose") WRITTEN 1968-1970 DEBUTED 1969;`,
+		`SONGS WRITTEN 1968-1970 WITH LYRICS("rose") DEBUTED 1969;`,
+		`SONGS DEBUTED 1969 WRITTEN 1968-1970 WITH LYRICS("rose");`,
+	} {
+		q, err := NewFromString(in).Parse()
+		require.NoError(t, err, in)
+		sq := q.(*ast.SongQuery)
+		require.NotNil(t, sq.With, in)
+		require.Len(t, sq.With.Conditions, 1, in)
+		require.NotNil(t, sq.Written, in)
+		assert.Equal(t, 1968, sq.Written.Start.Year)
+		require.NotNil(t, sq.Debuted, in)
+		assert.Equal(t, 1969, sq.Debuted.Start.Year)
+	}
+
+	_, err := NewFromString(`SONGS DEBUTED 1969 WITH LYRICS("rose") DEBUTED 1970;`).Parse()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "DEBUTED given twice")
+}
+
 func TestParsePerformanceQuery(t *testing.T) {
 	p := NewFromString(`PERFORMANCES OF "Dark Star" FROM 1972;`)
 	q, err := p.Parse()
@@ -184,6 +343,44 @@ func TestParsePerformanceQuery(t *testing.T) {
 	assert.Equal(t, 1972, pq.From.Start.Year)
 }
 
+func TestParsePerformanceQuery_MultipleSongs(t *testing.T) {
+	p := NewFromString(`PERFORMANCES OF "Scarlet Begonias", "Fire on the Mountain" FROM 1977;`)
+	q, err := p.Parse()
+	require.NoError(t, err)
+	pq, ok := q.(*ast.PerformanceQuery)
+	require.True(t, ok)
+	assert.Equal(t, "Scarlet Begonias", pq.Song.Name)
+	require.Len(t, pq.More, 1)
+	assert.Equal(t, "Fire on the Mountain", pq.More[0].Name)
+	require.NotNil(t, pq.From)
+}
+
+func TestParsePerformanceQuery_WithContext(t *testing.T) {
+	q, err := NewFromString(`PERFORMANCES OF "Fire on the Mountain" WITH CONTEXT, LENGTH > 10min;`).Parse()
+	require.NoError(t, err)
+	pq, ok := q.(*ast.PerformanceQuery)
+	require.True(t, ok)
+	require.NotNil(t, pq.With)
+	assert.True(t, pq.With.Context)
+	require.Len(t, pq.With.Conditions, 1)
+
+	_, err = NewFromString(`SONGS WITH CONTEXT;`).Parse()
+	require.ErrorContains(t, err, "WITH CONTEXT applies to PERFORMANCES")
+}
+
+func TestParsePerformanceQuery_ByYear(t *testing.T) {
+	q, err := NewFromString(`PERFORMANCES OF "Dark Star" FROM 1968-1974 BY YEAR AS CSV;`).Parse()
+	require.NoError(t, err)
+	pq, ok := q.(*ast.PerformanceQuery)
+	require.True(t, ok)
+	assert.True(t, pq.ByYear)
+	require.NotNil(t, pq.From)
+	assert.Equal(t, ast.OutputCSV, pq.OutputFmt)
+
+	_, err = NewFromString(`PERFORMANCES OF "Dark Star" BY DATE;`).Parse()
+	require.ErrorContains(t, err, "expected YEAR after BY")
+}
+
 func TestParseSetlistQuery(t *testing.T) {
 	p := NewFromString("SETLIST FOR 5/8/77;")
 	q, err := p.Parse()
@@ -196,6 +393,18 @@ func TestParseSetlistQuery(t *testing.T) {
 	assert.Equal(t, 8, sq.Date.Day)
 }
 
+func TestParseSetlistQuery_Month(t *testing.T) {
+	for _, q := range []string{"SETLIST FOR 5/77;", "SETLIST FOR 5/1977;", "SETLIST FOR 1977-05;"} {
+		got, err := NewFromString(q).Parse()
+		require.NoError(t, err, q)
+		assert.Equal(t, &ast.Date{Year: 1977, Month: 5}, got.(*ast.SetlistQuery).Date, q)
+	}
+	// A day-sized second number still needs the year
+	_, err := NewFromString("SETLIST FOR 5/8;").Parse()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expected / and year in M/D/YY")
+}
+
 func TestParseSetlistQuery_String(t *testing.T) {
 	p := NewFromString(`SETLIST FOR "Cornell 1977";`)
 	q, err := p.Parse()
@@ -291,6 +500,86 @@ func TestParseShowQuery_Before(t *testing.T) {
 	assert.Equal(t, 1970, sq.From.End.Year)
 }
 
+func TestParseShowQuery_DayRanges(t *testing.T) {
+	q, err := NewFromString("SHOWS FROM 5/8/77;").Parse()
+	require.NoError(t, err)
+	sq := q.(*ast.ShowQuery)
+	assert.Equal(t, &ast.Date{Year: 1977, Month: 5, Day: 8}, sq.From.Start)
+	assert.Nil(t, sq.From.End)
+
+	q, err = NewFromString("SHOWS FROM 2/26/77-5/77;").Parse()
+	require.NoError(t, err)
+	sq = q.(*ast.ShowQuery)
+	assert.Equal(t, &ast.Date{Year: 1977, Month: 2, Day: 26}, sq.From.Start)
+	assert.Equal(t, &ast.Date{Year: 1977, Month: 5}, sq.From.End)
+
+	q, err = NewFromString("SHOWS BEFORE 5/8/77;").Parse()
+	require.NoError(t, err)
+	sq = q.(*ast.ShowQuery)
+	assert.Equal(t, ast.OpenStartYear, sq.From.Start.Year)
+	assert.Equal(t, &ast.Date{Year: 1977, Month: 5, Day: 8}, sq.From.End)
+
+	_, err = NewFromString("SHOWS FROM 5/8;").Parse()
+	require.Error(t, err)
+}
+
+func TestParseShowQuery_ISODates(t *testing.T) {
+	from := func(in string) *ast.DateRange {
+		t.Helper()
+		q, err := NewFromString(in).Parse()
+		require.NoError(t, err, in)
+		return q.(*ast.ShowQuery).From
+	}
+	dr := from("SHOWS FROM 1977-05-08;")
+	assert.Equal(t, &ast.Date{Year: 1977, Month: 5, Day: 8}, dr.Start)
+	assert.Nil(t, dr.End)
+
+	dr = from("SHOWS FROM 1977-05;")
+	assert.Equal(t, &ast.Date{Year: 1977, Month: 5}, dr.Start)
+	assert.Nil(t, dr.End)
+
+	dr = from("SHOWS FROM 1977-02-26-1977-05-08;")
+	assert.Equal(t, &ast.Date{Year: 1977, Month: 2, Day: 26}, dr.Start)
+	assert.Equal(t, &ast.Date{Year: 1977, Month: 5, Day: 8}, dr.End)
+
+	dr = from("SHOWS FROM 1977-05-1978;")
+	assert.Equal(t, &ast.Date{Year: 1977, Month: 5}, dr.Start)
+	assert.Equal(t, &ast.Date{Year: 1978}, dr.End)
+
+	dr = from("SHOWS FROM 2/26/77-1977-05-08;")
+	assert.Equal(t, &ast.Date{Year: 1977, Month: 2, Day: 26}, dr.Start)
+	assert.Equal(t, &ast.Date{Year: 1977, Month: 5, Day: 8}, dr.End)
+
+	dr = from("SHOWS AFTER 1977-05-08;")
+	assert.Equal(t, &ast.Date{Year: 1977, Month: 5, Day: 8}, dr.Start)
+
+	// Two digits past 12 are still the end year of a range
+	dr = from("SHOWS FROM 1977-80;")
+	assert.Equal(t, &ast.Date{Year: 1977}, dr.Start)
+	assert.Equal(t, &ast.Date{Year: 1980}, dr.End)
+
+	q, err := NewFromString("SETLIST FOR 1977-05-08;").Parse()
+	require.NoError(t, err)
+	assert.Equal(t, &ast.Date{Year: 1977, Month: 5, Day: 8}, q.(*ast.SetlistQuery).Date)
+}
+
+func TestParseShowQuery_OpenEndedRanges(t *testing.T) {
+	p := NewFromString("SHOWS FROM 1977- LIMIT 5;")
+	q, err := p.Parse()
+	require.NoError(t, err)
+	sq := q.(*ast.ShowQuery)
+	assert.Equal(t, 1977, sq.From.Start.Year)
+	assert.Equal(t, ast.OpenEndYear, sq.From.End.Year)
+	require.NotNil(t, sq.Limit)
+
+	p = NewFromString("SHOWS FROM -1972;")
+	q, err = p.Parse()
+	require.NoError(t, err)
+	sq = q.(*ast.ShowQuery)
+	assert.Equal(t, ast.OpenStartYear, sq.From.Start.Year)
+	assert.Equal(t, 1972, sq.From.End.Year)
+}
+
 // === COUNT ===
 
 func TestParseCountQuery_Song(t *testing.T) {
@@ -324,6 +613,230 @@ func TestParseCountQuery_Shows(t *testing.T) {
 	require.NotNil(t, cq.From)
 }
 
+func TestParseCountQuery_Venues(t *testing.T) {
+	p := NewFromString("COUNT VENUES FROM 1977;")
+	q, err := p.Parse()
+	require.NoError(t, err)
+	cq := q.(*ast.CountQuery)
+	assert.True(t, cq.CountVenues)
+	assert.False(t, cq.CountShows)
+	require.NotNil(t, cq.From)
+}
+
+func TestParseVenueQuery(t *testing.T) {
+	p := NewFromString("VENUES FROM 1977 ORDER BY NAME LIMIT 5;")
+	q, err := p.Parse()
+	require.NoError(t, err)
+	vq, ok := q.(*ast.VenueQuery)
+	require.True(t, ok)
+	require.NotNil(t, vq.From)
+	require.NotNil(t, vq.OrderBy)
+	assert.Equal(t, "NAME", vq.OrderBy.Field)
+	require.NotNil(t, vq.Limit)
+	assert.Equal(t, 5, *vq.Limit)
+}
+
+func TestParseVenueQuery_OrderByShows(t *testing.T) {
+	q, err := NewFromString("VENUES FROM 1977 ORDER BY shows ASC;").Parse()
+	require.NoError(t, err)
+	assert.Equal(t, &ast.OrderClause{Field: "SHOWS"}, q.(*ast.VenueQuery).OrderBy)
+
+	// A venue's count is shows, a song's is TIMES_PLAYED; neither takes the other.
+	_, err = NewFromString("VENUES ORDER BY TIMES_PLAYED;").Parse()
+	require.ErrorContains(t, err, "VENUES can't be ordered by TIMES_PLAYED")
+	_, err = NewFromString("SONGS ORDER BY SHOWS;").Parse()
+	require.ErrorContains(t, err, "ORDER BY SHOWS only applies to VENUES")
+}
+
+func TestParsePerformanceQuery_ByYearOrder(t *testing.T) {
+	for in, want := range map[string]string{
+		`PERFORMANCES OF "Dark Star" BY YEAR FROM 1972;`:           "FROM must come before BY YEAR",
+		`PERFORMANCES OF "Dark Star" BY YEAR AFTER 1972;`:          "AFTER must come before BY YEAR",
+		`PERFORMANCES OF "Dark Star" BY YEAR WITH LENGTH > 20min;`: "WITH must come before BY YEAR",
+	} {
+		_, err := NewFromString(in).Parse()
+		require.Error(t, err, in)
+		assert.Contains(t, err.Error(), want, in)
+		assert.NotContains(t, err.Error(), "WHERE", in)
+	}
+}
+
+func TestParseCompareQuery(t *testing.T) {
+	for _, input := range []string{
+		`COMPARE "Dark Star" "Playing in the Band";`,
+		`COMPARE "Dark Star", "Playing in the Band" AS JSON;`,
+	} {
+		q, err := NewFromString(input).Parse()
+		require.NoError(t, err, input)
+		cq, ok := q.(*ast.CompareQuery)
+		require.True(t, ok)
+		require.Len(t, cq.Songs, 2)
+		assert.Equal(t, "Dark Star", cq.Songs[0].Name)
+		assert.Equal(t, "Playing in the Band", cq.Songs[1].Name)
+	}
+
+	_, err := NewFromString(`COMPARE "Dark Star";`).Parse()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "two quoted song names")
+	_, err = NewFromString(`COMPARE "A" "B" "C";`).Parse()
+	require.Error(t, err)
+}
+
+func TestParseVenueQuery_Stats(t *testing.T) {
+	q, err := NewFromString("STATS VENUES FROM 1977 LIMIT 3;").Parse()
+	require.NoError(t, err)
+	vq, ok := q.(*ast.VenueQuery)
+	require.True(t, ok)
+	assert.True(t, vq.Stats)
+	require.NotNil(t, vq.From)
+	require.NotNil(t, vq.Limit)
+	assert.Equal(t, 3, *vq.Limit)
+
+	_, err = NewFromString("STATS SONGS;").Parse()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expected VENUES after STATS")
+}
+
+func TestParseShowQuery_Best(t *testing.T) {
+	q, err := NewFromString("BEST SHOWS FROM 1977 LIMIT 10;").Parse()
+	require.NoError(t, err)
+	sq, ok := q.(*ast.ShowQuery)
+	require.True(t, ok)
+	assert.True(t, sq.Best)
+	assert.Nil(t, sq.OrderBy)
+	require.NotNil(t, sq.Limit)
+	assert.Equal(t, 10, *sq.Limit)
+
+	q, err = NewFromString("SHOWS FROM 1977 ORDER BY RATING DESC;").Parse()
+	require.NoError(t, err)
+	sq = q.(*ast.ShowQuery)
+	assert.False(t, sq.Best)
+	require.NotNil(t, sq.OrderBy)
+	assert.Equal(t, "RATING", sq.OrderBy.Field)
+
+	_, err = NewFromString("BEST SONGS;").Parse()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expected SHOWS after BEST")
+}
+
+func TestParseSongQuery_OrderByAvgLength(t *testing.T) {
+	q, err := NewFromString("SONGS ORDER BY AVG_LENGTH DESC LIMIT 10;").Parse()
+	require.NoError(t, err)
+	sq, ok := q.(*ast.SongQuery)
+	require.True(t, ok)
+	require.NotNil(t, sq.OrderBy)
+	assert.Equal(t, "AVG_LENGTH", sq.OrderBy.Field)
+	assert.True(t, sq.OrderBy.Desc)
+}
+
+func TestParseRunQuery(t *testing.T) {
+	q, err := NewFromString(`RUNS AT "Winterland" FROM 1977 LIMIT 5 AS JSON;`).Parse()
+	require.NoError(t, err)
+	rq, ok := q.(*ast.RunQuery)
+	require.True(t, ok)
+	assert.Equal(t, "Winterland", rq.At)
+	require.NotNil(t, rq.From)
+	assert.Equal(t, 1977, rq.From.Start.Year)
+	require.NotNil(t, rq.Limit)
+	assert.Equal(t, 5, *rq.Limit)
+	assert.Equal(t, ast.OutputJSON, rq.OutputFmt)
+
+	q, err = NewFromString("RUNS;").Parse()
+	require.NoError(t, err)
+	require.Equal(t, &ast.RunQuery{}, q)
+
+	_, err = NewFromString("RUNS AT 1977;").Parse()
+	require.ErrorContains(t, err, "expected venue name after AT")
+}
+
+func TestParseCoverageQuery(t *testing.T) {
+	q, err := NewFromString("COVERAGE FROM 1965-1995 AS CSV;").Parse()
+	require.NoError(t, err)
+	cq, ok := q.(*ast.CoverageQuery)
+	require.True(t, ok)
+	require.NotNil(t, cq.From)
+	assert.Equal(t, 1965, cq.From.Start.Year)
+	assert.Equal(t, 1995, cq.From.End.Year)
+	assert.Equal(t, ast.OutputCSV, cq.OutputFmt)
+
+	q, err = NewFromString("COVERAGE;").Parse()
+	require.NoError(t, err)
+	require.Equal(t, &ast.CoverageQuery{}, q)
+}
+
+func TestParseGapsQuery(t *testing.T) {
+	q, err := NewFromString(`GAPS IN TOUR "Spring 1990";`).Parse()
+	require.NoError(t, err)
+	require.Equal(t, &ast.GapsQuery{Tour: "Spring 1990"}, q)
+
+	q, err = NewFromString(`GAPS TOUR "Europe" FROM 1972 AS JSON;`).Parse()
+	require.NoError(t, err)
+	gq, ok := q.(*ast.GapsQuery)
+	require.True(t, ok)
+	assert.Equal(t, "Europe", gq.Tour)
+	require.NotNil(t, gq.From)
+	assert.Equal(t, 1972, gq.From.Start.Year)
+	assert.Equal(t, ast.OutputJSON, gq.OutputFmt)
+
+	q, err = NewFromString("GAPS FROM 5/1/77-5/31/77;").Parse()
+	require.NoError(t, err)
+	require.NotNil(t, q.(*ast.GapsQuery).From)
+
+	_, err = NewFromString("GAPS;").Parse()
+	require.ErrorContains(t, err, "GAPS needs a tour or a date range")
+}
+
+// Every spelling gdql eras lists must parse as that era.
+func TestParseEraAlias_MatchesExpanderNames(t *testing.T) {
+	for _, e := range expander.Eras() {
+		for _, name := range e.Names {
+			q, err := NewFromString("SHOWS FROM " + name + ";").Parse()
+			require.NoError(t, err, name)
+			sq := q.(*ast.ShowQuery)
+			require.NotNil(t, sq.From.Era, name)
+			assert.Equal(t, e.Alias, *sq.From.Era, name)
+		}
+	}
+}
+
+func TestParseDate_TwoDigitYears(t *testing.T) {
+	setlistDate := func(q string) ast.Date {
+		t.Helper()
+		parsed, err := NewFromString(q).Parse()
+		require.NoError(t, err, q)
+		return *parsed.(*ast.SetlistQuery).Date
+	}
+	assert.Equal(t, ast.Date{Year: 1977, Month: 5, Day: 8}, setlistDate("SETLIST FOR 5/8/77;"))
+	assert.Equal(t, ast.Date{Year: 1969, Month: 12, Day: 31}, setlistDate("SETLIST FOR 12/31/69;"))
+	assert.Equal(t, ast.Date{Year: 1995, Month: 1, Day: 1}, setlistDate("SETLIST FOR 1/1/95;"))
+	assert.Equal(t, ast.Date{Year: 1905, Month: 5, Day: 8}, setlistDate("SETLIST FOR 5/8/05;"))
+	assert.Equal(t, ast.Date{Year: 1969}, setlistDate("SETLIST FOR 69;"))
+
+	q, err := NewFromString("SHOWS FROM 65-69;").Parse()
+	require.NoError(t, err)
+	from := q.(*ast.ShowQuery).From
+	assert.Equal(t, 1965, from.Start.Year)
+	assert.Equal(t, 1969, from.End.Year)
+
+	pivoted := func(input string) ast.Date {
+		t.Helper()
+		parsed, err := NewFromStringWithOptions(input, Options{YearPivot: 30}).Parse()
+		require.NoError(t, err, input)
+		return *parsed.(*ast.SetlistQuery).Date
+	}
+	assert.Equal(t, ast.Date{Year: 2005, Month: 5, Day: 8}, pivoted("SETLIST FOR 5/8/05;"))
+	assert.Equal(t, ast.Date{Year: 1969, Month: 12, Day: 31}, pivoted("SETLIST FOR 12/31/69;"))
+	assert.Equal(t, ast.Date{Year: 1977, Month: 5, Day: 8}, pivoted("SETLIST FOR 5/8/1977;"))
+	// The pivot belongs to that parser only
+	assert.Equal(t, ast.Date{Year: 1905, Month: 5, Day: 8}, setlistDate("SETLIST FOR 5/8/05;"))
+
+	qs, err := ParseAllWithOptions("SETLIST FOR 5/8/05; SHOWS FROM 05-10;", Options{YearPivot: 30})
+	require.NoError(t, err)
+	require.Len(t, qs, 2)
+	assert.Equal(t, 2005, qs[0].(*ast.SetlistQuery).Date.Year)
+	assert.Equal(t, 2010, qs[1].(*ast.ShowQuery).From.End.Year)
+}
+
 func TestParseCountQuery_Bare(t *testing.T) {
 	p := NewFromString("COUNT;")
 	_, err := p.Parse()
@@ -399,6 +912,114 @@ func TestParseShowQuery_Closer(t *testing.T) {
 	assert.Equal(t, ast.PosClosed, pc.Operator)
 }
 
+// === NOTES CONTAINS ===
+
+func TestParseShowQuery_NotesContains(t *testing.T) {
+	p := NewFromString(`SHOWS WHERE NOTES CONTAINS "soundboard";`)
+	q, err := p.Parse()
+	require.NoError(t, err)
+	sq := q.(*ast.ShowQuery)
+	require.Len(t, sq.Where.Conditions, 1)
+	nc, ok := sq.Where.Conditions[0].(*ast.NotesCondition)
+	require.True(t, ok)
+	assert.Equal(t, "soundboard", nc.Text)
+}
+
+func TestParseShowQuery_NotesMissingContains(t *testing.T) {
+	p := NewFromString(`SHOWS WHERE NOTES "soundboard";`)
+	_, err := p.Parse()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expected CONTAINS")
+}
+
+// === SET NAMED ===
+
+func TestParseShowQuery_SetNamed(t *testing.T) {
+	q, err := NewFromString(`SHOWS WHERE set named "Acoustic" AND PLAYED "Dark Star";`).Parse()
+	require.NoError(t, err)
+	sq := q.(*ast.ShowQuery)
+	require.Len(t, sq.Where.Conditions, 2)
+	nc, ok := sq.Where.Conditions[0].(*ast.SetNameCondition)
+	require.True(t, ok)
+	assert.Equal(t, "Acoustic", nc.Name)
+
+	_, err = NewFromString(`SHOWS WHERE SET NAMED Acoustic;`).Parse()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expected string after SET NAMED")
+}
+
+// === TAPE / SOURCE ===
+
+func TestParseShowQuery_TapeAndSource(t *testing.T) {
+	p := NewFromString(`SHOWS WHERE TAPE AND SOURCE = "SBD";`)
+	q, err := p.Parse()
+	require.NoError(t, err)
+	sq := q.(*ast.ShowQuery)
+	require.Len(t, sq.Where.Conditions, 2)
+	_, ok := sq.Where.Conditions[0].(*ast.TapeCondition)
+	require.True(t, ok)
+	sc, ok := sq.Where.Conditions[1].(*ast.SourceCondition)
+	require.True(t, ok)
+	assert.Equal(t, "SBD", sc.Source)
+}
+
+func TestParseShowQuery_Complete(t *testing.T) {
+	q, err := NewFromString(`SHOWS WHERE COMPLETE AND NOT COMPLETE;`).Parse()
+	require.NoError(t, err)
+	conds := q.(*ast.ShowQuery).Where.Conditions
+	require.Len(t, conds, 2)
+	assert.Equal(t, &ast.CompleteCondition{}, conds[0])
+	assert.Equal(t, &ast.CompleteCondition{Negated: true}, conds[1])
+}
+
+func TestParseShowQuery_SourceMissingEquals(t *testing.T) {
+	p := NewFromString(`SHOWS WHERE SOURCE "SBD";`)
+	_, err := p.Parse()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expected = after SOURCE")
+}
+
+func TestParseShowQuery_Weekday(t *testing.T) {
+	q, err := NewFromString(`SHOWS FROM 1977 WHERE WEEKDAY = "Saturday" OR WEEKDAY = "sun";`).Parse()
+	require.NoError(t, err)
+	conds := q.(*ast.ShowQuery).Where.Conditions
+	require.Len(t, conds, 2)
+	assert.Equal(t, &ast.WeekdayCondition{Day: 6}, conds[0])
+	assert.Equal(t, &ast.WeekdayCondition{Day: 0}, conds[1])
+}
+
+func TestParseShowQuery_WeekdayTypo(t *testing.T) {
+	_, err := NewFromString(`SHOWS WHERE WEEKDAY = "Saturdy";`).Parse()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown weekday "Saturdy"`)
+	assert.Contains(t, err.Error(), "Did you mean: Saturday")
+}
+
+func TestParseShowQuery_Rating(t *testing.T) {
+	q, err := NewFromString(`SHOWS FROM 1977 WHERE RATING >= 4.5 AND rating < 5;`).Parse()
+	require.NoError(t, err)
+	conds := q.(*ast.ShowQuery).Where.Conditions
+	require.Len(t, conds, 2)
+	assert.Equal(t, &ast.RatingCondition{Operator: ast.CompGTE, Value: 4.5}, conds[0])
+	assert.Equal(t, &ast.RatingCondition{Operator: ast.CompLT, Value: 5}, conds[1])
+
+	_, err = NewFromString(`SHOWS WHERE RATING >= great;`).Parse()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expected number after RATING comparison")
+}
+
+func TestParseShowQuery_SongCount(t *testing.T) {
+	q, err := NewFromString(`SHOWS FROM 1977 WHERE SONGS > 20 AND PLAYED "Morning Dew";`).Parse()
+	require.NoError(t, err)
+	conds := q.(*ast.ShowQuery).Where.Conditions
+	require.Len(t, conds, 2)
+	assert.Equal(t, &ast.SongCountCondition{Operator: ast.CompGT, Count: 20}, conds[0])
+
+	_, err = NewFromString(`SHOWS WHERE SONGS > many;`).Parse()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expected number after SONGS comparison")
+}
+
 // === Bare song in WHERE → PLAYED ===
 
 func TestParseShowQuery_BareSongInWhere(t *testing.T) {
@@ -505,6 +1126,35 @@ func TestParseShowQuery_PlayedAndNotPlayed(t *testing.T) {
 	assert.Equal(t, "Saint Stephen", notPlayed.Song.Name)
 }
 
+func TestParseShowQuery_PlayedAllAny(t *testing.T) {
+	p := NewFromString(`SHOWS WHERE PLAYED ALL ("Scarlet Begonias", "Fire on the Mountain", "Estimated Prophet") AND NOT PLAYED ANY ("Dark Star", "St. Stephen");`)
+	q, err := p.Parse()
+	require.NoError(t, err)
+	sq := q.(*ast.ShowQuery)
+	require.Len(t, sq.Where.Conditions, 2)
+
+	all := sq.Where.Conditions[0].(*ast.PlayedSetCondition)
+	assert.True(t, all.All)
+	assert.False(t, all.Negated)
+	require.Len(t, all.Songs, 3)
+	assert.Equal(t, "Estimated Prophet", all.Songs[2].Name)
+
+	anyOf := sq.Where.Conditions[1].(*ast.PlayedSetCondition)
+	assert.False(t, anyOf.All)
+	assert.True(t, anyOf.Negated)
+	require.Len(t, anyOf.Songs, 2)
+	assert.Equal(t, "Dark Star", anyOf.Songs[0].Name)
+
+	for _, bad := range []string{
+		`SHOWS WHERE PLAYED ALL "Dark Star";`,
+		`SHOWS WHERE PLAYED ANY ("Dark Star" "St. Stephen");`,
+		`SHOWS WHERE PLAYED ALL ();`,
+	} {
+		_, err := NewFromString(bad).Parse()
+		assert.Error(t, err, bad)
+	}
+}
+
 // === SECURITY: ORDER BY SQL injection regression ===
 
 func TestParseError_OrderBySQLInjectionBlocked(t *testing.T) {
@@ -559,6 +1209,19 @@ func TestParseShowQuery_AllModifiers(t *testing.T) {
 	assert.Equal(t, ast.OutputJSON, sq.OutputFmt)
 }
 
+func TestParse_AsHTML(t *testing.T) {
+	p := NewFromString(`SETLIST FOR 5/8/77 AS HTML;`)
+	q, err := p.Parse()
+	require.NoError(t, err)
+	assert.Equal(t, ast.OutputHTML, q.(*ast.SetlistQuery).OutputFmt)
+}
+
+func TestParse_AsClassic(t *testing.T) {
+	q, err := NewFromString(`SHOWS FROM 1977 AS CLASSIC;`).Parse()
+	require.NoError(t, err)
+	assert.Equal(t, ast.OutputClassic, q.(*ast.ShowQuery).OutputFmt)
+}
+
 // === Arrow -> as segue ===
 
 func TestParseShowQuery_ArrowSegue(t *testing.T) {
@@ -575,6 +1238,41 @@ func TestParseShowQuery_ArrowSegue(t *testing.T) {
 	assert.Equal(t, "St. Stephen", seg.Songs[1].Name)
 }
 
+func TestParseShowQuery_ArrowSegueWithDateRange(t *testing.T) {
+	q, err := NewFromString(`SHOWS FROM 1977-1980 WHERE "Scarlet Begonias" -> "Fire on the Mountain";`).Parse()
+	require.NoError(t, err)
+	sq := q.(*ast.ShowQuery)
+	require.NotNil(t, sq.From)
+	assert.Equal(t, 1977, sq.From.Start.Year)
+	assert.Equal(t, 1980, sq.From.End.Year)
+	seg, ok := sq.Where.Conditions[0].(*ast.SegueCondition)
+	require.True(t, ok, "expected SegueCondition")
+	require.Len(t, seg.Songs, 2)
+}
+
+func TestParseShowQuery_AndBindsTighterThanOr(t *testing.T) {
+	q, err := NewFromString(`SHOWS WHERE PLAYED "Samson" OR PLAYED "Help" AND PLAYED "Dark Star";`).Parse()
+	require.NoError(t, err)
+	w := q.(*ast.ShowQuery).Where
+	require.Len(t, w.Conditions, 3)
+	require.Equal(t, []ast.LogicOp{ast.OpOr, ast.OpAnd}, w.Operators)
+	require.Len(t, w.Groups, 2, "a OR (b AND c)")
+	require.Len(t, w.Groups[0], 1)
+	require.Len(t, w.Groups[1], 2)
+	assert.Equal(t, "Help", w.Groups[1][0].(*ast.PlayedCondition).Song.Name)
+	assert.Equal(t, w.Groups, w.OrGroups())
+}
+
+func TestParseShowQuery_PlayedSegueStaysInGroup(t *testing.T) {
+	q, err := NewFromString(`SHOWS WHERE PLAYED "Dark Star" > "St. Stephen" OR PLAYED "Morning Dew";`).Parse()
+	require.NoError(t, err)
+	w := q.(*ast.ShowQuery).Where
+	require.Len(t, w.Conditions, 3)
+	require.Equal(t, []ast.LogicOp{ast.OpAnd, ast.OpOr}, w.Operators)
+	require.Len(t, w.Groups, 2)
+	require.Len(t, w.Groups[0], 2)
+}
+
 // === AND/OR between WITH conditions ===
 
 func TestParseSongQuery_WithAndBetweenLyrics(t *testing.T) {
@@ -975,3 +1673,28 @@ func TestParseShowQuery_MidChainNotGT(t *testing.T) {
 	assert.Equal(t, "Slipknot!", swn.Chain.Songs[1].Name)
 	assert.Equal(t, "Franklin's Tower", swn.NotSong.Name)
 }
+
+// === ParseAll (error recovery) ===
+
+func TestParseAll_MultipleStatements(t *testing.T) {
+	qs, err := ParseAll(`SHOWS FROM 1977; SONGS WITH LYRICS("rose"); SETLIST FOR 5/8/77;`)
+	require.NoError(t, err)
+	require.Len(t, qs, 3)
+	assert.IsType(t, &ast.ShowQuery{}, qs[0])
+	assert.IsType(t, &ast.SongQuery{}, qs[1])
+	assert.IsType(t, &ast.SetlistQuery{}, qs[2])
+}
+
+func TestParseAll_CollectsErrorsAndContinues(t *testing.T) {
+	input := "SHOWS FORM 1977;\nSHOWS FROM 1977 LIMIT 5;\nSONGZ;\nCOUNT SHOWS"
+	qs, err := ParseAll(input)
+	require.Error(t, err)
+	require.Len(t, qs, 2, "the two valid statements still parse")
+
+	var errs errors.ParseErrors
+	require.ErrorAs(t, err, &errs)
+	require.Len(t, errs, 2)
+	assert.Equal(t, 1, errs[0].Pos.Line)
+	assert.Equal(t, 3, errs[1].Pos.Line)
+	assert.Contains(t, err.Error(), "2 parse errors")
+}
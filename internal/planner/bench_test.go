@@ -0,0 +1,39 @@
+package planner
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gdql/gdql/internal/ast"
+	"github.com/gdql/gdql/internal/parser"
+	"github.com/gdql/gdql/internal/planner/expander"
+	"github.com/gdql/gdql/internal/planner/resolver"
+)
+
+func BenchmarkPlan(b *testing.B) {
+	sr := resolver.NewStaticResolver(map[string]int{
+		"Scarlet Begonias": 1, "Fire on the Mountain": 2, "Dark Star": 6,
+		"St. Stephen": 7, "Samson and Delilah": 10,
+	})
+	pl := New(sr, expander.New())
+	var queries []ast.Query
+	for _, s := range []string{
+		`SHOWS FROM 77-80 WHERE "Scarlet Begonias" > "Fire on the Mountain" LIMIT 10;`,
+		`SHOWS WHERE PLAYED "Dark Star" OR PLAYED "St. Stephen" AND SET2 OPENED "Samson and Delilah";`,
+		`PERFORMANCES OF "Dark Star" FROM 1972 WITH LENGTH > 20min;`,
+	} {
+		q, err := parser.NewFromString(s).Parse()
+		if err != nil {
+			b.Fatal(err)
+		}
+		queries = append(queries, q)
+	}
+	ctx := context.Background()
+	for b.Loop() {
+		for _, q := range queries {
+			if _, err := pl.Plan(ctx, q); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
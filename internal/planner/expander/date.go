@@ -12,6 +12,7 @@ type DateExpander interface {
 	Expand(*ast.DateRange) (*ir.ResolvedDateRange, error)
 	ExpandEra(ast.EraAlias) (*ir.ResolvedDateRange, error)
 	ExpandDate(*ast.Date) (time.Time, error)
+	ExpandPeriod(*ast.Date) *ir.ResolvedDateRange
 }
 
 type dateExpander struct{}
@@ -31,42 +32,68 @@ func (d *dateExpander) Expand(dr *ast.DateRange) (*ir.ResolvedDateRange, error)
 	if dr.Start == nil {
 		return nil, nil
 	}
-	start := time.Date(dr.Start.Year, 1, 1, 0, 0, 0, 0, time.UTC)
-	end := time.Date(dr.Start.Year, 12, 31, 23, 59, 59, 0, time.UTC)
+	// Both ends are inclusive: FROM 5/8/77 is that whole day, FROM 1977-1980
+	// runs through the last second of 1980.
+	start, end := span(dr.Start)
 	if dr.End != nil {
-		end = time.Date(dr.End.Year, 12, 31, 23, 59, 59, 0, time.UTC)
+		_, end = span(dr.End)
 	}
 	return &ir.ResolvedDateRange{Start: start, End: end}, nil
 }
 
+// span returns the first and last second of the year, month, or day a date
+// names.
+func span(date *ast.Date) (time.Time, time.Time) {
+	switch {
+	case date.Month == 0:
+		return time.Date(date.Year, 1, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(date.Year, 12, 31, 23, 59, 59, 0, time.UTC)
+	case date.Day == 0:
+		start := time.Date(date.Year, time.Month(date.Month), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, 0).Add(-time.Second)
+	}
+	start := time.Date(date.Year, time.Month(date.Month), date.Day, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 0, 1).Add(-time.Second)
+}
+
+// Era is a named span of the band's history that queries can use as a date
+// range (SHOWS FROM EUROPE72). Both ends are inclusive days.
+type Era struct {
+	Alias ast.EraAlias
+	Names []string // spellings the parser accepts; the first is the usual one
+	Start time.Time
+	End   time.Time
+	Note  string
+}
+
+var eras = []Era{
+	{ast.EraPrimal, []string{"PRIMAL"}, day(1965, 1, 1), day(1969, 12, 31), "the early years"},
+	{ast.EraEurope72, []string{"EUROPE72", "EUROPE"}, day(1972, 3, 1), day(1972, 5, 31), "spring 1972 European tour"},
+	{ast.EraWallOfSound, []string{"WALLOFSOUND"}, day(1974, 1, 1), day(1974, 12, 31), "the Wall of Sound PA"},
+	{ast.EraHiatus, []string{"HIATUS"}, day(1975, 1, 1), day(1975, 12, 31), "four shows all year"},
+	// Brent Mydland's first show was Apr 22, 1979; he died July 26, 1990.
+	{ast.EraBrent, []string{"BRENT_ERA", "BRENT"}, day(1979, 4, 22), day(1990, 7, 26), "Brent Mydland on keyboards"},
+	// Vince Welnick's first show with the Dead was Sept 7, 1990; the final
+	// show was Soldier Field, July 9, 1995.
+	{ast.EraVince, []string{"VINCE_ERA", "VINCE"}, day(1990, 9, 7), day(1995, 7, 9), "Vince Welnick on keyboards"},
+}
+
+func day(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// Eras lists the era aliases in chronological order, for gdql eras.
+func Eras() []Era {
+	return append([]Era(nil), eras...)
+}
+
 func (d *dateExpander) ExpandEra(era ast.EraAlias) (*ir.ResolvedDateRange, error) {
-	var start, end time.Time
-	switch era {
-	case ast.EraPrimal:
-		start = time.Date(1965, 1, 1, 0, 0, 0, 0, time.UTC)
-		end = time.Date(1969, 12, 31, 23, 59, 59, 0, time.UTC)
-	case ast.EraEurope72:
-		start = time.Date(1972, 3, 1, 0, 0, 0, 0, time.UTC)
-		end = time.Date(1972, 5, 31, 23, 59, 59, 0, time.UTC)
-	case ast.EraWallOfSound:
-		start = time.Date(1974, 1, 1, 0, 0, 0, 0, time.UTC)
-		end = time.Date(1974, 12, 31, 23, 59, 59, 0, time.UTC)
-	case ast.EraHiatus:
-		start = time.Date(1975, 1, 1, 0, 0, 0, 0, time.UTC)
-		end = time.Date(1975, 12, 31, 23, 59, 59, 0, time.UTC)
-	case ast.EraBrent:
-		// Brent Mydland's first show was Apr 22, 1979; he died July 26, 1990.
-		start = time.Date(1979, 4, 22, 0, 0, 0, 0, time.UTC)
-		end = time.Date(1990, 7, 26, 23, 59, 59, 0, time.UTC)
-	case ast.EraVince:
-		// Vince Welnick's first show with the Dead was Sept 7, 1990; the
-		// final show was Soldier Field, July 9, 1995.
-		start = time.Date(1990, 9, 7, 0, 0, 0, 0, time.UTC)
-		end = time.Date(1995, 7, 9, 23, 59, 59, 0, time.UTC)
-	default:
-		return nil, nil
+	for _, e := range eras {
+		if e.Alias == era {
+			return &ir.ResolvedDateRange{Start: e.Start, End: e.End.Add(24*time.Hour - time.Second)}, nil
+		}
 	}
-	return &ir.ResolvedDateRange{Start: start, End: end}, nil
+	return nil, nil
 }
 
 func (d *dateExpander) ExpandDate(date *ast.Date) (time.Time, error) {
@@ -87,3 +114,14 @@ func (d *dateExpander) ExpandDate(date *ast.Date) (time.Time, error) {
 	}
 	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC), nil
 }
+
+// ExpandPeriod returns the month or year a day-less date covers (5/77 is May
+// 1977, 1977 is all of 1977), or nil when the date names a single day or is a
+// free-form string.
+func (d *dateExpander) ExpandPeriod(date *ast.Date) *ir.ResolvedDateRange {
+	if date == nil || date.Season != "" || date.Year == 0 || date.Day != 0 {
+		return nil
+	}
+	start, end := span(date)
+	return &ir.ResolvedDateRange{Start: start, End: end}
+}
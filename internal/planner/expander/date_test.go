@@ -29,6 +29,43 @@ func TestExpand_YearRange(t *testing.T) {
 	require.Equal(t, time.Date(1980, 12, 31, 23, 59, 59, 0, time.UTC), r.End)
 }
 
+func TestExpand_OpenEndedRanges(t *testing.T) {
+	de := New()
+	r, err := de.Expand(&ast.DateRange{Start: &ast.Date{Year: 1977}, End: &ast.Date{Year: ast.OpenEndYear}})
+	require.NoError(t, err)
+	require.Equal(t, time.Date(1977, 1, 1, 0, 0, 0, 0, time.UTC), r.Start)
+	require.Equal(t, time.Date(ast.OpenEndYear, 12, 31, 23, 59, 59, 0, time.UTC), r.End)
+
+	r, err = de.Expand(&ast.DateRange{Start: &ast.Date{Year: ast.OpenStartYear}, End: &ast.Date{Year: 1972}})
+	require.NoError(t, err)
+	require.Equal(t, time.Date(ast.OpenStartYear, 1, 1, 0, 0, 0, 0, time.UTC), r.Start)
+	require.Equal(t, time.Date(1972, 12, 31, 23, 59, 59, 0, time.UTC), r.End)
+}
+
+func TestExpand_DayAndMonthBounds(t *testing.T) {
+	de := New()
+	// A single day runs from its first to its last second.
+	r, err := de.Expand(&ast.DateRange{Start: &ast.Date{Year: 1977, Month: 5, Day: 8}})
+	require.NoError(t, err)
+	require.Equal(t, time.Date(1977, 5, 8, 0, 0, 0, 0, time.UTC), r.Start)
+	require.Equal(t, time.Date(1977, 5, 8, 23, 59, 59, 0, time.UTC), r.End)
+
+	// The end of a range covers all of its day, month, or year.
+	r, err = de.Expand(&ast.DateRange{Start: &ast.Date{Year: 1977, Month: 2, Day: 26}, End: &ast.Date{Year: 1977, Month: 5, Day: 8}})
+	require.NoError(t, err)
+	require.Equal(t, time.Date(1977, 2, 26, 0, 0, 0, 0, time.UTC), r.Start)
+	require.Equal(t, time.Date(1977, 5, 8, 23, 59, 59, 0, time.UTC), r.End)
+
+	r, err = de.Expand(&ast.DateRange{Start: &ast.Date{Year: 1977, Month: 2}, End: &ast.Date{Year: 1980}})
+	require.NoError(t, err)
+	require.Equal(t, time.Date(1977, 2, 1, 0, 0, 0, 0, time.UTC), r.Start)
+	require.Equal(t, time.Date(1980, 12, 31, 23, 59, 59, 0, time.UTC), r.End)
+
+	r, err = de.Expand(&ast.DateRange{Start: &ast.Date{Year: 1980, Month: 2}})
+	require.NoError(t, err)
+	require.Equal(t, time.Date(1980, 2, 29, 23, 59, 59, 0, time.UTC), r.End, "leap year")
+}
+
 func TestExpand_NilRange(t *testing.T) {
 	de := New()
 	r, err := de.Expand(nil)
@@ -55,6 +92,20 @@ func TestExpand_EraEurope72(t *testing.T) {
 	require.Equal(t, 5, int(r.End.Month()))
 }
 
+func TestEras_ExpandToTheirDays(t *testing.T) {
+	de := New()
+	for _, e := range Eras() {
+		r, err := de.ExpandEra(e.Alias)
+		require.NoError(t, err)
+		require.Equal(t, e.Start, r.Start, e.Names[0])
+		require.Equal(t, e.End.Format("2006-01-02"), r.End.Format("2006-01-02"), e.Names[0])
+		require.Equal(t, "23:59:59", r.End.Format("15:04:05"), "end day is inclusive")
+	}
+	r, _ := de.ExpandEra(ast.EraBrent)
+	require.Equal(t, time.Date(1979, 4, 22, 0, 0, 0, 0, time.UTC), r.Start)
+	require.Equal(t, time.Date(1990, 7, 26, 23, 59, 59, 0, time.UTC), r.End)
+}
+
 func TestExpandDate_SingleDay(t *testing.T) {
 	de := New()
 	d := &ast.Date{Year: 1977, Month: 5, Day: 8}
@@ -81,3 +132,20 @@ func TestExpandDate_Nil(t *testing.T) {
 	require.NoError(t, err)
 	require.True(t, tm.IsZero())
 }
+
+func TestExpandPeriod(t *testing.T) {
+	de := New()
+	r := de.ExpandPeriod(&ast.Date{Year: 1977, Month: 2})
+	require.NotNil(t, r)
+	require.Equal(t, "1977-02-01", r.Start.Format("2006-01-02"))
+	require.Equal(t, "1977-02-28", r.End.Format("2006-01-02"))
+
+	r = de.ExpandPeriod(&ast.Date{Year: 1977})
+	require.NotNil(t, r)
+	require.Equal(t, "1977-01-01", r.Start.Format("2006-01-02"))
+	require.Equal(t, "1977-12-31", r.End.Format("2006-01-02"))
+
+	require.Nil(t, de.ExpandPeriod(&ast.Date{Year: 1977, Month: 5, Day: 8}))
+	require.Nil(t, de.ExpandPeriod(&ast.Date{Season: "Cornell 1977"}))
+	require.Nil(t, de.ExpandPeriod(nil))
+}
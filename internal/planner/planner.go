@@ -2,6 +2,7 @@ package planner
 
 import (
 	"context"
+	"fmt"
 	"strconv"
 	"strings"
 
@@ -27,7 +28,18 @@ func New(sr resolver.SongResolver, de expander.DateExpander) Planner {
 	return &planner{songResolver: sr, dateExpander: de}
 }
 
+// Plan resolves q into IR. Song names are looked up through a recorder, so
+// the IR's SongMatches says how each one resolved.
 func (p *planner) Plan(ctx context.Context, q ast.Query) (*ir.QueryIR, error) {
+	rec := &recordingResolver{SongResolver: p.songResolver}
+	out, err := (&planner{songResolver: rec, dateExpander: p.dateExpander}).plan(ctx, q)
+	if out != nil {
+		out.SongMatches = rec.matches
+	}
+	return out, err
+}
+
+func (p *planner) plan(ctx context.Context, q ast.Query) (*ir.QueryIR, error) {
 	switch x := q.(type) {
 	case *ast.ShowQuery:
 		return p.planShow(ctx, x)
@@ -43,6 +55,16 @@ func (p *planner) Plan(ctx context.Context, q ast.Query) (*ir.QueryIR, error) {
 		return p.planFirstLast(ctx, x)
 	case *ast.RandomShowQuery:
 		return p.planRandomShow(x)
+	case *ast.VenueQuery:
+		return p.planVenues(x)
+	case *ast.RunQuery:
+		return p.planRuns(x)
+	case *ast.CompareQuery:
+		return p.planCompare(ctx, x)
+	case *ast.CoverageQuery:
+		return p.planCoverage(x)
+	case *ast.GapsQuery:
+		return p.planGaps(x)
 	default:
 		return nil, nil
 	}
@@ -63,76 +85,67 @@ func (p *planner) planShow(ctx context.Context, s *ast.ShowQuery) (*ir.QueryIR,
 		// If the WHERE contains any OR, the JOIN-based primary chain optimization
 		// is unsafe (the JOIN is a mandatory filter, which silently turns OR into
 		// AND). In that case, all chains must be EXISTS subqueries — never lift.
-		canLiftPrimary := true
-		for _, op := range s.Where.Operators {
-			if op == ast.OpOr {
-				canLiftPrimary = false
-				break
-			}
-		}
-		for i, c := range s.Where.Conditions {
-			if seg, ok := c.(*ast.SegueCondition); ok {
-				chain, err := p.segueToIR(ctx, seg)
-				if err != nil {
-					return nil, p.wrapSongNotFound(ctx, err)
-				}
-				// First chain becomes the primary (lifted to JOINs);
-				// subsequent chains are EXISTS subqueries in Conditions.
-				if canLiftPrimary && out.SegueChain == nil {
-					out.SegueChain = chain
-				} else {
-					if len(out.Conditions) > 0 && i > 0 && i-1 < len(s.Where.Operators) {
-						out.ConditionOps = append(out.ConditionOps, astLogicOpToIR(s.Where.Operators[i-1]))
+		groups := s.Where.OrGroups()
+		canLiftPrimary := len(groups) == 1
+		for _, group := range groups {
+			add := newConditionAppender(out)
+			for _, c := range group {
+				if seg, ok := c.(*ast.SegueCondition); ok {
+					chain, err := p.segueToIR(ctx, seg)
+					if err != nil {
+						return nil, p.wrapSongNotFound(ctx, err)
 					}
-					out.Conditions = append(out.Conditions, &ir.SegueChainConditionIR{Chain: chain})
-				}
-				continue
-			}
-			if swn, ok := c.(*ast.SegueWithNegation); ok {
-				// Segue chain part
-				chain, err := p.segueToIR(ctx, swn.Chain)
-				if err != nil {
-					return nil, p.wrapSongNotFound(ctx, err)
-				}
-				if canLiftPrimary && out.SegueChain == nil {
-					out.SegueChain = chain
-				} else {
-					if len(out.Conditions) > 0 && i > 0 && i-1 < len(s.Where.Operators) {
-						out.ConditionOps = append(out.ConditionOps, astLogicOpToIR(s.Where.Operators[i-1]))
+					// First chain becomes the primary (lifted to JOINs);
+					// subsequent chains are EXISTS subqueries in Conditions.
+					if canLiftPrimary && out.SegueChain == nil {
+						out.SegueChain = chain
+					} else {
+						add(&ir.SegueChainConditionIR{Chain: chain})
 					}
-					out.Conditions = append(out.Conditions, &ir.SegueChainConditionIR{Chain: chain})
+					continue
 				}
-				// Negated adjacency part
-				fromID, err := p.songResolver.Resolve(ctx, swn.FromSong.Name)
-				if err != nil {
-					return nil, p.wrapSongNotFound(ctx, err)
+				if swn, ok := c.(*ast.SegueWithNegation); ok {
+					// Segue chain part
+					chain, err := p.segueToIR(ctx, swn.Chain)
+					if err != nil {
+						return nil, p.wrapSongNotFound(ctx, err)
+					}
+					if canLiftPrimary && out.SegueChain == nil {
+						out.SegueChain = chain
+					} else {
+						add(&ir.SegueChainConditionIR{Chain: chain})
+					}
+					// Negated adjacency part
+					fromID, err := p.songResolver.Resolve(ctx, swn.FromSong.Name)
+					if err != nil {
+						return nil, p.wrapSongNotFound(ctx, err)
+					}
+					notID, err := p.songResolver.Resolve(ctx, swn.NotSong.Name)
+					if err != nil {
+						return nil, p.wrapSongNotFound(ctx, err)
+					}
+					add(&ir.NegatedSegueConditionIR{SongID: fromID, NotSongID: notID})
+					continue
 				}
-				notID, err := p.songResolver.Resolve(ctx, swn.NotSong.Name)
+				cond, err := p.conditionToIR(ctx, c)
 				if err != nil {
 					return nil, p.wrapSongNotFound(ctx, err)
 				}
-				if len(out.Conditions) > 0 && i > 0 && i-1 < len(s.Where.Operators) {
-					out.ConditionOps = append(out.ConditionOps, astLogicOpToIR(s.Where.Operators[i-1]))
+				if cond != nil {
+					add(cond)
 				}
-				out.Conditions = append(out.Conditions, &ir.NegatedSegueConditionIR{SongID: fromID, NotSongID: notID})
-				continue
-			}
-			cond, err := p.conditionToIR(ctx, c)
-			if err != nil {
-				return nil, p.wrapSongNotFound(ctx, err)
-			}
-			if cond != nil {
-				// Add the operator *before* this condition (between prev and this)
-				if len(out.Conditions) > 0 && i > 0 && i-1 < len(s.Where.Operators) {
-					out.ConditionOps = append(out.ConditionOps, astLogicOpToIR(s.Where.Operators[i-1]))
-				}
-				out.Conditions = append(out.Conditions, cond)
 			}
 		}
 	}
 	if s.OrderBy != nil {
 		out.OrderBy = &ir.OrderByIR{Field: s.OrderBy.Field, Desc: s.OrderBy.Desc}
 	}
+	if s.Best {
+		out.RatedOnly = true
+		if out.OrderBy == nil {
+			out.OrderBy = &ir.OrderByIR{Field: "RATING", Desc: true}
+		}
+	}
 	out.Limit = s.Limit
 	out.OutputFmt = astOutputToIR(s.OutputFmt)
 	return out, nil
@@ -153,6 +166,30 @@ func (p *planner) planSong(ctx context.Context, s *ast.SongQuery) (*ir.QueryIR,
 			return nil, err
 		}
 		out.PlayedRange = dr
+		out.EveryYear = s.EveryYear
+	}
+	out.InSet = astSetPosToIR(s.InSet)
+	if s.Once {
+		out.Conditions = append(out.Conditions, &ir.PlayedOnceConditionIR{})
+	}
+	if s.Debuted != nil {
+		dr, err := p.dateExpander.Expand(s.Debuted)
+		if err != nil {
+			return nil, err
+		}
+		out.DebutRange = dr
+	}
+	if s.Where != nil {
+		for _, c := range s.Where.Conditions {
+			switch x := c.(type) {
+			case *ast.CoverCondition:
+				out.Conditions = append(out.Conditions, &ir.CoverConditionIR{Cover: x.Cover})
+			case *ast.TimesPlayedCondition:
+				out.Conditions = append(out.Conditions, &ir.TimesPlayedConditionIR{Operator: astCompOpToIR(x.Operator), Count: x.Count})
+			case *ast.MissingFieldCondition:
+				out.Conditions = append(out.Conditions, &ir.MissingFieldConditionIR{Field: x.Field})
+			}
+		}
 	}
 	if s.With != nil {
 		for _, c := range s.With.Conditions {
@@ -178,6 +215,16 @@ func (p *planner) planPerformance(ctx context.Context, perf *ast.PerformanceQuer
 		return nil, p.wrapSongNotFound(ctx, err)
 	}
 	out.SongID = &id
+	if len(perf.More) > 0 {
+		out.SongIDs = append(out.SongIDs, id)
+		for _, ref := range perf.More {
+			id, err := p.songResolver.Resolve(ctx, ref.Name)
+			if err != nil {
+				return nil, p.wrapSongNotFound(ctx, err)
+			}
+			out.SongIDs = append(out.SongIDs, id)
+		}
+	}
 	if perf.From != nil {
 		var err error
 		out.DateRange, err = p.dateExpander.Expand(perf.From)
@@ -193,17 +240,28 @@ func (p *planner) planPerformance(ctx context.Context, perf *ast.PerformanceQuer
 			}
 			out.Conditions = append(out.Conditions, cond)
 		}
+		out.WithContext = perf.With.Context
+	}
+	if perf.ByYear {
+		out.Type = ir.QueryTypePerformanceYears
 	}
 	if perf.OrderBy != nil {
 		out.OrderBy = &ir.OrderByIR{Field: perf.OrderBy.Field, Desc: perf.OrderBy.Desc}
 	}
 	out.Limit = perf.Limit
+	out.OutputFmt = astOutputToIR(perf.OutputFmt)
 	return out, nil
 }
 
 func (p *planner) planSetlist(sl *ast.SetlistQuery) (*ir.QueryIR, error) {
 	out := &ir.QueryIR{Type: ir.QueryTypeSetlist}
-	if sl.Date != nil {
+	if r := p.dateExpander.ExpandPeriod(sl.Date); r != nil {
+		// SETLIST FOR 5/77: every show that month, one setlist each
+		out.DateRange = r
+	} else if sl.Date != nil && sl.Date.Season != "" {
+		// SETLIST FOR "Winterland": the latest show at that venue
+		out.VenueName = strings.TrimSpace(sl.Date.Season)
+	} else if sl.Date != nil {
 		t, err := p.dateExpander.ExpandDate(sl.Date)
 		if err != nil {
 			return nil, err
@@ -215,9 +273,9 @@ func (p *planner) planSetlist(sl *ast.SetlistQuery) (*ir.QueryIR, error) {
 }
 
 func (p *planner) planCount(ctx context.Context, c *ast.CountQuery) (*ir.QueryIR, error) {
-	out := &ir.QueryIR{Type: ir.QueryTypeCount}
-	if c.CountShows {
-		// COUNT SHOWS — no song resolution needed
+	out := &ir.QueryIR{Type: ir.QueryTypeCount, CountVenues: c.CountVenues}
+	if c.CountShows || c.CountVenues {
+		// COUNT SHOWS / COUNT VENUES — no song resolution needed
 	} else {
 		id, err := p.songResolver.Resolve(ctx, c.Song.Name)
 		if err != nil {
@@ -233,24 +291,31 @@ func (p *planner) planCount(ctx context.Context, c *ast.CountQuery) (*ir.QueryIR
 		}
 	}
 	if c.Where != nil {
-		for i, cond := range c.Where.Conditions {
-			if seg, ok := cond.(*ast.SegueCondition); ok {
-				chain, err := p.segueToIR(ctx, seg)
+		groups := c.Where.OrGroups()
+		for _, group := range groups {
+			add := newConditionAppender(out)
+			for _, cond := range group {
+				if seg, ok := cond.(*ast.SegueCondition); ok {
+					chain, err := p.segueToIR(ctx, seg)
+					if err != nil {
+						return nil, p.wrapSongNotFound(ctx, err)
+					}
+					// Same rule as SHOWS: only lift the chain to JOINs when
+					// there is no OR for the JOIN to silently override.
+					if len(groups) == 1 && out.SegueChain == nil {
+						out.SegueChain = chain
+					} else {
+						add(&ir.SegueChainConditionIR{Chain: chain})
+					}
+					continue
+				}
+				irCond, err := p.conditionToIR(ctx, cond)
 				if err != nil {
 					return nil, p.wrapSongNotFound(ctx, err)
 				}
-				out.SegueChain = chain
-				continue
-			}
-			irCond, err := p.conditionToIR(ctx, cond)
-			if err != nil {
-				return nil, p.wrapSongNotFound(ctx, err)
-			}
-			if irCond != nil {
-				if len(out.Conditions) > 0 && i > 0 && i-1 < len(c.Where.Operators) {
-					out.ConditionOps = append(out.ConditionOps, astLogicOpToIR(c.Where.Operators[i-1]))
+				if irCond != nil {
+					add(irCond)
 				}
-				out.Conditions = append(out.Conditions, irCond)
 			}
 		}
 	}
@@ -267,6 +332,19 @@ func (p *planner) planFirstLast(ctx context.Context, fl *ast.FirstLastQuery) (*i
 	return out, nil
 }
 
+func (p *planner) planCompare(ctx context.Context, c *ast.CompareQuery) (*ir.QueryIR, error) {
+	out := &ir.QueryIR{Type: ir.QueryTypeCompare}
+	for _, ref := range c.Songs {
+		id, err := p.songResolver.Resolve(ctx, ref.Name)
+		if err != nil {
+			return nil, p.wrapSongNotFound(ctx, err)
+		}
+		out.SongIDs = append(out.SongIDs, id)
+	}
+	out.OutputFmt = astOutputToIR(c.OutputFmt)
+	return out, nil
+}
+
 func (p *planner) planRandomShow(r *ast.RandomShowQuery) (*ir.QueryIR, error) {
 	out := &ir.QueryIR{Type: ir.QueryTypeRandomShow}
 	if r.From != nil {
@@ -279,9 +357,88 @@ func (p *planner) planRandomShow(r *ast.RandomShowQuery) (*ir.QueryIR, error) {
 	return out, nil
 }
 
+func (p *planner) planVenues(v *ast.VenueQuery) (*ir.QueryIR, error) {
+	out := &ir.QueryIR{Type: ir.QueryTypeVenues}
+	if v.Stats {
+		out.Type = ir.QueryTypeVenueStats
+	}
+	if v.From != nil {
+		var err error
+		out.DateRange, err = p.dateExpander.Expand(v.From)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if v.OrderBy != nil {
+		out.OrderBy = &ir.OrderByIR{Field: v.OrderBy.Field, Desc: v.OrderBy.Desc}
+	}
+	out.Limit = v.Limit
+	out.OutputFmt = astOutputToIR(v.OutputFmt)
+	return out, nil
+}
+
+func (p *planner) planRuns(r *ast.RunQuery) (*ir.QueryIR, error) {
+	out := &ir.QueryIR{Type: ir.QueryTypeRuns, VenueName: r.At}
+	if r.From != nil {
+		var err error
+		out.DateRange, err = p.dateExpander.Expand(r.From)
+		if err != nil {
+			return nil, err
+		}
+	}
+	out.Limit = r.Limit
+	out.OutputFmt = astOutputToIR(r.OutputFmt)
+	return out, nil
+}
+
+func (p *planner) planCoverage(c *ast.CoverageQuery) (*ir.QueryIR, error) {
+	out := &ir.QueryIR{Type: ir.QueryTypeCoverage}
+	if c.From != nil {
+		var err error
+		out.DateRange, err = p.dateExpander.Expand(c.From)
+		if err != nil {
+			return nil, err
+		}
+	}
+	out.OutputFmt = astOutputToIR(c.OutputFmt)
+	return out, nil
+}
+
+func (p *planner) planGaps(g *ast.GapsQuery) (*ir.QueryIR, error) {
+	out := &ir.QueryIR{Type: ir.QueryTypeGaps, TourName: g.Tour}
+	if g.From != nil {
+		var err error
+		out.DateRange, err = p.dateExpander.Expand(g.From)
+		if err != nil {
+			return nil, err
+		}
+	}
+	out.OutputFmt = astOutputToIR(g.OutputFmt)
+	return out, nil
+}
+
+// MaxSegueChain caps the songs in one segue chain. Each song past the first
+// adds a self-join on performances, so very long chains get slow; 0 disables
+// the cap.
+var MaxSegueChain = 8
+
 func (p *planner) segueToIR(ctx context.Context, seg *ast.SegueCondition) (*ir.SegueChainIR, error) {
+	if MaxSegueChain > 0 && len(seg.Songs) > MaxSegueChain {
+		return nil, &errors.QueryError{
+			Type:    errors.ErrSegueTooLong,
+			Message: fmt.Sprintf("%d songs in one chain (the limit is %d)", len(seg.Songs), MaxSegueChain),
+			Hint:    fmt.Sprintf("Each song joins performances again. Search for a shorter stretch, e.g. the first %d songs, and check the setlists it finds.", MaxSegueChain),
+		}
+	}
 	ids := make([]int, 0, len(seg.Songs))
 	for _, ref := range seg.Songs {
+		if resolver.IsPattern(ref.Name) {
+			return nil, &errors.QueryError{
+				Type:    errors.ErrSongNotFound,
+				Message: ref.Name,
+				Hint:    "A segue chain needs one song at each step, so it can't hold a pattern (\"Scarlet%\", \"~Jam\"). A lone > \"Song\" can: SHOWS WHERE > \"~Fire\" finds a segue into any match.",
+			}
+		}
 		id, err := p.songResolver.Resolve(ctx, ref.Name)
 		if err != nil {
 			return nil, p.wrapSongNotFound(ctx, err)
@@ -307,7 +464,10 @@ func (p *planner) wrapSongNotFound(ctx context.Context, err error) error {
 		Message:     nf.Name,
 		Suggestions: suggestions,
 	}
-	if len(suggestions) == 0 {
+	switch {
+	case resolver.IsPattern(nf.Name):
+		qe.Hint = "Song patterns (\"Scarlet%\", \"~Jam\") match several songs, so they work with PLAYED, NOT PLAYED, and a lone > \"Song\" (not a segue chain) only, and need at least one match."
+	case len(suggestions) == 0:
 		qe.Hint = "Check the spelling, or try `SONGS WITH LYRICS(\"keyword\")` to search by a word in the lyrics."
 	}
 	return qe
@@ -347,6 +507,16 @@ func (p *planner) conditionToIR(ctx context.Context, c ast.Condition) (ir.Condit
 			return nil, p.wrapSongNotFound(ctx, err)
 		}
 		return &ir.PlayedConditionIR{SongIDs: ids, Negated: x.Negated}, nil
+	case *ast.PlayedSetCondition:
+		out := &ir.PlayedSetConditionIR{All: x.All, Negated: x.Negated}
+		for _, s := range x.Songs {
+			ids, err := p.songResolver.ResolveVariants(ctx, s.Name)
+			if err != nil {
+				return nil, p.wrapSongNotFound(ctx, err)
+			}
+			out.SongIDs = append(out.SongIDs, ids)
+		}
+		return out, nil
 	case *ast.LengthCondition:
 		var songID *int
 		if x.Song != nil {
@@ -360,6 +530,22 @@ func (p *planner) conditionToIR(ctx context.Context, c ast.Condition) (ir.Condit
 		return &ir.LengthConditionIR{SongID: songID, Operator: astCompOpToIR(x.Operator), Seconds: sec}, nil
 	case *ast.GuestCondition:
 		return &ir.GuestConditionIR{Name: x.Name}, nil
+	case *ast.NotesCondition:
+		return &ir.NotesConditionIR{Text: x.Text}, nil
+	case *ast.TapeCondition:
+		return &ir.TapeConditionIR{}, nil
+	case *ast.SourceCondition:
+		return &ir.SourceConditionIR{Source: x.Source}, nil
+	case *ast.CompleteCondition:
+		return &ir.CompleteConditionIR{Negated: x.Negated}, nil
+	case *ast.WeekdayCondition:
+		return &ir.WeekdayConditionIR{Day: x.Day}, nil
+	case *ast.SetNameCondition:
+		return &ir.SetNameConditionIR{Name: x.Name}, nil
+	case *ast.RatingCondition:
+		return &ir.RatingConditionIR{Operator: astCompOpToIR(x.Operator), Value: x.Value}, nil
+	case *ast.SongCountCondition:
+		return &ir.SongCountConditionIR{Operator: astCompOpToIR(x.Operator), Count: x.Count}, nil
 	case *ast.SegueIntoCondition:
 		ids, err := p.songResolver.ResolveVariants(ctx, x.Song.Name)
 		if err != nil {
@@ -395,6 +581,8 @@ func (p *planner) withConditionToIR(ctx context.Context, c ast.WithCondition) (i
 		return &ir.LengthConditionIR{Operator: astCompOpToIR(x.Operator), Seconds: sec}, nil
 	case *ast.GuestWithCondition:
 		return &ir.GuestConditionIR{Name: x.Name}, nil
+	case *ast.TimesPlayedCondition:
+		return &ir.TimesPlayedConditionIR{Operator: astCompOpToIR(x.Operator), Count: x.Count}, nil
 	default:
 		return nil, nil
 	}
@@ -456,6 +644,20 @@ func astCompOpToIR(o ast.CompOp) ir.CompOp {
 	return ir.CompGT
 }
 
+// newConditionAppender returns a func that appends conditions of one WHERE
+// AND-group to out: the group's first condition is joined to what came
+// before with OR, the rest with AND.
+func newConditionAppender(out *ir.QueryIR) func(ir.ConditionIR) {
+	op := ir.OpOr
+	return func(c ir.ConditionIR) {
+		if len(out.Conditions) > 0 {
+			out.ConditionOps = append(out.ConditionOps, op)
+		}
+		out.Conditions = append(out.Conditions, c)
+		op = ir.OpAnd
+	}
+}
+
 func astLogicOpToIR(o ast.LogicOp) ir.LogicOp {
 	if o == ast.OpOr {
 		return ir.OpOr
@@ -479,6 +681,10 @@ func astOutputToIR(o ast.OutputFormat) ir.OutputFormat {
 		return ir.OutputTable
 	case ast.OutputCount:
 		return ir.OutputCount
+	case ast.OutputHTML:
+		return ir.OutputHTML
+	case ast.OutputClassic:
+		return ir.OutputClassic
 	}
 	return ir.OutputDefault
 }
@@ -2,9 +2,11 @@ package planner
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	"github.com/gdql/gdql/internal/ast"
+	"github.com/gdql/gdql/internal/errors"
 	"github.com/gdql/gdql/internal/ir"
 	"github.com/gdql/gdql/internal/planner/expander"
 	"github.com/gdql/gdql/internal/planner/resolver"
@@ -27,6 +29,21 @@ func TestPlan_ShowQuery_Simple(t *testing.T) {
 	require.Equal(t, 1980, got.DateRange.End.Year())
 }
 
+func TestPlan_ShowQuery_Best(t *testing.T) {
+	pl := New(resolver.NewStaticResolver(nil), expander.New())
+
+	got, err := pl.Plan(context.Background(), &ast.ShowQuery{Best: true})
+	require.NoError(t, err)
+	require.True(t, got.RatedOnly)
+	require.Equal(t, &ir.OrderByIR{Field: "RATING", Desc: true}, got.OrderBy)
+
+	// An explicit ORDER BY wins; BEST still drops unrated shows.
+	got, err = pl.Plan(context.Background(), &ast.ShowQuery{Best: true, OrderBy: &ast.OrderClause{Field: "DATE"}})
+	require.NoError(t, err)
+	require.True(t, got.RatedOnly)
+	require.Equal(t, "DATE", got.OrderBy.Field)
+}
+
 func TestPlan_ShowQuery_WithSegue(t *testing.T) {
 	sr := resolver.NewStaticResolver(map[string]int{
 		"Scarlet Begonias":      1,
@@ -54,6 +71,48 @@ func TestPlan_ShowQuery_WithSegue(t *testing.T) {
 	require.Equal(t, ir.SegueOpSegue, got.SegueChain.Operators[0])
 }
 
+func TestPlan_SegueChainTooLong(t *testing.T) {
+	names := map[string]int{}
+	chain := func(n int) *ast.SegueCondition {
+		seg := &ast.SegueCondition{}
+		for i := 0; i < n; i++ {
+			name := fmt.Sprintf("Song %d", i)
+			names[name] = i + 1
+			seg.Songs = append(seg.Songs, &ast.SongRef{Name: name})
+			if i > 0 {
+				seg.Operators = append(seg.Operators, ast.SegueOpSegue)
+			}
+		}
+		return seg
+	}
+	show := func(seg *ast.SegueCondition) *ast.ShowQuery {
+		return &ast.ShowQuery{Where: &ast.WhereClause{Conditions: []ast.Condition{seg}}}
+	}
+	ok, tooLong := chain(MaxSegueChain), chain(MaxSegueChain+1)
+	pl := New(resolver.NewStaticResolver(names), expander.New())
+
+	got, err := pl.Plan(context.Background(), show(ok))
+	require.NoError(t, err)
+	require.Len(t, got.SegueChain.SongIDs, MaxSegueChain)
+
+	_, err = pl.Plan(context.Background(), show(tooLong))
+	var qe *errors.QueryError
+	require.ErrorAs(t, err, &qe)
+	require.Equal(t, errors.ErrSegueTooLong, qe.Type)
+	require.Contains(t, err.Error(), "9 songs in one chain (the limit is 8)")
+
+	// Inside a position condition too.
+	_, err = pl.Plan(context.Background(), &ast.ShowQuery{Where: &ast.WhereClause{Conditions: []ast.Condition{
+		&ast.PositionCondition{Set: ast.Set2, Operator: ast.PosOpened, SegueChain: tooLong},
+	}}})
+	require.ErrorAs(t, err, &qe)
+
+	defer func(n int) { MaxSegueChain = n }(MaxSegueChain)
+	MaxSegueChain = 0
+	_, err = pl.Plan(context.Background(), show(tooLong))
+	require.NoError(t, err)
+}
+
 func TestPlan_ShowQuery_WherePosition(t *testing.T) {
 	sr := resolver.NewStaticResolver(map[string]int{"Samson and Delilah": 5})
 	de := expander.New()
@@ -115,6 +174,25 @@ func TestPlan_SetlistQuery(t *testing.T) {
 	require.Equal(t, 8, got.SingleDate.Day())
 }
 
+func TestPlan_SetlistQuery_Month(t *testing.T) {
+	pl := New(resolver.NewStaticResolver(nil), expander.New())
+	got, err := pl.Plan(context.Background(), &ast.SetlistQuery{Date: &ast.Date{Year: 1977, Month: 5}})
+	require.NoError(t, err)
+	require.Nil(t, got.SingleDate)
+	require.NotNil(t, got.DateRange)
+	require.Equal(t, "1977-05-01", got.DateRange.Start.Format("2006-01-02"))
+	require.Equal(t, "1977-05-31", got.DateRange.End.Format("2006-01-02"))
+}
+
+func TestPlan_SetlistQuery_Venue(t *testing.T) {
+	pl := New(resolver.NewStaticResolver(nil), expander.New())
+	got, err := pl.Plan(context.Background(), &ast.SetlistQuery{Date: &ast.Date{Season: "Winterland"}})
+	require.NoError(t, err)
+	require.Nil(t, got.SingleDate)
+	require.Nil(t, got.DateRange)
+	require.Equal(t, "Winterland", got.VenueName)
+}
+
 func TestPlan_SongQuery_WithLyrics(t *testing.T) {
 	pl := New(resolver.NewStaticResolver(nil), expander.New())
 	q := &ast.SongQuery{
@@ -263,6 +341,20 @@ func TestPlan_FirstLastQuery(t *testing.T) {
 	require.True(t, got.IsLast)
 }
 
+func TestPlan_CompareQuery(t *testing.T) {
+	pl := newPlanner(map[string]int{"Dark Star": 10, "Playing in the Band": 20})
+	q := &ast.CompareQuery{Songs: []*ast.SongRef{{Name: "Dark Star"}, {Name: "Playing in the Band"}}, OutputFmt: ast.OutputJSON}
+	got, err := pl.Plan(context.Background(), q)
+	require.NoError(t, err)
+	require.Equal(t, ir.QueryTypeCompare, got.Type)
+	require.Equal(t, []int{10, 20}, got.SongIDs)
+	require.Equal(t, ir.OutputJSON, got.OutputFmt)
+
+	q.Songs[1].Name = "Nope"
+	_, err = pl.Plan(context.Background(), q)
+	require.Error(t, err)
+}
+
 func TestPlan_RandomShowQuery(t *testing.T) {
 	pl := newPlanner(nil)
 	q := &ast.RandomShowQuery{}
@@ -333,3 +425,21 @@ func TestPlan_OpenerCondition(t *testing.T) {
 	require.Equal(t, ir.PosOpened, pc.Operator)
 	require.Equal(t, 7, pc.SongID)
 }
+
+func TestPlan_ShowQuery_AndOrGroups(t *testing.T) {
+	sr := resolver.NewStaticResolver(map[string]int{"Samson and Delilah": 4, "Help on the Way": 3, "Dark Star": 6})
+	pl := New(sr, expander.New())
+
+	// PLAYED Samson OR PLAYED Help AND PLAYED Dark Star
+	played := func(name string) ast.Condition { return &ast.PlayedCondition{Song: &ast.SongRef{Name: name}} }
+	q := &ast.ShowQuery{
+		Where: &ast.WhereClause{
+			Conditions: []ast.Condition{played("Samson and Delilah"), played("Help on the Way"), played("Dark Star")},
+			Operators:  []ast.LogicOp{ast.OpOr, ast.OpAnd},
+		},
+	}
+	got, err := pl.Plan(context.Background(), q)
+	require.NoError(t, err)
+	require.Len(t, got.Conditions, 3)
+	require.Equal(t, []ir.LogicOp{ir.OpOr, ir.OpAnd}, got.ConditionOps)
+}
@@ -0,0 +1,54 @@
+package planner
+
+import (
+	"context"
+
+	"github.com/gdql/gdql/internal/ir"
+	"github.com/gdql/gdql/internal/planner/resolver"
+)
+
+// recordingResolver passes lookups through to a SongResolver and remembers,
+// once per name, what each one resolved to. Single names go through Lookup
+// when the resolver has it, so the record carries GetSong's match stage.
+type recordingResolver struct {
+	resolver.SongResolver
+	matches []ir.SongMatch
+}
+
+func (r *recordingResolver) Resolve(ctx context.Context, name string) (int, error) {
+	lk, ok := r.SongResolver.(resolver.SongLookup)
+	if !ok {
+		id, err := r.SongResolver.Resolve(ctx, name)
+		if err == nil {
+			r.record(ir.SongMatch{Name: name, IDs: []int{id}})
+		}
+		return id, err
+	}
+	song, err := lk.Lookup(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+	r.record(ir.SongMatch{Name: name, IDs: []int{song.ID}, Song: song.Name, Stage: string(song.MatchedBy)})
+	return song.ID, nil
+}
+
+func (r *recordingResolver) ResolveVariants(ctx context.Context, name string) ([]int, error) {
+	ids, err := r.SongResolver.ResolveVariants(ctx, name)
+	if err == nil {
+		stage := "variants"
+		if resolver.IsPattern(name) {
+			stage = "pattern"
+		}
+		r.record(ir.SongMatch{Name: name, IDs: ids, Stage: stage})
+	}
+	return ids, err
+}
+
+func (r *recordingResolver) record(m ir.SongMatch) {
+	for _, seen := range r.matches {
+		if seen.Name == m.Name && seen.Stage == m.Stage {
+			return
+		}
+	}
+	r.matches = append(r.matches, m)
+}
@@ -0,0 +1,186 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/gdql/gdql/internal/data"
+)
+
+// CachingResolver wraps a DataSourceResolver for long-lived callers (the
+// REPL, a server) that resolve the same songs query after query.
+//
+// On first use it loads every song name into memory, keyed like
+// GetSongVariantIDs keys them (data.NormalizeSongName), and every alias,
+// keyed like GetSong matches them (case-insensitively), so ResolveVariants
+// needs no database round-trip when a name or alias matches. Everything else
+// — names that need GetSong's fuzzy fallback, Resolve, patterns — goes to the
+// wrapped resolver once per name and is remembered: GetSong breaks ties by
+// play count, so its answers are reused rather than re-derived. Not-found
+// results are remembered too; other errors (a cancelled query) are not.
+//
+// The cache doesn't notice writes. Call Invalidate after importing shows,
+// merging songs, or editing aliases.
+type CachingResolver struct {
+	Inner *DataSourceResolver
+
+	mu       sync.Mutex
+	index    map[string][]int // normalized name -> song IDs in id order; nil until loaded
+	aliases  map[string][]int // lowercased alias -> its song's variant IDs
+	songs    map[string]cachedSong
+	variants map[string]cachedIDs
+}
+
+type cachedSong struct {
+	song *data.Song
+	err  error
+}
+
+type cachedIDs struct {
+	ids []int
+	err error
+}
+
+// NewCachingResolver returns a CachingResolver around inner.
+func NewCachingResolver(inner *DataSourceResolver) *CachingResolver {
+	return &CachingResolver{Inner: inner}
+}
+
+// Invalidate drops everything cached; the next lookup reloads song names.
+func (c *CachingResolver) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.index, c.aliases, c.songs, c.variants = nil, nil, nil, nil
+}
+
+// Resolve returns the wrapped resolver's answer for name, asking it only the
+// first time.
+func (c *CachingResolver) Resolve(ctx context.Context, name string) (int, error) {
+	song, err := c.Lookup(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+	return song.ID, nil
+}
+
+// Lookup returns the wrapped resolver's song for name, asking it only the
+// first time. Implements SongLookup.
+func (c *CachingResolver) Lookup(ctx context.Context, name string) (*data.Song, error) {
+	c.mu.Lock()
+	r, ok := c.songs[name]
+	c.mu.Unlock()
+	if ok {
+		return r.song, r.err
+	}
+	song, err := c.Inner.Lookup(ctx, name)
+	if cacheable(err) {
+		c.mu.Lock()
+		if c.songs == nil {
+			c.songs = make(map[string]cachedSong)
+		}
+		c.songs[name] = cachedSong{song, err}
+		c.mu.Unlock()
+	}
+	return song, err
+}
+
+// ResolveVariants answers from the in-memory name index when name's
+// normalized form is there, and otherwise from the wrapped resolver (once).
+func (c *CachingResolver) ResolveVariants(ctx context.Context, name string) ([]int, error) {
+	c.mu.Lock()
+	r, ok := c.variants[name]
+	c.mu.Unlock()
+	if ok {
+		return r.ids, r.err
+	}
+	if !IsPattern(name) && !c.Inner.Strict {
+		if err := c.load(ctx); err != nil {
+			return nil, err
+		}
+		c.mu.Lock()
+		ids := c.index[data.NormalizeSongName(name)]
+		if len(ids) == 0 {
+			ids = c.aliases[aliasKey(name)]
+		}
+		c.mu.Unlock()
+		if len(ids) > 0 {
+			return append([]int(nil), ids...), nil
+		}
+	}
+	ids, err := c.Inner.ResolveVariants(ctx, name)
+	if cacheable(err) {
+		c.mu.Lock()
+		if c.variants == nil {
+			c.variants = make(map[string]cachedIDs)
+		}
+		c.variants[name] = cachedIDs{ids, err}
+		c.mu.Unlock()
+	}
+	return ids, err
+}
+
+// load builds the name index if it isn't built yet.
+func (c *CachingResolver) load(ctx context.Context) error {
+	c.mu.Lock()
+	loaded := c.index != nil
+	c.mu.Unlock()
+	if loaded {
+		return nil
+	}
+	rs, err := c.Inner.DataSource.ExecuteQuery(ctx, "SELECT id, name FROM songs ORDER BY id")
+	if err != nil {
+		return err
+	}
+	index := make(map[string][]int, len(rs.Rows))
+	for _, row := range rs.Rows {
+		key := data.NormalizeSongName(row.Text(1))
+		if key != "" {
+			index[key] = append(index[key], row.Int(0))
+		}
+	}
+	// An alias stands for every spelling of its song, as ResolveVariants'
+	// GetSong fallback would find them. Older databases have no aliases.
+	aliases := make(map[string][]int)
+	if as, ok := c.Inner.DataSource.(data.AliasSource); !ok || as.HasAliases() {
+		rs, err = c.Inner.DataSource.ExecuteQuery(ctx, "SELECT a.alias, a.song_id, s.name FROM song_aliases a JOIN songs s ON s.id = a.song_id")
+		if err != nil {
+			return err
+		}
+		for _, row := range rs.Rows {
+			ids := index[data.NormalizeSongName(row.Text(2))]
+			if len(ids) == 0 {
+				ids = []int{row.Int(1)}
+			}
+			aliases[aliasKey(row.Text(0))] = ids
+		}
+	}
+	c.mu.Lock()
+	c.index, c.aliases = index, aliases
+	c.mu.Unlock()
+	return nil
+}
+
+// aliasKey is the key aliases are indexed under: GetSong matches them in NFC,
+// ignoring case.
+func aliasKey(name string) string {
+	return strings.ToLower(data.ComposeTitle(name))
+}
+
+// ResolveFuzzy is the wrapped resolver's; it only runs for "did you mean?".
+func (c *CachingResolver) ResolveFuzzy(ctx context.Context, name string) ([]SongMatch, error) {
+	return c.Inner.ResolveFuzzy(ctx, name)
+}
+
+// Suggest is the wrapped resolver's; it only runs for "did you mean?".
+func (c *CachingResolver) Suggest(ctx context.Context, name string) []string {
+	return c.Inner.Suggest(ctx, name)
+}
+
+// cacheable reports whether a lookup's outcome holds until the data changes:
+// success or song-not-found, not a database or context error.
+func cacheable(err error) bool {
+	var nf *ErrSongNotFound
+	return err == nil || errors.As(err, &nf)
+}
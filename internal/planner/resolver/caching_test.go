@@ -0,0 +1,110 @@
+package resolver
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/gdql/gdql/internal/data"
+	"github.com/gdql/gdql/internal/data/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachingResolver(t *testing.T) {
+	var queries, lookups int
+	songs := []data.Row{{int64(1), "Scarlet Begonias"}, {int64(2), "Fire on the Mountain"}, {int64(3), "St. Stephen"}, {int64(4), "Saint Stephen"}}
+	aliases := []data.Row{{"Scarlet Begonias-", int64(1), "Scarlet Begonias"}, {"Stephen", int64(3), "St. Stephen"}}
+	ds := &mock.DataSource{
+		ExecuteQueryFunc: func(ctx context.Context, sql string, args ...interface{}) (*data.ResultSet, error) {
+			if strings.Contains(sql, "song_aliases") {
+				return &data.ResultSet{Columns: []string{"alias", "song_id", "name"}, Rows: aliases}, nil
+			}
+			queries++
+			return &data.ResultSet{Columns: []string{"id", "name"}, Rows: songs}, nil
+		},
+		GetSongFunc: func(ctx context.Context, name string) (*data.Song, error) {
+			lookups++
+			if name == "Scarlet" {
+				return &data.Song{ID: 1, Name: "Scarlet Begonias"}, nil
+			}
+			return nil, nil
+		},
+		GetSongVariantIDsFunc: func(ctx context.Context, name string) ([]int, error) {
+			lookups++
+			if name == "Scarlet Begonias" {
+				return []int{1}, nil
+			}
+			return nil, nil
+		},
+	}
+	c := NewCachingResolver(NewDataSourceResolver(ds))
+	ctx := context.Background()
+
+	// Variants come from the name index, loaded once, with the same
+	// normalization as GetSongVariantIDs.
+	for range 3 {
+		ids, err := c.ResolveVariants(ctx, "saint stephen")
+		require.NoError(t, err)
+		require.Equal(t, []int{3, 4}, ids)
+	}
+	require.Equal(t, 1, queries)
+	require.Zero(t, lookups)
+
+	// So do aliases, standing for every spelling of their song.
+	ids, err := c.ResolveVariants(ctx, "scarlet begonias-")
+	require.NoError(t, err)
+	require.Equal(t, []int{1}, ids)
+	ids, err = c.ResolveVariants(ctx, "Stephen")
+	require.NoError(t, err)
+	require.Equal(t, []int{3, 4}, ids)
+	require.Equal(t, 1, queries)
+	require.Zero(t, lookups)
+
+	// Names the index can't place go to the data source once.
+	for range 3 {
+		ids, err := c.ResolveVariants(ctx, "Scarlet")
+		require.NoError(t, err)
+		require.Equal(t, []int{1}, ids)
+		id, err := c.Resolve(ctx, "Scarlet")
+		require.NoError(t, err)
+		require.Equal(t, 1, id)
+	}
+	require.Equal(t, 4, lookups, "three for the first ResolveVariants, one for the first Resolve")
+
+	// Not found is remembered too.
+	for range 2 {
+		_, err := c.Resolve(ctx, "Nonexistent")
+		var nf *ErrSongNotFound
+		require.ErrorAs(t, err, &nf)
+	}
+	require.Equal(t, 5, lookups)
+
+	// After Invalidate everything is looked up afresh.
+	songs = append(songs, data.Row{int64(5), "Dark Star"})
+	c.Invalidate()
+	ids, err = c.ResolveVariants(ctx, "Dark Star")
+	require.NoError(t, err)
+	require.Equal(t, []int{5}, ids)
+	require.Equal(t, 2, queries)
+	_, err = c.Resolve(ctx, "Scarlet")
+	require.NoError(t, err)
+	require.Equal(t, 6, lookups)
+}
+
+// noAliases is a data source whose database predates song_aliases.
+type noAliases struct{ *mock.DataSource }
+
+func (noAliases) HasAliases() bool { return false }
+
+func TestCachingResolver_WithoutAliasTable(t *testing.T) {
+	ds := noAliases{&mock.DataSource{
+		ExecuteQueryFunc: func(ctx context.Context, sql string, args ...interface{}) (*data.ResultSet, error) {
+			require.NotContains(t, sql, "song_aliases", "the table isn't there to query")
+			return &data.ResultSet{Columns: []string{"id", "name"}, Rows: []data.Row{{int64(1), "Scarlet Begonias"}}}, nil
+		},
+	}}
+	c := NewCachingResolver(NewDataSourceResolver(ds))
+	ids, err := c.ResolveVariants(context.Background(), "Scarlet Begonias")
+	require.NoError(t, err)
+	require.Equal(t, []int{1}, ids)
+}
@@ -2,6 +2,7 @@ package resolver
 
 import (
 	"context"
+	"strings"
 
 	"github.com/gdql/gdql/internal/data"
 )
@@ -9,6 +10,10 @@ import (
 // DataSourceResolver resolves song names via a DataSource (GetSong).
 type DataSourceResolver struct {
 	DataSource data.DataSource
+	// Strict accepts only exact (case-insensitive) names and aliases, skipping
+	// the trimming and punctuation heuristics, for callers that would rather
+	// get "song not found" than a near miss.
+	Strict bool
 }
 
 // NewDataSourceResolver returns a SongResolver that uses the given DataSource.
@@ -16,22 +21,48 @@ func NewDataSourceResolver(ds data.DataSource) *DataSourceResolver {
 	return &DataSourceResolver{DataSource: ds}
 }
 
-// Resolve returns the song ID for name via DataSource.GetSong.
+// Resolve returns the song ID for name via DataSource.GetSong. Song patterns
+// stand for several songs, so they never resolve to one.
 func (r *DataSourceResolver) Resolve(ctx context.Context, name string) (int, error) {
-	song, err := r.DataSource.GetSong(ctx, name)
+	song, err := r.Lookup(ctx, name)
 	if err != nil {
 		return 0, err
 	}
+	return song.ID, nil
+}
+
+// Lookup returns the song Resolve would pick for name, with MatchedBy set.
+// Implements SongLookup.
+func (r *DataSourceResolver) Lookup(ctx context.Context, name string) (*data.Song, error) {
+	if IsPattern(name) {
+		return nil, &ErrSongNotFound{Name: name}
+	}
+	song, err := r.getSong(ctx, name)
+	if err != nil {
+		return nil, err
+	}
 	if song == nil {
-		return 0, &ErrSongNotFound{Name: name}
+		return nil, &ErrSongNotFound{Name: name}
 	}
-	return song.ID, nil
+	return song, nil
 }
 
 // ResolveVariants returns ALL song IDs whose normalized name matches.
 // Used for set-membership tests (PLAYED, NOT PLAYED) so duplicates count as one song.
 // Falls back to GetSong (which supports fuzzy/prefix matching) if no exact variants found.
+// A song pattern ("Scarlet%", "~Jam") resolves to every song it matches.
 func (r *DataSourceResolver) ResolveVariants(ctx context.Context, name string) ([]int, error) {
+	if IsPattern(name) {
+		return r.ResolvePattern(ctx, name)
+	}
+	if r.Strict {
+		// Normalized variants are a heuristic too; strict means the one song.
+		id, err := r.Resolve(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		return []int{id}, nil
+	}
 	ids, err := r.DataSource.GetSongVariantIDs(ctx, name)
 	if err != nil {
 		return nil, err
@@ -57,6 +88,44 @@ func (r *DataSourceResolver) ResolveVariants(ctx context.Context, name string) (
 	return ids, nil
 }
 
+// ResolvePattern returns the IDs of all songs matching pattern (see IsPattern),
+// found with SearchSongs. It returns ErrSongNotFound when nothing matches.
+func (r *DataSourceResolver) ResolvePattern(ctx context.Context, pattern string) ([]int, error) {
+	songs, err := r.DataSource.SearchSongs(ctx, patternSearch(pattern))
+	if err != nil {
+		return nil, err
+	}
+	// SearchSongs already did the fuzzy match, on short names too.
+	fuzzy := strings.HasPrefix(pattern, "~")
+	var ids []int
+	for _, s := range songs {
+		if fuzzy || matchPattern(pattern, s.Name) {
+			ids = append(ids, s.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, &ErrSongNotFound{Name: pattern}
+	}
+	return ids, nil
+}
+
+// getSong looks name up with GetSong, or in strict mode with GetSongStrict.
+// Data sources without GetSongStrict fall back to GetSong, keeping only a
+// result whose name matches exactly.
+func (r *DataSourceResolver) getSong(ctx context.Context, name string) (*data.Song, error) {
+	if !r.Strict {
+		return r.DataSource.GetSong(ctx, name)
+	}
+	if ss, ok := r.DataSource.(data.StrictSongSource); ok {
+		return ss.GetSongStrict(ctx, name)
+	}
+	song, err := r.DataSource.GetSong(ctx, name)
+	if err != nil || song == nil || !strings.EqualFold(song.Name, name) {
+		return nil, err
+	}
+	return song, nil
+}
+
 // ResolveFuzzy uses SearchSongs and returns matches with scores.
 func (r *DataSourceResolver) ResolveFuzzy(ctx context.Context, name string) ([]SongMatch, error) {
 	songs, err := r.DataSource.SearchSongs(ctx, name)
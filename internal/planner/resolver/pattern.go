@@ -0,0 +1,58 @@
+package resolver
+
+import "strings"
+
+// Song patterns stand for every song they match instead of one resolved song:
+//
+//	"Scarlet%"   LIKE-style: % matches any run of characters, case-insensitively
+//	"%Jam"       e.g. "Dark Star Jam", "Mind Left Body Jam"
+//	"~Space"     fuzzy: name or short name contains the rest
+//
+// They are accepted wherever a condition takes a set of songs (PLAYED, NOT
+// PLAYED, > "Song"); conditions about one song reject them as not found.
+
+// IsPattern reports whether name is a song pattern rather than a song name.
+func IsPattern(name string) bool {
+	return strings.HasPrefix(name, "~") || strings.Contains(name, "%")
+}
+
+// patternSearch is the text to pass to SearchSongs for pattern: the part
+// after ~, or the longest run between %s (results are then filtered with
+// matchPattern).
+func patternSearch(pattern string) string {
+	if rest, ok := strings.CutPrefix(pattern, "~"); ok {
+		return strings.TrimSpace(rest)
+	}
+	longest := ""
+	for _, part := range strings.Split(pattern, "%") {
+		if len(part) > len(longest) {
+			longest = part
+		}
+	}
+	return longest
+}
+
+// matchPattern reports whether name matches pattern, ignoring case.
+func matchPattern(pattern, name string) bool {
+	if strings.HasPrefix(pattern, "~") {
+		return strings.Contains(strings.ToLower(name), strings.ToLower(patternSearch(pattern)))
+	}
+	parts := strings.Split(strings.ToLower(pattern), "%")
+	s := strings.ToLower(name)
+	if len(parts) == 1 {
+		return s == parts[0]
+	}
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+	last := len(parts) - 1
+	for _, part := range parts[1:last] {
+		i := strings.Index(s, part)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(part):]
+	}
+	return strings.HasSuffix(s, parts[last])
+}
@@ -2,7 +2,10 @@ package resolver
 
 import (
 	"context"
+	"sort"
 	"strings"
+
+	"github.com/gdql/gdql/internal/data"
 )
 
 // SongResolver resolves song names to canonical IDs.
@@ -13,6 +16,13 @@ type SongResolver interface {
 	Suggest(ctx context.Context, name string) []string
 }
 
+// SongLookup is implemented by resolvers that can return the song a name
+// resolves to, not just its ID, with Song.MatchedBy saying which GetSong step
+// found it. The planner uses it to report how names resolved.
+type SongLookup interface {
+	Lookup(ctx context.Context, name string) (*data.Song, error)
+}
+
 // SongMatch is a fuzzy match result.
 type SongMatch struct {
 	ID    int
@@ -49,8 +59,22 @@ func (s *StaticResolver) Resolve(ctx context.Context, name string) (int, error)
 	return 0, &ErrSongNotFound{Name: name}
 }
 
-// ResolveVariants returns just the resolved ID (StaticResolver has no duplicates).
+// ResolveVariants returns just the resolved ID (StaticResolver has no duplicates),
+// or for a song pattern every matching ID.
 func (s *StaticResolver) ResolveVariants(ctx context.Context, name string) ([]int, error) {
+	if IsPattern(name) {
+		var ids []int
+		for n, id := range s.ByName {
+			if matchPattern(name, n) {
+				ids = append(ids, id)
+			}
+		}
+		if len(ids) == 0 {
+			return nil, &ErrSongNotFound{Name: name}
+		}
+		sort.Ints(ids)
+		return ids, nil
+	}
 	id, err := s.Resolve(ctx, name)
 	if err != nil {
 		return nil, err
@@ -33,6 +33,43 @@ func TestStaticResolver_Resolve_NotFound(t *testing.T) {
 	require.Equal(t, "Unknown Song", nf.Name)
 }
 
+func TestMatchPattern(t *testing.T) {
+	cases := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"Scarlet%", "Scarlet Begonias", true},
+		{"scarlet%", "Scarlet Begonias", true},
+		{"Scarlet%", "The Scarlet Jam", false},
+		{"%Jam", "Dark Star Jam", true},
+		{"%Jam", "Jam > Space", false},
+		{"%on the%", "Fire on the Mountain", true},
+		{"S%n%", "Samson and Delilah", true},
+		{"S%n", "Samson and Delilah", false},
+		{"%", "Anything", true},
+		{"~space", "Space", true},
+		{"~space", "Spanish Jam", false},
+	}
+	for _, tc := range cases {
+		require.Equal(t, tc.want, matchPattern(tc.pattern, tc.name), "%q ~ %q", tc.pattern, tc.name)
+	}
+	require.True(t, IsPattern("Scarlet%"))
+	require.True(t, IsPattern("~Jam"))
+	require.False(t, IsPattern("Jam ~ Space"))
+}
+
+func TestStaticResolver_ResolveVariants_Pattern(t *testing.T) {
+	r := NewStaticResolver(map[string]int{"Scarlet Begonias": 1, "Dark Star Jam": 2, "Mind Left Body Jam": 3})
+	ids, err := r.ResolveVariants(context.Background(), "%Jam")
+	require.NoError(t, err)
+	require.Equal(t, []int{2, 3}, ids)
+
+	_, err = r.ResolveVariants(context.Background(), "%Space%")
+	require.Error(t, err)
+	_, err = r.Resolve(context.Background(), "Scarlet%")
+	require.Error(t, err, "a pattern never resolves to a single song")
+}
+
 func TestStaticResolver_Suggest(t *testing.T) {
 	r := NewStaticResolver(map[string]int{
 		"Scarlet Begonias": 1,
@@ -0,0 +1,38 @@
+package sqlgen
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gdql/gdql/internal/ir"
+)
+
+func BenchmarkGenerate(b *testing.B) {
+	lim := 10
+	queries := []*ir.QueryIR{
+		{
+			Type:       ir.QueryTypeShows,
+			DateRange:  &ir.ResolvedDateRange{Start: time.Date(1977, 1, 1, 0, 0, 0, 0, time.UTC), End: time.Date(1980, 12, 31, 0, 0, 0, 0, time.UTC)},
+			SegueChain: &ir.SegueChainIR{SongIDs: []int{1, 2}, Operators: []ir.SegueOp{ir.SegueOpSegue}},
+			Limit:      &lim,
+		},
+		{
+			Type: ir.QueryTypeShows,
+			Conditions: []ir.ConditionIR{
+				&ir.PlayedConditionIR{SongIDs: []int{6}},
+				&ir.PlayedConditionIR{SongIDs: []int{7}},
+				&ir.CompleteConditionIR{},
+			},
+			ConditionOps: []ir.LogicOp{ir.OpOr, ir.OpAnd},
+		},
+		{Type: ir.QueryTypeSongs, OrderBy: &ir.OrderByIR{Field: "TIMES_PLAYED", Desc: true}},
+	}
+	g := New()
+	for b.Loop() {
+		for _, q := range queries {
+			if _, err := g.Generate(q); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
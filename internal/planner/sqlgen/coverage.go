@@ -0,0 +1,78 @@
+package sqlgen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gdql/gdql/internal/ir"
+)
+
+// ShowsPerYear is the approximate number of Grateful Dead shows played each
+// year, from the published show lists. COVERAGE compares an import against it;
+// the figures are rough (early years especially), so a year a few shows over
+// or under is not a problem in the data.
+var ShowsPerYear = map[int]int{
+	1965: 26, 1966: 110, 1967: 120, 1968: 116, 1969: 146,
+	1970: 141, 1971: 81, 1972: 86, 1973: 72, 1974: 40,
+	1975: 4, 1976: 41, 1977: 60, 1978: 81, 1979: 75,
+	1980: 87, 1981: 86, 1982: 61, 1983: 67, 1984: 64,
+	1985: 71, 1986: 46, 1987: 86, 1988: 80, 1989: 73,
+	1990: 74, 1991: 77, 1992: 55, 1993: 81, 1994: 84,
+	1995: 47,
+}
+
+// genCoverage counts shows per year and joins the counts to ShowsPerYear,
+// passed in as a VALUES table. Every reference year the range touches gets a
+// row, whole years at a time, even when the database has no shows for it.
+func (g *generator) genCoverage(q *ir.QueryIR) (*SQLQuery, error) {
+	var years []int
+	for y := range ShowsPerYear {
+		if q.DateRange == nil || (y >= q.DateRange.Start.Year() && y <= q.DateRange.End.Year()) {
+			years = append(years, y)
+		}
+	}
+	if len(years) == 0 {
+		return &SQLQuery{SQL: "SELECT 0 AS year, 0 AS have, 0 AS expected, 0.0 AS percent WHERE 0"}, nil
+	}
+	sort.Ints(years)
+	rows := make([]string, len(years))
+	args := make([]interface{}, 0, 2*len(years)+2)
+	for i, y := range years {
+		rows[i] = "(?, ?)"
+		args = append(args, y, ShowsPerYear[y])
+	}
+	args = append(args, fmt.Sprintf("%d-01-01", years[0]), fmt.Sprintf("%d-12-31", years[len(years)-1]))
+	sql := "WITH expected(year, shows) AS (VALUES " + strings.Join(rows, ", ") + ")," +
+		" have AS (SELECT CAST(strftime('%Y', date) AS INTEGER) AS year, count(*) AS shows FROM shows WHERE date >= ? AND date <= ? GROUP BY year)" +
+		" SELECT e.year, coalesce(h.shows, 0) AS have, e.shows AS expected, round(100.0 * coalesce(h.shows, 0) / e.shows, 1) AS percent" +
+		" FROM expected e LEFT JOIN have h ON h.year = e.year ORDER BY e.year"
+	return &SQLQuery{SQL: sql, Args: args}, nil
+}
+
+// genGaps lists the runs of days with no show in a span, one row per run
+// (start, end, days). The span is the date range, or with a tour the first to
+// last show of the tour inside the range. Days come from a recursive CTE; a
+// run is the missing days whose date minus their rank is the same.
+func (g *generator) genGaps(q *ir.QueryIR) (*SQLQuery, error) {
+	var bounds string
+	var args []interface{}
+	if q.TourName != "" {
+		bounds = "SELECT min(date), max(date) FROM shows WHERE tour LIKE ? ESCAPE '\\'"
+		args = append(args, "%"+escapeLike(q.TourName)+"%")
+		if q.DateRange != nil {
+			bounds += " AND date >= ? AND date <= ?"
+			args = append(args, formatDate(q.DateRange.Start), formatDate(q.DateRange.End))
+		}
+	} else if q.DateRange != nil {
+		bounds = "SELECT ?, ?"
+		args = append(args, formatDate(q.DateRange.Start), formatDate(q.DateRange.End))
+	} else {
+		return nil, fmt.Errorf("GAPS needs a tour or a date range")
+	}
+	sql := "WITH RECURSIVE bounds(lo, hi) AS (" + bounds + ")," +
+		" days(d) AS (SELECT lo FROM bounds WHERE lo IS NOT NULL UNION ALL SELECT date(d, '+1 day') FROM days, bounds WHERE d < hi)," +
+		" missing AS (SELECT d, julianday(d) - row_number() OVER (ORDER BY d) AS run FROM days WHERE NOT EXISTS (SELECT 1 FROM shows s WHERE s.date = days.d))" +
+		" SELECT min(d) AS start, max(d) AS end, count(*) AS days FROM missing GROUP BY run ORDER BY start"
+	return &SQLQuery{SQL: sql, Args: args}, nil
+}
@@ -2,9 +2,11 @@ package sqlgen
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/gdql/gdql/internal/data"
 	"github.com/gdql/gdql/internal/ir"
 )
 
@@ -12,6 +14,55 @@ import (
 type SQLQuery struct {
 	SQL  string
 	Args []interface{}
+	// SongMatches is the plan's record of how song names resolved; Compile
+	// fills it in for explain output.
+	SongMatches []ir.SongMatch
+}
+
+// Inline returns the statement with each ? replaced by its argument written
+// as an SQL literal, so it can be pasted into the sqlite3 shell as is. A ?
+// inside a quoted string in the SQL itself is left alone.
+func (q *SQLQuery) Inline() string {
+	var b strings.Builder
+	args := q.Args
+	var quote byte
+	for i := 0; i < len(q.SQL); i++ {
+		c := q.SQL[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '?' && len(args) > 0:
+			b.WriteString(sqlLiteral(args[0]))
+			args = args[1:]
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// sqlLiteral writes v the way SQLite would read it back: numbers bare,
+// strings single-quoted with quotes doubled, nil as NULL.
+func sqlLiteral(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(v)
+	case string:
+		return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	default:
+		return "'" + strings.ReplaceAll(fmt.Sprint(v), "'", "''") + "'"
+	}
 }
 
 // SQLGenerator generates SQL from IR.
@@ -42,6 +93,18 @@ func (g *generator) Generate(q *ir.QueryIR) (*SQLQuery, error) {
 		return g.genFirstLast(q)
 	case ir.QueryTypeRandomShow:
 		return g.genRandomShow(q)
+	case ir.QueryTypeVenues, ir.QueryTypeVenueStats:
+		return g.genVenues(q)
+	case ir.QueryTypeRuns:
+		return g.genRuns(q)
+	case ir.QueryTypeCompare:
+		return g.genCompare(q)
+	case ir.QueryTypeCoverage:
+		return g.genCoverage(q)
+	case ir.QueryTypeGaps:
+		return g.genGaps(q)
+	case ir.QueryTypePerformanceYears:
+		return g.genPerformanceYears(q)
 	default:
 		return nil, fmt.Errorf("unknown query type: %d", q.Type)
 	}
@@ -53,7 +116,7 @@ func (g *generator) genShows(q *ir.QueryIR) (*SQLQuery, error) {
 	}
 	var b strings.Builder
 	var args []interface{}
-	b.WriteString("SELECT s.id, s.date, s.venue_id, v.name AS venue, v.city, v.state, s.tour FROM shows s LEFT JOIN venues v ON s.venue_id = v.id")
+	b.WriteString("SELECT " + showColumns(q) + " FROM shows s LEFT JOIN venues v ON s.venue_id = v.id")
 	where, wa := g.whereShows(q)
 	if where != "" {
 		b.WriteString(" WHERE ")
@@ -74,6 +137,50 @@ func (g *generator) genShows(q *ir.QueryIR) (*SQLQuery, error) {
 	return &SQLQuery{SQL: b.String(), Args: args}, nil
 }
 
+// showColumns is the select list of every shows query, in the order the
+// executor's mapRowsToShows reads it. Segue queries append their match
+// columns after it; anything else that returns shows must start with it too.
+// total_length costs a subquery per show, so it is NULL unless q shows or
+// sorts on it (see showNeedsLength).
+func showColumns(q *ir.QueryIR) string {
+	if showNeedsLength(q) {
+		return showBaseColumns + showTotalLength
+	}
+	return showBaseColumns + "NULL AS total_length"
+}
+
+const showBaseColumns = "s.id, s.date, s.venue_id, v.name AS venue, v.city, v.state, s.tour, "
+
+// showNeedsLength reports whether shows query q needs each show's total
+// length: it is ordered by LENGTH, or its output is a table or JSON, the
+// formats that print length. A --format override of the AS clause isn't
+// known here, so e.g. AS CSV under --format json shows no lengths.
+func showNeedsLength(q *ir.QueryIR) bool {
+	if q.OrderBy != nil && strings.EqualFold(q.OrderBy.Field, "LENGTH") {
+		return true
+	}
+	switch q.OutputFmt {
+	case ir.OutputDefault, ir.OutputTable, ir.OutputJSON:
+		return true
+	}
+	return false
+}
+
+// showTotalLength is the select-list expression for a show's total duration in
+// seconds: the sum of its performance lengths, or NULL when any performance is
+// missing a length (so partial data never masquerades as a short show).
+const showTotalLength = "(SELECT CASE WHEN count(*) > 0 AND min(COALESCE(pt.length_seconds, 0)) > 0 THEN sum(pt.length_seconds) END FROM performances pt WHERE pt.show_id = s.id) AS total_length"
+
+// showLengthOrder sorts shows by total_length, with unknown lengths last in either direction.
+func showLengthOrder(dir string) string {
+	return "ORDER BY total_length IS NULL, total_length " + dir + ", s.date ASC, s.id ASC"
+}
+
+// showRatingOrder sorts shows by rating, with unrated shows last in either direction.
+func showRatingOrder(dir string) string {
+	return "ORDER BY s.rating IS NULL, s.rating " + dir + ", s.date ASC, s.id ASC"
+}
+
 func (g *generator) whereShows(q *ir.QueryIR) (clause string, args []interface{}) {
 	// Fixed parts (venue, tour, date) — always ANDed
 	var fixedParts []string
@@ -85,6 +192,9 @@ func (g *generator) whereShows(q *ir.QueryIR) (clause string, args []interface{}
 		fixedParts = append(fixedParts, "s.tour LIKE ? ESCAPE '\\'")
 		args = append(args, "%"+escapeLike(q.TourName)+"%")
 	}
+	if q.RatedOnly {
+		fixedParts = append(fixedParts, "s.rating IS NOT NULL")
+	}
 	if q.DateRange != nil {
 		fixedParts = append(fixedParts, "s.date >= ? AND s.date <= ?")
 		args = append(args, formatDate(q.DateRange.Start), formatDate(q.DateRange.End))
@@ -109,9 +219,39 @@ func (g *generator) whereShows(q *ir.QueryIR) (clause string, args []interface{}
 			} else {
 				condParts = append(condParts, "EXISTS (SELECT 1 FROM performances p WHERE p.show_id = s.id AND "+inClause+")")
 			}
+		case *ir.PlayedSetConditionIR:
+			part, a := playedSetCondition("p", x)
+			condParts = append(condParts, part)
+			args = append(args, a...)
 		case *ir.GuestConditionIR:
 			condParts = append(condParts, "EXISTS (SELECT 1 FROM performances p WHERE p.show_id = s.id AND p.guest IS NOT NULL AND p.guest != '' AND (p.guest = ? OR p.guest LIKE ? ESCAPE '\\'))")
 			args = append(args, x.Name, "%"+escapeLike(x.Name)+"%")
+		case *ir.NotesConditionIR:
+			condParts = append(condParts, "s.notes LIKE ? ESCAPE '\\'")
+			args = append(args, "%"+escapeLike(x.Text)+"%")
+		case *ir.TapeConditionIR:
+			condParts = append(condParts, "EXISTS (SELECT 1 FROM performances p WHERE p.show_id = s.id AND p.tape = 1)")
+		case *ir.SourceConditionIR:
+			condParts = append(condParts, "EXISTS (SELECT 1 FROM show_recordings r WHERE r.show_id = s.id AND lower(r.source) = lower(?))")
+			args = append(args, x.Source)
+		case *ir.WeekdayConditionIR:
+			condParts = append(condParts, "strftime('%w', s.date) = ?")
+			args = append(args, strconv.Itoa(x.Day))
+		case *ir.RatingConditionIR:
+			condParts = append(condParts, "s.rating "+compOpSQL(x.Operator)+" ?")
+			args = append(args, x.Value)
+		case *ir.SongCountConditionIR:
+			condParts = append(condParts, songCountCondition(x))
+			args = append(args, x.Count)
+		case *ir.SetNameConditionIR:
+			condParts = append(condParts, "EXISTS (SELECT 1 FROM performances p WHERE p.show_id = s.id AND p.set_name LIKE ? ESCAPE '\\')")
+			args = append(args, "%"+escapeLike(x.Name)+"%")
+		case *ir.CompleteConditionIR:
+			condParts = append(condParts, completeCondition(x))
+		case *ir.LengthConditionIR:
+			part, a := lengthCondition(x)
+			condParts = append(condParts, part)
+			args = append(args, a...)
 		case *ir.SegueIntoConditionIR:
 			part, a := segueIntoCondition(x)
 			condParts = append(condParts, part)
@@ -134,23 +274,42 @@ func (g *generator) whereShows(q *ir.QueryIR) (clause string, args []interface{}
 	var whereParts []string
 	whereParts = append(whereParts, fixedParts...)
 	if len(condParts) > 0 {
-		var condSQL strings.Builder
-		condSQL.WriteString(condParts[0])
-		for i := 1; i < len(condParts); i++ {
-			op := " AND "
-			if i-1 < len(q.ConditionOps) && q.ConditionOps[i-1] == ir.OpOr {
-				op = " OR "
-			}
-			condSQL.WriteString(op)
-			condSQL.WriteString(condParts[i])
+		whereParts = append(whereParts, joinConditions(condParts, q.ConditionOps, len(fixedParts) > 0))
+	}
+	return strings.Join(whereParts, " AND "), args
+}
+
+// joinConditions joins condition SQL with its AND/OR operators. AND binds
+// tighter than OR, so parts are grouped into AND-groups joined by OR, and each
+// multi-part group is parenthesized: `a OR b AND c` becomes `a OR (b AND c)`.
+// When nested is true and there is an OR, the whole expression is wrapped so
+// it can be ANDed with the query's fixed filters.
+func joinConditions(parts []string, ops []ir.LogicOp, nested bool) string {
+	var groups [][]string
+	var cur []string
+	for i, part := range parts {
+		if i > 0 && i-1 < len(ops) && ops[i-1] == ir.OpOr {
+			groups = append(groups, cur)
+			cur = nil
 		}
-		condStr := condSQL.String()
-		if len(fixedParts) > 0 && strings.Contains(condStr, " OR ") {
-			condStr = "(" + condStr + ")"
+		cur = append(cur, part)
+	}
+	groups = append(groups, cur)
+	if len(groups) == 1 {
+		return strings.Join(groups[0], " AND ")
+	}
+	ors := make([]string, len(groups))
+	for i, g := range groups {
+		ors[i] = strings.Join(g, " AND ")
+		if len(g) > 1 {
+			ors[i] = "(" + ors[i] + ")"
 		}
-		whereParts = append(whereParts, condStr)
 	}
-	return strings.Join(whereParts, " AND "), args
+	s := strings.Join(ors, " OR ")
+	if nested {
+		s = "(" + s + ")"
+	}
+	return s
 }
 
 // segueIntoCondition generates SQL for standalone segue-into conditions: >"Song", >>"Song", ~>"Song".
@@ -178,6 +337,62 @@ func segueIntoCondition(c *ir.SegueIntoConditionIR) (string, []interface{}) {
 	return sql, args
 }
 
+// songCountCondition generates SONGS <op> N for shows: the show is among
+// those whose performance count passes, so it composes with other conditions
+// like any predicate on s. Takes one arg, the count.
+func songCountCondition(c *ir.SongCountConditionIR) string {
+	return "s.id IN (SELECT show_id FROM performances GROUP BY show_id HAVING COUNT(*) " + compOpSQL(c.Operator) + " ?)"
+}
+
+// playedSetCondition generates PLAYED ALL/ANY (...) with performance alias p:
+// one EXISTS per song joined by AND for ALL, and a single EXISTS over every
+// song's IDs for ANY.
+func playedSetCondition(p string, c *ir.PlayedSetConditionIR) (string, []interface{}) {
+	exists := func(ids []int) string {
+		return "EXISTS (SELECT 1 FROM performances " + p + " WHERE " + p + ".show_id = s.id AND " + p + ".song_id IN (" +
+			strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",") + "))"
+	}
+	var args []interface{}
+	var sql string
+	if c.All {
+		parts := make([]string, len(c.SongIDs))
+		for i, ids := range c.SongIDs {
+			parts[i] = exists(ids)
+			for _, id := range ids {
+				args = append(args, id)
+			}
+		}
+		sql = "(" + strings.Join(parts, " AND ") + ")"
+	} else {
+		var all []int
+		for _, ids := range c.SongIDs {
+			all = append(all, ids...)
+		}
+		for _, id := range all {
+			args = append(args, id)
+		}
+		sql = exists(all)
+	}
+	if c.Negated {
+		sql = "NOT " + sql
+	}
+	return sql, args
+}
+
+// lengthCondition generates SQL for LENGTH("Song") > 20min in a shows WHERE.
+// With no song, any performance in the show may satisfy the comparison.
+func lengthCondition(c *ir.LengthConditionIR) (string, []interface{}) {
+	var args []interface{}
+	sql := "EXISTS (SELECT 1 FROM performances pl WHERE pl.show_id = s.id"
+	if c.SongID != nil {
+		sql += " AND pl.song_id = ?"
+		args = append(args, *c.SongID)
+	}
+	sql += " AND pl.length_seconds " + compOpSQL(c.Operator) + " ?)"
+	args = append(args, c.Seconds)
+	return sql, args
+}
+
 func (g *generator) positionCondition(c *ir.PositionConditionIR) (string, []interface{}) {
 	if c.SegueChain != nil {
 		return positionConditionWithSegue(c)
@@ -186,17 +401,10 @@ func (g *generator) positionCondition(c *ir.PositionConditionIR) (string, []inte
 }
 
 func buildPositionCondition(c *ir.PositionConditionIR) (string, []interface{}) {
-	// Build set filter: Encore matches set >= 3 (covers both set 3 and set 4 encores)
 	var setFilter string
-	var setArgs []interface{}
-	isEncore := c.Set == ir.Encore
-	setNum := setPositionToNumber(c.Set)
-	if isEncore {
-		// Match songs in the last set of the show (not just set >= 3)
-		setFilter = " AND p.set_number = (SELECT MAX(p2.set_number) FROM performances p2 WHERE p2.show_id = p.show_id)"
-	} else if setNum > 0 {
-		setFilter = " AND p.set_number = ?"
-		setArgs = append(setArgs, setNum)
+	setCond, setArgs := setCondition("p", c.Set)
+	if setCond != "" {
+		setFilter = " AND " + setCond
 	}
 
 	exists := "EXISTS"
@@ -255,6 +463,17 @@ func positionConditionWithSegue(c *ir.PositionConditionIR) (string, []interface{
 	}
 	b.WriteString(" WHERE pc1.show_id = s.id")
 
+	// The set applies where the chain is anchored: its last song for CLOSED,
+	// its first otherwise.
+	anchor := "pc1"
+	if c.Operator == ir.PosClosed {
+		anchor = fmt.Sprintf("pc%d", n)
+	}
+	if cond, condArgs := setCondition(anchor, c.Set); cond != "" {
+		b.WriteString(" AND " + cond)
+		args = append(args, condArgs...)
+	}
+
 	// First song must be opener or closer
 	if c.Operator == ir.PosOpened {
 		b.WriteString(" AND pc1.is_opener = 1")
@@ -272,8 +491,21 @@ func positionConditionWithSegue(c *ir.PositionConditionIR) (string, []interface{
 	return b.String(), args
 }
 
-// setPositionToNumber maps set position to set_number.
-// Returns 0 for Encore — callers handle encore specially with >= 3.
+// setCondition restricts performance alias p to a set. The encore is the
+// last set of the show, whatever its number, or any set past the third:
+// setlist.fm imports number a double encore 4 and 5. SetAny returns "".
+func setCondition(p string, set ir.SetPosition) (string, []interface{}) {
+	if set == ir.Encore {
+		return "(" + p + ".set_number > 3 OR " + p + ".set_number = (SELECT MAX(e.set_number) FROM performances e WHERE e.show_id = " + p + ".show_id))", nil
+	}
+	if n := setPositionToNumber(set); n > 0 {
+		return p + ".set_number = ?", []interface{}{n}
+	}
+	return "", nil
+}
+
+// setPositionToNumber maps set position to set_number. Encore has no fixed
+// number and returns 0; setCondition matches it by position in the show.
 func setPositionToNumber(s ir.SetPosition) int {
 	switch s {
 	case ir.Set1:
@@ -283,7 +515,7 @@ func setPositionToNumber(s ir.SetPosition) int {
 	case ir.Set3:
 		return 3
 	case ir.Encore:
-		return 0 // handled specially
+		return 0
 	}
 	return 0
 }
@@ -293,51 +525,73 @@ func (g *generator) genShowsWithSegue(q *ir.QueryIR) (*SQLQuery, error) {
 }
 
 func (g *generator) genSongs(q *ir.QueryIR) (*SQLQuery, error) {
-	// SONGS FROM/PLAYED IN: count performances per song within a date range
-	if q.PlayedRange != nil {
+	// SONGS FROM/PLAYED IN/IN SET2: count performances per song
+	if q.PlayedRange != nil || q.InSet != ir.SetAny {
 		return g.genSongsPlayedIn(q)
 	}
 
 	var b strings.Builder
 	var args []interface{}
 	isCount := q.OutputFmt == ir.OutputCount
-	if isCount {
+	avgLength := !isCount && orderByAvgLength(q)
+	switch {
+	case isCount:
 		b.WriteString("SELECT count(*) AS count, 'songs' AS name FROM songs")
-	} else {
+	case avgLength:
+		// Songs with no timed performance drop out of the join.
+		b.WriteString("SELECT songs.id, songs.name, songs.short_name, songs.writers, songs.first_played, songs.last_played, songs.times_played, " + avgLengthColumn +
+			" FROM songs JOIN performances p ON p.song_id = songs.id AND p.length_seconds > 0")
+	default:
 		b.WriteString("SELECT id, name, short_name, writers, first_played, last_played, times_played FROM songs")
 	}
 	var parts []string
 	for _, c := range q.Conditions {
 		switch x := c.(type) {
+		case *ir.CoverConditionIR:
+			parts = append(parts, coverCondition(x))
+		case *ir.MissingFieldConditionIR:
+			parts = append(parts, missingFieldCondition(x))
+		case *ir.PlayedOnceConditionIR:
+			parts = append(parts, playedOnceCondition)
+		case *ir.TimesPlayedConditionIR:
+			parts = append(parts, "times_played "+compOpSQL(x.Operator)+" ?")
+			args = append(args, x.Count)
+		case *ir.LengthConditionIR:
+			parts = append(parts, "EXISTS (SELECT 1 FROM performances pl WHERE pl.song_id = songs.id AND "+songLengthCondition("pl", x)+")")
+			args = append(args, x.Seconds)
 		case *ir.LyricsConditionIR:
 			if len(x.Words) == 0 {
 				continue
 			}
-			likes := make([]string, len(x.Words))
-			for i, w := range x.Words {
-				// Whole-word match: normalize punctuation to spaces, then match with space boundaries
-				likes[i] = "(' ' || REPLACE(REPLACE(REPLACE(REPLACE(REPLACE(LOWER(l.lyrics), ',', ' '), '.', ' '), '!', ' '), '?', ' '), '''', ' ') || ' ') LIKE ?"
-				args = append(args, "% "+strings.ToLower(w)+" %")
-			}
 			op := " AND "
 			if x.Operator == ir.OpOr {
 				op = " OR "
 			}
-			parts = append(parts, "EXISTS (SELECT 1 FROM lyrics l WHERE l.song_id = songs.id AND ("+strings.Join(likes, op)+"))")
+			cond, condArgs := lyricsExists(x.Words, op)
+			parts = append(parts, cond)
+			args = append(args, condArgs...)
 		}
 	}
 	if q.DateRange != nil {
-		parts = append(parts, "first_played >= ? AND last_played <= ?")
+		// SONGS WRITTEN: there's no composition date, so the debut stands in for
+		// it. Only first_played is bounded — songs kept in rotation still match.
+		parts = append(parts, debutCondition)
 		args = append(args, formatDate(q.DateRange.Start), formatDate(q.DateRange.End))
 	}
+	if q.DebutRange != nil {
+		parts = append(parts, debutCondition)
+		args = append(args, formatDate(q.DebutRange.Start), formatDate(q.DebutRange.End))
+	}
 	if len(parts) > 0 {
 		b.WriteString(" WHERE ")
 		b.WriteString(strings.Join(parts, " AND "))
 	}
-	order := g.orderBy(q, "songs")
-	if order != "" {
+	if avgLength {
+		b.WriteString(" GROUP BY songs.id")
+	}
+	if !isCount {
 		b.WriteString(" ")
-		b.WriteString(order)
+		b.WriteString(g.orderBy(q, "songs"))
 	}
 	if q.Limit != nil {
 		b.WriteString(" LIMIT ?")
@@ -346,36 +600,176 @@ func (g *generator) genSongs(q *ir.QueryIR) (*SQLQuery, error) {
 	return &SQLQuery{SQL: b.String(), Args: args}, nil
 }
 
+// songLengthCondition is SONGS WITH LENGTH > 20min against performance alias
+// p: a song matches when some timed performance of it compares true, so > is
+// its longest version and < its shortest. Untimed performances (length 0 or
+// NULL) never match. Takes one arg, c.Seconds.
+func songLengthCondition(p string, c *ir.LengthConditionIR) string {
+	return p + ".length_seconds > 0 AND " + p + ".length_seconds " + compOpSQL(c.Operator) + " ?"
+}
+
+// lyricsExists generates the EXISTS subquery for LYRICS(...) words joined by op.
+// Each word is a whole-word match against the raw lyrics (punctuation mapped to
+// spaces) or against lyrics_fts, which import stores as data.FoldText output so
+// case, accents, and apostrophe variants don't matter there.
+func lyricsExists(words []string, op string) (string, []interface{}) {
+	var args []interface{}
+	likes := make([]string, len(words))
+	for i, w := range words {
+		likes[i] = "((' ' || REPLACE(REPLACE(REPLACE(REPLACE(REPLACE(LOWER(l.lyrics), ',', ' '), '.', ' '), '!', ' '), '?', ' '), '''', ' ') || ' ') LIKE ? ESCAPE '\\'" +
+			" OR (' ' || COALESCE(l.lyrics_fts, '') || ' ') LIKE ? ESCAPE '\\')"
+		args = append(args, "% "+escapeLike(strings.ToLower(w))+" %", "% "+escapeLike(data.FoldText(w))+" %")
+	}
+	return "EXISTS (SELECT 1 FROM lyrics l WHERE l.song_id = songs.id AND (" + strings.Join(likes, op) + "))", args
+}
+
+// ShowComplete is a predicate on shows alias s that's true when the setlist
+// looks fully documented:
+//   - some performance is flagged as the show opener (is_opener = 1),
+//   - some performance is flagged as a set closer (is_closer = 1), and
+//   - every set is numbered 1..n with no gaps (no missing songs mid-set) and
+//     no repeats (two songs at one position would otherwise fill a gap's count).
+//
+// Imports that lose an encore, truncate a set, or skip songs fail at least one
+// of these. The executor reuses it to fill data.Show.Complete when asked to.
+const ShowComplete = "(EXISTS (SELECT 1 FROM performances pc WHERE pc.show_id = s.id AND pc.is_opener = 1)" +
+	" AND EXISTS (SELECT 1 FROM performances pc WHERE pc.show_id = s.id AND pc.is_closer = 1)" +
+	" AND NOT EXISTS (SELECT 1 FROM performances pc WHERE pc.show_id = s.id GROUP BY pc.set_number HAVING min(pc.position) != 1 OR count(*) != max(pc.position) OR count(DISTINCT pc.position) != count(*)))"
+
+func completeCondition(c *ir.CompleteConditionIR) string {
+	if c.Negated {
+		return "NOT " + ShowComplete
+	}
+	return ShowComplete
+}
+
+// avgLengthColumn is a song's mean timed performance length in whole seconds,
+// selected (and ordered on) by SONGS ... ORDER BY AVG_LENGTH. Untimed
+// performances (length 0 or NULL) don't count.
+const avgLengthColumn = "CAST(round(avg(NULLIF(p.length_seconds, 0))) AS INTEGER) AS avg_length"
+
+// orderByAvgLength reports whether song query q is ordered by AVG_LENGTH.
+// LENGTH means the same for songs: a song has no length of its own, so
+// SONGS ORDER BY LENGTH sorts by its average performance.
+func orderByAvgLength(q *ir.QueryIR) bool {
+	return q.OrderBy != nil && (strings.EqualFold(q.OrderBy.Field, "AVG_LENGTH") || strings.EqualFold(q.OrderBy.Field, "LENGTH"))
+}
+
+// debutCondition is SONGS DEBUTED: first_played within the range. Songs with no
+// recorded debut never match.
+const debutCondition = "songs.first_played IS NOT NULL AND songs.first_played >= ? AND songs.first_played <= ?"
+
+// coverCondition generates SQL for SONGS WHERE COVER / ORIGINAL. Songs whose
+// is_cover flag was never set count as originals.
+func coverCondition(c *ir.CoverConditionIR) string {
+	if c.Cover {
+		return "songs.is_cover = 1"
+	}
+	return "COALESCE(songs.is_cover, 0) = 0"
+}
+
+// missingFieldCondition generates SQL for SONGS WHERE NO SHORT_NAME / NO
+// WRITERS. Imports leave unknown values NULL or empty, so both count.
+func missingFieldCondition(c *ir.MissingFieldConditionIR) string {
+	col := "songs.short_name"
+	if c.Field == "WRITERS" {
+		col = "songs.writers"
+	}
+	return "(" + col + " IS NULL OR " + col + " = '')"
+}
+
+// playedOnceCondition is SONGS PLAYED ONCE: exactly one performance.
+const playedOnceCondition = "(SELECT count(*) FROM performances po WHERE po.song_id = songs.id) = 1"
+
 // genSongsPlayedIn generates SQL for SONGS FROM/PLAYED IN — counts performances per song in a date range.
+// SONGS IN SET2 counts only that set's performances (over all dates when there's no range).
 func (g *generator) genSongsPlayedIn(q *ir.QueryIR) (*SQLQuery, error) {
 	var b strings.Builder
 	var args []interface{}
 
+	// TIMES_PLAYED compares against the in-range count, so it becomes HAVING.
+	var having []string
+	var havingArgs []interface{}
+	for _, c := range q.Conditions {
+		if x, ok := c.(*ir.TimesPlayedConditionIR); ok {
+			having = append(having, "count(*) "+compOpSQL(x.Operator)+" ?")
+			havingArgs = append(havingArgs, x.Count)
+		}
+	}
+	if q.EveryYear {
+		// Every calendar year of the range has at least one performance.
+		having = append(having, "count(DISTINCT strftime('%Y', s.date)) = ?")
+		havingArgs = append(havingArgs, q.PlayedRange.End.Year()-q.PlayedRange.Start.Year()+1)
+	}
+
 	isCount := q.OutputFmt == ir.OutputCount
-	if isCount {
+	avgLength := !isCount && orderByAvgLength(q)
+	if avgLength {
+		// Averaged over the range's timed performances; songs with none drop out.
+		having = append(having, "avg(NULLIF(p.length_seconds, 0)) IS NOT NULL")
+	}
+	if isCount && len(having) > 0 {
+		b.WriteString("SELECT count(*) AS count, 'songs' AS name FROM (SELECT songs.id FROM songs")
+	} else if isCount {
 		b.WriteString("SELECT count(DISTINCT songs.id) AS count, 'songs' AS name FROM songs")
+	} else if avgLength {
+		b.WriteString("SELECT songs.id, songs.name, songs.short_name, songs.writers, songs.first_played, songs.last_played, count(*) AS times_played, " + avgLengthColumn + " FROM songs")
 	} else {
 		b.WriteString("SELECT songs.id, songs.name, songs.short_name, songs.writers, songs.first_played, songs.last_played, count(*) AS times_played FROM songs")
 	}
 	b.WriteString(" JOIN performances p ON p.song_id = songs.id JOIN shows s ON p.show_id = s.id")
-	b.WriteString(" WHERE s.date >= ? AND s.date <= ?")
-	args = append(args, formatDate(q.PlayedRange.Start), formatDate(q.PlayedRange.End))
+	var where []string
+	if q.PlayedRange != nil {
+		where = append(where, "s.date >= ? AND s.date <= ?")
+		args = append(args, formatDate(q.PlayedRange.Start), formatDate(q.PlayedRange.End))
+	}
+	if cond, condArgs := setCondition("p", q.InSet); cond != "" {
+		where = append(where, cond)
+		args = append(args, condArgs...)
+	}
 
 	// Lyrics conditions
 	for _, c := range q.Conditions {
 		if x, ok := c.(*ir.LyricsConditionIR); ok && len(x.Words) > 0 {
-			likes := make([]string, len(x.Words))
-			for i, w := range x.Words {
-				likes[i] = "(' ' || REPLACE(REPLACE(REPLACE(REPLACE(REPLACE(LOWER(l.lyrics), ',', ' '), '.', ' '), '!', ' '), '?', ' '), '''', ' ') || ' ') LIKE ?"
-				args = append(args, "% "+strings.ToLower(w)+" %")
-			}
-			b.WriteString(" AND EXISTS (SELECT 1 FROM lyrics l WHERE l.song_id = songs.id AND (" + strings.Join(likes, " AND ") + "))")
+			cond, condArgs := lyricsExists(x.Words, " AND ")
+			where = append(where, cond)
+			args = append(args, condArgs...)
+		}
+		if x, ok := c.(*ir.CoverConditionIR); ok {
+			where = append(where, coverCondition(x))
+		}
+		if x, ok := c.(*ir.MissingFieldConditionIR); ok {
+			where = append(where, missingFieldCondition(x))
 		}
+		if x, ok := c.(*ir.LengthConditionIR); ok {
+			// Only the qualifying performances are counted.
+			where = append(where, songLengthCondition("p", x))
+			args = append(args, x.Seconds)
+		}
+	}
+	if q.DebutRange != nil {
+		where = append(where, debutCondition)
+		args = append(args, formatDate(q.DebutRange.Start), formatDate(q.DebutRange.End))
+	}
+	if len(where) > 0 {
+		b.WriteString(" WHERE " + strings.Join(where, " AND "))
 	}
 
+	if isCount && len(having) > 0 {
+		b.WriteString(" GROUP BY songs.id HAVING " + strings.Join(having, " AND ") + ")")
+		args = append(args, havingArgs...)
+	}
 	if !isCount {
 		b.WriteString(" GROUP BY songs.id")
+		if len(having) > 0 {
+			b.WriteString(" HAVING " + strings.Join(having, " AND "))
+			args = append(args, havingArgs...)
+		}
 		order := g.orderBy(q, "songs")
+		if q.OrderBy == nil && q.InSet != ir.SetAny {
+			// IN SET2 is a ranking: most played in that set first.
+			order = "ORDER BY count(*) DESC, songs.name ASC, songs.id ASC"
+		}
 		if order != "" {
 			// Replace songs.times_played with the computed count
 			order = strings.Replace(order, "songs.times_played", "count(*)", 1)
@@ -393,8 +787,53 @@ func (g *generator) genSongsPlayedIn(q *ir.QueryIR) (*SQLQuery, error) {
 func (g *generator) genPerformances(q *ir.QueryIR) (*SQLQuery, error) {
 	var b strings.Builder
 	var args []interface{}
-	b.WriteString("SELECT p.id, p.show_id, p.song_id, p.set_number, p.position, p.segue_type, p.length_seconds, songs.name, s.date, v.name FROM performances p JOIN shows s ON p.show_id = s.id JOIN songs ON p.song_id = songs.id LEFT JOIN venues v ON s.venue_id = v.id WHERE p.song_id = ?")
-	args = append(args, *q.SongID)
+	ids := q.SongIDs
+	if len(ids) == 0 {
+		ids = []int{*q.SongID}
+	}
+	in := "?" + strings.Repeat(", ?", len(ids)-1)
+	// nth is numbered over every performance of each song, before any date/length
+	// filtering, so "#112" means the 112th time ever played. Ties on a date
+	// (early/late shows) fall back to show id, then set/position.
+	b.WriteString("SELECT p.id, p.show_id, p.song_id, p.set_number, p.position, p.segue_type, p.length_seconds, songs.name, s.date, v.name, p.nth")
+	if q.WithContext {
+		b.WriteString(", " + neighbourSQL("sg.name", -1) + " AS prev_song, " + neighbourSQL("pc.segue_type", -1) + " AS prev_segue, " + neighbourSQL("sg.name", 1) + " AS next_song")
+	}
+	b.WriteString(" FROM (SELECT pp.*, ROW_NUMBER() OVER (PARTITION BY pp.song_id ORDER BY ss.date, ss.id, pp.set_number, pp.position, pp.id) AS nth FROM performances pp JOIN shows ss ON pp.show_id = ss.id WHERE pp.song_id IN (" + in + ")) p JOIN shows s ON p.show_id = s.id JOIN songs ON p.song_id = songs.id LEFT JOIN venues v ON s.venue_id = v.id WHERE p.song_id IN (" + in + ")")
+	idArgs := make([]interface{}, len(ids))
+	for i, id := range ids {
+		idArgs[i] = id
+	}
+	args = append(args, idArgs...)
+	args = append(args, idArgs...)
+	filter, filterArgs := performanceFilters(q)
+	b.WriteString(filter)
+	args = append(args, filterArgs...)
+	order := g.orderBy(q, "p")
+	if order != "" {
+		b.WriteString(" ")
+		b.WriteString(order)
+	}
+	if q.Limit != nil {
+		b.WriteString(" LIMIT ?")
+		args = append(args, *q.Limit)
+	}
+	return &SQLQuery{SQL: b.String(), Args: args}, nil
+}
+
+// neighbourSQL selects col from the performance offset positions away from p
+// in the same set (-1 the song before, +1 the song after), or NULL at a set
+// boundary. Subqueries rather than joins, so duplicate rows at a position
+// can't multiply p.
+func neighbourSQL(col string, offset int) string {
+	return fmt.Sprintf("(SELECT %s FROM performances pc JOIN songs sg ON sg.id = pc.song_id WHERE pc.show_id = p.show_id AND pc.set_number = p.set_number AND pc.position = p.position + %d LIMIT 1)", col, offset)
+}
+
+// performanceFilters returns the " AND ..." terms a PERFORMANCES query adds
+// to its song filter: the date range and WITH LENGTH conditions.
+func performanceFilters(q *ir.QueryIR) (string, []interface{}) {
+	var b strings.Builder
+	var args []interface{}
 	if q.DateRange != nil {
 		b.WriteString(" AND s.date >= ? AND s.date <= ?")
 		args = append(args, formatDate(q.DateRange.Start), formatDate(q.DateRange.End))
@@ -405,13 +844,84 @@ func (g *generator) genPerformances(q *ir.QueryIR) (*SQLQuery, error) {
 			args = append(args, l.Seconds)
 		}
 	}
-	order := g.orderBy(q, "p")
-	if order != "" {
-		b.WriteString(" ")
-		b.WriteString(order)
-	} else {
-		b.WriteString(" ORDER BY s.date, p.set_number, p.position")
+	return b.String(), args
+}
+
+// genPerformanceYears counts PERFORMANCES OF ... BY YEAR: the same
+// performances as genPerformances, one row per year that has any.
+func (g *generator) genPerformanceYears(q *ir.QueryIR) (*SQLQuery, error) {
+	ids := q.SongIDs
+	if len(ids) == 0 {
+		ids = []int{*q.SongID}
+	}
+	args := make([]interface{}, 0, len(ids)+2)
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	filter, filterArgs := performanceFilters(q)
+	args = append(args, filterArgs...)
+	sql := "SELECT CAST(strftime('%Y', s.date) AS INTEGER) AS year, count(*) AS count FROM performances p JOIN shows s ON p.show_id = s.id" +
+		" WHERE p.song_id IN (?" + strings.Repeat(", ?", len(ids)-1) + ")" + filter +
+		" GROUP BY year ORDER BY year"
+	return &SQLQuery{SQL: sql, Args: args}, nil
+}
+
+// genVenues generates SQL for VENUES [FROM range]: venues played in the range,
+// with per-venue show counts. Defaults to most-played first.
+func (g *generator) genVenues(q *ir.QueryIR) (*SQLQuery, error) {
+	var b strings.Builder
+	var args []interface{}
+	b.WriteString("SELECT v.id, v.name, v.city, v.state, v.country, count(*) AS shows")
+	if q.Type == ir.QueryTypeVenueStats {
+		// STATS VENUES: the span of shows there, within the range
+		b.WriteString(", min(s.date) AS first_show, max(s.date) AS last_show")
+	}
+	b.WriteString(" FROM venues v JOIN shows s ON s.venue_id = v.id")
+	if q.DateRange != nil {
+		b.WriteString(" WHERE s.date >= ? AND s.date <= ?")
+		args = append(args, formatDate(q.DateRange.Start), formatDate(q.DateRange.End))
+	}
+	b.WriteString(" GROUP BY v.id")
+	// SECURITY: whitelisted columns only.
+	order := "ORDER BY shows DESC, v.name ASC"
+	if q.OrderBy != nil {
+		dir := "ASC"
+		if q.OrderBy.Desc {
+			dir = "DESC"
+		}
+		switch strings.ToUpper(q.OrderBy.Field) {
+		case "NAME":
+			order = "ORDER BY v.name " + dir + ", v.id ASC"
+		case "SHOWS":
+			order = "ORDER BY shows " + dir + ", v.name ASC"
+		}
 	}
+	b.WriteString(" " + order)
+	if q.Limit != nil {
+		b.WriteString(" LIMIT ?")
+		args = append(args, *q.Limit)
+	}
+	return &SQLQuery{SQL: b.String(), Args: args}, nil
+}
+
+// genRuns finds stretches of consecutive nights at one venue. Nights are
+// distinct show dates (early and late shows count once); within a venue,
+// julianday(date) minus the night's rank is constant across a run, so it
+// groups the run. Only runs of two or more nights are returned, and a run is
+// cut off where the date range ends.
+func (g *generator) genRuns(q *ir.QueryIR) (*SQLQuery, error) {
+	var b strings.Builder
+	b.WriteString("WITH nights AS (SELECT s.venue_id, s.date, count(*) AS shows FROM shows s JOIN venues v ON s.venue_id = v.id")
+	where, args := g.whereShows(q)
+	if where != "" {
+		b.WriteString(" WHERE " + where)
+	}
+	b.WriteString(" GROUP BY s.venue_id, s.date)," +
+		" runs AS (SELECT venue_id, date, shows, julianday(date) - ROW_NUMBER() OVER (PARTITION BY venue_id ORDER BY date) AS run FROM nights)" +
+		" SELECT r.venue_id, v.name, v.city, v.state, min(r.date) AS start, max(r.date) AS end, count(*) AS nights, sum(r.shows) AS shows" +
+		" FROM runs r JOIN venues v ON v.id = r.venue_id" +
+		" GROUP BY r.venue_id, r.run HAVING count(*) >= 2" +
+		" ORDER BY start ASC, v.name ASC")
 	if q.Limit != nil {
 		b.WriteString(" LIMIT ?")
 		args = append(args, *q.Limit)
@@ -420,6 +930,21 @@ func (g *generator) genPerformances(q *ir.QueryIR) (*SQLQuery, error) {
 }
 
 func (g *generator) genSetlist(q *ir.QueryIR) (*SQLQuery, error) {
+	if q.SingleDate == nil && q.DateRange != nil {
+		// One setlist per show in the period; s.date lets the executor date each one.
+		sql := "SELECT p.id, p.show_id, p.song_id, p.set_number, p.position, p.segue_type, p.length_seconds, songs.name, s.date FROM performances p JOIN shows s ON p.show_id = s.id JOIN songs ON p.song_id = songs.id WHERE s.date >= ? AND s.date <= ? ORDER BY s.date, p.show_id, p.set_number, p.position"
+		return &SQLQuery{SQL: sql, Args: []interface{}{formatDate(q.DateRange.Start), formatDate(q.DateRange.End)}}, nil
+	}
+	if q.SingleDate == nil && q.VenueName != "" {
+		// SETLIST FOR "Winterland": the latest show with a setlist at each
+		// venue whose name or city matches, newest first. More than one show
+		// means the name was ambiguous; the executor reports their dates.
+		sql := "SELECT p.id, p.show_id, p.song_id, p.set_number, p.position, p.segue_type, p.length_seconds, songs.name, s.date FROM performances p JOIN shows s ON p.show_id = s.id JOIN songs ON p.song_id = songs.id" +
+			" WHERE s.id IN (SELECT (SELECT sv.id FROM shows sv WHERE sv.venue_id = v.id AND EXISTS (SELECT 1 FROM performances pv WHERE pv.show_id = sv.id) ORDER BY sv.date DESC, sv.id DESC LIMIT 1) FROM venues v WHERE v.name LIKE ? ESCAPE '\\' OR v.city LIKE ? ESCAPE '\\')" +
+			" ORDER BY s.date DESC, p.show_id, p.set_number, p.position"
+		like := "%" + escapeLike(q.VenueName) + "%"
+		return &SQLQuery{SQL: sql, Args: []interface{}{like, like}}, nil
+	}
 	if q.SingleDate == nil {
 		return nil, fmt.Errorf("setlist query requires a date")
 	}
@@ -428,7 +953,9 @@ func (g *generator) genSetlist(q *ir.QueryIR) (*SQLQuery, error) {
 }
 
 func (g *generator) genCount(q *ir.QueryIR) (*SQLQuery, error) {
-	// COUNT SHOWS with WHERE/segue — reuse the shows query and wrap in COUNT
+	// COUNT SHOWS with WHERE/segue — reuse the shows query and wrap in COUNT.
+	// Both shows builders return one row per show (the segue builder groups by
+	// s.id), so the outer count(*) never double-counts repeated matches.
 	if q.SongID == nil && (q.SegueChain != nil || len(q.Conditions) > 0) {
 		showsQ := &ir.QueryIR{
 			Type:       ir.QueryTypeShows,
@@ -447,7 +974,14 @@ func (g *generator) genCount(q *ir.QueryIR) (*SQLQuery, error) {
 
 	var b strings.Builder
 	var args []interface{}
-	if q.SongID == nil {
+	if q.CountVenues {
+		// COUNT VENUES — distinct venues with at least one show in range
+		b.WriteString("SELECT count(DISTINCT s.venue_id) AS count, 'venues' AS name FROM shows s WHERE s.venue_id IS NOT NULL")
+		if q.DateRange != nil {
+			b.WriteString(" AND s.date >= ? AND s.date <= ?")
+			args = append(args, formatDate(q.DateRange.Start), formatDate(q.DateRange.End))
+		}
+	} else if q.SongID == nil {
 		// COUNT SHOWS
 		b.WriteString("SELECT count(*) AS count, 'shows' AS name FROM shows s")
 		if q.DateRange != nil {
@@ -465,6 +999,26 @@ func (g *generator) genCount(q *ir.QueryIR) (*SQLQuery, error) {
 	return &SQLQuery{SQL: b.String(), Args: args}, nil
 }
 
+// genCompare aggregates each COMPARE song's performances in its own subquery,
+// in the order the songs were named. Columns match the songs query with
+// AVG_LENGTH, with the counts and dates taken from performances rather than
+// the songs table. A song never played still gets a row.
+func (g *generator) genCompare(q *ir.QueryIR) (*SQLQuery, error) {
+	parts := make([]string, len(q.SongIDs))
+	args := make([]interface{}, 0, len(q.SongIDs))
+	for i, id := range q.SongIDs {
+		parts[i] = fmt.Sprintf("SELECT %d AS side, songs.id, songs.name, songs.short_name, songs.writers,"+
+			" min(s.date) AS first_played, max(s.date) AS last_played, count(p.id) AS times_played, "+avgLengthColumn+
+			" FROM songs LEFT JOIN performances p ON p.song_id = songs.id LEFT JOIN shows s ON p.show_id = s.id"+
+			" WHERE songs.id = ? GROUP BY songs.id", i)
+		args = append(args, id)
+	}
+	return &SQLQuery{
+		SQL:  "SELECT id, name, short_name, writers, first_played, last_played, times_played, avg_length FROM (" + strings.Join(parts, " UNION ALL ") + ") ORDER BY side",
+		Args: args,
+	}, nil
+}
+
 func (g *generator) genFirstLast(q *ir.QueryIR) (*SQLQuery, error) {
 	dir := "ASC"
 	if q.IsLast {
@@ -487,10 +1041,36 @@ func (g *generator) genRandomShow(q *ir.QueryIR) (*SQLQuery, error) {
 	return &SQLQuery{SQL: b.String(), Args: args}, nil
 }
 
+// orderBy returns the ORDER BY clause for q. When the query has no ORDER BY
+// (or names a field that doesn't apply to this query type) it falls back to
+// defaultOrderBy so results are always in a stable, reproducible order.
 func (g *generator) orderBy(q *ir.QueryIR, prefix string) string {
 	if q.OrderBy == nil {
+		return defaultOrderBy(prefix)
+	}
+	if order := explicitOrderBy(q, prefix); order != "" {
+		return order
+	}
+	return defaultOrderBy(prefix)
+}
+
+// defaultOrderBy is the deterministic ordering used when none is specified:
+// shows by date, songs by name, performances by show date then set/position.
+// The trailing id column breaks ties (e.g. early/late shows on the same date).
+func defaultOrderBy(prefix string) string {
+	switch prefix {
+	case "s":
+		return "ORDER BY s.date ASC, s.id ASC"
+	case "songs":
+		return "ORDER BY songs.name ASC, songs.id ASC"
+	case "p":
+		return "ORDER BY s.date ASC, p.set_number ASC, p.position ASC"
+	default:
 		return ""
 	}
+}
+
+func explicitOrderBy(q *ir.QueryIR, prefix string) string {
 	field := strings.ToUpper(q.OrderBy.Field)
 	if field == "" {
 		field = "DATE"
@@ -509,15 +1089,30 @@ func (g *generator) orderBy(q *ir.QueryIR, prefix string) string {
 			col = prefix + ".date"
 		}
 	case "LENGTH":
-		if prefix == "p" {
+		switch prefix {
+		case "p":
 			col = "p.length_seconds"
-		} else {
-			return "" // LENGTH only valid for performances
+		case "s":
+			return showLengthOrder(dir) // total show length
+		case "songs":
+			col = "avg_length" // average performance; see orderByAvgLength
+		default:
+			return "" // LENGTH only valid for performances, shows, and songs
 		}
+	case "RATING":
+		if prefix != "s" {
+			return "" // only shows are rated
+		}
+		return showRatingOrder(dir)
 	case "NAME":
 		col = prefix + ".name"
 	case "TIMES_PLAYED":
 		col = prefix + ".times_played"
+	case "AVG_LENGTH":
+		if prefix != "songs" {
+			return "" // only SONGS selects avg_length
+		}
+		col = "avg_length"
 	case "POSITION":
 		if prefix == "p" {
 			col = "p.position"
@@ -538,11 +1133,15 @@ func (g *generator) limit(q *ir.QueryIR) string {
 	return "LIMIT ?"
 }
 
+// formatDate renders a range bound as the stored YYYY-MM-DD form. Dropping
+// the time is what makes "s.date <= end" include the whole end day: stored
+// dates have no time part, so '1977-05-08' <= '1977-05-08' holds.
 func formatDate(t time.Time) string {
 	return t.Format("2006-01-02")
 }
 
-// escapeLike escapes LIKE pattern metacharacters (% and _) in user input.
+// escapeLike escapes LIKE pattern metacharacters (\, % and _) in user input;
+// the LIKE using it needs ESCAPE '\'.
 func escapeLike(s string) string {
 	s = strings.ReplaceAll(s, "\\", "\\\\")
 	s = strings.ReplaceAll(s, "%", "\\%")
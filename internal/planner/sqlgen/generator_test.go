@@ -2,9 +2,12 @@ package sqlgen
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/gdql/gdql/internal/data"
 	"github.com/gdql/gdql/internal/data/sqlite"
 	"github.com/gdql/gdql/internal/ir"
 	"github.com/gdql/gdql/test/fixtures"
@@ -49,6 +52,19 @@ func TestGenerate_Shows_WithDateRange(t *testing.T) {
 	require.Equal(t, 2, rows, "fixture has 2 shows in 1977")
 }
 
+func TestGenerate_Shows_SingleDayRangeIsInclusive(t *testing.T) {
+	db := openDB(t)
+	day := func(start, end time.Time) int {
+		return execQuery(t, db, &ir.QueryIR{Type: ir.QueryTypeShows, DateRange: &ir.ResolvedDateRange{Start: start, End: end}})
+	}
+	// End of day, as the expander builds it, and bare midnight both include
+	// the stored '1977-05-08': bounds compare as dates.
+	require.Equal(t, 1, day(time.Date(1977, 5, 8, 0, 0, 0, 0, time.UTC), time.Date(1977, 5, 8, 23, 59, 59, 0, time.UTC)))
+	require.Equal(t, 1, day(time.Date(1977, 5, 8, 0, 0, 0, 0, time.UTC), time.Date(1977, 5, 8, 0, 0, 0, 0, time.UTC)))
+	require.Equal(t, 0, day(time.Date(1977, 5, 9, 0, 0, 0, 0, time.UTC), time.Date(1977, 5, 9, 23, 59, 59, 0, time.UTC)))
+	require.Equal(t, 0, day(time.Date(1977, 5, 7, 0, 0, 0, 0, time.UTC), time.Date(1977, 5, 7, 23, 59, 59, 0, time.UTC)))
+}
+
 func TestGenerate_Shows_WithLimit(t *testing.T) {
 	db := openDB(t)
 	lim := 1
@@ -56,6 +72,222 @@ func TestGenerate_Shows_WithLimit(t *testing.T) {
 	require.Equal(t, 1, rows)
 }
 
+func TestGenerate_Shows_DefaultOrderByDate(t *testing.T) {
+	db := openDB(t)
+	sq, err := New().Generate(&ir.QueryIR{Type: ir.QueryTypeShows})
+	require.NoError(t, err)
+	require.Contains(t, sq.SQL, "ORDER BY s.date ASC, s.id ASC")
+	rs, err := db.ExecuteQuery(context.Background(), sq.SQL, sq.Args...)
+	require.NoError(t, err)
+	require.Len(t, rs.Rows, 3)
+	require.Equal(t, "1977-02-26", rs.Rows[0][1])
+	require.Equal(t, "1977-05-08", rs.Rows[1][1])
+	require.Equal(t, "1978-04-24", rs.Rows[2][1])
+}
+
+func TestGenerate_Shows_OrderByTotalLength(t *testing.T) {
+	db := openDB(t)
+	// Fixture totals: Cornell 3980s, Winterland 2640s, Landover 1570s.
+	// A performance with no length makes Landover's total unknown.
+	_, err := db.DB().Exec("INSERT INTO performances (id, show_id, song_id, set_number, position, length_seconds) VALUES (99, 3, 3, 2, 4, 0)")
+	require.NoError(t, err)
+	sq, err := New().Generate(&ir.QueryIR{Type: ir.QueryTypeShows, OrderBy: &ir.OrderByIR{Field: "LENGTH"}})
+	require.NoError(t, err)
+	rs, err := db.ExecuteQuery(context.Background(), sq.SQL, sq.Args...)
+	require.NoError(t, err)
+	require.Len(t, rs.Rows, 3)
+	require.EqualValues(t, 2, rs.Rows[0][0])
+	require.EqualValues(t, 2640, rs.Rows[0][7])
+	require.EqualValues(t, 1, rs.Rows[1][0])
+	require.EqualValues(t, 3, rs.Rows[2][0], "incomplete lengths sort last")
+	require.Nil(t, rs.Rows[2][7])
+}
+
+func TestGenerate_Shows_OrderByRating(t *testing.T) {
+	db := openDB(t)
+	// Winterland is unrated.
+	_, err := db.DB().Exec("UPDATE shows SET rating = CASE id WHEN 1 THEN 4.9 WHEN 3 THEN 4.2 END")
+	require.NoError(t, err)
+	for _, desc := range []bool{true, false} {
+		sq, err := New().Generate(&ir.QueryIR{Type: ir.QueryTypeShows, OrderBy: &ir.OrderByIR{Field: "RATING", Desc: desc}})
+		require.NoError(t, err)
+		rs, err := db.ExecuteQuery(context.Background(), sq.SQL, sq.Args...)
+		require.NoError(t, err)
+		require.Len(t, rs.Rows, 3)
+		require.EqualValues(t, 2, rs.Rows[2][0], "unrated shows sort last (desc=%v)", desc)
+	}
+
+	sq, err := New().Generate(&ir.QueryIR{Type: ir.QueryTypeShows, RatedOnly: true, OrderBy: &ir.OrderByIR{Field: "RATING", Desc: true}})
+	require.NoError(t, err)
+	rs, err := db.ExecuteQuery(context.Background(), sq.SQL, sq.Args...)
+	require.NoError(t, err)
+	require.Len(t, rs.Rows, 2)
+	require.EqualValues(t, 1, rs.Rows[0][0])
+	require.EqualValues(t, 3, rs.Rows[1][0])
+
+	// The segue path filters and orders the same way.
+	sq, err = New().Generate(&ir.QueryIR{Type: ir.QueryTypeShows, RatedOnly: true, SegueChain: &ir.SegueChainIR{SongIDs: []int{1, 2}, Operators: []ir.SegueOp{ir.SegueOpSegue}}, OrderBy: &ir.OrderByIR{Field: "RATING", Desc: true}})
+	require.NoError(t, err)
+	require.Contains(t, sq.SQL, "s.rating IS NOT NULL")
+	require.Contains(t, sq.SQL, "ORDER BY s.rating IS NULL, s.rating DESC")
+	_, err = db.ExecuteQuery(context.Background(), sq.SQL, sq.Args...)
+	require.NoError(t, err)
+}
+
+func TestGenerate_Songs_DefaultOrderByName(t *testing.T) {
+	sq, err := New().Generate(&ir.QueryIR{Type: ir.QueryTypeSongs})
+	require.NoError(t, err)
+	require.Contains(t, sq.SQL, "ORDER BY songs.name ASC, songs.id ASC")
+}
+
+func TestGenerate_Songs_OrderByAvgLength(t *testing.T) {
+	db := openDB(t)
+	// Untimed performances don't drag an average down; untimed songs drop out.
+	_, err := db.DB().Exec(`INSERT INTO performances (id, show_id, song_id, set_number, position, length_seconds) VALUES (99, 3, 3, 2, 4, 0);
+		INSERT INTO songs (id, name, times_played) VALUES (7, 'Untimed', 1);
+		INSERT INTO performances (id, show_id, song_id, set_number, position, length_seconds) VALUES (100, 3, 7, 2, 5, NULL)`)
+	require.NoError(t, err)
+	sq, err := New().Generate(&ir.QueryIR{Type: ir.QueryTypeSongs, OrderBy: &ir.OrderByIR{Field: "AVG_LENGTH", Desc: true}})
+	require.NoError(t, err)
+	rs, err := db.ExecuteQuery(context.Background(), sq.SQL, sq.Args...)
+	require.NoError(t, err)
+	var ids, avgs []int64
+	for _, row := range rs.Rows {
+		ids = append(ids, row[0].(int64))
+		avgs = append(avgs, row[7].(int64))
+	}
+	// Dark Star (1320, 1500), Dew, Fire (620, 600, 610), Scarlet, Samson, Help
+	require.Equal(t, []int64{6, 5, 2, 1, 4, 3}, ids)
+	require.Equal(t, []int64{1410, 720, 610, 560, 410, 320}, avgs)
+
+	// ORDER BY LENGTH on songs is the same ordering, not a column songs lacks.
+	byLength, err := New().Generate(&ir.QueryIR{Type: ir.QueryTypeSongs, OrderBy: &ir.OrderByIR{Field: "LENGTH", Desc: true}})
+	require.NoError(t, err)
+	require.Equal(t, sq.SQL, byLength.SQL)
+	require.NotContains(t, byLength.SQL, "songs.length")
+	rs, err = db.ExecuteQuery(context.Background(), byLength.SQL, byLength.Args...)
+	require.NoError(t, err)
+	require.Len(t, rs.Rows, 6)
+	require.Equal(t, int64(6), rs.Rows[0][0])
+
+	// Within a range, only that range's performances are averaged.
+	sq, err = New().Generate(&ir.QueryIR{
+		Type:        ir.QueryTypeSongs,
+		PlayedRange: &ir.ResolvedDateRange{Start: time.Date(1978, 1, 1, 0, 0, 0, 0, time.UTC), End: time.Date(1978, 12, 31, 0, 0, 0, 0, time.UTC)},
+		OrderBy:     &ir.OrderByIR{Field: "AVG_LENGTH"},
+	})
+	require.NoError(t, err)
+	rs, err = db.ExecuteQuery(context.Background(), sq.SQL, sq.Args...)
+	require.NoError(t, err)
+	ids, avgs = nil, nil
+	for _, row := range rs.Rows {
+		ids = append(ids, row[0].(int64))
+		avgs = append(avgs, row[7].(int64))
+	}
+	require.Equal(t, []int64{4, 1, 2}, ids)
+	require.Equal(t, []int64{400, 560, 610}, avgs)
+}
+
+func TestGenerate_Runs(t *testing.T) {
+	db := openDB(t)
+	// Winterland (venue 2) already has 1977-02-26. Add the next two nights,
+	// an early show on the 27th, and a lone night after a day off.
+	_, err := db.DB().Exec(`INSERT INTO shows (id, date, venue_id) VALUES
+		(10, '1977-02-27', 2), (11, '1977-02-27', 2), (12, '1977-02-28', 2), (13, '1977-03-02', 2),
+		(14, '1978-04-25', 3)`)
+	require.NoError(t, err)
+
+	run := func(q *ir.QueryIR) []data.Row {
+		sq, err := New().Generate(q)
+		require.NoError(t, err)
+		rs, err := db.ExecuteQuery(context.Background(), sq.SQL, sq.Args...)
+		require.NoError(t, err)
+		return rs.Rows
+	}
+	rows := run(&ir.QueryIR{Type: ir.QueryTypeRuns})
+	require.Len(t, rows, 2)
+	// venue_id, name, city, state, start, end, nights, shows
+	require.Equal(t, data.Row{int64(2), "Winterland Arena", "San Francisco", "CA", "1977-02-26", "1977-02-28", int64(3), int64(4)}, rows[0])
+	require.Equal(t, "1978-04-24", rows[1][4])
+	require.EqualValues(t, 2, rows[1][6])
+
+	rows = run(&ir.QueryIR{Type: ir.QueryTypeRuns, VenueName: "Winterland"})
+	require.Len(t, rows, 1)
+
+	// The range cuts runs at its edges.
+	rows = run(&ir.QueryIR{Type: ir.QueryTypeRuns, DateRange: &ir.ResolvedDateRange{
+		Start: time.Date(1977, 2, 27, 0, 0, 0, 0, time.UTC), End: time.Date(1977, 12, 31, 0, 0, 0, 0, time.UTC),
+	}})
+	require.Len(t, rows, 1)
+	require.Equal(t, "1977-02-27", rows[0][4])
+	require.EqualValues(t, 2, rows[0][6])
+}
+
+func TestGenerate_Coverage(t *testing.T) {
+	db := openDB(t)
+	run := func(q *ir.QueryIR) []data.Row {
+		sq, err := New().Generate(q)
+		require.NoError(t, err)
+		rs, err := db.ExecuteQuery(context.Background(), sq.SQL, sq.Args...)
+		require.NoError(t, err)
+		return rs.Rows
+	}
+	// Fixture: two 1977 shows, one 1978 show; 1979 has none but still gets a row.
+	rows := run(&ir.QueryIR{Type: ir.QueryTypeCoverage, DateRange: &ir.ResolvedDateRange{
+		Start: time.Date(1977, 5, 1, 0, 0, 0, 0, time.UTC), End: time.Date(1979, 12, 31, 0, 0, 0, 0, time.UTC),
+	}})
+	// year, have, expected, percent
+	require.Equal(t, []data.Row{
+		{int64(1977), int64(2), int64(ShowsPerYear[1977]), 3.3},
+		{int64(1978), int64(1), int64(ShowsPerYear[1978]), 1.2},
+		{int64(1979), int64(0), int64(ShowsPerYear[1979]), 0.0},
+	}, rows)
+
+	require.Len(t, run(&ir.QueryIR{Type: ir.QueryTypeCoverage}), len(ShowsPerYear))
+	require.Empty(t, run(&ir.QueryIR{Type: ir.QueryTypeCoverage, DateRange: &ir.ResolvedDateRange{
+		Start: time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2001, 12, 31, 0, 0, 0, 0, time.UTC),
+	}}))
+}
+
+func TestGenerate_Gaps(t *testing.T) {
+	db := openDB(t)
+	for _, stmt := range []string{
+		`INSERT INTO shows (id, date, venue_id, tour) VALUES (4, '1977-05-11', 1, 'Spring 1977')`,
+		`INSERT INTO shows (id, date, venue_id, tour) VALUES (5, '1977-05-13', 1, 'Spring 1977')`,
+	} {
+		_, err := db.DB().Exec(stmt)
+		require.NoError(t, err)
+	}
+	run := func(q *ir.QueryIR) []data.Row {
+		sq, err := New().Generate(q)
+		require.NoError(t, err)
+		rs, err := db.ExecuteQuery(context.Background(), sq.SQL, sq.Args...)
+		require.NoError(t, err)
+		return rs.Rows
+	}
+	// Spring 1977 runs 5/8 to 5/13 with shows on 5/8, 5/11, and 5/13.
+	require.Equal(t, []data.Row{
+		{"1977-05-09", "1977-05-10", int64(2)},
+		{"1977-05-12", "1977-05-12", int64(1)},
+	}, run(&ir.QueryIR{Type: ir.QueryTypeGaps, TourName: "Spring 1977"}))
+
+	// A date range is taken whole, ends included.
+	require.Equal(t, []data.Row{
+		{"1977-02-25", "1977-02-25", int64(1)},
+		{"1977-02-27", "1977-02-28", int64(2)},
+	}, run(&ir.QueryIR{Type: ir.QueryTypeGaps, DateRange: &ir.ResolvedDateRange{
+		Start: time.Date(1977, 2, 25, 0, 0, 0, 0, time.UTC), End: time.Date(1977, 2, 28, 0, 0, 0, 0, time.UTC),
+	}}))
+
+	// A tour with no shows in range has no span at all.
+	require.Empty(t, run(&ir.QueryIR{Type: ir.QueryTypeGaps, TourName: "Spring 1977", DateRange: &ir.ResolvedDateRange{
+		Start: time.Date(1978, 1, 1, 0, 0, 0, 0, time.UTC), End: time.Date(1978, 12, 31, 0, 0, 0, 0, time.UTC),
+	}}))
+
+	_, err := New().Generate(&ir.QueryIR{Type: ir.QueryTypeGaps})
+	require.Error(t, err)
+}
+
 func TestGenerate_Shows_WithSegue(t *testing.T) {
 	db := openDB(t)
 	// Scarlet (1) > Fire (2) — fixture has 3 shows with this adjacency
@@ -69,6 +301,21 @@ func TestGenerate_Shows_WithSegue(t *testing.T) {
 	require.Equal(t, 3, rows, "fixture has Scarlet > Fire at Cornell, Winterland, Landover")
 }
 
+func TestGenerate_Shows_WithSegueAndLength(t *testing.T) {
+	db := openDB(t)
+	fire := 2
+	// Fire runs 620s (Cornell), 600s (Winterland), 610s (Landover)
+	rows := execQuery(t, db, &ir.QueryIR{
+		Type: ir.QueryTypeShows,
+		SegueChain: &ir.SegueChainIR{
+			SongIDs:   []int{1, 2},
+			Operators: []ir.SegueOp{ir.SegueOpSegue},
+		},
+		Conditions: []ir.ConditionIR{&ir.LengthConditionIR{SongID: &fire, Operator: ir.CompGT, Seconds: 605}},
+	})
+	require.Equal(t, 2, rows, "Winterland's 600s Fire is filtered out")
+}
+
 func TestGenerate_Shows_WithVenue(t *testing.T) {
 	db := openDB(t)
 	rows := execQuery(t, db, &ir.QueryIR{
@@ -88,6 +335,66 @@ func TestGenerate_Performances(t *testing.T) {
 	require.Equal(t, 2, rows, "fixture has 2 Dark Star performances")
 }
 
+func TestGenerate_Performances_NthTimePlayed(t *testing.T) {
+	db := openDB(t)
+	songID := 6 // Dark Star: Winterland 2/26/77 (1500s), then Cornell 5/8/77 (1320s)
+	sq, err := New().Generate(&ir.QueryIR{
+		Type:       ir.QueryTypePerformances,
+		SongID:     &songID,
+		Conditions: []ir.ConditionIR{&ir.LengthConditionIR{Operator: ir.CompLT, Seconds: 1400}},
+	})
+	require.NoError(t, err)
+	rs, err := db.ExecuteQuery(context.Background(), sq.SQL, sq.Args...)
+	require.NoError(t, err)
+	require.Len(t, rs.Rows, 1)
+	require.Equal(t, "1977-05-08", rs.Rows[0][8])
+	require.EqualValues(t, 2, rs.Rows[0][10], "numbered across all performances, not just the filtered ones")
+}
+
+func TestGenerate_PerformanceYears(t *testing.T) {
+	db := openDB(t)
+	run := func(q *ir.QueryIR) []data.Row {
+		sq, err := New().Generate(q)
+		require.NoError(t, err)
+		rs, err := db.ExecuteQuery(context.Background(), sq.SQL, sq.Args...)
+		require.NoError(t, err)
+		return rs.Rows
+	}
+	songID := 1 // Scarlet Begonias: twice in 1977, once in 1978
+	require.Equal(t, []data.Row{{int64(1977), int64(2)}, {int64(1978), int64(1)}},
+		run(&ir.QueryIR{Type: ir.QueryTypePerformanceYears, SongID: &songID}))
+
+	// FROM and WITH LENGTH narrow the counts as they do the performances.
+	require.Equal(t, []data.Row{{int64(1977), int64(1)}}, run(&ir.QueryIR{
+		Type:       ir.QueryTypePerformanceYears,
+		SongID:     &songID,
+		DateRange:  &ir.ResolvedDateRange{Start: time.Date(1977, 1, 1, 0, 0, 0, 0, time.UTC), End: time.Date(1977, 12, 31, 0, 0, 0, 0, time.UTC)},
+		Conditions: []ir.ConditionIR{&ir.LengthConditionIR{Operator: ir.CompGT, Seconds: 570}},
+	}))
+}
+
+func TestGenerate_Performances_MultipleSongs(t *testing.T) {
+	db := openDB(t)
+	songID := 1
+	sq, err := New().Generate(&ir.QueryIR{
+		Type:    ir.QueryTypePerformances,
+		SongID:  &songID,
+		SongIDs: []int{1, 6}, // Scarlet Begonias, Dark Star
+	})
+	require.NoError(t, err)
+	rs, err := db.ExecuteQuery(context.Background(), sq.SQL, sq.Args...)
+	require.NoError(t, err)
+	require.Len(t, rs.Rows, 5, "3 Scarlet + 2 Dark Star")
+	// nth counts each song separately
+	first := map[string]bool{}
+	for _, row := range rs.Rows {
+		if row[10] == int64(1) {
+			first[row[7].(string)] = true
+		}
+	}
+	require.Equal(t, map[string]bool{"Scarlet Begonias": true, "Dark Star": true}, first)
+}
+
 func TestGenerate_Setlist(t *testing.T) {
 	db := openDB(t)
 	d := time.Date(1977, 5, 8, 0, 0, 0, 0, time.UTC)
@@ -98,6 +405,42 @@ func TestGenerate_Setlist(t *testing.T) {
 	require.GreaterOrEqual(t, rows, 5, "Cornell 77 setlist has at least 5 songs in fixture")
 }
 
+func TestGenerate_Setlist_Venue(t *testing.T) {
+	db := openDB(t)
+	rows := execQuery(t, db, &ir.QueryIR{Type: ir.QueryTypeSetlist, VenueName: "Barton"})
+	require.Equal(t, 6, rows, "the one Barton Hall show")
+
+	// Ithaca is Barton Hall's city; a second show there makes the first one old news
+	_, err := db.DB().Exec("INSERT INTO shows (id, date, venue_id) VALUES (4, '1980-05-07', 1)")
+	require.NoError(t, err)
+	_, err = db.DB().Exec("INSERT INTO performances (id, show_id, song_id, set_number, position) VALUES (13, 4, 5, 1, 1)")
+	require.NoError(t, err)
+	rows = execQuery(t, db, &ir.QueryIR{Type: ir.QueryTypeSetlist, VenueName: "ithaca"})
+	require.Equal(t, 1, rows, "only the latest show's setlist")
+}
+
+func TestGenerate_Setlist_Period(t *testing.T) {
+	db := openDB(t)
+	q := &ir.QueryIR{
+		Type:      ir.QueryTypeSetlist,
+		DateRange: &ir.ResolvedDateRange{Start: time.Date(1977, 1, 1, 0, 0, 0, 0, time.UTC), End: time.Date(1977, 12, 31, 0, 0, 0, 0, time.UTC)},
+	}
+	sq, err := New().Generate(q)
+	require.NoError(t, err)
+	rs, err := db.ExecuteQuery(context.Background(), sq.SQL, sq.Args...)
+	require.NoError(t, err)
+	require.NotEmpty(t, rs.Rows)
+	// Rows come grouped by show in date order: Winterland, then Cornell
+	var dates []string
+	for _, row := range rs.Rows {
+		d := row[8].(string)[:10]
+		if len(dates) == 0 || dates[len(dates)-1] != d {
+			dates = append(dates, d)
+		}
+	}
+	require.Equal(t, []string{"1977-02-26", "1977-05-08"}, dates)
+}
+
 func TestGenerate_Songs_WithLyrics(t *testing.T) {
 	db := openDB(t)
 	rows := execQuery(t, db, &ir.QueryIR{
@@ -109,6 +452,40 @@ func TestGenerate_Songs_WithLyrics(t *testing.T) {
 	require.Equal(t, 1, rows, "only Scarlet Begonias has 'walkin' in lyrics")
 }
 
+func TestGenerate_Songs_WithLyrics_AccentInsensitive(t *testing.T) {
+	db := openDB(t)
+	// lyrics_fts holds folded text, as written by the lyrics import
+	_, err := db.DB().Exec("INSERT INTO lyrics (song_id, lyrics, lyrics_fts) VALUES (6, 'Café au lait, it’s a Dark Star night', 'cafe au lait it s a dark star night')")
+	require.NoError(t, err)
+	for _, w := range []string{"café", "CAFÉ", "cafe"} {
+		rows := execQuery(t, db, &ir.QueryIR{
+			Type:       ir.QueryTypeSongs,
+			Conditions: []ir.ConditionIR{&ir.LyricsConditionIR{Words: []string{w}}},
+		})
+		require.Equal(t, 1, rows, w)
+	}
+}
+
+func TestGenerate_LikeInputIsLiteral(t *testing.T) {
+	db := openDB(t)
+	_, err := db.DB().Exec("UPDATE performances SET guest = 'DJ_Ray' WHERE id = 1")
+	require.NoError(t, err)
+	_, err = db.DB().Exec("UPDATE performances SET guest = 'DJ Ray' WHERE id = 8")
+	require.NoError(t, err)
+
+	rows := execQuery(t, db, &ir.QueryIR{
+		Type:       ir.QueryTypeShows,
+		Conditions: []ir.ConditionIR{&ir.GuestConditionIR{Name: "J_R"}},
+	})
+	require.Equal(t, 1, rows, `"_" in GUEST matches only an underscore, not the space in "DJ Ray"`)
+
+	rows = execQuery(t, db, &ir.QueryIR{
+		Type:       ir.QueryTypeSongs,
+		Conditions: []ir.ConditionIR{&ir.LyricsConditionIR{Words: []string{"walk_n"}}},
+	})
+	require.Equal(t, 0, rows, `"_" in LYRICS doesn't stand for the "i" in "walkin"`)
+}
+
 // === COUNT ===
 
 func execScalar(t *testing.T, db *sqlite.DB, q *ir.QueryIR) (int, string) {
@@ -136,6 +513,230 @@ func execScalar(t *testing.T, db *sqlite.DB, q *ir.QueryIR) (int, string) {
 	return count, name
 }
 
+func TestGenerate_Songs_CoverFilter(t *testing.T) {
+	db := openDB(t)
+	// Fixture flags Samson and Delilah and Morning Dew as covers
+	rows := execQuery(t, db, &ir.QueryIR{
+		Type:       ir.QueryTypeSongs,
+		Conditions: []ir.ConditionIR{&ir.CoverConditionIR{Cover: true}},
+	})
+	require.Equal(t, 2, rows)
+	rows = execQuery(t, db, &ir.QueryIR{
+		Type:       ir.QueryTypeSongs,
+		Conditions: []ir.ConditionIR{&ir.CoverConditionIR{Cover: false}},
+	})
+	require.Equal(t, 4, rows)
+}
+
+func TestGenerate_Songs_PlayedOnce(t *testing.T) {
+	db := openDB(t)
+	// Help on the Way and Morning Dew have one fixture performance each; the
+	// fixture's times_played (in the hundreds) doesn't enter into it.
+	once := &ir.PlayedOnceConditionIR{}
+	require.Equal(t, 2, execQuery(t, db, &ir.QueryIR{Type: ir.QueryTypeSongs, Conditions: []ir.ConditionIR{once}}))
+	n, _ := execScalar(t, db, &ir.QueryIR{Type: ir.QueryTypeSongs, Conditions: []ir.ConditionIR{once}, OutputFmt: ir.OutputCount})
+	require.Equal(t, 2, n)
+	require.Equal(t, 1, execQuery(t, db, &ir.QueryIR{Type: ir.QueryTypeSongs, Conditions: []ir.ConditionIR{once, &ir.CoverConditionIR{Cover: true}}}), "only Morning Dew is a cover")
+}
+
+func TestGenerate_Songs_MissingFields(t *testing.T) {
+	db := openDB(t)
+	// Blank out Morning Dew's short name and Dark Star's writers (one NULL, one empty)
+	_, err := db.DB().Exec("UPDATE songs SET short_name = CASE id WHEN 5 THEN NULL ELSE short_name END, writers = CASE id WHEN 6 THEN '' ELSE writers END")
+	require.NoError(t, err)
+
+	noShort := &ir.MissingFieldConditionIR{Field: "SHORT_NAME"}
+	noWriters := &ir.MissingFieldConditionIR{Field: "WRITERS"}
+	require.Equal(t, 1, execQuery(t, db, &ir.QueryIR{Type: ir.QueryTypeSongs, Conditions: []ir.ConditionIR{noShort}}))
+	require.Equal(t, 1, execQuery(t, db, &ir.QueryIR{Type: ir.QueryTypeSongs, Conditions: []ir.ConditionIR{noWriters}}))
+	require.Equal(t, 0, execQuery(t, db, &ir.QueryIR{Type: ir.QueryTypeSongs, Conditions: []ir.ConditionIR{noShort, noWriters}}))
+
+	// Composes with ORDER BY and LIMIT, and with PLAYED IN (Dew and Dark Star are 1977)
+	limit := 1
+	require.Equal(t, 1, execQuery(t, db, &ir.QueryIR{
+		Type:       ir.QueryTypeSongs,
+		Conditions: []ir.ConditionIR{noWriters},
+		OrderBy:    &ir.OrderByIR{Field: "TIMES_PLAYED", Desc: true},
+		Limit:      &limit,
+	}))
+	start := time.Date(1977, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(1977, 12, 31, 23, 59, 59, 0, time.UTC)
+	require.Equal(t, 1, execQuery(t, db, &ir.QueryIR{
+		Type:        ir.QueryTypeSongs,
+		PlayedRange: &ir.ResolvedDateRange{Start: start, End: end},
+		Conditions:  []ir.ConditionIR{noShort},
+	}))
+}
+
+func TestGenerate_Songs_CoversPlayedIn(t *testing.T) {
+	db := openDB(t)
+	start := time.Date(1977, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(1977, 12, 31, 23, 59, 59, 0, time.UTC)
+	// 1977 fixture shows include Samson and Morning Dew at Cornell
+	rows := execQuery(t, db, &ir.QueryIR{
+		Type:        ir.QueryTypeSongs,
+		PlayedRange: &ir.ResolvedDateRange{Start: start, End: end},
+		Conditions:  []ir.ConditionIR{&ir.CoverConditionIR{Cover: true}},
+	})
+	require.Equal(t, 2, rows)
+}
+
+func TestGenerate_Songs_TimesPlayed(t *testing.T) {
+	db := openDB(t)
+	// Fixture times_played: Scarlet 314, Help 306, Fire 303, Samson 287, Dew 232, Dark Star 228
+	rows := execQuery(t, db, &ir.QueryIR{
+		Type:       ir.QueryTypeSongs,
+		Conditions: []ir.ConditionIR{&ir.TimesPlayedConditionIR{Operator: ir.CompGT, Count: 300}},
+	})
+	require.Equal(t, 3, rows)
+	count, _ := execScalar(t, db, &ir.QueryIR{
+		Type:       ir.QueryTypeSongs,
+		OutputFmt:  ir.OutputCount,
+		Conditions: []ir.ConditionIR{&ir.TimesPlayedConditionIR{Operator: ir.CompLT, Count: 250}},
+	})
+	require.Equal(t, 2, count)
+}
+
+func TestGenerate_Songs_WithLength(t *testing.T) {
+	db := openDB(t)
+	songs := func(op ir.CompOp, sec int) int {
+		return execQuery(t, db, &ir.QueryIR{
+			Type:       ir.QueryTypeSongs,
+			Conditions: []ir.ConditionIR{&ir.LengthConditionIR{Operator: op, Seconds: sec}},
+		})
+	}
+	// Longest versions: Dark Star 25:00, Dew 12:00, Fire 10:20, Scarlet 9:40.
+	require.Equal(t, 1, songs(ir.CompGT, 20*60))
+	require.Equal(t, 3, songs(ir.CompGT, 10*60))
+	// Shortest: Help on the Way, 5:20.
+	require.Equal(t, 1, songs(ir.CompLT, 6*60))
+
+	// In a range only the qualifying performances are counted: at Landover
+	// (1978), Scarlet 9:20 and Fire 10:10 run past 9 minutes, Samson doesn't.
+	sqlQ, err := New().Generate(&ir.QueryIR{
+		Type: ir.QueryTypeSongs,
+		PlayedRange: &ir.ResolvedDateRange{
+			Start: time.Date(1978, 1, 1, 0, 0, 0, 0, time.UTC),
+			End:   time.Date(1978, 12, 31, 23, 59, 59, 0, time.UTC),
+		},
+		Conditions: []ir.ConditionIR{&ir.LengthConditionIR{Operator: ir.CompGT, Seconds: 9 * 60}},
+	})
+	require.NoError(t, err)
+	rs, err := db.ExecuteQuery(context.Background(), sqlQ.SQL, sqlQ.Args...)
+	require.NoError(t, err)
+	var got []string
+	for _, row := range rs.Rows {
+		got = append(got, fmt.Sprintf("%s %d", row.Text(1), row.Int(6)))
+	}
+	require.Equal(t, []string{"Fire on the Mountain 1", "Scarlet Begonias 1"}, got)
+}
+
+func TestGenerate_Songs_TimesPlayedInRange(t *testing.T) {
+	db := openDB(t)
+	q := &ir.QueryIR{
+		Type: ir.QueryTypeSongs,
+		PlayedRange: &ir.ResolvedDateRange{
+			Start: time.Date(1977, 1, 1, 0, 0, 0, 0, time.UTC),
+			End:   time.Date(1977, 12, 31, 23, 59, 59, 0, time.UTC),
+		},
+		Conditions: []ir.ConditionIR{&ir.TimesPlayedConditionIR{Operator: ir.CompGT, Count: 1}},
+	}
+	// Played twice in 1977 (Cornell + Winterland): Scarlet, Fire, Dark Star
+	require.Equal(t, 3, execQuery(t, db, q))
+	q.OutputFmt = ir.OutputCount
+	count, _ := execScalar(t, db, q)
+	require.Equal(t, 3, count)
+}
+
+func TestGenerate_Songs_InSet(t *testing.T) {
+	db := openDB(t)
+	q := &ir.QueryIR{
+		Type:  ir.QueryTypeSongs,
+		InSet: ir.Set2,
+		PlayedRange: &ir.ResolvedDateRange{
+			Start: time.Date(1977, 1, 1, 0, 0, 0, 0, time.UTC),
+			End:   time.Date(1977, 12, 31, 23, 59, 59, 0, time.UTC),
+		},
+	}
+	sqlQ, err := New().Generate(q)
+	require.NoError(t, err)
+	rs, err := db.ExecuteQuery(context.Background(), sqlQ.SQL, sqlQ.Args...)
+	require.NoError(t, err)
+	// Set 2 in 1977: Scarlet and Fire at Cornell and Winterland, the rest once;
+	// Dark Star only opened set 1. Most played first, ties by name.
+	var got []string
+	for _, row := range rs.Rows {
+		got = append(got, fmt.Sprintf("%s %d", row.Text(1), row.Int(6)))
+	}
+	require.Equal(t, []string{"Fire on the Mountain 2", "Scarlet Begonias 2", "Help on the Way 1", "Morning Dew 1", "Samson and Delilah 1"}, got)
+
+	// No range: every show; set 1 is only Dark Star.
+	require.Equal(t, 1, execQuery(t, db, &ir.QueryIR{Type: ir.QueryTypeSongs, InSet: ir.Set1}))
+	// The encore is the last set, which is set 2 at every fixture show.
+	require.Equal(t, 5, execQuery(t, db, &ir.QueryIR{Type: ir.QueryTypeSongs, InSet: ir.Encore}))
+
+	q.OutputFmt = ir.OutputCount
+	count, _ := execScalar(t, db, q)
+	require.Equal(t, 5, count)
+}
+
+func TestGenerate_Songs_PlayedEveryYear(t *testing.T) {
+	db := openDB(t)
+	years := func(from, to int) *ir.ResolvedDateRange {
+		return &ir.ResolvedDateRange{
+			Start: time.Date(from, 1, 1, 0, 0, 0, 0, time.UTC),
+			End:   time.Date(to, 12, 31, 23, 59, 59, 0, time.UTC),
+		}
+	}
+	// 1977 (Cornell, Winterland) and 1978 (Landover): Scarlet, Fire, Samson
+	q := &ir.QueryIR{Type: ir.QueryTypeSongs, PlayedRange: years(1977, 1978), EveryYear: true}
+	require.Equal(t, 3, execQuery(t, db, q))
+	q.OutputFmt = ir.OutputCount
+	count, _ := execScalar(t, db, q)
+	require.Equal(t, 3, count)
+
+	// No fixture shows in 1979
+	require.Equal(t, 0, execQuery(t, db, &ir.QueryIR{Type: ir.QueryTypeSongs, PlayedRange: years(1977, 1979), EveryYear: true}))
+}
+
+func TestGenerate_Songs_Written(t *testing.T) {
+	db := openDB(t)
+	// Dark Star debuted 1968-02-02 and was played until 1994
+	rows := execQuery(t, db, &ir.QueryIR{
+		Type: ir.QueryTypeSongs,
+		DateRange: &ir.ResolvedDateRange{
+			Start: time.Date(1968, 1, 1, 0, 0, 0, 0, time.UTC),
+			End:   time.Date(1970, 12, 31, 23, 59, 59, 0, time.UTC),
+		},
+	})
+	require.Equal(t, 1, rows, "songs still played after the range are included")
+}
+
+func TestGenerate_Songs_Debuted(t *testing.T) {
+	db := openDB(t)
+	debut := &ir.ResolvedDateRange{
+		Start: time.Date(1975, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(1977, 12, 31, 23, 59, 59, 0, time.UTC),
+	}
+	// Help (1975), Samson (1976), Fire (1977)
+	rows := execQuery(t, db, &ir.QueryIR{Type: ir.QueryTypeSongs, DebutRange: debut})
+	require.Equal(t, 3, rows)
+
+	// A song with no recorded debut never matches
+	_, err := db.DB().Exec("UPDATE songs SET first_played = NULL WHERE id = 3")
+	require.NoError(t, err)
+	rows = execQuery(t, db, &ir.QueryIR{Type: ir.QueryTypeSongs, DebutRange: debut})
+	require.Equal(t, 2, rows)
+
+	// Combined with a played range: debuted 1975-77 and played in 1978 (Landover)
+	rows = execQuery(t, db, &ir.QueryIR{
+		Type:        ir.QueryTypeSongs,
+		DebutRange:  debut,
+		PlayedRange: &ir.ResolvedDateRange{Start: time.Date(1978, 1, 1, 0, 0, 0, 0, time.UTC), End: time.Date(1978, 12, 31, 23, 59, 59, 0, time.UTC)},
+	})
+	require.Equal(t, 2, rows, "Samson and Fire")
+}
+
 func TestGenerate_Count_Song(t *testing.T) {
 	db := openDB(t)
 	songID := 1 // Scarlet Begonias
@@ -181,6 +782,96 @@ func TestGenerate_Count_ShowsWithRange(t *testing.T) {
 	require.Equal(t, 2, count, "fixture has 2 shows in 1977")
 }
 
+func TestGenerate_Count_ShowsWithSegue(t *testing.T) {
+	db := openDB(t)
+	q := &ir.QueryIR{
+		Type: ir.QueryTypeCount,
+		SegueChain: &ir.SegueChainIR{
+			SongIDs:   []int{1, 2},
+			Operators: []ir.SegueOp{ir.SegueOpSegue},
+		},
+	}
+	count, name := execScalar(t, db, q)
+	require.Equal(t, 3, count, "fixture has Scarlet > Fire at Cornell, Winterland, Landover")
+	require.Equal(t, "shows", name)
+
+	// A second Scarlet > Fire in the same show still counts the show once
+	_, err := db.DB().Exec(`INSERT INTO performances (id, show_id, song_id, set_number, position) VALUES (100, 1, 1, 1, 5), (101, 1, 2, 1, 6)`)
+	require.NoError(t, err)
+	count, _ = execScalar(t, db, q)
+	require.Equal(t, 3, count)
+}
+
+func TestGenerate_Count_VenuesWithRange(t *testing.T) {
+	db := openDB(t)
+	start := time.Date(1977, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(1977, 12, 31, 23, 59, 59, 0, time.UTC)
+	count, name := execScalar(t, db, &ir.QueryIR{
+		Type:        ir.QueryTypeCount,
+		CountVenues: true,
+		DateRange:   &ir.ResolvedDateRange{Start: start, End: end},
+	})
+	require.Equal(t, 2, count, "1977 fixture shows were at Barton Hall and Winterland")
+	require.Equal(t, "venues", name)
+}
+
+func TestGenerate_Venues(t *testing.T) {
+	db := openDB(t)
+	start := time.Date(1977, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(1977, 12, 31, 23, 59, 59, 0, time.UTC)
+	rows := execQuery(t, db, &ir.QueryIR{
+		Type:      ir.QueryTypeVenues,
+		DateRange: &ir.ResolvedDateRange{Start: start, End: end},
+	})
+	require.Equal(t, 2, rows, "Capital Centre (1978) is outside the range")
+	rows = execQuery(t, db, &ir.QueryIR{Type: ir.QueryTypeVenues})
+	require.Equal(t, 3, rows)
+
+	sq, err := New().Generate(&ir.QueryIR{Type: ir.QueryTypeVenues, OrderBy: &ir.OrderByIR{Field: "SHOWS"}})
+	require.NoError(t, err)
+	require.Contains(t, sq.SQL, "ORDER BY shows ASC")
+}
+
+func TestGenerate_VenueStats(t *testing.T) {
+	db := openDB(t)
+	sq, err := New().Generate(&ir.QueryIR{Type: ir.QueryTypeVenueStats})
+	require.NoError(t, err)
+	rs, err := db.ExecuteQuery(context.Background(), sq.SQL, sq.Args...)
+	require.NoError(t, err)
+	require.Len(t, rs.Rows, 3)
+	// One show per fixture venue, so ties are broken by name.
+	row := rs.Rows[0]
+	require.Equal(t, "Barton Hall", row.Text(1))
+	require.Equal(t, 1, row.Int(5))
+	require.Equal(t, "1977-05-08", row.Text(6))
+	require.Equal(t, "1977-05-08", row.Text(7))
+}
+
+func TestGenerate_Compare(t *testing.T) {
+	db := openDB(t)
+	// Dark Star, Help on the Way, and a song id with no performances
+	_, err := db.DB().Exec(`INSERT INTO songs (id, name) VALUES (99, 'Unplayed')`)
+	require.NoError(t, err)
+	sq, err := New().Generate(&ir.QueryIR{Type: ir.QueryTypeCompare, SongIDs: []int{6, 3, 99}})
+	require.NoError(t, err)
+	rs, err := db.ExecuteQuery(context.Background(), sq.SQL, sq.Args...)
+	require.NoError(t, err)
+	require.Len(t, rs.Rows, 3)
+
+	// Rows come back in the order the songs were named.
+	dark, help, unplayed := rs.Rows[0], rs.Rows[1], rs.Rows[2]
+	require.Equal(t, "Dark Star", dark.Text(1))
+	require.Equal(t, 2, dark.Int(6))
+	require.Equal(t, "1977-02-26", dark.Text(4))
+	require.Equal(t, "1977-05-08", dark.Text(5))
+	require.Equal(t, 1410, dark.Int(7))
+	require.Equal(t, "Help on the Way", help.Text(1))
+	require.Equal(t, 1, help.Int(6))
+	require.Equal(t, 0, unplayed.Int(6))
+	require.True(t, unplayed.IsNull(4))
+	require.True(t, unplayed.IsNull(7))
+}
+
 // === FIRST/LAST ===
 
 func TestGenerate_FirstLast(t *testing.T) {
@@ -251,6 +942,33 @@ func TestGenerate_Shows_AtVenuePartialMatch(t *testing.T) {
 	require.Equal(t, 1, rows)
 }
 
+func TestGenerate_ShowWithoutVenue(t *testing.T) {
+	db := openDB(t)
+	fixtures.AddShowWithoutVenue(t, db.DB())
+
+	// Listed, with a NULL venue, rather than dropped by the venue join.
+	sq, err := New().Generate(&ir.QueryIR{Type: ir.QueryTypeShows})
+	require.NoError(t, err)
+	rs, err := db.ExecuteQuery(context.Background(), sq.SQL, sq.Args...)
+	require.NoError(t, err)
+	require.Len(t, rs.Rows, 4)
+	require.Equal(t, "1969-02-27", rs.Rows[0].Text(1))
+	require.True(t, rs.Rows[0].IsNull(2))
+	require.True(t, rs.Rows[0].IsNull(3))
+
+	// AT never matches it, even with a pattern every venue matches.
+	require.Equal(t, 3, execQuery(t, db, &ir.QueryIR{Type: ir.QueryTypeShows, VenueName: "a"}))
+	// Segue and position queries keep it.
+	require.Equal(t, 1, execQuery(t, db, &ir.QueryIR{Type: ir.QueryTypeShows, Conditions: []ir.ConditionIR{
+		&ir.PositionConditionIR{Set: ir.Set1, Operator: ir.PosOpened, SongID: 6},
+	}, DateRange: &ir.ResolvedDateRange{Start: time.Date(1969, 1, 1, 0, 0, 0, 0, time.UTC), End: time.Date(1969, 12, 31, 23, 59, 59, 0, time.UTC)}}))
+	// Venue lists and counts skip it.
+	require.Equal(t, 3, execQuery(t, db, &ir.QueryIR{Type: ir.QueryTypeVenues}))
+	count, _ := execScalar(t, db, &ir.QueryIR{Type: ir.QueryTypeCount, CountVenues: true})
+	require.Equal(t, 3, count)
+	require.Equal(t, 0, execQuery(t, db, &ir.QueryIR{Type: ir.QueryTypeRuns}))
+}
+
 func TestGenerate_Shows_AtCity(t *testing.T) {
 	db := openDB(t)
 	rows := execQuery(t, db, &ir.QueryIR{
@@ -260,6 +978,135 @@ func TestGenerate_Shows_AtCity(t *testing.T) {
 	require.GreaterOrEqual(t, rows, 1)
 }
 
+func TestGenerate_Shows_NotesContains(t *testing.T) {
+	db := openDB(t)
+	rows := execQuery(t, db, &ir.QueryIR{
+		Type:       ir.QueryTypeShows,
+		Conditions: []ir.ConditionIR{&ir.NotesConditionIR{Text: "cornell"}}, // case-insensitive match on "Cornell 77"
+	})
+	require.Equal(t, 1, rows)
+}
+
+func TestGenerate_Shows_WhereSetName(t *testing.T) {
+	db := openDB(t)
+	_, err := db.DB().Exec("UPDATE performances SET set_name = 'Acoustic Set' WHERE show_id = 2 AND set_number = 1")
+	require.NoError(t, err)
+	rows := execQuery(t, db, &ir.QueryIR{Type: ir.QueryTypeShows, Conditions: []ir.ConditionIR{&ir.SetNameConditionIR{Name: "acoustic"}}})
+	require.Equal(t, 1, rows)
+	rows = execQuery(t, db, &ir.QueryIR{Type: ir.QueryTypeShows, Conditions: []ir.ConditionIR{&ir.SetNameConditionIR{Name: "Electric"}}})
+	require.Equal(t, 0, rows)
+}
+
+func TestGenerate_Shows_WhereSource(t *testing.T) {
+	db := openDB(t)
+	rows := execQuery(t, db, &ir.QueryIR{
+		Type:       ir.QueryTypeShows,
+		Conditions: []ir.ConditionIR{&ir.SourceConditionIR{Source: "SBD"}}, // stored lowercase
+	})
+	require.Equal(t, 1, rows, "only Cornell has a soundboard")
+	rows = execQuery(t, db, &ir.QueryIR{
+		Type:       ir.QueryTypeShows,
+		Conditions: []ir.ConditionIR{&ir.SourceConditionIR{Source: "aud"}},
+	})
+	require.Equal(t, 2, rows)
+}
+
+func TestGenerate_Shows_WhereWeekday(t *testing.T) {
+	db := openDB(t)
+	// Cornell (1977-05-08) was a Sunday, Winterland (1977-02-26) a Saturday
+	for day, want := range map[int]int{0: 1, 6: 1, 1: 1, 3: 0} {
+		rows := execQuery(t, db, &ir.QueryIR{Type: ir.QueryTypeShows, Conditions: []ir.ConditionIR{&ir.WeekdayConditionIR{Day: day}}})
+		require.Equal(t, want, rows, "day %d", day)
+	}
+}
+
+func TestGenerate_Shows_WhereRating(t *testing.T) {
+	db := openDB(t)
+	// Fixture ratings: Cornell 4.9, Winterland 4.5, Landover 4.2
+	for v, want := range map[float64]int{4.5: 2, 4.9: 1, 5: 0, 0: 3} {
+		q := &ir.QueryIR{Type: ir.QueryTypeShows, Conditions: []ir.ConditionIR{&ir.RatingConditionIR{Operator: ir.CompGTE, Value: v}}}
+		require.Equal(t, want, execQuery(t, db, q), "RATING >= %v", v)
+	}
+	_, err := db.DB().Exec("UPDATE shows SET rating = NULL WHERE id = 3")
+	require.NoError(t, err)
+	q := &ir.QueryIR{Type: ir.QueryTypeShows, Conditions: []ir.ConditionIR{&ir.RatingConditionIR{Operator: ir.CompLT, Value: 4.6}}}
+	require.Equal(t, 1, execQuery(t, db, q), "unrated shows never match")
+}
+
+func TestGenerate_Shows_WhereSongCount(t *testing.T) {
+	db := openDB(t)
+	// Fixture setlists: Cornell 6 songs, Winterland 3, Landover 3
+	songs := func(op ir.CompOp, n int) *ir.SongCountConditionIR {
+		return &ir.SongCountConditionIR{Operator: op, Count: n}
+	}
+	for c, want := range map[*ir.SongCountConditionIR]int{songs(ir.CompGT, 3): 1, songs(ir.CompGTE, 3): 3, songs(ir.CompLT, 4): 2, songs(ir.CompGT, 6): 0} {
+		q := &ir.QueryIR{Type: ir.QueryTypeShows, Conditions: []ir.ConditionIR{c}}
+		require.Equal(t, want, execQuery(t, db, q), "SONGS %v %d", c.Operator, c.Count)
+	}
+
+	// Composes with other conditions and with a segue search.
+	q := &ir.QueryIR{Type: ir.QueryTypeShows, Conditions: []ir.ConditionIR{
+		songs(ir.CompLT, 4), &ir.PlayedConditionIR{SongIDs: []int{6}},
+	}, ConditionOps: []ir.LogicOp{ir.OpAnd}}
+	require.Equal(t, 1, execQuery(t, db, q), "Winterland: 3 songs, with Dark Star")
+	q = &ir.QueryIR{
+		Type:       ir.QueryTypeShows,
+		SegueChain: &ir.SegueChainIR{SongIDs: []int{1, 2}, Operators: []ir.SegueOp{ir.SegueOpSegue}},
+		Conditions: []ir.ConditionIR{songs(ir.CompLT, 4)},
+	}
+	require.Equal(t, 2, execQuery(t, db, q), "Scarlet > Fire at Winterland and Landover")
+}
+
+func TestGenerate_Shows_WhereTape(t *testing.T) {
+	db := openDB(t)
+	q := &ir.QueryIR{Type: ir.QueryTypeShows, Conditions: []ir.ConditionIR{&ir.TapeConditionIR{}}}
+	require.Equal(t, 0, execQuery(t, db, q))
+	_, err := db.DB().Exec("UPDATE performances SET tape = 1 WHERE id = 8")
+	require.NoError(t, err)
+	require.Equal(t, 1, execQuery(t, db, q))
+}
+
+func TestGenerate_Shows_WhereComplete(t *testing.T) {
+	db := openDB(t)
+	// Cornell is complete; Winterland's set 2 starts at #3 and Landover has no closer
+	rows := execQuery(t, db, &ir.QueryIR{Type: ir.QueryTypeShows, Conditions: []ir.ConditionIR{&ir.CompleteConditionIR{}}})
+	require.Equal(t, 1, rows)
+	rows = execQuery(t, db, &ir.QueryIR{Type: ir.QueryTypeShows, Conditions: []ir.ConditionIR{&ir.CompleteConditionIR{Negated: true}}})
+	require.Equal(t, 2, rows)
+
+	// A set numbered 1, 1, 3 has as many songs as its last position but a
+	// hole at 2. (Only unnumbered sets can repeat a position.)
+	_, err := db.DB().Exec("INSERT INTO performances (show_id, song_id, set_number, position) VALUES (1, 1, NULL, 1), (1, 2, NULL, 1), (1, 3, NULL, 3)")
+	require.NoError(t, err)
+	rows = execQuery(t, db, &ir.QueryIR{Type: ir.QueryTypeShows, Conditions: []ir.ConditionIR{&ir.CompleteConditionIR{}}})
+	require.Equal(t, 0, rows)
+}
+
+func TestGenerate_Shows_WhereLength(t *testing.T) {
+	db := openDB(t)
+	darkStar := 6
+	// Dark Star runs 1320s at Cornell and 1500s at Winterland
+	rows := execQuery(t, db, &ir.QueryIR{
+		Type:       ir.QueryTypeShows,
+		Conditions: []ir.ConditionIR{&ir.LengthConditionIR{SongID: &darkStar, Operator: ir.CompGT, Seconds: 20 * 60}},
+	})
+	require.Equal(t, 2, rows)
+
+	rows = execQuery(t, db, &ir.QueryIR{
+		Type:       ir.QueryTypeShows,
+		Conditions: []ir.ConditionIR{&ir.LengthConditionIR{SongID: &darkStar, Operator: ir.CompGT, Seconds: 25 * 60}},
+	})
+	require.Equal(t, 0, rows, "the length clause must not be dropped")
+
+	sq, err := New().Generate(&ir.QueryIR{
+		Type:       ir.QueryTypeShows,
+		Conditions: []ir.ConditionIR{&ir.LengthConditionIR{SongID: &darkStar, Operator: ir.CompGTE, Seconds: 1500}},
+	})
+	require.NoError(t, err)
+	require.Contains(t, sq.SQL, "pl.length_seconds >= ?")
+	require.Equal(t, []interface{}{6, 1500}, sq.Args)
+}
+
 // === OPENER/CLOSER (any set) ===
 
 func TestGenerate_Shows_OpenerAnySet(t *testing.T) {
@@ -278,6 +1125,35 @@ func TestGenerate_Shows_OpenerAnySet(t *testing.T) {
 	require.GreaterOrEqual(t, rows, 1, "Samson opens at least one set in fixture")
 }
 
+func TestGenerate_Shows_EncorePositions(t *testing.T) {
+	db := openDB(t)
+	// A setlist.fm double encore at Cornell: encore 1 is set 4, encore 2 set 5.
+	for _, stmt := range []string{
+		`INSERT INTO performances (id, show_id, song_id, set_number, position, segue_type, is_opener, is_closer) VALUES (13, 1, 3, 4, 1, '>', 1, 0)`,
+		`INSERT INTO performances (id, show_id, song_id, set_number, position, segue_type, is_opener, is_closer) VALUES (14, 1, 4, 4, 2, NULL, 0, 1)`,
+		`INSERT INTO performances (id, show_id, song_id, set_number, position, segue_type, is_opener, is_closer) VALUES (15, 1, 5, 5, 1, NULL, 1, 1)`,
+	} {
+		_, err := db.DB().Exec(stmt)
+		require.NoError(t, err)
+	}
+	shows := func(c *ir.PositionConditionIR) int {
+		return execQuery(t, db, &ir.QueryIR{Type: ir.QueryTypeShows, Conditions: []ir.ConditionIR{c}})
+	}
+	chain := &ir.SegueChainIR{SongIDs: []int{3, 4}, Operators: []ir.SegueOp{ir.SegueOpSegue}}
+
+	// Both encores count, not just the last set.
+	require.Equal(t, 1, shows(&ir.PositionConditionIR{Set: ir.Encore, Operator: ir.PosEquals, SongID: 3}))
+	require.Equal(t, 1, shows(&ir.PositionConditionIR{Set: ir.Encore, Operator: ir.PosOpened, SongID: 5}))
+	require.Equal(t, 1, shows(&ir.PositionConditionIR{Set: ir.Encore, Operator: ir.PosClosed, SongID: 4}))
+	// Scarlet opens Cornell's set 2, which is no longer its last set.
+	require.Equal(t, 0, shows(&ir.PositionConditionIR{Set: ir.Encore, Operator: ir.PosOpened, SongID: 1}))
+
+	// Chains are held to the set too.
+	require.Equal(t, 1, shows(&ir.PositionConditionIR{Set: ir.Encore, Operator: ir.PosOpened, SegueChain: chain}))
+	require.Equal(t, 1, shows(&ir.PositionConditionIR{Set: ir.Encore, Operator: ir.PosClosed, SegueChain: chain}))
+	require.Equal(t, 0, shows(&ir.PositionConditionIR{Set: ir.Set2, Operator: ir.PosOpened, SegueChain: chain}))
+}
+
 // === AS COUNT for SONGS ===
 
 func TestGenerate_Songs_AsCount(t *testing.T) {
@@ -300,3 +1176,104 @@ func TestGenerate_Songs_AsCountWithLyricsFilter(t *testing.T) {
 	})
 	require.Equal(t, 1, count, "only Scarlet has 'walkin'")
 }
+
+func TestGenerate_Shows_AndBindsTighterThanOr(t *testing.T) {
+	db := openDB(t)
+	// PLAYED Samson OR PLAYED Help AND PLAYED Dark Star
+	// = Samson OR (Help AND Dark Star): Cornell + Landover. The left-to-right
+	// reading (Samson OR Help) AND Dark Star would match only Cornell.
+	q := &ir.QueryIR{
+		Type: ir.QueryTypeShows,
+		Conditions: []ir.ConditionIR{
+			&ir.PlayedConditionIR{SongIDs: []int{4}},
+			&ir.PlayedConditionIR{SongIDs: []int{3}},
+			&ir.PlayedConditionIR{SongIDs: []int{6}},
+		},
+		ConditionOps: []ir.LogicOp{ir.OpOr, ir.OpAnd},
+	}
+	sq, err := New().Generate(q)
+	require.NoError(t, err)
+	require.Contains(t, sq.SQL, " OR (EXISTS")
+	require.Equal(t, 2, execQuery(t, db, q))
+}
+
+func TestGenerate_Shows_PlayedAllAny(t *testing.T) {
+	db := openDB(t)
+	shows := func(c *ir.PlayedSetConditionIR) int {
+		return execQuery(t, db, &ir.QueryIR{Type: ir.QueryTypeShows, Conditions: []ir.ConditionIR{c}})
+	}
+	// Scarlet and Dark Star: Cornell and Winterland; Landover had no Dark Star.
+	require.Equal(t, 2, shows(&ir.PlayedSetConditionIR{SongIDs: [][]int{{1}, {6}}, All: true}))
+	// Samson and Dark Star: only Cornell had both.
+	require.Equal(t, 1, shows(&ir.PlayedSetConditionIR{SongIDs: [][]int{{4}, {6}}, All: true}))
+	// Help or Dark Star: Cornell and Winterland.
+	require.Equal(t, 2, shows(&ir.PlayedSetConditionIR{SongIDs: [][]int{{3}, {6}}}))
+	require.Equal(t, 1, shows(&ir.PlayedSetConditionIR{SongIDs: [][]int{{3}, {6}}, Negated: true}))
+	// Order in the list doesn't matter.
+	require.Equal(t, 1, shows(&ir.PlayedSetConditionIR{SongIDs: [][]int{{6}, {4}}, All: true}))
+}
+
+func TestJoinConditions(t *testing.T) {
+	and, or := ir.OpAnd, ir.OpOr
+	require.Equal(t, "a AND b", joinConditions([]string{"a", "b"}, []ir.LogicOp{and}, true))
+	require.Equal(t, "a OR (b AND c)", joinConditions([]string{"a", "b", "c"}, []ir.LogicOp{or, and}, false))
+	require.Equal(t, "((a AND b) OR c)", joinConditions([]string{"a", "b", "c"}, []ir.LogicOp{and, or}, true))
+}
+
+// Plain and segue shows queries are mapped by the same code, so their
+// columns must agree; segue queries only add match columns at the end.
+func TestGenerate_ShowsColumnsMatchSegue(t *testing.T) {
+	db := openDB(t)
+	columns := func(q *ir.QueryIR) []string {
+		t.Helper()
+		sq, err := New().Generate(q)
+		require.NoError(t, err)
+		rs, err := db.ExecuteQuery(context.Background(), sq.SQL, sq.Args...)
+		require.NoError(t, err)
+		return rs.Columns
+	}
+	plain := columns(&ir.QueryIR{Type: ir.QueryTypeShows})
+	segue := columns(&ir.QueryIR{Type: ir.QueryTypeShows, SegueChain: &ir.SegueChainIR{SongIDs: []int{1, 2}, Operators: []ir.SegueOp{ir.SegueOpSegue}}})
+	require.Equal(t, []string{"id", "date", "venue_id", "venue", "city", "state", "tour", "total_length"}, plain)
+	require.Greater(t, len(segue), len(plain))
+	require.Equal(t, plain, segue[:len(plain)])
+	require.Equal(t, []string{"match_set", "match_position"}, segue[len(plain):len(plain)+2])
+}
+
+func TestGenerate_ShowsLengthOnlyWhenNeeded(t *testing.T) {
+	for _, tc := range []struct {
+		q    *ir.QueryIR
+		want bool
+	}{
+		{&ir.QueryIR{Type: ir.QueryTypeShows}, true},
+		{&ir.QueryIR{Type: ir.QueryTypeShows, OutputFmt: ir.OutputJSON}, true},
+		{&ir.QueryIR{Type: ir.QueryTypeShows, OutputFmt: ir.OutputCSV}, false},
+		{&ir.QueryIR{Type: ir.QueryTypeShows, OutputFmt: ir.OutputCSV, OrderBy: &ir.OrderByIR{Field: "LENGTH"}}, true},
+		{&ir.QueryIR{Type: ir.QueryTypeShows, OutputFmt: ir.OutputSetlist, SegueChain: &ir.SegueChainIR{SongIDs: []int{1, 2}, Operators: []ir.SegueOp{ir.SegueOpSegue}}}, false},
+	} {
+		sq, err := New().Generate(tc.q)
+		require.NoError(t, err)
+		require.Equal(t, tc.want, strings.Contains(sq.SQL, "pt.length_seconds"), sq.SQL)
+		require.Contains(t, sq.SQL, "AS total_length", "the column stays in place either way")
+	}
+}
+
+func TestSQLQuery_Inline(t *testing.T) {
+	q := &SQLQuery{
+		SQL:  "SELECT '?' FROM t WHERE a = ? AND b = ? AND c = ? AND d LIKE ? ESCAPE '\\'",
+		Args: []interface{}{3, "Sugar Mag's", nil, "%x\\_%"},
+	}
+	require.Equal(t, "SELECT '?' FROM t WHERE a = 3 AND b = 'Sugar Mag''s' AND c = NULL AND d LIKE '%x\\_%' ESCAPE '\\'", q.Inline())
+
+	// The inlined statement finds the same rows as the parameterized one.
+	db := openDB(t)
+	sq, err := New().Generate(&ir.QueryIR{Type: ir.QueryTypeShows, DateRange: &ir.ResolvedDateRange{
+		Start: time.Date(1977, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(1977, 12, 31, 0, 0, 0, 0, time.UTC),
+	}})
+	require.NoError(t, err)
+	require.NotEmpty(t, sq.Args)
+	rs, err := db.ExecuteQuery(context.Background(), sq.Inline())
+	require.NoError(t, err)
+	require.Len(t, rs.Rows, 2)
+}
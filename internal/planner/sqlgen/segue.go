@@ -2,12 +2,16 @@ package sqlgen
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/gdql/gdql/internal/ir"
 )
 
-// BuildSegueShowsSQL builds SELECT DISTINCT shows for a segue chain (2+ songs).
+// BuildSegueShowsSQL builds one row per show for a segue chain (2+ songs).
+// Each row also carries where the chain started (p1's set_number and position
+// as match_set/match_position); when the chain occurs more than once in a show,
+// the earliest occurrence is reported.
 //
 // Operator semantics:
 //   >  (segue):    songs are positionally adjacent in the same set (B at position A+1)
@@ -29,7 +33,10 @@ func BuildSegueShowsSQL(q *ir.QueryIR) (*SQLQuery, error) {
 	var b strings.Builder
 	var args []interface{}
 
-	b.WriteString("SELECT DISTINCT s.id, s.date, s.venue_id, v.name AS venue, v.city, v.state, s.tour FROM ")
+	// SQLite takes bare columns from the row that satisfies min(), so
+	// match_set/match_position describe the same (earliest) occurrence.
+	b.WriteString("SELECT " + showColumns(q) +
+		", p1.set_number AS match_set, p1.position AS match_position, min(p1.set_number * 1000 + p1.position) FROM ")
 	for i := 0; i < n; i++ {
 		alias := fmt.Sprintf("p%d", i+1)
 		if i == 0 {
@@ -53,6 +60,9 @@ func BuildSegueShowsSQL(q *ir.QueryIR) (*SQLQuery, error) {
 		fixedParts = append(fixedParts, "(v.name LIKE ? ESCAPE '\\' OR v.city LIKE ? ESCAPE '\\')")
 		args = append(args, "%"+escapeLike(q.VenueName)+"%", "%"+escapeLike(q.VenueName)+"%")
 	}
+	if q.RatedOnly {
+		fixedParts = append(fixedParts, "s.rating IS NOT NULL")
+	}
 	if q.DateRange != nil {
 		fixedParts = append(fixedParts, "s.date >= ? AND s.date <= ?")
 		args = append(args, formatDate(q.DateRange.Start), formatDate(q.DateRange.End))
@@ -83,9 +93,39 @@ func BuildSegueShowsSQL(q *ir.QueryIR) (*SQLQuery, error) {
 			} else {
 				condParts = append(condParts, "EXISTS (SELECT 1 FROM performances px WHERE px.show_id = s.id AND "+inClause+")")
 			}
+		case *ir.PlayedSetConditionIR:
+			part, a := playedSetCondition("px", x)
+			condParts = append(condParts, part)
+			args = append(args, a...)
 		case *ir.GuestConditionIR:
 			condParts = append(condParts, "EXISTS (SELECT 1 FROM performances px WHERE px.show_id = s.id AND px.guest IS NOT NULL AND (px.guest = ? OR px.guest LIKE ? ESCAPE '\\'))")
 			args = append(args, x.Name, "%"+escapeLike(x.Name)+"%")
+		case *ir.NotesConditionIR:
+			condParts = append(condParts, "s.notes LIKE ? ESCAPE '\\'")
+			args = append(args, "%"+escapeLike(x.Text)+"%")
+		case *ir.TapeConditionIR:
+			condParts = append(condParts, "EXISTS (SELECT 1 FROM performances px WHERE px.show_id = s.id AND px.tape = 1)")
+		case *ir.SourceConditionIR:
+			condParts = append(condParts, "EXISTS (SELECT 1 FROM show_recordings r WHERE r.show_id = s.id AND lower(r.source) = lower(?))")
+			args = append(args, x.Source)
+		case *ir.WeekdayConditionIR:
+			condParts = append(condParts, "strftime('%w', s.date) = ?")
+			args = append(args, strconv.Itoa(x.Day))
+		case *ir.RatingConditionIR:
+			condParts = append(condParts, "s.rating "+compOpSQL(x.Operator)+" ?")
+			args = append(args, x.Value)
+		case *ir.SongCountConditionIR:
+			condParts = append(condParts, songCountCondition(x))
+			args = append(args, x.Count)
+		case *ir.SetNameConditionIR:
+			condParts = append(condParts, "EXISTS (SELECT 1 FROM performances px WHERE px.show_id = s.id AND px.set_name LIKE ? ESCAPE '\\')")
+			args = append(args, "%"+escapeLike(x.Name)+"%")
+		case *ir.CompleteConditionIR:
+			condParts = append(condParts, completeCondition(x))
+		case *ir.LengthConditionIR:
+			part, a := lengthCondition(x)
+			condParts = append(condParts, part)
+			args = append(args, a...)
 		case *ir.SegueIntoConditionIR:
 			part, a := segueIntoCondition(x)
 			condParts = append(condParts, part)
@@ -107,34 +147,28 @@ func BuildSegueShowsSQL(q *ir.QueryIR) (*SQLQuery, error) {
 	var whereParts []string
 	whereParts = append(whereParts, fixedParts...)
 	if len(condParts) > 0 {
-		// Join conditions with their operators
-		var condSQL strings.Builder
-		condSQL.WriteString(condParts[0])
-		for i := 1; i < len(condParts); i++ {
-			op := " AND "
-			if i-1 < len(q.ConditionOps) && q.ConditionOps[i-1] == ir.OpOr {
-				op = " OR "
-			}
-			condSQL.WriteString(op)
-			condSQL.WriteString(condParts[i])
-		}
-		// Wrap in parens if mixed with fixed parts and has OR
-		condStr := condSQL.String()
-		if len(fixedParts) > 0 && strings.Contains(condStr, " OR ") {
-			condStr = "(" + condStr + ")"
-		}
-		whereParts = append(whereParts, condStr)
+		whereParts = append(whereParts, joinConditions(condParts, q.ConditionOps, len(fixedParts) > 0))
 	}
 	if len(whereParts) > 0 {
 		b.WriteString(" WHERE ")
 		b.WriteString(strings.Join(whereParts, " AND "))
 	}
+	b.WriteString(" GROUP BY s.id")
 	if q.OrderBy != nil {
 		dir := "ASC"
 		if q.OrderBy.Desc {
 			dir = "DESC"
 		}
-		b.WriteString(" ORDER BY s.date " + dir)
+		switch strings.ToUpper(q.OrderBy.Field) {
+		case "LENGTH":
+			b.WriteString(" " + showLengthOrder(dir))
+		case "RATING":
+			b.WriteString(" " + showRatingOrder(dir))
+		default:
+			b.WriteString(" ORDER BY s.date " + dir + ", match_set, match_position, s.id " + dir)
+		}
+	} else {
+		b.WriteString(" ORDER BY s.date ASC, match_set, match_position, s.id ASC")
 	}
 	if q.Limit != nil {
 		b.WriteString(" LIMIT ?")
@@ -177,6 +211,15 @@ func segueChainSubquery(chain *ir.SegueChainIR, showAlias, aliasPrefix string) (
 	return b.String(), args
 }
 
+// SegueStepSQL checks whether song from was ever followed by song to with op,
+// in any show. It returns one row if so, none otherwise.
+func SegueStepSQL(from, to int, op ir.SegueOp) *SQLQuery {
+	return &SQLQuery{
+		SQL:  "SELECT 1 FROM performances p1 JOIN performances p2 ON " + joinForOp("p1", "p2", op) + " WHERE p1.song_id = ? AND p2.song_id = ? LIMIT 1",
+		Args: []interface{}{from, to},
+	}
+}
+
 // joinForOp returns the JOIN ON clause linking two adjacent performance aliases
 // based on the segue operator semantics.
 func joinForOp(prev, curr string, op ir.SegueOp) string {
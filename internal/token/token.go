@@ -16,6 +16,7 @@ const (
 	WHERE
 	WITH
 	WRITTEN
+	DEBUTED
 	ORDER
 	BY
 	LIMIT
@@ -52,6 +53,21 @@ const (
 	FOR
 	ASC
 	DESC
+	NOTES
+	CONTAINS
+	COVER
+	ORIGINAL
+	VENUES
+	TAPE
+	SOURCE
+	COMPLETE
+	WEEKDAY
+	RUNS
+	STATS
+	COMPARE
+	BEST
+	COVERAGE
+	GAPS
 
 	// Literals
 	STRING
@@ -91,6 +107,7 @@ var tokens = [...]string{
 	WHERE:        "WHERE",
 	WITH:         "WITH",
 	WRITTEN:      "WRITTEN",
+	DEBUTED:      "DEBUTED",
 	ORDER:        "ORDER",
 	BY:           "BY",
 	LIMIT:        "LIMIT",
@@ -127,6 +144,21 @@ var tokens = [...]string{
 	FOR:          "FOR",
 	ASC:          "ASC",
 	DESC:         "DESC",
+	NOTES:        "NOTES",
+	CONTAINS:     "CONTAINS",
+	COVER:        "COVER",
+	ORIGINAL:     "ORIGINAL",
+	VENUES:       "VENUES",
+	TAPE:         "TAPE",
+	SOURCE:       "SOURCE",
+	COMPLETE:     "COMPLETE",
+	WEEKDAY:      "WEEKDAY",
+	RUNS:         "RUNS",
+	STATS:        "STATS",
+	COMPARE:      "COMPARE",
+	BEST:         "BEST",
+	COVERAGE:     "COVERAGE",
+	GAPS:         "GAPS",
 
 	STRING:   "<string>",
 	NUMBER:   "<number>",
@@ -0,0 +1,50 @@
+package run
+
+import (
+	"context"
+
+	"github.com/gdql/gdql/internal/data/sqlite"
+	"github.com/gdql/gdql/internal/executor"
+	"github.com/gdql/gdql/internal/import/canonical"
+)
+
+// Show, Venue, Set, and SongInSet are the canonical import shapes accepted by
+// NewInMemory (see docs/CANONICAL_IMPORT.md).
+type (
+	Show      = canonical.Show
+	Venue     = canonical.Venue
+	Set       = canonical.Set
+	SongInSet = canonical.SongInSet
+)
+
+// InMemory is a throwaway database held in memory, seeded with shows and
+// ready to query. It embeds an executor, so Execute works directly (and
+// executor.ExecuteAll for scripts); Query returns JSON like RunWithDB. Nothing touches disk. Call Close when done.
+type InMemory struct {
+	executor.Executor
+	db *sqlite.DB
+}
+
+// NewInMemory creates an in-memory database with the GDQL schema and writes
+// shows into it (venues and songs are created as needed).
+func NewInMemory(ctx context.Context, shows []Show) (*InMemory, error) {
+	db, err := sqlite.OpenMemory()
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := canonical.WriteShows(ctx, db.DB(), shows); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &InMemory{Executor: executor.New(db), db: db}, nil
+}
+
+// Query runs one or more semicolon-separated GDQL queries and returns JSON.
+func (m *InMemory) Query(ctx context.Context, query string) (string, error) {
+	return runJSON(ctx, m.db, query)
+}
+
+// Close releases the database; its contents are discarded.
+func (m *InMemory) Close() error {
+	return m.db.Close()
+}
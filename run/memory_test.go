@@ -0,0 +1,38 @@
+package run
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewInMemory(t *testing.T) {
+	ctx := context.Background()
+	mem, err := NewInMemory(ctx, []Show{
+		{
+			Date:  "1977-05-08",
+			Venue: Venue{Name: "Barton Hall", City: "Ithaca", State: "NY", Country: "US"},
+			Sets: []Set{
+				{Songs: []SongInSet{{Name: "New Minglewood Blues"}, {Name: "Loser"}}},
+				{Songs: []SongInSet{{Name: "Scarlet Begonias"}, {Name: "Fire on the Mountain", SegueBefore: true}}},
+			},
+		},
+		{
+			Date:  "1977-05-09",
+			Venue: Venue{Name: "War Memorial", City: "Buffalo", State: "NY", Country: "US"},
+			Sets:  []Set{{Songs: []SongInSet{{Name: "Help on the Way"}}}},
+		},
+	})
+	require.NoError(t, err)
+	defer mem.Close()
+
+	result, err := mem.Execute(ctx, `SHOWS WHERE "Scarlet Begonias" > "Fire on the Mountain";`)
+	require.NoError(t, err)
+	require.Len(t, result.Shows, 1)
+	require.Equal(t, "Barton Hall", result.Shows[0].Venue)
+
+	out, err := mem.Query(ctx, `COUNT SHOWS FROM 1977;`)
+	require.NoError(t, err)
+	require.Contains(t, out, `"count": 2`)
+}
@@ -46,6 +46,11 @@ func ensureEmbeddedDB() (string, error) {
 			embeddedDBErr = err
 			return
 		}
+		// RunWithDB opens read-only, so apply migrations to the fresh copy here.
+		if err := sqlite.Upgrade(path); err != nil {
+			embeddedDBErr = err
+			return
+		}
 		embeddedDBPath = path
 	})
 	return embeddedDBPath, embeddedDBErr
@@ -68,15 +73,21 @@ func RunWithEmbeddedDB(ctx context.Context, query string) (string, error) {
 }
 
 // RunWithDB executes one or more semicolon-separated GDQL queries against
-// the SQLite database at dbPath and returns the result as JSON.
+// the SQLite database at dbPath and returns the result as JSON. The
+// database is opened read-only.
 // Multiple statements produce a JSON array of results.
 func RunWithDB(ctx context.Context, dbPath, query string) (jsonResult string, err error) {
-	db, err := sqlite.Open(dbPath)
+	db, err := sqlite.OpenReadOnly(dbPath)
 	if err != nil {
 		return "", err
 	}
 	defer db.Close()
+	return runJSON(ctx, db, query)
+}
 
+// runJSON runs each statement in query against db: one statement returns a
+// single JSON result object, several return a JSON array.
+func runJSON(ctx context.Context, db *sqlite.DB, query string) (string, error) {
 	stmts := SplitStatements(query)
 	if len(stmts) == 0 {
 		return "{}", nil
@@ -104,7 +115,7 @@ func RunWithDB(ctx context.Context, dbPath, query string) (jsonResult string, er
 }
 
 func runOne(ctx context.Context, db *sqlite.DB, query string) (string, error) {
-	ex := executor.New(db)
+	ex := executor.NewWithOptions(db, executor.Options{ShowCompleteness: true})
 	result, err := ex.Execute(ctx, query)
 	if err != nil {
 		return "", err
@@ -2,8 +2,11 @@ package run
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/gdql/gdql/internal/data/sqlite"
 	"github.com/gdql/gdql/test/fixtures"
 	"github.com/stretchr/testify/require"
 )
@@ -40,3 +43,22 @@ func TestRunWithDB_BadDBPath(t *testing.T) {
 	_, err := RunWithDB(context.Background(), "/tmp/gdql-nonexistent-test.db", "SHOWS;")
 	require.Error(t, err) // Either open or query fails
 }
+
+// TestEmbeddedDB_Migrated checks the embedded database ships at the current
+// schema version, so unpacking it doesn't have to migrate anything.
+func TestEmbeddedDB_Migrated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "embedded.db")
+	require.NoError(t, os.WriteFile(path, EmbeddedDB(), 0o600))
+	db, err := sqlite.OpenReadOnly(path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	var version int
+	require.NoError(t, db.DB().QueryRow("SELECT version FROM schema_version").Scan(&version))
+	require.Equal(t, sqlite.SchemaVersion(), version)
+	for _, idx := range []string{"idx_perf_position", "idx_perf_song", "idx_shows_date", "idx_song_aliases_song", "idx_perf_unique"} {
+		var n int
+		require.NoError(t, db.DB().QueryRow("SELECT count(*) FROM sqlite_master WHERE type = 'index' AND name = ?", idx).Scan(&n))
+		require.Equal(t, 1, n, idx)
+	}
+}
@@ -2,10 +2,16 @@ package acceptance
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	stderrors "errors"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/gdql/gdql/internal/errors"
 	"github.com/gdql/gdql/internal/executor"
+	"github.com/gdql/gdql/internal/formatter"
 	"github.com/gdql/gdql/internal/data/sqlite"
 	"github.com/gdql/gdql/run"
 	"github.com/gdql/gdql/test/fixtures"
@@ -42,8 +48,7 @@ func TestE2E_ShowsFromYear(t *testing.T) {
 	for i, s := range result.Shows {
 		dates[i] = s.Date.Format("2006-01-02")
 	}
-	require.Contains(t, dates, "1977-02-26")
-	require.Contains(t, dates, "1977-05-08")
+	require.Equal(t, []string{"1977-02-26", "1977-05-08"}, dates, "shows default to date order")
 }
 
 func TestE2E_SegueScarletFire(t *testing.T) {
@@ -53,6 +58,336 @@ func TestE2E_SegueScarletFire(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, executor.ResultShows, result.Type)
 	require.Len(t, result.Shows, 3, "seed has Scarlet > Fire at Cornell, Winterland, Landover")
+	require.Equal(t, "1977-02-26", result.Shows[0].Date.Format("2006-01-02"))
+	require.Equal(t, "1978-04-24", result.Shows[2].Date.Format("2006-01-02"))
+	// Each show reports where the chain started
+	require.Equal(t, 2, result.Shows[1].MatchSet)
+	require.Equal(t, 1, result.Shows[1].MatchPosition, "Cornell: Scarlet opened set 2")
+	require.Equal(t, 2, result.Shows[2].MatchPosition, "Landover: Scarlet was second in set 2")
+}
+
+// Segue queries build their own SQL; the shows they return must map the same
+// as from a plain SHOWS query, venue included.
+func TestE2E_SegueShowsVenue(t *testing.T) {
+	db := openTestDB(t)
+	ex := executor.New(db)
+	segue, err := ex.Execute(context.Background(), `SHOWS FROM 1977-1978 WHERE "Scarlet Begonias" > "Fire on the Mountain"`)
+	require.NoError(t, err)
+	plain, err := ex.Execute(context.Background(), `SHOWS FROM 1977-1978`)
+	require.NoError(t, err)
+	require.Len(t, segue.Shows, len(plain.Shows))
+	for i, got := range segue.Shows {
+		want := plain.Shows[i]
+		require.NotEmpty(t, got.Venue, got.Date)
+		require.NotEmpty(t, got.City, got.Date)
+		require.Equal(t, want.ID, got.ID)
+		require.Equal(t, want.Date, got.Date)
+		require.Equal(t, want.VenueID, got.VenueID)
+		require.Equal(t, want.Venue, got.Venue)
+		require.Equal(t, want.City, got.City)
+		require.Equal(t, want.State, got.State)
+		require.Equal(t, want.Tour, got.Tour)
+		require.Equal(t, want.LengthSeconds, got.LengthSeconds)
+	}
+	require.Equal(t, "Barton Hall", segue.Shows[1].Venue)
+}
+
+func TestE2E_ShowsCompleteFlag(t *testing.T) {
+	db := openTestDB(t)
+	ex := executor.New(db)
+	result, err := ex.Execute(context.Background(), `SHOWS;`)
+	require.NoError(t, err)
+	require.Len(t, result.Shows, 3)
+	for _, s := range result.Shows {
+		require.Nil(t, s.Complete, "only computed when asked for: %s", s.Venue)
+	}
+
+	for _, run := range []func() (*executor.Result, error){
+		func() (*executor.Result, error) { return ex.Execute(context.Background(), `SHOWS AS JSON;`) },
+		func() (*executor.Result, error) {
+			return executor.NewWithOptions(db, executor.Options{ShowCompleteness: true}).Execute(context.Background(), `SHOWS;`)
+		},
+	} {
+		result, err := run()
+		require.NoError(t, err)
+		require.Len(t, result.Shows, 3)
+		for _, s := range result.Shows {
+			require.NotNil(t, s.Complete, s.Venue)
+			require.Equal(t, s.Date.Format("2006-01-02") == "1977-05-08", *s.Complete, s.Venue)
+		}
+	}
+
+	result, err = ex.Execute(context.Background(), `SHOWS WHERE COMPLETE;`)
+	require.NoError(t, err)
+	require.Len(t, result.Shows, 1)
+}
+
+func TestE2E_ShowsWhereLength(t *testing.T) {
+	db := openTestDB(t)
+	ex := executor.New(db)
+	result, err := ex.Execute(context.Background(), `SHOWS WHERE LENGTH("Dark Star") > 24min`)
+	require.NoError(t, err)
+	require.Equal(t, executor.ResultShows, result.Type)
+	require.Len(t, result.Shows, 1, "only the Winterland Dark Star (25:00) runs past 24 minutes")
+	require.Equal(t, "1977-02-26", result.Shows[0].Date.Format("2006-01-02"))
+}
+
+func TestE2E_ExecuteAllScript(t *testing.T) {
+	db := openTestDB(t)
+	ex := executor.New(db)
+	results, err := executor.ExecuteAll(context.Background(), ex, `
+		-- a small .gdql script
+		SHOWS FROM 1977;
+		COUNT SHOWS;
+		SETLIST FOR 5/8/77;
+	`)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	require.Equal(t, executor.ResultShows, results[0].Type)
+	require.Len(t, results[0].Shows, 2)
+	require.Equal(t, executor.ResultCount, results[1].Type)
+	require.Equal(t, 3, results[1].Count.Count)
+	require.Equal(t, executor.ResultSetlist, results[2].Type)
+}
+
+func TestE2E_LongestShow(t *testing.T) {
+	db := openTestDB(t)
+	ex := executor.New(db)
+	result, err := ex.Execute(context.Background(), "SHOWS ORDER BY LENGTH DESC LIMIT 1")
+	require.NoError(t, err)
+	require.Len(t, result.Shows, 1)
+	require.Equal(t, "1977-05-08", result.Shows[0].Date.Format("2006-01-02"))
+	require.Equal(t, 3980, result.Shows[0].LengthSeconds)
+}
+
+func TestE2E_BestShows(t *testing.T) {
+	db := openTestDB(t)
+	_, err := db.DB().Exec("UPDATE shows SET rating = CASE id WHEN 1 THEN 4.9 WHEN 2 THEN 4.6 END")
+	require.NoError(t, err)
+	ex := executor.New(db)
+
+	result, err := ex.Execute(context.Background(), "BEST SHOWS FROM 1977 LIMIT 10")
+	require.NoError(t, err)
+	require.Len(t, result.Shows, 2)
+	require.Equal(t, "1977-05-08", result.Shows[0].Date.Format("2006-01-02"))
+	require.Equal(t, "1977-02-26", result.Shows[1].Date.Format("2006-01-02"))
+
+	result, err = ex.Execute(context.Background(), "BEST SHOWS")
+	require.NoError(t, err)
+	require.Len(t, result.Shows, 2, "unrated Landover is left out")
+
+	result, err = ex.Execute(context.Background(), "SHOWS ORDER BY RATING DESC")
+	require.NoError(t, err)
+	require.Len(t, result.Shows, 3)
+	require.Equal(t, "1978-04-24", result.Shows[2].Date.Format("2006-01-02"), "unrated shows sort last")
+}
+
+func TestE2E_CombinedRatings(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	// Winterland's stored 4.5 is overridden by its sources' combined 4.4;
+	// Cornell's sources average 4.8 over 400 votes.
+	_, err := db.DB().Exec(`INSERT INTO show_ratings (show_id, source, rating, votes) VALUES
+		(1, 'headyversion', 5.0, 300), (1, 'deadbase', 4.2, 100), (2, 'deadbase', 4.4, 20)`)
+	require.NoError(t, err)
+	require.NoError(t, sqlite.UpdateShowRatings(ctx, db.DB()))
+
+	result, err := executor.New(db).Execute(ctx, "SHOWS WHERE RATING >= 4.5")
+	require.NoError(t, err)
+	require.Len(t, result.Shows, 1)
+	cornell := result.Shows[0]
+	require.Equal(t, "1977-05-08", cornell.Date.Format("2006-01-02"))
+	require.NotNil(t, cornell.Rating)
+	require.InDelta(t, 4.8, *cornell.Rating, 1e-9)
+	require.Equal(t, 400, cornell.RatingCount)
+
+	out, err := formatter.New().Format(result, formatter.FormatJSON)
+	require.NoError(t, err)
+	require.Contains(t, out, `"rating_count": 400`)
+	out, err = formatter.New().Format(result, formatter.FormatTable)
+	require.NoError(t, err)
+	require.Contains(t, out, "4.8 (400)")
+}
+
+func TestE2E_Coverage(t *testing.T) {
+	db := openTestDB(t)
+	result, err := executor.New(db).Execute(context.Background(), "COVERAGE FROM 1977-1978 AS JSON")
+	require.NoError(t, err)
+	require.Equal(t, executor.ResultCoverage, result.Type)
+	require.Len(t, result.Coverage, 2)
+	require.Equal(t, 1977, result.Coverage[0].Year)
+	require.Equal(t, 2, result.Coverage[0].Have)
+	require.Equal(t, 1, result.Coverage[1].Have)
+
+	out, err := formatter.New().Format(result, formatter.FormatJSON)
+	require.NoError(t, err)
+	require.Contains(t, out, `"type": "coverage"`)
+	require.Contains(t, out, `"year": 1978`)
+	out, err = formatter.New().Format(result, formatter.FormatCSV)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(out, "year,have,expected,percent\n1977,2,"), out)
+}
+
+func TestE2E_EmptySegueHint(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	ex := executor.New(db)
+
+	result, err := ex.Execute(ctx, `SHOWS WHERE "Morning Dew" > "Dark Star"`)
+	require.NoError(t, err)
+	require.Empty(t, result.Shows)
+	require.Equal(t, `"Morning Dew" and "Dark Star" were both played, but never "Morning Dew" > "Dark Star".`, result.Hint)
+
+	out, err := formatter.New().Format(result, formatter.FormatJSON)
+	require.NoError(t, err)
+	require.Contains(t, out, `"hint": `)
+
+	_, err = db.DB().Exec(`INSERT INTO songs (id, name, times_played) VALUES (7, 'Mountains of the Moon', 0)`)
+	require.NoError(t, err)
+	result, err = ex.Execute(ctx, `SHOWS WHERE "Dark Star" > "Mountains of the Moon"`)
+	require.NoError(t, err)
+	require.Equal(t, `"Mountains of the Moon" was never played, so no show has this segue.`, result.Hint)
+
+	// The segue happened, just not in range: nothing to explain.
+	result, err = ex.Execute(ctx, `SHOWS FROM 1990 WHERE "Scarlet Begonias" > "Fire on the Mountain"`)
+	require.NoError(t, err)
+	require.Empty(t, result.Shows)
+	require.Empty(t, result.Hint)
+}
+
+func TestE2E_Gaps(t *testing.T) {
+	db := openTestDB(t)
+	_, err := db.DB().Exec(`INSERT INTO shows (id, date, venue_id, tour) VALUES (4, '1977-05-11', 1, 'Spring 1977')`)
+	require.NoError(t, err)
+	result, err := executor.New(db).Execute(context.Background(), `GAPS IN TOUR "Spring 1977" AS JSON`)
+	require.NoError(t, err)
+	require.Equal(t, executor.ResultGaps, result.Type)
+	require.Len(t, result.Gaps, 1)
+	require.Equal(t, 2, result.Gaps[0].Days)
+
+	out, err := formatter.New().Format(result, formatter.FormatJSON)
+	require.NoError(t, err)
+	require.Contains(t, out, `"type": "gaps"`)
+	require.Contains(t, out, `"start": "1977-05-09"`)
+	out, err = formatter.New().Format(result, formatter.FormatCSV)
+	require.NoError(t, err)
+	require.Equal(t, "start,end,days\n1977-05-09,1977-05-10,2\n", out)
+}
+
+func TestE2E_SetlistShortNames(t *testing.T) {
+	db := openTestDB(t)
+	_, err := db.DB().Exec(`UPDATE songs SET short_name = NULL WHERE id = 3`)
+	require.NoError(t, err)
+	result, err := executor.New(db).Execute(context.Background(), `SETLIST FOR 5/8/77`)
+	require.NoError(t, err)
+	require.NotNil(t, result.Setlist)
+	out, err := formatter.NewWithOptions(formatter.Options{ShortNames: true}).Format(result, formatter.FormatClassic)
+	require.NoError(t, err)
+	// Help on the Way has no short name, so it keeps its full one.
+	require.Contains(t, out, "S2: Scarlet, Fire >> Help on the Way > Samson, Dew")
+}
+
+func TestE2E_PerformancesByYear(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	_, err := db.DB().Exec(`INSERT INTO shows (id, date, venue_id) VALUES (4, '1980-05-01', 1)`)
+	require.NoError(t, err)
+	_, err = db.DB().Exec(`INSERT INTO performances (id, show_id, song_id, set_number, position) VALUES (13, 4, 1, 1, 1)`)
+	require.NoError(t, err)
+
+	result, err := executor.New(db).Execute(ctx, `PERFORMANCES OF "Scarlet Begonias" BY YEAR AS JSON`)
+	require.NoError(t, err)
+	require.Equal(t, executor.ResultYears, result.Type)
+	require.Equal(t, []*executor.YearCount{{Year: 1977, Count: 2}, {Year: 1978, Count: 1}, {Year: 1979}, {Year: 1980, Count: 1}}, result.Years, "1979 filled in as a gap")
+	out, err := formatter.New().Format(result, formatter.FromIR(result.OutputFmt))
+	require.NoError(t, err)
+	require.Contains(t, out, `"type": "years"`)
+
+	result, err = executor.New(db).Execute(ctx, `PERFORMANCES OF "Scarlet Begonias" FROM 1978-1980 BY YEAR`)
+	require.NoError(t, err)
+	out, err = formatter.New().Format(result, formatter.FormatCSV)
+	require.NoError(t, err)
+	require.Equal(t, "year,count\n1978,1\n1979,0\n1980,1\n", out)
+}
+
+func TestE2E_SongPatterns(t *testing.T) {
+	db := openTestDB(t)
+	ex := executor.New(db)
+	ctx := context.Background()
+
+	counts := map[string]int{
+		`SHOWS WHERE PLAYED "Help%"`:     1, // Help on the Way, Cornell only
+		`SHOWS WHERE PLAYED "%dew"`:      1, // Morning Dew
+		`SHOWS WHERE PLAYED "S%"`:        3, // Scarlet and Samson
+		`SHOWS WHERE PLAYED "~elp on"`:   1,
+		`SHOWS WHERE NOT PLAYED "Morn%"`: 2,
+		`SHOWS WHERE "Dark%" AND "%Dew"`: 1,
+		`SHOWS WHERE > "~Mountain"`:      3, // Scarlet > Fire everywhere
+	}
+	for q, want := range counts {
+		result, err := ex.Execute(ctx, q)
+		require.NoError(t, err, q)
+		require.Len(t, result.Shows, want, q)
+	}
+
+	_, err := ex.Execute(ctx, `SHOWS WHERE PLAYED "Nothing Like This%"`)
+	require.ErrorContains(t, err, "Song patterns")
+	_, err = ex.Execute(ctx, `SHOWS WHERE SET2 OPENED "Scarlet%"`)
+	require.ErrorContains(t, err, "Song patterns")
+	// Chains take one song per step, at either end
+	for _, q := range []string{`SHOWS WHERE "Scarlet Begonias" > "~Fire"`, `SHOWS WHERE "Scarlet%" > "Fire on the Mountain"`} {
+		_, err = ex.Execute(ctx, q)
+		require.ErrorContains(t, err, "segue chain needs one song at each step", q)
+	}
+}
+
+func TestE2E_VenuesFrom1977(t *testing.T) {
+	db := openTestDB(t)
+	ex := executor.New(db)
+	result, err := ex.Execute(context.Background(), "VENUES FROM 1977")
+	require.NoError(t, err)
+	require.Equal(t, executor.ResultVenues, result.Type)
+	require.Len(t, result.Venues, 2)
+	for _, v := range result.Venues {
+		require.Equal(t, 1, v.ShowCount)
+	}
+	require.Equal(t, "Barton Hall", result.Venues[0].Name, "ties break by name")
+
+	result, err = ex.Execute(context.Background(), "COUNT VENUES FROM 1977")
+	require.NoError(t, err)
+	require.Equal(t, executor.ResultCount, result.Type)
+	require.Equal(t, 2, result.Count.Count)
+}
+
+func TestE2E_PerformancesOfMultipleSongs(t *testing.T) {
+	db := openTestDB(t)
+	ex := executor.New(db)
+	result, err := ex.Execute(context.Background(), `PERFORMANCES OF "Scarlet Begonias", "Fire on the Mountain" FROM 1977`)
+	require.NoError(t, err)
+	require.Equal(t, executor.ResultPerformances, result.Type)
+	require.Len(t, result.Performances, 4, "Scarlet and Fire at both 1977 shows")
+	names := map[string]int{}
+	for _, p := range result.Performances {
+		names[p.SongName]++
+	}
+	require.Equal(t, map[string]int{"Scarlet Begonias": 2, "Fire on the Mountain": 2}, names)
+}
+
+func TestE2E_PerformancesWithContext(t *testing.T) {
+	db := openTestDB(t)
+	ex := executor.New(db)
+	result, err := ex.Execute(context.Background(), `PERFORMANCES OF "Fire on the Mountain" WITH CONTEXT;`)
+	require.NoError(t, err)
+	require.Len(t, result.Performances, 3)
+	for _, p := range result.Performances {
+		require.Equal(t, "Scarlet Begonias", p.PrevSong, "every Fire follows Scarlet")
+		require.Equal(t, ">", p.PrevSegue)
+		if p.ShowID == 1 {
+			require.Equal(t, "Help on the Way", p.NextSong, "Cornell: Fire then Help")
+		} else {
+			require.Empty(t, p.NextSong, "Fire closes the set at show %d", p.ShowID)
+		}
+	}
 }
 
 func TestE2E_PerformancesDarkStar(t *testing.T) {
@@ -78,6 +413,55 @@ func TestE2E_SetlistForDate(t *testing.T) {
 	require.GreaterOrEqual(t, len(result.Setlist.Performances), 5, "Cornell 77 set 2 has Scarlet, Fire, Help, Samson, Dew")
 }
 
+func TestE2E_SetlistForVenue(t *testing.T) {
+	db := openTestDB(t)
+	ex := executor.New(db)
+	result, err := ex.Execute(context.Background(), `SETLIST FOR "Winterland"`)
+	require.NoError(t, err)
+	require.Equal(t, executor.ResultSetlist, result.Type)
+	require.NotNil(t, result.Setlist)
+	require.Equal(t, "1977-02-26", result.Setlist.Date.Format("2006-01-02"))
+	require.Equal(t, "Winterland Arena", result.Setlist.Venue)
+	require.Len(t, result.Setlist.Performances, 3)
+
+	// Winterland Arena and Landover's Capital Centre: list both, newest first
+	_, err = ex.Execute(context.Background(), `SETLIST FOR "land"`)
+	require.ErrorContains(t, err, `"land" matches 2 venues`)
+	require.ErrorContains(t, err, "Capital Centre, Landover (last show 1978-04-24)\n  - Winterland Arena")
+
+	_, err = ex.Execute(context.Background(), `SETLIST FOR "Fillmore"`)
+	require.ErrorContains(t, err, `no setlists at a venue matching "Fillmore"`)
+}
+
+func TestE2E_SetlistForPeriod(t *testing.T) {
+	db := openTestDB(t)
+	ex := executor.New(db)
+	for _, q := range []string{"SETLIST FOR 5/77", "SETLIST FOR 1977-05"} {
+		result, err := ex.Execute(context.Background(), q)
+		require.NoError(t, err, q)
+		require.Equal(t, executor.ResultSetlist, result.Type)
+		require.Nil(t, result.Setlist)
+		require.Len(t, result.Setlists, 1, q)
+		require.Equal(t, "1977-05-08", result.Setlists[0].Date.Format("2006-01-02"))
+		require.Equal(t, "Barton Hall", result.Setlists[0].Venue)
+	}
+
+	result, err := ex.Execute(context.Background(), "SETLIST FOR 1977")
+	require.NoError(t, err)
+	require.Len(t, result.Setlists, 2, "Winterland and Cornell")
+	require.Equal(t, "1977-02-26", result.Setlists[0].Date.Format("2006-01-02"))
+	require.Equal(t, "1977-05-08", result.Setlists[1].Date.Format("2006-01-02"))
+	for _, sl := range result.Setlists {
+		for _, p := range sl.Performances {
+			require.Equal(t, sl.ShowID, p.ShowID)
+		}
+	}
+
+	result, err = ex.Execute(context.Background(), "SETLIST FOR 6/77")
+	require.NoError(t, err)
+	require.Empty(t, result.Setlists)
+}
+
 func TestE2E_SongsWithLyrics(t *testing.T) {
 	db := openTestDB(t)
 	ex := executor.New(db)
@@ -88,6 +472,238 @@ func TestE2E_SongsWithLyrics(t *testing.T) {
 	require.Contains(t, result.Songs[0].Name, "Scarlet")
 }
 
+func TestE2E_SongsWithLength(t *testing.T) {
+	db := openTestDB(t)
+	ex := executor.New(db)
+	result, err := ex.Execute(context.Background(), `SONGS WITH LENGTH > 20min`)
+	require.NoError(t, err)
+	require.Equal(t, executor.ResultSongs, result.Type)
+	require.Len(t, result.Songs, 1, "only Dark Star runs past 20 minutes")
+	require.Equal(t, "Dark Star", result.Songs[0].Name)
+
+	result, err = ex.Execute(context.Background(), `SONGS WITH LENGTH > 10min AS COUNT`)
+	require.NoError(t, err)
+	require.Equal(t, 3, result.Count.Count)
+}
+
+func TestE2E_PlayedAllAny(t *testing.T) {
+	db := openTestDB(t)
+	ex := executor.New(db)
+	result, err := ex.Execute(context.Background(), `SHOWS WHERE PLAYED ALL ("Samson and Delilah", "Scarlet Begonias", "Dark Star")`)
+	require.NoError(t, err)
+	require.Len(t, result.Shows, 1)
+	require.Equal(t, "1977-05-08", result.Shows[0].Date.Format("2006-01-02"), "only Cornell had all three")
+
+	result, err = ex.Execute(context.Background(), `SHOWS WHERE PLAYED ANY ("Help on the Way", "Dark Star")`)
+	require.NoError(t, err)
+	require.Len(t, result.Shows, 2, "Cornell had Help, Winterland Dark Star")
+}
+
+func TestE2E_ShowsWhereSongCount(t *testing.T) {
+	db := openTestDB(t)
+	ex := executor.New(db)
+	result, err := ex.Execute(context.Background(), `SHOWS FROM 1977 WHERE SONGS > 5`)
+	require.NoError(t, err)
+	require.Len(t, result.Shows, 1)
+	require.Equal(t, "1977-05-08", result.Shows[0].Date.Format("2006-01-02"), "Cornell: six songs")
+}
+
+func TestE2E_SongsLyricsAndDebut(t *testing.T) {
+	db := openTestDB(t)
+	ex := executor.New(db)
+	// "I" is in Scarlet's and Samson's lyrics; only Samson debuted in 1976-77.
+	for _, q := range []string{
+		`SONGS DEBUTED 1976-1977 WITH LYRICS("I")`,
+		`SONGS WITH LYRICS("I") DEBUTED 1976-1977`,
+	} {
+		result, err := ex.Execute(context.Background(), q)
+		require.NoError(t, err, q)
+		require.Len(t, result.Songs, 1, q)
+		require.Equal(t, "Samson and Delilah", result.Songs[0].Name, q)
+	}
+}
+
+func TestE2E_SongsOrderByAvgLength(t *testing.T) {
+	db := openTestDB(t)
+	ex := executor.New(db)
+	result, err := ex.Execute(context.Background(), "SONGS ORDER BY AVG_LENGTH DESC LIMIT 2")
+	require.NoError(t, err)
+	require.Len(t, result.Songs, 2)
+	require.Equal(t, "Dark Star", result.Songs[0].Name)
+	require.Equal(t, 1410, result.Songs[0].AvgLength)
+	require.Equal(t, "Morning Dew", result.Songs[1].Name)
+	require.Equal(t, 720, result.Songs[1].AvgLength)
+}
+
+func TestE2E_DayRanges(t *testing.T) {
+	db := openTestDB(t)
+	ex := executor.New(db)
+	for query, want := range map[string]int{
+		"SHOWS FROM 5/8/77":                1, // Cornell
+		"SHOWS FROM 2/26/77-5/8/77":        2, // both ends included
+		"SHOWS FROM 2/27/77-5/7/77":        0,
+		"SHOWS FROM 5/77":                  1,
+		"SHOWS AFTER 5/8/77":               2, // Cornell and Landover
+		"SHOWS BEFORE 2/26/77":             1, // Winterland
+		"COUNT SHOWS FROM 2/26/77-5/8/77":  2,
+		"SHOWS FROM 1977-05-08":            1,
+		"SHOWS FROM 1977-02-26-1977-05-08": 2,
+		"SHOWS FROM 1977-05":               1,
+		"SHOWS AFTER 1977-05-08":           2,
+	} {
+		result, err := ex.Execute(context.Background(), query)
+		require.NoError(t, err, query)
+		got := len(result.Shows)
+		if result.Count != nil {
+			got = result.Count.Count
+		}
+		require.Equal(t, want, got, query)
+	}
+}
+
+func TestE2E_SetlistISODate(t *testing.T) {
+	db := openTestDB(t)
+	ex := executor.New(db)
+	for _, query := range []string{"SETLIST FOR 5/8/77", "SETLIST FOR 1977-05-08"} {
+		result, err := ex.Execute(context.Background(), query)
+		require.NoError(t, err, query)
+		require.NotNil(t, result.Setlist, query)
+		require.Equal(t, "1977-05-08", result.Setlist.Date.Format("2006-01-02"), query)
+	}
+}
+
+func TestE2E_SongsInSet(t *testing.T) {
+	db := openTestDB(t)
+	ex := executor.New(db)
+	result, err := ex.Execute(context.Background(), "SONGS IN SET2 FROM 1977 LIMIT 2")
+	require.NoError(t, err)
+	require.Equal(t, executor.ResultSongs, result.Type)
+	require.Len(t, result.Songs, 2)
+	require.Equal(t, "Fire on the Mountain", result.Songs[0].Name)
+	require.Equal(t, 2, result.Songs[0].TimesPlayed)
+	require.Equal(t, "Scarlet Begonias", result.Songs[1].Name)
+}
+
+func TestE2E_ShowWithoutVenue(t *testing.T) {
+	db := openTestDB(t)
+	fixtures.AddShowWithoutVenue(t, db.DB())
+	ex := executor.New(db)
+	result, err := ex.Execute(context.Background(), "SHOWS FROM 1969")
+	require.NoError(t, err)
+	require.Len(t, result.Shows, 1)
+	require.Equal(t, "", result.Shows[0].Venue)
+	out, err := formatter.New().Format(result, formatter.FormatTable)
+	require.NoError(t, err)
+	require.Contains(t, out, "(unknown venue)")
+
+	result, err = ex.Execute(context.Background(), `SHOWS AT "a" FROM 1969`)
+	require.NoError(t, err)
+	require.Empty(t, result.Shows)
+}
+
+func TestE2E_SetNames(t *testing.T) {
+	db := openTestDB(t)
+	_, err := db.DB().Exec("UPDATE performances SET set_name = 'Acoustic Set' WHERE show_id = 2 AND set_number = 1")
+	require.NoError(t, err)
+	ex := executor.New(db)
+
+	result, err := ex.Execute(context.Background(), `SHOWS WHERE SET NAMED "acoustic"`)
+	require.NoError(t, err)
+	require.Len(t, result.Shows, 1)
+	require.Equal(t, "1977-02-26", result.Shows[0].Date.Format("2006-01-02"))
+
+	result, err = ex.Execute(context.Background(), "SETLIST FOR 2/26/77")
+	require.NoError(t, err)
+	require.Equal(t, "Acoustic Set", result.Setlist.Performances[0].SetName)
+	out, err := formatter.New().Format(result, formatter.FormatSetlist)
+	require.NoError(t, err)
+	require.Contains(t, out, "Acoustic Set\n  1. > Dark Star")
+	require.Contains(t, out, "Set 2\n")
+
+	result, err = ex.Execute(context.Background(), "SHOWS FROM 1977 AS SETLIST")
+	require.NoError(t, err)
+	require.Len(t, result.Setlists, 2)
+	require.Equal(t, "Acoustic Set", result.Setlists[0].Performances[0].SetName)
+	require.Empty(t, result.Setlists[1].Performances[0].SetName)
+}
+
+func TestE2E_SetlistJSONForEmbedding(t *testing.T) {
+	db := openTestDB(t)
+	ex := executor.New(db)
+	for _, date := range []string{"5/8/77", "1977-05-08"} {
+		result, err := ex.Execute(context.Background(), "SETLIST FOR "+date)
+		require.NoError(t, err, date)
+		out, err := formatter.SetlistJSON(result.Setlist)
+		require.NoError(t, err)
+
+		var doc struct {
+			Date  string `json:"date"`
+			Venue string `json:"venue"`
+			City  string `json:"city"`
+			Sets  []struct {
+				Name  string `json:"name"`
+				Songs []struct {
+					Name          string `json:"name"`
+					Segue         string `json:"segue"`
+					LengthSeconds int    `json:"length_seconds"`
+				} `json:"songs"`
+			} `json:"sets"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(out), &doc), out)
+		require.Equal(t, "1977-05-08", doc.Date)
+		require.Equal(t, "Barton Hall", doc.Venue)
+		require.Equal(t, "Ithaca", doc.City)
+		require.Len(t, doc.Sets, 2)
+		require.Equal(t, "Set 1", doc.Sets[0].Name)
+		require.Equal(t, "Dark Star", doc.Sets[0].Songs[0].Name)
+		require.Equal(t, "Set 2", doc.Sets[1].Name)
+		require.Equal(t, "Scarlet Begonias", doc.Sets[1].Songs[0].Name)
+		require.NotContains(t, out, "show_id", "no database ids in the embeddable shape")
+	}
+}
+
+func TestE2E_Compare(t *testing.T) {
+	db := openTestDB(t)
+	ex := executor.New(db)
+	result, err := ex.Execute(context.Background(), `COMPARE "Dark Star" "Scarlet Begonias"`)
+	require.NoError(t, err)
+	require.Equal(t, executor.ResultCompare, result.Type)
+	require.Len(t, result.Songs, 2)
+	require.Equal(t, "Dark Star", result.Songs[0].Name)
+	require.Equal(t, 2, result.Songs[0].TimesPlayed)
+	require.Equal(t, 1410, result.Songs[0].AvgLength)
+	require.Equal(t, "Scarlet Begonias", result.Songs[1].Name)
+	require.Equal(t, 3, result.Songs[1].TimesPlayed)
+	require.Equal(t, 1978, result.Songs[1].LastPlayed.Year())
+
+	_, err = ex.Execute(context.Background(), `COMPARE "Dark Star" "Not A Song"`)
+	require.Error(t, err)
+}
+
+func TestE2E_VenueStats(t *testing.T) {
+	db := openTestDB(t)
+	ex := executor.New(db)
+	result, err := ex.Execute(context.Background(), "STATS VENUES FROM 1977 LIMIT 5")
+	require.NoError(t, err)
+	require.Equal(t, executor.ResultVenueStats, result.Type)
+	require.Len(t, result.Venues, 2, "Landover (1978) is outside the range")
+	for _, v := range result.Venues {
+		require.Equal(t, 1, v.ShowCount)
+		require.Equal(t, 1977, v.FirstShow.Year())
+		require.Equal(t, v.FirstShow, v.LastShow)
+	}
+}
+
+func TestE2E_Runs(t *testing.T) {
+	db := openTestDB(t)
+	ex := executor.New(db)
+	// The fixture's three shows are at three venues, so there are no runs.
+	result, err := ex.Execute(context.Background(), `RUNS AT "Winterland" FROM 1977`)
+	require.NoError(t, err)
+	require.Equal(t, executor.ResultRuns, result.Type)
+	require.Empty(t, result.Runs)
+}
+
 // TestE2E_SegueWorksWithoutSegueMetadata verifies that "A" > "B" matches by
 // positional adjacency even when segue_type is empty (as with setlist.fm imports).
 func TestE2E_SegueWorksWithoutSegueMetadata(t *testing.T) {
@@ -299,3 +915,37 @@ func TestIntegration_ShowsTourWithFrom(t *testing.T) {
 	require.NoError(t, json.Unmarshal([]byte(result), &data))
 	require.Contains(t, data, "shows")
 }
+
+// TestE2E_ReadOnlyOldSchema runs filters on columns added by migrations
+// against a read-only database that predates them: each fails with a
+// QueryError naming what's missing instead of SQLite's message.
+func TestE2E_ReadOnlyOldSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.db")
+	raw, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	_, err = raw.Exec(`
+		CREATE TABLE venues (id INTEGER PRIMARY KEY, name TEXT, city TEXT, state TEXT, country TEXT);
+		CREATE TABLE shows (id INTEGER PRIMARY KEY, date TEXT, venue_id INTEGER, tour TEXT, notes TEXT, rating REAL);
+		CREATE TABLE songs (id INTEGER PRIMARY KEY, name TEXT, short_name TEXT, writers TEXT, first_played TEXT, last_played TEXT, times_played INTEGER DEFAULT 0);
+		CREATE TABLE performances (id INTEGER PRIMARY KEY, show_id INTEGER, song_id INTEGER, set_number INTEGER, position INTEGER, segue_type TEXT, length_seconds INTEGER DEFAULT 0);
+		INSERT INTO venues (id, name) VALUES (1, 'Barton Hall');
+		INSERT INTO shows (id, date, venue_id) VALUES (1, '1977-05-08', 1);`)
+	require.NoError(t, err)
+	require.NoError(t, raw.Close())
+
+	db, err := sqlite.OpenReadOnly(path)
+	require.NoError(t, err)
+	defer db.Close()
+	ex := executor.New(db)
+	for query, want := range map[string]string{
+		`SHOWS WHERE TAPE`:                 "WHERE TAPE needs performances.tape",
+		`SHOWS WHERE SET NAMED "Acoustic"`: "SET NAMED needs performances.set_name",
+	} {
+		_, err := ex.Execute(context.Background(), query)
+		var qe *errors.QueryError
+		require.True(t, stderrors.As(err, &qe), "%s: %v", query, err)
+		require.Equal(t, errors.ErrSchemaOutdated, qe.Type)
+		require.Contains(t, qe.Message, want)
+		require.NotEmpty(t, qe.Hint)
+	}
+}
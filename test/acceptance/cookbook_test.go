@@ -50,7 +50,7 @@ func runQueryExpectCount(t *testing.T, query string) {
 	data := runQuery(t, query)
 	require.Contains(t, data, "count", "expected count for: %s", query)
 	count := data["count"].(map[string]interface{})
-	require.Greater(t, count["Count"].(float64), float64(0), "count was 0 for: %s", query)
+	require.Greater(t, count["count"].(float64), float64(0), "count was 0 for: %s", query)
 }
 
 // === What shows were played? ===
@@ -130,6 +130,13 @@ func TestDocExamples_Songs(t *testing.T) {
 	mustParse(t, ex, `SONGS WITH LYRICS("train", "road");`)
 	mustParse(t, ex, `SONGS WRITTEN 1968-1970;`)
 	mustParse(t, ex, `SONGS WRITTEN 1970;`)
+
+	r = mustParse(t, ex, `SONGS DEBUTED FROM 1976-1977;`)
+	names := make([]string, len(r.Songs))
+	for i, s := range r.Songs {
+		names[i] = s.Name
+	}
+	require.ElementsMatch(t, []string{"Fire on the Mountain", "Samson and Delilah"}, names)
 }
 
 func TestDocExamples_Performances(t *testing.T) {
@@ -26,3 +26,18 @@ func TestCreateTestDB_AndQuery(t *testing.T) {
 	require.NotNil(t, song)
 	require.Equal(t, "Scarlet Begonias", song.Name)
 }
+
+func TestCreateLargeTestDB(t *testing.T) {
+	db, err := sqlite.Open(CreateLargeTestDB(t, 100))
+	require.NoError(t, err)
+	defer db.Close()
+
+	rs, err := db.ExecuteQuery(context.Background(), "SELECT COUNT(*) FROM shows")
+	require.NoError(t, err)
+	require.EqualValues(t, 100, rs.Rows[0][0])
+
+	rs, err = db.ExecuteQuery(context.Background(),
+		"SELECT COUNT(*) FROM performances a JOIN performances b ON a.show_id = b.show_id AND a.set_number = b.set_number AND b.position = a.position + 1 WHERE a.song_id = 1 AND b.song_id = 2")
+	require.NoError(t, err)
+	require.NotZero(t, rs.Rows[0][0], "generated data should contain Scarlet > Fire")
+}
@@ -0,0 +1,113 @@
+package fixtures
+
+import (
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// largeSongs are real titles mixed into the generated catalog so benchmarks
+// can run the same queries as the docs (Scarlet > Fire, Dark Star, ...).
+var largeSongs = []string{
+	"Scarlet Begonias", "Fire on the Mountain", "Help on the Way", "Slipknot!",
+	"Franklin's Tower", "Dark Star", "St. Stephen", "The Eleven", "Morning Dew",
+	"Samson and Delilah", "China Cat Sunflower", "I Know You Rider", "Estimated Prophet",
+	"Eyes of the World", "Playing in the Band", "Drums", "Space", "Truckin'",
+	"Bertha", "Sugar Magnolia",
+}
+
+// CreateLargeTestDB creates a temporary database with the test schema and
+// numShows generated shows (about 20 performances each, 200 songs, 50 venues).
+// Data is deterministic, so benchmark runs are comparable. Returns the file path.
+func CreateLargeTestDB(tb testing.TB, numShows int) string {
+	tb.Helper()
+	path := filepath.Join(tb.TempDir(), "large.db")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		tb.Fatalf("open large db: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(schemaSQL); err != nil {
+		tb.Fatalf("exec schema: %v", err)
+	}
+	if err := fillLarge(db, numShows); err != nil {
+		tb.Fatalf("fill large db: %v", err)
+	}
+	return path
+}
+
+func fillLarge(db *sql.DB, numShows int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	const numVenues, numSongs = 50, 200
+	for i := 1; i <= numVenues; i++ {
+		if _, err := tx.Exec("INSERT INTO venues (id, name, city, state, country) VALUES (?, ?, ?, ?, 'USA')",
+			i, fmt.Sprintf("Venue %02d", i), fmt.Sprintf("City %02d", i), []string{"CA", "NY", "IL", "MD", "OR"}[i%5]); err != nil {
+			return err
+		}
+	}
+	for i := 1; i <= numSongs; i++ {
+		name := fmt.Sprintf("Song %03d", i)
+		if i <= len(largeSongs) {
+			name = largeSongs[i-1]
+		}
+		if _, err := tx.Exec("INSERT INTO songs (id, name, times_played, is_cover) VALUES (?, ?, 0, ?)", i, name, i%7 == 0); err != nil {
+			return err
+		}
+	}
+
+	perf, err := tx.Prepare("INSERT INTO performances (id, show_id, song_id, set_number, position, segue_type, length_seconds, is_opener, is_closer) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer perf.Close()
+
+	rng := rand.New(rand.NewSource(1977))
+	date := time.Date(1965, 12, 4, 0, 0, 0, 0, time.UTC)
+	perfID := 1
+	for showID := 1; showID <= numShows; showID++ {
+		date = date.AddDate(0, 0, 1+rng.Intn(4))
+		if _, err := tx.Exec("INSERT INTO shows (id, date, venue_id, tour) VALUES (?, ?, ?, ?)",
+			showID, date.Format("2006-01-02"), 1+rng.Intn(numVenues), fmt.Sprintf("Tour %d", date.Year())); err != nil {
+			return err
+		}
+		for set, size := range []int{9, 9, 2} {
+			for pos := 1; pos <= size; pos++ {
+				songID := 1 + rng.Intn(numSongs)
+				var segue interface{}
+				if rng.Intn(3) == 0 {
+					segue = ">"
+				}
+				// Keep the classic pairings so segue benchmarks have matches.
+				if songID == 1 && pos < size {
+					segue = ">"
+				}
+				if _, err := perf.Exec(perfID, showID, songID, set+1, pos, segue, 180+rng.Intn(1200), pos == 1, pos == size); err != nil {
+					return err
+				}
+				perfID++
+				if songID == 1 && pos < size {
+					pos++
+					if _, err := perf.Exec(perfID, showID, 2, set+1, pos, nil, 180+rng.Intn(1200), false, pos == size); err != nil {
+						return err
+					}
+					perfID++
+				}
+			}
+		}
+	}
+	if _, err := tx.Exec(`UPDATE songs SET
+		times_played = (SELECT count(*) FROM performances p WHERE p.song_id = songs.id),
+		first_played = (SELECT min(s.date) FROM performances p JOIN shows s ON p.show_id = s.id WHERE p.song_id = songs.id),
+		last_played = (SELECT max(s.date) FROM performances p JOIN shows s ON p.show_id = s.id WHERE p.song_id = songs.id)`); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
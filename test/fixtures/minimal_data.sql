@@ -10,13 +10,13 @@ INSERT INTO shows (id, date, venue_id, tour, notes, rating) VALUES
 (2, '1977-02-26', 2, 'Winter 1977', NULL, 4.5),
 (3, '1978-04-24', 3, 'Spring 1978', NULL, 4.2);
 
-INSERT INTO songs (id, name, short_name, writers, first_played, last_played, times_played) VALUES
-(1, 'Scarlet Begonias', 'Scarlet', 'Hunter/Garcia', '1974-03-23', '1995-07-09', 314),
-(2, 'Fire on the Mountain', 'Fire', 'Hunter/Hart', '1977-03-18', '1995-07-09', 303),
-(3, 'Help on the Way', 'Help', 'Hunter/Garcia', '1975-08-13', '1995-07-09', 306),
-(4, 'Samson and Delilah', 'Samson', 'traditional', '1976-06-03', '1995-06-25', 287),
-(5, 'Morning Dew', 'Dew', 'Dobson/Rose', '1967-03-18', '1995-06-25', 232),
-(6, 'Dark Star', 'Dark Star', 'Hunter/Garcia/Hart/Kreutzmann/Lesh/Weir', '1968-02-02', '1994-10-01', 228);
+INSERT INTO songs (id, name, short_name, writers, first_played, last_played, times_played, is_cover) VALUES
+(1, 'Scarlet Begonias', 'Scarlet', 'Hunter/Garcia', '1974-03-23', '1995-07-09', 314, 0),
+(2, 'Fire on the Mountain', 'Fire', 'Hunter/Hart', '1977-03-18', '1995-07-09', 303, 0),
+(3, 'Help on the Way', 'Help', 'Hunter/Garcia', '1975-08-13', '1995-07-09', 306, 0),
+(4, 'Samson and Delilah', 'Samson', 'traditional', '1976-06-03', '1995-06-25', 287, 1),
+(5, 'Morning Dew', 'Dew', 'Dobson/Rose', '1967-03-18', '1995-06-25', 232, 1),
+(6, 'Dark Star', 'Dark Star', 'Hunter/Garcia/Hart/Kreutzmann/Lesh/Weir', '1968-02-02', '1994-10-01', 228, 0);
 
 -- Alias for GetSong / resolver tests (e.g. Relisten-style "Scarlet Begonias-")
 INSERT INTO song_aliases (alias, song_id) VALUES
@@ -51,3 +51,9 @@ INSERT INTO lyrics (song_id, lyrics, lyrics_fts) VALUES
 (1, 'As I was walkin round the town...', 'As I was walkin round the town'),
 (2, 'Long distance runner...', 'Long distance runner'),
 (4, 'If I had my way I would tear this old building down', 'If I had my way I would tear this old building down');
+
+-- Archive.org recordings for SOURCE = "..." tests
+INSERT INTO show_recordings (show_id, identifier, source) VALUES
+(1, 'gd77-05-08.sbd.hicks.4982.sbeok.shnf', 'sbd'),
+(1, 'gd1977-05-08.aud.vernon.82.sbeok.shnf', 'aud'),
+(2, 'gd77-02-26.aud.unknown.flac16', 'aud');
@@ -19,7 +19,8 @@ CREATE TABLE shows (
     notes TEXT,
     soundboard INTEGER,
     archive_id TEXT,
-    rating REAL
+    rating REAL,
+    source_url TEXT -- the show's page at its import source (e.g. setlist.fm); NULL if unknown
 );
 
 CREATE TABLE songs (
@@ -48,6 +49,8 @@ CREATE TABLE performances (
     is_closer INTEGER,
     guest TEXT,
     notes TEXT,
+    tape INTEGER DEFAULT 0, -- 1 when the song was played from tape (setlist.fm)
+    set_name TEXT, -- the source's name for the set, e.g. "Acoustic Set"; NULL for plain numbered sets
     UNIQUE(show_id, set_number, position)
 );
 
@@ -62,9 +65,37 @@ CREATE TABLE song_aliases (
     song_id INTEGER NOT NULL REFERENCES songs(id)
 );
 
+CREATE TABLE song_relations (
+    from_song_id INTEGER NOT NULL REFERENCES songs(id),
+    to_song_id INTEGER NOT NULL REFERENCES songs(id),
+    kind TEXT NOT NULL CHECK (kind IN ('merge_into', 'variant_of', 'pairs_with')),
+    PRIMARY KEY (from_song_id, to_song_id, kind),
+    CHECK (from_song_id != to_song_id)
+);
+
+CREATE TABLE show_recordings (
+    show_id INTEGER NOT NULL REFERENCES shows(id),
+    identifier TEXT NOT NULL,
+    source TEXT,
+    downloads INTEGER,
+    rating REAL,
+    title TEXT,
+    PRIMARY KEY (show_id, identifier)
+);
+
+CREATE TABLE show_ratings (
+    show_id INTEGER NOT NULL REFERENCES shows(id),
+    source TEXT NOT NULL,
+    rating REAL NOT NULL,
+    votes INTEGER,
+    PRIMARY KEY (show_id, source)
+);
+
 CREATE INDEX idx_shows_date ON shows(date);
 CREATE INDEX idx_songs_name ON songs(name);
 CREATE INDEX idx_perf_song ON performances(song_id);
 CREATE INDEX idx_perf_show ON performances(show_id);
+CREATE UNIQUE INDEX idx_perf_unique ON performances(show_id, song_id, ifnull(set_number, -1), position);
 CREATE INDEX idx_perf_position ON performances(show_id, set_number, position);
 CREATE INDEX idx_shows_venue ON shows(venue_id);
+CREATE INDEX idx_song_aliases_song ON song_aliases(song_id);
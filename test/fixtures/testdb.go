@@ -41,6 +41,17 @@ func CreateTestDB(t *testing.T) (path string, cleanup func()) {
 	return path, cleanup
 }
 
+// AddShowWithoutVenue adds show 4: 1969-02-27 with a NULL venue_id and Dark
+// Star (performance 13) opening set 1. It's separate from minimal_data so the
+// fixture's show and venue counts stay as other tests expect.
+func AddShowWithoutVenue(t *testing.T, db *sql.DB) {
+	t.Helper()
+	if _, err := db.Exec(`INSERT INTO shows (id, date, venue_id, notes) VALUES (4, '1969-02-27', NULL, 'venue not known');
+		INSERT INTO performances (id, show_id, song_id, set_number, position, length_seconds, is_opener, is_closer) VALUES (13, 4, 6, 1, 1, 1380, 1, 1);`); err != nil {
+		t.Fatalf("add show without venue: %v", err)
+	}
+}
+
 // OpenTestDB opens a temporary database and returns a *sql.DB (caller must Close).
 func OpenTestDB(t *testing.T) *sql.DB {
 	t.Helper()